@@ -0,0 +1,94 @@
+// 数据库迁移命令
+//
+// 用法:
+//
+//	go run ./cmd/migrate -action up              执行全部未应用的迁移
+//	go run ./cmd/migrate -action up -to 3        迁移到指定版本
+//	go run ./cmd/migrate -action down -steps 1   回滚最近一次迁移
+//	go run ./cmd/migrate -action status          查看迁移状态
+//	go run ./cmd/migrate -action up -dry-run     只打印将要执行的 SQL
+//
+// MySQL 的 DDL 语句会隐式提交，迁移中途失败时已执行的语句不会回滚，
+// 在生产环境执行前建议先备份数据库，或使用 -dry-run 检查将要执行的 SQL。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"todolist/config"
+	"todolist/internal/migration"
+	"todolist/internal/repository"
+)
+
+func main() {
+	configFile := flag.String("config", "config/config.yaml", "配置文件路径")
+	action := flag.String("action", "up", "执行的操作: up, down, status, verify")
+	target := flag.Int("to", 0, "up 的目标版本，0 表示最新版本")
+	steps := flag.Int("steps", 1, "down 回滚的迁移数量")
+	dryRun := flag.Bool("dry-run", false, "只打印 SQL，不修改数据库")
+	flag.Parse()
+
+	// 加载配置
+	if err := config.LoadConfig(*configFile); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 连接数据库
+	db, err := repository.OpenDB(config.GlobalConfig, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+
+	migrator, err := migration.New(db)
+	if err != nil {
+		log.Fatalf("加载数据库迁移失败: %v", err)
+	}
+	migrator.DryRun = *dryRun
+	migrator.Out = os.Stdout
+
+	if !*dryRun && (*action == "up" || *action == "down") && db.Dialector.Name() == "mysql" {
+		fmt.Println("注意: MySQL 的 DDL 语句会隐式提交，迁移中途失败时已执行的语句不会回滚，需要手动恢复")
+	}
+
+	switch *action {
+	case "up":
+		done, err := migrator.Up(*target)
+		if err != nil {
+			log.Fatalf("执行迁移失败: %v", err)
+		}
+		fmt.Printf("完成 %d 个迁移\n", len(done))
+	case "down":
+		done, err := migrator.Down(*steps)
+		if err != nil {
+			log.Fatalf("回滚迁移失败: %v", err)
+		}
+		fmt.Printf("回滚 %d 个迁移\n", len(done))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("读取迁移状态失败: %v", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "未执行"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-40s %s\n", status.Migration, appliedAt)
+		}
+	case "verify":
+		if err := migrator.Verify(); err != nil {
+			log.Fatalf("校验迁移失败: %v", err)
+		}
+		fmt.Println("迁移校验通过")
+	default:
+		log.Fatalf("未知的操作: %s", *action)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,17 +14,36 @@ mapstructure它是一个结构体标签（struct tag），用于指定配置文
 */
 // Config 配置结构体
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	MySQL  MySQLConfig  `mapstructure:"mysql"`
-	Redis  RedisConfig  `mapstructure:"redis"`
-	JWT    JWTConfig    `mapstructure:"jwt"`
-	Log    LogConfig    `mapstructure:"log"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	MySQL       MySQLConfig       `mapstructure:"mysql"`
+	SQLite      SQLiteConfig      `mapstructure:"sqlite"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Log         LogConfig         `mapstructure:"log"`
+	Trash       TrashConfig       `mapstructure:"trash"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Webhook     WebhookConfig     `mapstructure:"webhook"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port           int    `mapstructure:"port"`
+	Mode           string `mapstructure:"mode"`
+	RequireIfMatch bool   `mapstructure:"require_if_match"` // 更新任务时必须提供 If-Match 请求头
+}
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// DatabaseConfig 数据库通用配置
+type DatabaseConfig struct {
+	Driver      string `mapstructure:"driver"`       // mysql 或 sqlite，默认 mysql
+	AutoMigrate bool   `mapstructure:"auto_migrate"` // 启动时自动执行数据库迁移
 }
 
 // MySQLConfig MySQL配置
@@ -48,6 +68,24 @@ func (c MySQLConfig) DSN() string {
 		c.Charset, c.ParseTime, c.Loc)
 }
 
+// SQLiteConfig SQLite配置
+type SQLiteConfig struct {
+	Path string `mapstructure:"path"` // 数据库文件路径，":memory:" 表示内存数据库
+}
+
+// DSN 返回SQLite连接字符串，开启外键约束
+func (c SQLiteConfig) DSN() string {
+	path := c.Path
+	if path == "" {
+		path = ":memory:"
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_foreign_keys=on"
+}
+
 // RedisConfig Redis配置
 type RedisConfig struct {
 	Host            string        `mapstructure:"host"`
@@ -64,11 +102,29 @@ func (c RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// 支持的缓存驱动
+const (
+	CacheDriverRedis  = "redis"
+	CacheDriverMemory = "memory" // 进程内缓存，只适用于单实例部署
+	CacheDriverNone   = "none"
+)
+
+// CacheConfig 仓库缓存配置，缓存按ID读取的任务和用户，修改时失效
+type CacheConfig struct {
+	Driver     string        `mapstructure:"driver"`      // redis、memory 或 none，默认 none
+	TTLSeconds time.Duration `mapstructure:"ttl_seconds"` // 缓存条目的过期时间
+}
+
+// DefaultCacheTTL 未配置时缓存条目的过期时间
+const DefaultCacheTTL = 5 * time.Minute
+
 // JWTConfig JWT配置
 type JWTConfig struct {
-	SecretKey   string        `mapstructure:"secret_key"`
-	ExpireHours time.Duration `mapstructure:"expire_hours"`
-	Issuer      string        `mapstructure:"issuer"`
+	SecretKey           string        `mapstructure:"secret_key"`
+	ExpireHours         time.Duration `mapstructure:"expire_hours"`
+	AccessExpireMinutes time.Duration `mapstructure:"access_expire_minutes"` // 访问令牌有效期
+	RefreshExpireHours  time.Duration `mapstructure:"refresh_expire_hours"`  // 刷新令牌有效期
+	Issuer              string        `mapstructure:"issuer"`
 }
 
 // LogConfig 日志配置
@@ -81,6 +137,43 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`
 }
 
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays time.Duration `mapstructure:"retention_days"` // 已删除任务的保留期限，超过后永久删除
+}
+
+// DefaultTrashRetention 未配置时回收站的保留期限
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// IdempotencyConfig 幂等键配置
+type IdempotencyConfig struct {
+	TTLHours time.Duration `mapstructure:"ttl_hours"` // 幂等键记录的保留时间，期间相同幂等键的重试返回首次请求的响应
+	// MaxBodyMB 携带幂等键的请求体的最大长度，计算指纹前读取整个请求体，超过时返回 413
+	MaxBodyMB int64 `mapstructure:"max_body_mb"`
+}
+
+// 幂等键配置的默认值
+const (
+	DefaultIdempotencyTTL       = 24 * time.Hour
+	DefaultIdempotencyMaxBodyMB = 10
+)
+
+// WebhookConfig Webhook 投递配置
+type WebhookConfig struct {
+	TimeoutSeconds   time.Duration `mapstructure:"timeout_seconds"`    // 单次投递的超时时间
+	MaxAttempts      int           `mapstructure:"max_attempts"`       // 最多投递次数，用尽后不再重试
+	RetryBaseSeconds time.Duration `mapstructure:"retry_base_seconds"` // 第一次失败后的重试等待时间，之后每次翻倍
+	// AllowPrivateNetworks 允许投递到本机、内网和链路本地地址，只用于本地开发和测试
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// Webhook 投递配置的默认值
+const (
+	DefaultWebhookTimeout     = 10 * time.Second
+	DefaultWebhookMaxAttempts = 8
+	DefaultWebhookRetryBase   = 30 * time.Second
+)
+
 var GlobalConfig Config
 
 // LoadConfig 加载配置
@@ -96,10 +189,45 @@ func LoadConfig(configFile string) error {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if GlobalConfig.Database.Driver == "" {
+		GlobalConfig.Database.Driver = DriverMySQL
+	}
+
 	// 转换时间单位
 	GlobalConfig.MySQL.ConnMaxLifetime *= time.Second
 	GlobalConfig.Redis.MaxConnLifetime *= time.Second
+	if GlobalConfig.Cache.Driver == "" {
+		GlobalConfig.Cache.Driver = CacheDriverNone
+	}
+	GlobalConfig.Cache.TTLSeconds *= time.Second
+	if GlobalConfig.Cache.TTLSeconds <= 0 {
+		GlobalConfig.Cache.TTLSeconds = DefaultCacheTTL
+	}
 	GlobalConfig.JWT.ExpireHours *= time.Hour
+	GlobalConfig.JWT.AccessExpireMinutes *= time.Minute
+	GlobalConfig.JWT.RefreshExpireHours *= time.Hour
+	GlobalConfig.Trash.RetentionDays *= 24 * time.Hour
+	if GlobalConfig.Trash.RetentionDays <= 0 {
+		GlobalConfig.Trash.RetentionDays = DefaultTrashRetention
+	}
+	GlobalConfig.Idempotency.TTLHours *= time.Hour
+	if GlobalConfig.Idempotency.TTLHours <= 0 {
+		GlobalConfig.Idempotency.TTLHours = DefaultIdempotencyTTL
+	}
+	if GlobalConfig.Idempotency.MaxBodyMB <= 0 {
+		GlobalConfig.Idempotency.MaxBodyMB = DefaultIdempotencyMaxBodyMB
+	}
+	GlobalConfig.Webhook.TimeoutSeconds *= time.Second
+	if GlobalConfig.Webhook.TimeoutSeconds <= 0 {
+		GlobalConfig.Webhook.TimeoutSeconds = DefaultWebhookTimeout
+	}
+	if GlobalConfig.Webhook.MaxAttempts <= 0 {
+		GlobalConfig.Webhook.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	GlobalConfig.Webhook.RetryBaseSeconds *= time.Second
+	if GlobalConfig.Webhook.RetryBaseSeconds <= 0 {
+		GlobalConfig.Webhook.RetryBaseSeconds = DefaultWebhookRetryBase
+	}
 
 	return nil
 }
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+)
+
+// Assigned godoc
+// @Summary 获取指派给我的任务
+// @Description 获取个人空间、共享任务和全部工作区中指派给当前用户的任务，支持与任务列表相同的过滤和排序参数
+// @Tags 任务指派
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(10)
+// @Param status query []string false "任务状态，多个用逗号分隔或重复传参" collectionFormat(csv) Enums(todo,in_progress,done)
+// @Param sort query string false "排序字段，逗号分隔，前缀 - 表示降序，如 due_date,-created_at"
+// @Success 200 {object} Response{data=ListTasksResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/assigned [get]
+func (h *TaskHandler) Assigned(c *gin.Context) {
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	filter.UserID = middleware.GetUserID(c)
+	filter.AllScopes = true
+
+	tasks, total, err := h.taskService.List(filter)
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "获取指派给我的任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	responseTasks := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responseTasks = append(responseTasks, newTaskResponse(task))
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取指派给我的任务成功",
+		Data: ListTasksResponse{
+			Total: total,
+			Items: responseTasks,
+		},
+	})
+}
+
+// SetAssignees godoc
+// @Summary 重新指派任务
+// @Description 将任务的负责人替换为给定的用户，空列表表示取消全部指派。需要编辑权限，负责人必须有权访问该任务
+// @Tags 任务指派
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param request body SetAssigneesRequest true "负责人"
+// @Success 200 {object} Response{data=TaskResponse} "指派成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/assignees [put]
+func (h *TaskHandler) SetAssignees(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	var req SetAssigneesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskService.SetAssignees(taskID, middleware.GetUserID(c), req.UserIDs)
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "指派任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "指派任务成功",
+		Data:    newTaskResponse(task),
+	})
+}
+
+// AddAssignee godoc
+// @Summary 添加负责人
+// @Description 为任务添加一个负责人，已是负责人时不做修改。需要编辑权限，负责人必须有权访问该任务
+// @Tags 任务指派
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param request body AddAssigneeRequest true "负责人"
+// @Success 200 {object} Response{data=TaskResponse} "添加成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/assignees [post]
+func (h *TaskHandler) AddAssignee(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	var req AddAssigneeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskService.AddAssignee(taskID, middleware.GetUserID(c), req.UserID)
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "添加负责人失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "添加负责人成功",
+		Data:    newTaskResponse(task),
+	})
+}
+
+// RemoveAssignee godoc
+// @Summary 移除负责人
+// @Description 移除任务的负责人，需要编辑权限；负责人可以移除自己
+// @Tags 任务指派
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param user_id path int true "负责人用户ID"
+// @Success 200 {object} Response{} "移除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务或负责人不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/assignees/{user_id} [delete]
+func (h *TaskHandler) RemoveAssignee(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+	assigneeID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	if err := h.taskService.RemoveAssignee(taskID, middleware.GetUserID(c), assigneeID); err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "移除负责人失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "移除负责人成功",
+	})
+}
+
+// SetAssigneesRequest 重新指派任务请求
+type SetAssigneesRequest struct {
+	UserIDs []int `json:"user_ids" binding:"required,dive,min=1"`
+}
+
+// AddAssigneeRequest 添加负责人请求
+type AddAssigneeRequest struct {
+	UserID int `json:"user_id" binding:"required,min=1"`
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"todolist/internal/middleware"
+	"todolist/internal/service"
+)
+
+// Batch godoc
+// @Summary 批量操作任务
+// @Description 在同一事务中按顺序执行创建、更新和删除操作，返回每一项的结果。
+// @Description atomic 为 true 时任意一项失败则全部回滚，响应状态码为失败项的状态码，其他项为 424；否则只回滚失败的项，响应状态码为 200。
+// @Description 更新操作的 task 为合并补丁，与 PATCH /tasks/{id} 相同
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body BatchRequest true "批量操作"
+// @Success 200 {object} Response{data=BatchResponse} "执行完成，失败项见 results"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/batch [post]
+func (h *TaskHandler) Batch(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	workspaceID := middleware.GetWorkspaceID(c)
+	ops := make([]service.BatchOperation, 0, len(req.Operations))
+	for i := range req.Operations {
+		op, err := req.Operations[i].toOperation(userID, workspaceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{
+				Code:    400,
+				Message: "请求参数错误",
+				Error:   fmt.Sprintf("operations[%d]: %v", i, err),
+			})
+			return
+		}
+		ops = append(ops, op)
+	}
+
+	results, err := h.taskService.Batch(userID, ops, service.BatchOptions{Atomic: req.Atomic})
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "批量操作失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := BatchResponse{Results: make([]BatchResultResponse, 0, len(results))}
+	status := http.StatusOK
+	for i, result := range results {
+		item := BatchResultResponse{
+			Index:  i,
+			Op:     result.Op,
+			ID:     result.TaskID,
+			Status: http.StatusOK,
+		}
+		if result.Err != nil {
+			item.Status = taskErrorStatus(result.Err)
+			item.Error = result.Err.Error()
+			response.Failed++
+			if req.Atomic && result.Err != service.ErrBatchAborted {
+				status = item.Status
+			}
+		} else {
+			response.Succeeded++
+		}
+		if result.Task != nil {
+			task := newTaskResponse(result.Task)
+			item.Task = &task
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	message := "批量操作成功"
+	if status != http.StatusOK {
+		message = "批量操作失败，已全部回滚"
+	} else if response.Failed > 0 {
+		message = "批量操作部分成功"
+	}
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Data:    response,
+	})
+}
+
+// toOperation 将请求转换为批量操作
+func (req *BatchOperationRequest) toOperation(userID, workspaceID int) (service.BatchOperation, error) {
+	op := service.BatchOperation{Op: req.Op, TaskID: req.ID}
+	switch req.Op {
+	case service.BatchOpCreate:
+		if len(req.Task) == 0 {
+			return op, errors.New("创建操作缺少 task")
+		}
+		var create CreateTaskRequest
+		if err := json.Unmarshal(req.Task, &create); err != nil {
+			return op, err
+		}
+		if err := binding.Validator.ValidateStruct(&create); err != nil {
+			return op, err
+		}
+		task, err := create.toTask(userID, workspaceID)
+		if err != nil {
+			return op, err
+		}
+		op.Task = task
+	case service.BatchOpUpdate:
+		if req.ID <= 0 {
+			return op, errors.New("更新操作缺少 id")
+		}
+		patchReq, err := parsePatchTaskRequest(req.Task)
+		if err != nil {
+			return op, err
+		}
+		if op.Patch, err = patchReq.toPatch(); err != nil {
+			return op, err
+		}
+		op.Patch.Version = req.Version
+	case service.BatchOpDelete:
+		if req.ID <= 0 {
+			return op, errors.New("删除操作缺少 id")
+		}
+		op.Delete = service.DeleteOptions{Cascade: req.Cascade}
+	}
+	return op, nil
+}
+
+// BatchRequest 批量操作请求
+type BatchRequest struct {
+	// Atomic 为 true 时任意一项失败则全部回滚
+	Atomic     bool                    `json:"atomic"`
+	Operations []BatchOperationRequest `json:"operations" binding:"required,min=1,dive"`
+}
+
+// BatchOperationRequest 批量操作中的一项
+type BatchOperationRequest struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	ID      int             `json:"id"`                        // 更新和删除的任务ID
+	Version int             `json:"version"`                   // 更新时客户端读取到的版本号，不为0时必须与当前版本一致
+	Cascade bool            `json:"cascade"`                   // 删除时是否一并删除子任务
+	Task    json.RawMessage `json:"task" swaggertype:"object"` // 创建时同创建任务请求，更新时为合并补丁
+}
+
+// BatchResponse 批量操作响应
+type BatchResponse struct {
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Results   []BatchResultResponse `json:"results"`
+}
+
+// BatchResultResponse 批量操作中一项的结果
+type BatchResultResponse struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	ID     int           `json:"id,omitempty"`
+	Status int           `json:"status"` // 该项的 HTTP 状态码
+	Error  string        `json:"error,omitempty"`
+	Task   *TaskResponse `json:"task,omitempty"`
+}
@@ -0,0 +1,606 @@
+package api
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/service"
+	"todolist/pkg/ical"
+)
+
+const (
+	// caldavPrefix CalDAV 服务的路径前缀
+	caldavPrefix = "/caldav/"
+	// caldavSyncTokenPrefix 同步令牌的前缀，RFC 6578 要求同步令牌是 URI
+	caldavSyncTokenPrefix = "urn:todolist:caldav:sync:"
+	// caldavRealm Basic 认证的域
+	caldavRealm = "TodoList CalDAV"
+	// caldavDisplayName 客户端中显示的任务集合名称
+	caldavDisplayName = "TodoList"
+	// calTodoContentType 任务集合中对象的类型
+	calTodoContentType = "text/calendar; charset=utf-8; component=VTODO"
+)
+
+// caldavMethods CalDAV 路径支持的方法
+var caldavMethods = []string{http.MethodOptions, "PROPFIND", "REPORT", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete}
+
+// 属性、报告和前置条件的名称
+var (
+	davResourceType            = xml.Name{Space: davNS, Local: "resourcetype"}
+	davDisplayName             = xml.Name{Space: davNS, Local: "displayname"}
+	davGetETag                 = xml.Name{Space: davNS, Local: "getetag"}
+	davGetContentType          = xml.Name{Space: davNS, Local: "getcontenttype"}
+	davGetLastModified         = xml.Name{Space: davNS, Local: "getlastmodified"}
+	davCurrentUserPrincipal    = xml.Name{Space: davNS, Local: "current-user-principal"}
+	davPrincipalURL            = xml.Name{Space: davNS, Local: "principal-URL"}
+	davSupportedReportSet      = xml.Name{Space: davNS, Local: "supported-report-set"}
+	davCurrentUserPrivilegeSet = xml.Name{Space: davNS, Local: "current-user-privilege-set"}
+	davSyncToken               = xml.Name{Space: davNS, Local: "sync-token"}
+	calHomeSet                 = xml.Name{Space: calDAVNS, Local: "calendar-home-set"}
+	calSupportedComponentSet   = xml.Name{Space: calDAVNS, Local: "supported-calendar-component-set"}
+	calSupportedCalendarData   = xml.Name{Space: calDAVNS, Local: "supported-calendar-data"}
+	calCalendarData            = xml.Name{Space: calDAVNS, Local: "calendar-data"}
+	csGetCTag                  = xml.Name{Space: calServerNS, Local: "getctag"}
+
+	davSyncCollection   = xml.Name{Space: davNS, Local: "sync-collection"}
+	calCalendarQuery    = xml.Name{Space: calDAVNS, Local: "calendar-query"}
+	calCalendarMultiget = xml.Name{Space: calDAVNS, Local: "calendar-multiget"}
+
+	davSupportedReport    = xml.Name{Space: davNS, Local: "supported-report"}
+	davValidSyncToken     = xml.Name{Space: davNS, Local: "valid-sync-token"}
+	calNoUIDConflict      = xml.Name{Space: calDAVNS, Local: "no-uid-conflict"}
+	calSupportedComponent = xml.Name{Space: calDAVNS, Local: "supported-calendar-component"}
+	calValidCalendarData  = xml.Name{Space: calDAVNS, Local: "valid-calendar-data"}
+)
+
+var (
+	// calSupportedReportSet 任务集合支持的报告
+	calSupportedReportSet = supportedReports(calCalendarQuery, calCalendarMultiget, davSyncCollection)
+	// calCollectionPrivilege 用户对自己的任务集合拥有的权限
+	calCollectionPrivilege = privileges("read", "write", "write-content", "bind", "unbind")
+)
+
+// caldavPathKind CalDAV 路径对应的资源类型
+type caldavPathKind int
+
+const (
+	caldavRoot       caldavPathKind = iota // /caldav/
+	caldavPrincipal                        // /caldav/{user_id}/，同时作为日历主目录
+	caldavCollection                       // /caldav/{user_id}/tasks/
+	caldavObject                           // /caldav/{user_id}/tasks/{name}
+)
+
+// caldavPath 解析后的 CalDAV 路径
+type caldavPath struct {
+	kind   caldavPathKind
+	userID int
+	name   string
+}
+
+// CalDAVHandler CalDAV 处理器。
+// 按 RFC 4791 提供每个用户一个 VTODO 任务集合，客户端可以双向同步任务；
+// 使用 WebDAV 方法和 XML 请求体，不在 Swagger 文档中描述
+type CalDAVHandler struct {
+	caldavService service.CalDAVService
+	userService   service.UserService
+}
+
+// NewCalDAVHandler 创建 CalDAV 处理器，userService 用于校验 Basic 认证的用户名和密码
+func NewCalDAVHandler(caldavService service.CalDAVService, userService service.UserService) *CalDAVHandler {
+	return &CalDAVHandler{
+		caldavService: caldavService,
+		userService:   userService,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *CalDAVHandler) RegisterRoutes(r *gin.Engine) {
+	// RFC 6764 服务发现
+	for _, method := range []string{http.MethodGet, "PROPFIND"} {
+		r.Handle(method, "/.well-known/caldav", func(c *gin.Context) {
+			c.Redirect(http.StatusMovedPermanently, caldavPrefix)
+		})
+	}
+
+	caldav := r.Group("/caldav")
+	caldav.Use(h.authenticate)
+	for _, method := range caldavMethods {
+		caldav.Handle(method, "/*path", h.Serve)
+	}
+}
+
+// authenticate CalDAV 客户端不支持访问令牌，使用 Basic 认证，用户名和密码与登录相同
+func (h *CalDAVHandler) authenticate(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		unauthorizedCalDAV(c, "未提供认证信息")
+		return
+	}
+	user, err := h.userService.Authenticate(username, password)
+	if err == service.ErrUserNotFound || err == service.ErrInvalidPassword {
+		unauthorizedCalDAV(c, "用户名或密码错误")
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, Response{
+			Code:    500,
+			Message: "校验认证信息失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Set(middleware.ContextKeyUserID, user.ID)
+	c.Set(middleware.ContextKeyUsername, user.Username)
+	c.Next()
+}
+
+// unauthorizedCalDAV 返回 401 并要求客户端使用 Basic 认证
+func unauthorizedCalDAV(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Basic realm="`+caldavRealm+`", charset="UTF-8"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, Response{
+		Code:    401,
+		Message: message,
+	})
+}
+
+// Serve 按路径和方法分发 CalDAV 请求
+func (h *CalDAVHandler) Serve(c *gin.Context) {
+	path, ok := parseCalDAVPath(c.Param("path"))
+	if !ok {
+		c.JSON(http.StatusNotFound, Response{
+			Code:    404,
+			Message: "资源不存在",
+		})
+		return
+	}
+	userID := middleware.GetUserID(c)
+	if path.kind != caldavRoot && path.userID != userID {
+		c.JSON(http.StatusForbidden, Response{
+			Code:    403,
+			Message: "无权访问其他用户的任务集合",
+		})
+		return
+	}
+
+	c.Header("DAV", "1, 3, calendar-access")
+	method := c.Request.Method
+	switch {
+	case method == http.MethodOptions:
+		c.Header("Allow", strings.Join(caldavMethods, ", "))
+		c.Status(http.StatusOK)
+	case method == "PROPFIND":
+		h.propfind(c, path)
+	case method == "REPORT" && path.kind == caldavCollection:
+		h.report(c, userID)
+	case (method == http.MethodGet || method == http.MethodHead) && path.kind == caldavObject:
+		h.get(c, userID, path.name)
+	case method == http.MethodPut && path.kind == caldavObject:
+		h.put(c, userID, path.name)
+	case method == http.MethodDelete && path.kind == caldavObject:
+		h.delete(c, userID, path.name)
+	default:
+		c.JSON(http.StatusMethodNotAllowed, Response{
+			Code:    405,
+			Message: "该资源不支持此方法",
+		})
+	}
+}
+
+// parseCalDAVPath 解析 /caldav 之后的路径
+func parseCalDAVPath(path string) (caldavPath, bool) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return caldavPath{kind: caldavRoot}, true
+	}
+	parts := strings.Split(path, "/")
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil || userID <= 0 || len(parts) > 3 || (len(parts) > 1 && parts[1] != "tasks") {
+		return caldavPath{}, false
+	}
+	switch len(parts) {
+	case 1:
+		return caldavPath{kind: caldavPrincipal, userID: userID}, true
+	case 2:
+		return caldavPath{kind: caldavCollection, userID: userID}, true
+	}
+	return caldavPath{kind: caldavObject, userID: userID, name: parts[2]}, true
+}
+
+// principalHref 用户主体和日历主目录的地址
+func principalHref(userID int) string {
+	return caldavPrefix + strconv.Itoa(userID) + "/"
+}
+
+// collectionHref 用户任务集合的地址
+func collectionHref(userID int) string {
+	return principalHref(userID) + "tasks/"
+}
+
+// objectHref 任务集合中对象的地址
+func objectHref(userID int, name string) string {
+	return collectionHref(userID) + url.PathEscape(name)
+}
+
+// propfind 返回资源的属性，Depth 为1或 infinity 时同时返回直接子资源
+func (h *CalDAVHandler) propfind(c *gin.Context, path caldavPath) {
+	var req davPropfind
+	ok, err := readDAVBody(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求体不是有效的 PROPFIND",
+			Error:   err.Error(),
+		})
+		return
+	}
+	var names []xml.Name
+	if ok && req.AllProp == nil {
+		names = req.Prop
+	}
+	propName := req.PropName != nil
+	children := c.GetHeader("Depth") != "0"
+
+	userID := middleware.GetUserID(c)
+	var responses []davResponse
+	add := func(href string, props []davProperty) {
+		// allprop 不返回日历数据
+		responses = append(responses, selectDAVProperties(href, props, names, propName, calCalendarData))
+	}
+
+	switch path.kind {
+	case caldavRoot:
+		add(caldavPrefix, h.rootProperties(userID))
+		if children {
+			add(principalHref(userID), h.principalProperties(c, userID))
+		}
+	case caldavPrincipal:
+		add(principalHref(userID), h.principalProperties(c, userID))
+		if children {
+			seq, err := h.caldavService.SyncToken(userID)
+			if err != nil {
+				respondCalDAVError(c, "获取任务集合失败", err)
+				return
+			}
+			add(collectionHref(userID), h.collectionProperties(userID, seq))
+		}
+	case caldavCollection:
+		if !children {
+			seq, err := h.caldavService.SyncToken(userID)
+			if err != nil {
+				respondCalDAVError(c, "获取任务集合失败", err)
+				return
+			}
+			add(collectionHref(userID), h.collectionProperties(userID, seq))
+			break
+		}
+		resources, seq, err := h.caldavService.Collection(userID)
+		if err != nil {
+			respondCalDAVError(c, "获取任务集合失败", err)
+			return
+		}
+		add(collectionHref(userID), h.collectionProperties(userID, seq))
+		withData := containsDAVName(names, calCalendarData)
+		for _, resource := range resources {
+			// 加载之前被删除或取消共享的任务不再列出，下一次同步时对象被标记为已删除
+			if resource.Task == nil {
+				continue
+			}
+			add(objectHref(userID, resource.Object.Name), objectProperties(resource, withData))
+		}
+	case caldavObject:
+		resource, err := h.caldavService.Get(userID, path.name)
+		if err != nil {
+			respondCalDAVError(c, "获取任务失败", err)
+			return
+		}
+		add(objectHref(userID, path.name), objectProperties(*resource, containsDAVName(names, calCalendarData)))
+	}
+	writeMultistatus(c, responses, "")
+}
+
+// rootProperties 服务根路径的属性，客户端从这里找到当前用户的主体
+func (h *CalDAVHandler) rootProperties(userID int) []davProperty {
+	return []davProperty{
+		{Name: davResourceType, Value: "<D:collection/>"},
+		{Name: davCurrentUserPrincipal, Value: davHref(principalHref(userID))},
+	}
+}
+
+// principalProperties 用户主体的属性，日历主目录与主体使用同一地址
+func (h *CalDAVHandler) principalProperties(c *gin.Context, userID int) []davProperty {
+	return []davProperty{
+		{Name: davResourceType, Value: "<D:collection/><D:principal/>"},
+		{Name: davDisplayName, Value: davEscape(middleware.GetUsername(c))},
+		{Name: davCurrentUserPrincipal, Value: davHref(principalHref(userID))},
+		{Name: davPrincipalURL, Value: davHref(principalHref(userID))},
+		{Name: calHomeSet, Value: davHref(principalHref(userID))},
+	}
+}
+
+// collectionProperties 任务集合的属性，getctag 与同步令牌相同
+func (h *CalDAVHandler) collectionProperties(userID int, seq int64) []davProperty {
+	token := davEscape(formatSyncToken(seq))
+	return []davProperty{
+		{Name: davResourceType, Value: "<D:collection/><C:calendar/>"},
+		{Name: davDisplayName, Value: caldavDisplayName},
+		{Name: davCurrentUserPrincipal, Value: davHref(principalHref(userID))},
+		{Name: davCurrentUserPrivilegeSet, Value: calCollectionPrivilege},
+		{Name: davSupportedReportSet, Value: calSupportedReportSet},
+		{Name: calSupportedComponentSet, Value: `<C:comp name="VTODO"/>`},
+		{Name: calSupportedCalendarData, Value: `<C:calendar-data content-type="text/calendar" version="2.0"/>`},
+		{Name: davSyncToken, Value: token},
+		{Name: csGetCTag, Value: token},
+	}
+}
+
+// objectProperties 对象的属性，withData 为 true 时包括日历数据。resource.Task 不能为 nil
+func objectProperties(resource service.CalDAVResource, withData bool) []davProperty {
+	props := []davProperty{
+		{Name: davResourceType},
+		{Name: davGetETag, Value: davEscape(resource.ETag())},
+		{Name: davGetContentType, Value: calTodoContentType},
+		{Name: davGetLastModified, Value: resource.Task.UpdatedAt.UTC().Format(http.TimeFormat)},
+	}
+	if withData {
+		props = append(props, davProperty{Name: calCalendarData, Value: davEscape(resource.Calendar().String())})
+	}
+	return props
+}
+
+// supportedReports 生成 supported-report-set 的内容
+func supportedReports(reports ...xml.Name) string {
+	var w davXMLWriter
+	for _, report := range reports {
+		w.WriteString("<D:supported-report><D:report>")
+		w.element(report, "")
+		w.WriteString("</D:report></D:supported-report>")
+	}
+	return w.String()
+}
+
+// privileges 生成 current-user-privilege-set 的内容
+func privileges(names ...string) string {
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString("<D:privilege><D:" + name + "/></D:privilege>")
+	}
+	return sb.String()
+}
+
+// report 处理任务集合上的 calendar-query、calendar-multiget 和 sync-collection 报告
+func (h *CalDAVHandler) report(c *gin.Context, userID int) {
+	var req davReport
+	ok, err := readDAVBody(c, &req)
+	if err != nil || !ok {
+		message := "请求体不能为空"
+		if err != nil {
+			message = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求体不是有效的 REPORT",
+			Error:   message,
+		})
+		return
+	}
+	var names []xml.Name
+	if req.AllProp == nil {
+		names = req.Prop
+	}
+	withData := containsDAVName(names, calCalendarData)
+
+	var responses []davResponse
+	add := func(resource service.CalDAVResource) {
+		responses = append(responses, selectDAVProperties(objectHref(userID, resource.Object.Name), objectProperties(resource, withData), names, false, calCalendarData))
+	}
+
+	switch req.XMLName {
+	case calCalendarQuery:
+		resources, _, err := h.caldavService.Collection(userID)
+		if err != nil {
+			respondCalDAVError(c, "查询任务失败", err)
+			return
+		}
+		if queryMatchesTodo(req.Filter.CompFilter) {
+			for _, resource := range resources {
+				if resource.Task == nil {
+					continue
+				}
+				add(resource)
+			}
+		}
+		writeMultistatus(c, responses, "")
+
+	case calCalendarMultiget:
+		for _, href := range req.Hrefs {
+			name, ok := hrefObjectName(userID, href)
+			if !ok {
+				responses = append(responses, davResponse{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			resource, err := h.caldavService.Get(userID, name)
+			if err == service.ErrCalDAVObjectNotFound {
+				responses = append(responses, davResponse{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			if err != nil {
+				respondCalDAVError(c, "获取任务失败", err)
+				return
+			}
+			add(*resource)
+		}
+		writeMultistatus(c, responses, "")
+
+	case davSyncCollection:
+		var since int64
+		if req.SyncToken != "" {
+			if since, ok = parseSyncToken(req.SyncToken); !ok {
+				writeDAVError(c, http.StatusForbidden, davValidSyncToken)
+				return
+			}
+		}
+		changes, seq, err := h.caldavService.Changes(userID, since)
+		if err != nil {
+			respondCalDAVError(c, "同步任务失败", err)
+			return
+		}
+		for _, resource := range changes {
+			if resource.Task == nil {
+				responses = append(responses, davResponse{Href: objectHref(userID, resource.Object.Name), Status: http.StatusNotFound})
+				continue
+			}
+			add(resource)
+		}
+		writeMultistatus(c, responses, formatSyncToken(seq))
+
+	default:
+		writeDAVError(c, http.StatusForbidden, davSupportedReport)
+	}
+}
+
+// queryMatchesTodo 判断 calendar-query 的组件过滤条件是否包括 VTODO。
+// 只按组件名称过滤，时间范围和属性过滤条件被忽略，返回的结果可能多于条件，由客户端进一步过滤
+func queryMatchesTodo(filter davCompFilter) bool {
+	if filter.Name == "" {
+		return true
+	}
+	if filter.Name != "VCALENDAR" {
+		return false
+	}
+	if len(filter.CompFilters) == 0 {
+		return true
+	}
+	for _, child := range filter.CompFilters {
+		if child.Name == service.CalendarComponentTodo {
+			return true
+		}
+	}
+	return false
+}
+
+// hrefObjectName 从 href 中取出任务集合中对象的名称，href 可以是完整的 URL
+func hrefObjectName(userID int, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(u.Path, collectionHref(userID))
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+// formatSyncToken 将同步序号格式化为同步令牌
+func formatSyncToken(seq int64) string {
+	return caldavSyncTokenPrefix + strconv.FormatInt(seq, 10)
+}
+
+// parseSyncToken 解析同步令牌，返回同步序号
+func parseSyncToken(token string) (int64, bool) {
+	value, ok := strings.CutPrefix(token, caldavSyncTokenPrefix)
+	if !ok {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(value, 10, 64)
+	return seq, err == nil && seq >= 0
+}
+
+// get 返回对象的日历数据
+func (h *CalDAVHandler) get(c *gin.Context, userID int, name string) {
+	resource, err := h.caldavService.Get(userID, name)
+	if err != nil {
+		respondCalDAVError(c, "获取任务失败", err)
+		return
+	}
+
+	etag := resource.ETag()
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", resource.Task.UpdatedAt.UTC().Format(http.TimeFormat))
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, ical.ContentType, []byte(resource.Calendar().String()))
+}
+
+// put 用请求体中的待办创建或替换任务
+func (h *CalDAVHandler) put(c *gin.Context, userID int, name string) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDAVBodySize+1))
+	if err != nil {
+		respondCalDAVError(c, "保存任务失败", err)
+		return
+	}
+	if len(data) > maxDAVBodySize {
+		c.JSON(http.StatusRequestEntityTooLarge, Response{
+			Code:    413,
+			Message: "请求体过大",
+		})
+		return
+	}
+
+	_, created, err := h.caldavService.Put(userID, name, data, c.GetHeader("If-Match"), c.GetHeader("If-None-Match"))
+	if err != nil {
+		respondCalDAVError(c, "保存任务失败", err)
+		return
+	}
+	// 任务只保存待办的部分属性，与上传的内容不同，按 RFC 4791 不返回 ETag，客户端需要重新获取
+	if created {
+		c.Header("Location", objectHref(userID, name))
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// delete 删除对象对应的任务
+func (h *CalDAVHandler) delete(c *gin.Context, userID int, name string) {
+	if err := h.caldavService.Delete(userID, name, c.GetHeader("If-Match")); err != nil {
+		respondCalDAVError(c, "删除任务失败", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondCalDAVError 返回错误，RFC 4791 和 RFC 6578 定义的前置条件以 XML 返回，其他错误按任务接口的格式返回
+func respondCalDAVError(c *gin.Context, message string, err error) {
+	switch err {
+	case service.ErrCalDAVUIDConflict:
+		writeDAVError(c, http.StatusForbidden, calNoUIDConflict)
+		return
+	case service.ErrUnsupportedComponent:
+		writeDAVError(c, http.StatusForbidden, calSupportedComponent)
+		return
+	case service.ErrInvalidCalendarData:
+		writeDAVError(c, http.StatusForbidden, calValidCalendarData)
+		return
+	case service.ErrInvalidSyncToken:
+		writeDAVError(c, http.StatusForbidden, davValidSyncToken)
+		return
+	}
+
+	var status int
+	switch err {
+	case service.ErrCalDAVObjectNotFound:
+		status = http.StatusNotFound
+	case service.ErrCalDAVPrecondition:
+		status = http.StatusPreconditionFailed
+	case service.ErrInvalidCalDAVName:
+		status = http.StatusBadRequest
+	default:
+		status = taskErrorStatus(err)
+	}
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalDAV 使用的 XML 命名空间
+const (
+	davNS       = "DAV:"
+	calDAVNS    = "urn:ietf:params:xml:ns:caldav"
+	calServerNS = "http://calendarserver.org/ns/"
+)
+
+// maxDAVBodySize XML 和日历请求体的最大长度
+const maxDAVBodySize = 1 << 20
+
+// davPrefixes 输出时使用的命名空间前缀，其他命名空间在元素上单独声明
+var davPrefixes = map[string]string{davNS: "D", calDAVNS: "C", calServerNS: "CS"}
+
+// davPropNames <D:prop> 中请求的属性名
+type davPropNames []xml.Name
+
+// UnmarshalXML 只记录直接子元素的名称，忽略其内容
+func (p *davPropNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			*p = append(*p, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// davPropfind PROPFIND 请求体，请求体为空时等同于 allprop
+type davPropfind struct {
+	XMLName  xml.Name     `xml:"DAV: propfind"`
+	AllProp  *struct{}    `xml:"DAV: allprop"`
+	PropName *struct{}    `xml:"DAV: propname"`
+	Prop     davPropNames `xml:"DAV: prop"`
+}
+
+// davCompFilter CalDAV 查询中的组件过滤条件，只使用组件名称
+type davCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// davReport REPORT 请求体，XMLName 区分 calendar-query、calendar-multiget 和 sync-collection
+type davReport struct {
+	XMLName   xml.Name     `xml:""`
+	AllProp   *struct{}    `xml:"DAV: allprop"`
+	Prop      davPropNames `xml:"DAV: prop"`
+	Hrefs     []string     `xml:"DAV: href"`
+	SyncToken string       `xml:"DAV: sync-token"`
+	Filter    struct {
+		CompFilter davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// readDAVBody 解析 XML 请求体，请求体为空时返回 false
+func readDAVBody(c *gin.Context, v any) (bool, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDAVBodySize))
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return false, nil
+	}
+	return true, xml.Unmarshal(body, v)
+}
+
+// davProperty 资源的属性，Value 为已转义的 XML 内容
+type davProperty struct {
+	Name  xml.Name
+	Value string
+}
+
+// davResponse multistatus 中一个资源的结果，Status 不为0时表示整个资源的状态（如同步中已删除的资源）
+type davResponse struct {
+	Href    string
+	Status  int
+	Found   []davProperty
+	Missing []xml.Name
+}
+
+// selectDAVProperties 按请求选择属性：names 为空时返回 all 中除 exclude 外的全部属性，
+// propName 为 true 时只返回属性名，请求了不存在的属性时记录在 Missing 中
+func selectDAVProperties(href string, all []davProperty, names []xml.Name, propName bool, exclude ...xml.Name) davResponse {
+	response := davResponse{Href: href}
+	if len(names) == 0 {
+		for _, p := range all {
+			if containsDAVName(exclude, p.Name) {
+				continue
+			}
+			if propName {
+				p.Value = ""
+			}
+			response.Found = append(response.Found, p)
+		}
+		return response
+	}
+
+	for _, name := range names {
+		found := false
+		for _, p := range all {
+			if p.Name == name {
+				response.Found = append(response.Found, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			response.Missing = append(response.Missing, name)
+		}
+	}
+	return response
+}
+
+// containsDAVName 判断名称列表中是否包含 name
+func containsDAVName(names []xml.Name, name xml.Name) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// davXMLWriter 输出带固定前缀的 DAV XML
+type davXMLWriter struct {
+	strings.Builder
+	// extra 已声明的其他命名空间数量，用于生成前缀
+	extra int
+}
+
+// start 输出开始标签并返回标签名，命名空间没有固定前缀时在元素上声明
+func (w *davXMLWriter) start(name xml.Name, selfClosing bool) string {
+	prefix, ok := davPrefixes[name.Space]
+	declaration := ""
+	if !ok {
+		w.extra++
+		prefix = "X" + strconv.Itoa(w.extra)
+		declaration = " xmlns:" + prefix + `="` + davEscape(name.Space) + `"`
+	}
+	tag := prefix + ":" + name.Local
+	w.WriteString("<" + tag + declaration)
+	if selfClosing {
+		w.WriteString("/>")
+	} else {
+		w.WriteString(">")
+	}
+	return tag
+}
+
+// element 输出元素，value 为空时输出自闭合元素
+func (w *davXMLWriter) element(name xml.Name, value string) {
+	if value == "" {
+		w.start(name, true)
+		return
+	}
+	tag := w.start(name, false)
+	w.WriteString(value)
+	w.WriteString("</" + tag + ">")
+}
+
+// statusLine multistatus 中的状态行
+func statusLine(status int) string {
+	return "<D:status>HTTP/1.1 " + strconv.Itoa(status) + " " + http.StatusText(status) + "</D:status>"
+}
+
+// writeMultistatus 输出 207 Multi-Status，syncToken 不为空时附带同步令牌
+func writeMultistatus(c *gin.Context, responses []davResponse, syncToken string) {
+	var w davXMLWriter
+	w.WriteString(xml.Header)
+	w.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:CS="http://calendarserver.org/ns/">`)
+	for _, r := range responses {
+		w.WriteString("<D:response>" + davHref(r.Href))
+		if r.Status != 0 {
+			w.WriteString(statusLine(r.Status))
+		}
+		if len(r.Found) > 0 {
+			w.WriteString("<D:propstat><D:prop>")
+			for _, p := range r.Found {
+				w.element(p.Name, p.Value)
+			}
+			w.WriteString("</D:prop>" + statusLine(http.StatusOK) + "</D:propstat>")
+		}
+		if len(r.Missing) > 0 {
+			w.WriteString("<D:propstat><D:prop>")
+			for _, name := range r.Missing {
+				w.element(name, "")
+			}
+			w.WriteString("</D:prop>" + statusLine(http.StatusNotFound) + "</D:propstat>")
+		}
+		w.WriteString("</D:response>")
+	}
+	if syncToken != "" {
+		w.WriteString("<D:sync-token>" + davEscape(syncToken) + "</D:sync-token>")
+	}
+	w.WriteString("</D:multistatus>")
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(w.String()))
+}
+
+// writeDAVError 输出带前置条件元素的错误，如 <C:no-uid-conflict/>
+func writeDAVError(c *gin.Context, status int, condition xml.Name) {
+	var w davXMLWriter
+	w.WriteString(xml.Header)
+	w.WriteString(`<D:error xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`)
+	w.element(condition, "")
+	w.WriteString("</D:error>")
+	c.Data(status, "application/xml; charset=utf-8", []byte(w.String()))
+}
+
+// davEscape 转义 XML 文本
+func davEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// davHref 输出 <D:href> 元素
+func davHref(href string) string {
+	return "<D:href>" + davEscape(href) + "</D:href>"
+}
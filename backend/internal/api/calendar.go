@@ -0,0 +1,252 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/service"
+	"todolist/pkg/ical"
+)
+
+// calendarFeedPath 订阅地址的路径前缀，之后为令牌加上 .ics
+const calendarFeedPath = "/api/v1/calendar/feed/"
+
+// CalendarHandler 任务日历处理器
+type CalendarHandler struct {
+	calendarService service.CalendarService
+}
+
+// NewCalendarHandler 创建任务日历处理器
+func NewCalendarHandler(calendarService service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// Export godoc
+// @Summary 导出任务日历
+// @Description 将当前用户可以看到的有截止日期的任务导出为 iCalendar（RFC 5545）文件，范围与任务列表相同。
+// @Description 每个任务输出一个截止日期当天的 VEVENT 和一个带 DUE 的 VTODO，VTODO 的 STATUS 由任务状态映射：
+// @Description todo 为 NEEDS-ACTION，in_progress 为 IN-PROCESS，done 为 COMPLETED；重复任务带有 RRULE
+// @Tags 任务日历
+// @Produce text/calendar
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时导出个人任务和共享给当前用户的任务"
+// @Param component query string false "只输出一种组件" Enums(vevent,vtodo)
+// @Success 200 {string} string "iCalendar 文件"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "不是工作区成员"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/export.ics [get]
+func (h *CalendarHandler) Export(c *gin.Context) {
+	h.writeCalendar(c, middleware.GetUserID(c), middleware.GetWorkspaceID(c), "attachment; filename=\"tasks.ics\"")
+}
+
+// Feed godoc
+// @Summary 订阅任务日历
+// @Description 日历应用通过带订阅令牌的地址订阅任务日历，无需登录，内容与导出的日历相同。
+// @Description 令牌通过 POST /calendar/token 生成，重新生成或吊销后旧地址失效
+// @Tags 任务日历
+// @Produce text/calendar
+// @Param token path string true "订阅令牌加上 .ics"
+// @Param workspace_id query int false "工作区ID，不提供时为个人任务和共享给令牌所属用户的任务"
+// @Param component query string false "只输出一种组件" Enums(vevent,vtodo)
+// @Success 200 {string} string "iCalendar 文件"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 403 {object} Response{} "不是工作区成员"
+// @Failure 404 {object} Response{} "订阅令牌无效"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /calendar/feed/{token} [get]
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	userID, err := h.calendarService.Authenticate(strings.TrimSuffix(c.Param("token"), ".ics"))
+	if err != nil {
+		respondCalendarError(c, "订阅任务日历失败", err)
+		return
+	}
+
+	workspaceID := 0
+	if value := c.Query("workspace_id"); value != "" {
+		if workspaceID, err = strconv.Atoi(value); err != nil || workspaceID <= 0 {
+			c.JSON(http.StatusBadRequest, Response{
+				Code:    400,
+				Message: "无效的工作区ID",
+			})
+			return
+		}
+	}
+	h.writeCalendar(c, userID, workspaceID, "inline; filename=\"tasks.ics\"")
+}
+
+// writeCalendar 生成并输出日历
+func (h *CalendarHandler) writeCalendar(c *gin.Context, userID, workspaceID int, disposition string) {
+	var components []string
+	switch c.Query("component") {
+	case "":
+	case "vevent":
+		components = []string{service.CalendarComponentEvent}
+	case "vtodo":
+		components = []string{service.CalendarComponentTodo}
+	default:
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   "component 参数应为 vevent 或 vtodo",
+		})
+		return
+	}
+
+	calendar, err := h.calendarService.Export(userID, workspaceID, components)
+	if err != nil {
+		respondCalendarError(c, "导出任务日历失败", err)
+		return
+	}
+
+	c.Header("Content-Disposition", disposition)
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, ical.ContentType, []byte(calendar.String()))
+}
+
+// GetToken godoc
+// @Summary 获取日历订阅令牌
+// @Description 返回当前用户是否已生成订阅令牌及生成时间，令牌明文只在生成时返回
+// @Tags 任务日历
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=CalendarTokenResponse} "获取成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "尚未生成订阅令牌"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /calendar/token [get]
+func (h *CalendarHandler) GetToken(c *gin.Context) {
+	token, err := h.calendarService.GetToken(middleware.GetUserID(c))
+	if err != nil {
+		respondCalendarError(c, "获取订阅令牌失败", err)
+		return
+	}
+	if token == nil {
+		c.JSON(http.StatusNotFound, Response{
+			Code:    404,
+			Message: "尚未生成订阅令牌",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取订阅令牌成功",
+		Data:    CalendarTokenResponse{CreatedAt: token.CreatedAt},
+	})
+}
+
+// CreateToken godoc
+// @Summary 生成日历订阅令牌
+// @Description 生成新的订阅令牌并返回订阅地址，已有令牌时替换，旧地址随即失效。
+// @Description 订阅地址可以直接添加到日历应用，拥有地址的人都可以读取日历，请妥善保管
+// @Tags 任务日历
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=CalendarTokenResponse} "生成成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /calendar/token [post]
+func (h *CalendarHandler) CreateToken(c *gin.Context) {
+	raw, token, err := h.calendarService.CreateToken(middleware.GetUserID(c))
+	if err != nil {
+		respondCalendarError(c, "生成订阅令牌失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "生成订阅令牌成功",
+		Data: CalendarTokenResponse{
+			Token:     raw,
+			URL:       calendarFeedURL(c, raw),
+			CreatedAt: token.CreatedAt,
+		},
+	})
+}
+
+// RevokeToken godoc
+// @Summary 吊销日历订阅令牌
+// @Description 吊销当前用户的订阅令牌，之前的订阅地址失效
+// @Tags 任务日历
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{} "吊销成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /calendar/token [delete]
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	if err := h.calendarService.RevokeToken(middleware.GetUserID(c)); err != nil {
+		respondCalendarError(c, "吊销订阅令牌失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "吊销订阅令牌成功",
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *CalendarHandler) RegisterRoutes(r *gin.Engine) {
+	tasks := r.Group("/api/v1/tasks")
+	tasks.Use(middleware.AuthMiddleware(), middleware.WorkspaceMiddleware())
+	{
+		tasks.GET("/export.ics", h.Export)
+	}
+
+	// 日历应用无法携带访问令牌，订阅地址通过路径中的订阅令牌认证
+	r.GET(calendarFeedPath+":token", h.Feed)
+
+	token := r.Group("/api/v1/calendar/token")
+	token.Use(middleware.AuthMiddleware())
+	{
+		token.GET("", h.GetToken)
+		token.POST("", h.CreateToken)
+		token.DELETE("", h.RevokeToken)
+	}
+}
+
+// calendarFeedURL 根据当前请求的地址生成订阅地址
+func calendarFeedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + calendarFeedPath + token + ".ics"
+}
+
+// respondCalendarError 根据日历服务的错误返回对应的状态码
+func respondCalendarError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrInvalidCalendarToken:
+		status = http.StatusNotFound
+	case service.ErrNotWorkspaceMember:
+		status = http.StatusForbidden
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// CalendarTokenResponse 日历订阅令牌响应，Token 和 URL 只在生成时返回
+type CalendarTokenResponse struct {
+	Token     string    `json:"token,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
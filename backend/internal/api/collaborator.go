@@ -0,0 +1,191 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/service"
+)
+
+// CollaboratorHandler 任务协作者处理器
+type CollaboratorHandler struct {
+	collaboratorService service.CollaboratorService
+}
+
+// NewCollaboratorHandler 创建任务协作者处理器
+func NewCollaboratorHandler(collaboratorService service.CollaboratorService) *CollaboratorHandler {
+	return &CollaboratorHandler{
+		collaboratorService: collaboratorService,
+	}
+}
+
+// List godoc
+// @Summary 获取任务协作者
+// @Description 获取任务的所有者和协作者，所有者排在第一位
+// @Tags 任务协作
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} Response{data=[]model.TaskCollaborator} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/collaborators [get]
+func (h *CollaboratorHandler) List(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	collaborators, err := h.collaboratorService.List(taskID, middleware.GetUserID(c))
+	if err != nil {
+		respondCollaboratorError(c, "获取协作者失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取协作者成功",
+		Data:    collaborators,
+	})
+}
+
+// Invite godoc
+// @Summary 邀请协作者
+// @Description 按用户名邀请协作者，已是协作者时更新其角色，只有任务所有者可以邀请。共享任务时其子任务一并共享
+// @Tags 任务协作
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param request body InviteCollaboratorRequest true "协作者信息"
+// @Success 200 {object} Response{data=model.TaskCollaborator} "邀请成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务或用户不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/collaborators [post]
+func (h *CollaboratorHandler) Invite(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	var req InviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	collaborator, err := h.collaboratorService.Invite(taskID, middleware.GetUserID(c), req.Username, req.Role)
+	if err != nil {
+		respondCollaboratorError(c, "邀请协作者失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "邀请协作者成功",
+		Data:    collaborator,
+	})
+}
+
+// Remove godoc
+// @Summary 移除协作者
+// @Description 任务所有者可以移除任何协作者，协作者可以移除自己以退出共享
+// @Tags 任务协作
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param user_id path int true "协作者用户ID"
+// @Success 200 {object} Response{} "移除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务或协作者不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/collaborators/{user_id} [delete]
+func (h *CollaboratorHandler) Remove(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+	collaboratorID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	if err := h.collaboratorService.Remove(taskID, middleware.GetUserID(c), collaboratorID); err != nil {
+		respondCollaboratorError(c, "移除协作者失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "移除协作者成功",
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *CollaboratorHandler) RegisterRoutes(r *gin.Engine) {
+	collaborators := r.Group("/api/v1/tasks/:id/collaborators")
+	collaborators.Use(middleware.AuthMiddleware())
+	{
+		collaborators.GET("", h.List)
+		collaborators.POST("", h.Invite)
+		collaborators.DELETE("/:user_id", h.Remove)
+	}
+}
+
+// respondCollaboratorError 根据协作者服务的错误返回对应的状态码
+func respondCollaboratorError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrInvalidCollaboratorRole, service.ErrCollaboratorIsOwner:
+		status = http.StatusBadRequest
+	case service.ErrTaskOwnerOnly:
+		status = http.StatusForbidden
+	case service.ErrTaskNotFound, service.ErrTaskAccessDenied, service.ErrUserNotFound, service.ErrCollaboratorNotFound:
+		// 不区分不存在和无权访问，避免泄露其他用户的任务
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// InviteCollaboratorRequest 邀请协作者请求
+type InviteCollaboratorRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=editor viewer"`
+}
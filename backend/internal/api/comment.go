@@ -0,0 +1,263 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// CommentHandler 任务评论处理器
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+// NewCommentHandler 创建任务评论处理器
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// List godoc
+// @Summary 获取任务评论
+// @Description 分页获取任务的评论，按发表顺序排序，有权访问任务的用户都可以查看
+// @Tags 任务评论
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(20)
+// @Success 200 {object} Response{data=ListCommentsResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/comments [get]
+func (h *CommentHandler) List(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(service.DefaultPageSize)))
+
+	comments, total, err := h.commentService.List(taskID, middleware.GetUserID(c), page, pageSize)
+	if err != nil {
+		respondCommentError(c, "获取评论失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取评论成功",
+		Data: ListCommentsResponse{
+			Total: total,
+			Items: comments,
+		},
+	})
+}
+
+// Create godoc
+// @Summary 发表评论
+// @Description 在任务下发表评论，有权访问任务的用户都可以发表
+// @Tags 任务评论
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param request body CommentRequest true "评论内容"
+// @Success 200 {object} Response{data=model.Comment} "发表成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/comments [post]
+func (h *CommentHandler) Create(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	comment := &model.Comment{
+		TaskID: taskID,
+		UserID: middleware.GetUserID(c),
+		Body:   req.Body,
+	}
+	if err := h.commentService.Create(comment); err != nil {
+		respondCommentError(c, "发表评论失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "发表评论成功",
+		Data:    comment,
+	})
+}
+
+// Update godoc
+// @Summary 编辑评论
+// @Description 编辑评论内容并记录编辑时间，只有评论作者可以编辑
+// @Tags 任务评论
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param comment_id path int true "评论ID"
+// @Param request body CommentRequest true "评论内容"
+// @Success 200 {object} Response{data=model.Comment} "编辑成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务或评论不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/comments/{comment_id} [put]
+func (h *CommentHandler) Update(c *gin.Context) {
+	taskID, commentID, ok := parseCommentPath(c)
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.Update(taskID, commentID, middleware.GetUserID(c), req.Body)
+	if err != nil {
+		respondCommentError(c, "编辑评论失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "编辑评论成功",
+		Data:    comment,
+	})
+}
+
+// Delete godoc
+// @Summary 删除评论
+// @Description 删除评论，评论作者和任务所有者可以删除
+// @Tags 任务评论
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param comment_id path int true "评论ID"
+// @Success 200 {object} Response{} "删除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务或评论不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/comments/{comment_id} [delete]
+func (h *CommentHandler) Delete(c *gin.Context) {
+	taskID, commentID, ok := parseCommentPath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.Delete(taskID, commentID, middleware.GetUserID(c)); err != nil {
+		respondCommentError(c, "删除评论失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "删除评论成功",
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *CommentHandler) RegisterRoutes(r *gin.Engine) {
+	comments := r.Group("/api/v1/tasks/:id/comments")
+	comments.Use(middleware.AuthMiddleware())
+	{
+		comments.GET("", h.List)
+		comments.POST("", h.Create)
+		comments.PUT("/:comment_id", h.Update)
+		comments.DELETE("/:comment_id", h.Delete)
+	}
+}
+
+// parseCommentPath 解析路径中的任务ID和评论ID，失败时写入错误响应
+func parseCommentPath(c *gin.Context) (int, int, bool) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return 0, 0, false
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的评论ID",
+		})
+		return 0, 0, false
+	}
+	return taskID, commentID, true
+}
+
+// respondCommentError 根据评论服务的错误返回对应的状态码
+func respondCommentError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrEmptyComment, service.ErrCommentTooLong:
+		status = http.StatusBadRequest
+	case service.ErrCommentAuthorOnly, service.ErrCommentDeleteDenied:
+		status = http.StatusForbidden
+	case service.ErrTaskNotFound, service.ErrTaskAccessDenied, service.ErrCommentNotFound:
+		// 不区分不存在和无权访问，避免泄露其他用户的任务
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// CommentRequest 发表或编辑评论请求
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// ListCommentsResponse 评论列表响应
+type ListCommentsResponse struct {
+	Total int64            `json:"total"`
+	Items []*model.Comment `json:"items"`
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/config"
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// errInvalidIfMatch If-Match 请求头格式错误
+var errInvalidIfMatch = errors.New("If-Match 应为单个 ETag，如 \"3\"，或 *")
+
+// taskETag 根据任务版本号生成强 ETag
+func taskETag(task *model.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// parseIfMatch 解析 If-Match 请求头，返回期望的任务版本号
+// 未提供或为 * 时返回0表示不检查；弱 ETag 按 RFC 9110 永远不匹配，返回-1
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return -1, nil
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version <= 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// ifMatchVersion 读取 If-Match 请求头中的版本号，配置要求必须提供时缺少请求头返回 428；
+// 请求头无效时已写入错误响应，返回 false
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" && config.GlobalConfig.Server.RequireIfMatch {
+		c.JSON(http.StatusPreconditionRequired, Response{
+			Code:    http.StatusPreconditionRequired,
+			Message: "缺少 If-Match 请求头",
+		})
+		return 0, false
+	}
+	version, err := parseIfMatch(ifMatch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// respondVersionConflict 返回 412 和任务的当前内容，客户端可以据此合并修改后重试
+func (h *TaskHandler) respondVersionConflict(c *gin.Context, taskID int) {
+	task, err := h.taskService.Get(taskID, middleware.GetUserID(c))
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "更新任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusPreconditionFailed, Response{
+		Code:    http.StatusPreconditionFailed,
+		Message: "任务已被修改",
+		Error:   service.ErrVersionConflict.Error(),
+		Data:    newTaskResponse(task),
+	})
+}
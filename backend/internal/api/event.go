@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/event"
+	"todolist/internal/middleware"
+)
+
+const (
+	// eventResetType 部分事件已无法补发时发送的事件，客户端应重新加载任务
+	eventResetType = "reset"
+	// eventKeepAliveInterval 没有事件时发送注释行的间隔，避免代理断开空闲连接
+	eventKeepAliveInterval = 30 * time.Second
+	// eventRetryMillis 建议客户端断线后重连的等待时间
+	eventRetryMillis = 3000
+)
+
+// EventHandler 实时事件处理器
+type EventHandler struct {
+	bus *event.Bus
+}
+
+// NewEventHandler 创建实时事件处理器实例
+func NewEventHandler(bus *event.Bus) *EventHandler {
+	return &EventHandler{bus: bus}
+}
+
+// Stream godoc
+// @Summary 订阅任务事件
+// @Description 以 Server-Sent Events 推送当前用户可以看到的任务的变更，事件类型为 task.created、task.updated 和 task.deleted，
+// @Description 数据为变更后的任务（删除事件为删除时的任务，不含 role）。每个事件带有 id，断线重连时通过 Last-Event-ID 请求头
+// @Description 或 last_event_id 参数从该事件之后继续；错过的事件无法补发时先发送 reset 事件，客户端应重新加载任务。
+// @Description 浏览器的 EventSource 不能设置请求头，可以通过 access_token 参数传递访问令牌。
+// @Description 访问令牌过期、退出登录或被吊销后服务端关闭连接，客户端应使用新的访问令牌重连
+// @Tags 实时事件
+// @Produce text/event-stream
+// @Security Bearer
+// @Param Last-Event-ID header string false "最后收到的事件ID"
+// @Param last_event_id query int false "最后收到的事件ID，与 Last-Event-ID 请求头相同"
+// @Param access_token query string false "访问令牌，未提供 Authorization 请求头时使用"
+// @Success 200 {string} string "事件流"
+// @Failure 400 {object} Response{} "无效的事件ID"
+// @Failure 401 {object} Response{} "未授权"
+// @Router /events [get]
+func (h *EventHandler) Stream(c *gin.Context) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var since int64
+	if lastEventID != "" {
+		var err error
+		if since, err = strconv.ParseInt(lastEventID, 10, 64); err != nil || since < 0 {
+			c.JSON(http.StatusBadRequest, Response{
+				Code:    400,
+				Message: "无效的事件ID",
+			})
+			return
+		}
+	}
+
+	sub, missed, complete := h.bus.Subscribe(middleware.GetUserID(c), since)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// 关闭 nginx 的响应缓冲，事件才能及时送达
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetryMillis)
+	if !complete {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventResetType)
+	}
+	for _, e := range missed {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	// 认证只在连接时进行，令牌过期时关闭连接，推送事件和保活前检查令牌是否已被吊销
+	claims := middleware.GetClaims(c)
+	expired := time.NewTimer(time.Until(time.Unix(claims.ExpiresAt, 0)))
+	defer expired.Stop()
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expired.C:
+			return
+		case e, ok := <-sub.Events():
+			// 读取过慢时总线关闭订阅，客户端重连后从最后收到的事件继续
+			if !ok || tokenRevoked(claims.Id) {
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if tokenRevoked(claims.Id) {
+				return
+			}
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// tokenRevoked 判断事件流的访问令牌是否已被吊销，检查失败时同样视为失效，由客户端重连时重新认证
+func tokenRevoked(tokenID string) bool {
+	revoked, err := middleware.IsTokenRevoked(tokenID)
+	return err != nil || revoked
+}
+
+// writeEvent 按 Server-Sent Events 格式写出一个事件
+func writeEvent(w io.Writer, e event.Event) error {
+	data, err := json.Marshal(newTaskResponse(e.Task))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// queryAccessToken 未提供 Authorization 请求头时使用 access_token 参数中的访问令牌，需要在认证中间件之前使用
+func queryAccessToken(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		if token := c.Query("access_token"); token != "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	c.Next()
+}
+
+// RegisterRoutes 注册路由
+func (h *EventHandler) RegisterRoutes(r *gin.Engine) {
+	events := r.Group("/api/v1/events")
+	events.Use(queryAccessToken, middleware.AuthMiddleware())
+	{
+		events.GET("", h.Stream)
+	}
+}
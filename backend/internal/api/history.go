@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// HistoryHandler 任务变更历史处理器
+type HistoryHandler struct {
+	historyService service.HistoryService
+}
+
+// NewHistoryHandler 创建任务变更历史处理器
+func NewHistoryHandler(historyService service.HistoryService) *HistoryHandler {
+	return &HistoryHandler{
+		historyService: historyService,
+	}
+}
+
+// List godoc
+// @Summary 获取任务变更历史
+// @Description 分页获取任务的创建、修改和删除记录，最新的排在前面。每条记录包含操作者和字段级的变更前后值，有权访问任务的用户都可以查看
+// @Tags 任务历史
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(20)
+// @Success 200 {object} Response{data=ListHistoryResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/history [get]
+func (h *HistoryHandler) List(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(service.DefaultPageSize)))
+
+	entries, total, err := h.historyService.List(taskID, middleware.GetUserID(c), page, pageSize)
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "获取任务历史失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取任务历史成功",
+		Data: ListHistoryResponse{
+			Total: total,
+			Items: entries,
+		},
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *HistoryHandler) RegisterRoutes(r *gin.Engine) {
+	history := r.Group("/api/v1/tasks/:id/history")
+	history.Use(middleware.AuthMiddleware())
+	{
+		history.GET("", h.List)
+	}
+}
+
+// ListHistoryResponse 任务变更历史列表响应
+type ListHistoryResponse struct {
+	Total int64                `json:"total"`
+	Items []*model.TaskHistory `json:"items"`
+}
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// maxImportFileSize 导入文件的最大长度
+const maxImportFileSize = 5 << 20
+
+// taskExportVersion 导出文件的格式版本，格式不兼容时递增
+const taskExportVersion = 1
+
+// ImportHandler 任务导入导出处理器
+type ImportHandler struct {
+	importService service.ImportService
+	taskService   service.TaskService
+}
+
+// NewImportHandler 创建任务导入导出处理器
+func NewImportHandler(importService service.ImportService, taskService service.TaskService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+		taskService:   taskService,
+	}
+}
+
+// Import godoc
+// @Summary 导入任务
+// @Description 从文件批量创建任务，每一行或每一项单独校验，校验规则与创建任务相同，失败的项不影响其他项，错误见 rows。
+// @Description format 为 csv 时第一行为表头，默认按列名 title、description、status、priority、due_date、tags、recurrence 对应任务字段（不区分大小写），
+// @Description 其他列名通过 mapping 指定，如 {"title":"任务名称","due_date":"截止日期"}；tags 列中多个标签用逗号分隔。
+// @Description format 为 json 时为 GET /tasks/export.json 导出的文件；todoist 为 Todoist 导出的任务 JSON（任务数组，或含 items、tasks 的对象）；
+// @Description trello 为 Trello 看板导出的 JSON，不导入已归档的卡片和列表，标签按名称导入，没有名称时使用颜色。
+// @Description 按名称引用的标签不存在时自动创建。子任务导入为独立的任务，Todoist 的重复规则为自然语言，只导入下一次的截止日期。
+// @Description dry_run 为 true 时只返回预览结果，不创建任务和标签
+// @Tags 任务管理
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时导入到个人空间"
+// @Param file formData file true "导入文件"
+// @Param format formData string true "文件格式" Enums(csv,json,todoist,trello)
+// @Param mapping formData string false "CSV 列映射，JSON 对象，键为任务字段，值为列名"
+// @Param dry_run formData bool false "只预览，不创建任务"
+// @Param project_id formData int false "导入到的项目ID"
+// @Success 200 {object} Response{data=ImportResponse} "导入完成，失败的行见 rows"
+// @Failure 400 {object} Response{} "请求参数错误或文件格式错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有在工作区中创建任务的权限"
+// @Failure 413 {object} Response{} "文件过大"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/import [post]
+func (h *ImportHandler) Import(c *gin.Context) {
+	var req ImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	data, status, err := readImportFile(c)
+	if err != nil {
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "读取导入文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	items, err := parseImportFile(req.Format, data, req.Mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "导入文件格式错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	workspaceID := middleware.GetWorkspaceID(c)
+	for _, item := range items {
+		if item.Task == nil {
+			continue
+		}
+		item.Task.WorkspaceID = &workspaceID
+		if req.ProjectID > 0 {
+			projectID := req.ProjectID
+			item.Task.ProjectID = &projectID
+		}
+	}
+
+	result, err := h.importService.Import(middleware.GetUserID(c), items, service.ImportOptions{DryRun: req.DryRun})
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "导入任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := ImportResponse{
+		DryRun:  req.DryRun,
+		Total:   len(result.Items),
+		Created: result.Created,
+		Failed:  result.Failed,
+		NewTags: result.NewTags,
+		Rows:    make([]ImportRowResponse, 0, len(result.Items)),
+	}
+	if response.NewTags == nil {
+		response.NewTags = []string{}
+	}
+	for _, item := range result.Items {
+		row := ImportRowResponse{Row: item.Row}
+		if item.Err != nil {
+			row.Error = item.Err.Error()
+		} else if item.Task != nil {
+			task := newTaskResponse(item.Task)
+			row.Task = &task
+		}
+		response.Rows = append(response.Rows, row)
+	}
+
+	message := "导入任务成功"
+	switch {
+	case req.DryRun:
+		message = "导入预览"
+	case result.Created == 0:
+		message = "导入任务失败"
+	case result.Failed > 0:
+		message = "导入任务部分成功"
+	}
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: message,
+		Data:    response,
+	})
+}
+
+// readImportFile 读取上传的导入文件，失败时返回对应的状态码
+func readImportFile(c *gin.Context) ([]byte, int, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("缺少导入文件: %w", err)
+	}
+	if header.Size > maxImportFileSize {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("导入文件不能超过%dMB", maxImportFileSize>>20)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return data, http.StatusOK, nil
+}
+
+// Export godoc
+// @Summary 导出任务
+// @Description 将当前用户可以看到的任务导出为 JSON 文件，范围与任务列表相同，可以通过 POST /tasks/import 以 json 格式导入。
+// @Description 状态和优先级以文本形式导出，标签导出为名称；子任务导出为独立的任务
+// @Tags 任务管理
+// @Produce json
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时导出个人任务和共享给当前用户的任务"
+// @Success 200 {object} TaskExportFile "导出文件"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "不是工作区成员"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/export.json [get]
+func (h *ImportHandler) Export(c *gin.Context) {
+	tasks, _, err := h.taskService.List(model.TaskFilter{
+		UserID:      middleware.GetUserID(c),
+		WorkspaceID: middleware.GetWorkspaceID(c),
+		Page:        1,
+		PageSize:    -1,
+	})
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "导出任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	file := TaskExportFile{
+		Version:    taskExportVersion,
+		ExportedAt: time.Now(),
+		Tasks:      make([]TaskExport, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		file.Tasks = append(file.Tasks, newTaskExport(task))
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"tasks.json\"")
+	c.JSON(http.StatusOK, file)
+}
+
+// RegisterRoutes 注册路由
+func (h *ImportHandler) RegisterRoutes(r *gin.Engine) {
+	tasks := r.Group("/api/v1/tasks")
+	tasks.Use(middleware.AuthMiddleware(), middleware.WorkspaceMiddleware())
+	{
+		tasks.GET("/export.json", h.Export)
+		tasks.POST("/import", middleware.IdempotencyMiddleware(), h.Import)
+	}
+}
+
+// ImportRequest 导入任务请求，文件通过 file 字段上传
+type ImportRequest struct {
+	Format    string `form:"format" binding:"required,oneof=csv json todoist trello"`
+	Mapping   string `form:"mapping"` // CSV 列映射，JSON 对象，键为任务字段，值为列名
+	DryRun    bool   `form:"dry_run"`
+	ProjectID int    `form:"project_id" binding:"min=0"`
+}
+
+// ImportResponse 导入任务响应
+type ImportResponse struct {
+	DryRun bool `json:"dry_run"`
+	Total  int  `json:"total"`
+	// Created 成功导入的数量，预览时为可以导入的数量
+	Created int `json:"created"`
+	Failed  int `json:"failed"`
+	// NewTags 新建的标签，预览时为将要新建的标签
+	NewTags []string            `json:"new_tags"`
+	Rows    []ImportRowResponse `json:"rows"`
+}
+
+// ImportRowResponse 导入文件中一行或一项的结果
+type ImportRowResponse struct {
+	Row   int           `json:"row"` // CSV 为行号，JSON 为从1开始的序号
+	Error string        `json:"error,omitempty"`
+	Task  *TaskResponse `json:"task,omitempty"` // 预览时任务ID为0
+}
+
+// TaskExportFile 任务导出文件
+type TaskExportFile struct {
+	Version    int          `json:"version"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Tasks      []TaskExport `json:"tasks"`
+}
+
+// TaskExport 导出文件中的任务
+type TaskExport struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Recurrence  *string    `json:"recurrence,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"` // 只用于参考，导入时不使用
+}
+
+// newTaskExport 将任务转换为导出格式
+func newTaskExport(task *model.Task) TaskExport {
+	export := TaskExport{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.GetStatusText(),
+		Priority:    task.GetPriorityText(),
+		DueDate:     task.DueDate,
+		Recurrence:  task.Recurrence,
+		CreatedAt:   task.CreatedAt,
+	}
+	for _, tag := range task.Tags {
+		export.Tags = append(export.Tags, tag.Name)
+	}
+	return export
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// 导入文件格式
+const (
+	importFormatCSV     = "csv"
+	importFormatJSON    = "json"
+	importFormatTodoist = "todoist"
+	importFormatTrello  = "trello"
+)
+
+// importFields CSV 中可以导入的任务字段
+var importFields = []string{"title", "description", "status", "priority", "due_date", "tags", "recurrence"}
+
+// utf8BOM Excel 保存 CSV 时在开头写入的字节顺序标记
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// parseImportFile 按格式解析导入文件，文件整体无法解析时返回错误，单项的错误记录在 ImportItem.Err 中
+func parseImportFile(format string, data []byte, mapping string) ([]service.ImportItem, error) {
+	switch format {
+	case importFormatCSV:
+		return parseCSVImport(data, mapping)
+	case importFormatJSON:
+		return parseJSONImport(data)
+	case importFormatTodoist:
+		return parseTodoistImport(data)
+	case importFormatTrello:
+		return parseTrelloImport(data)
+	}
+	return nil, fmt.Errorf("不支持的导入格式: %s", format)
+}
+
+// importTaskFields 导入的任务字段的文本形式，各格式转换为此结构后统一解析
+type importTaskFields struct {
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	DueDate     string
+	Recurrence  string
+	Tags        []string
+}
+
+// toItem 解析字段并生成导入项，状态、优先级或日期无效时记录在 Err 中
+func (f importTaskFields) toItem(row int) service.ImportItem {
+	item := service.ImportItem{Row: row, TagNames: f.Tags}
+	task := &model.Task{
+		Title:       strings.TrimSpace(f.Title),
+		Description: strings.TrimSpace(f.Description),
+	}
+
+	var err error
+	if status := strings.ToLower(strings.TrimSpace(f.Status)); status != "" {
+		if task.Status, err = model.ParseTaskStatus(status); err != nil {
+			item.Err = fmt.Errorf("%w: %s", err, f.Status)
+			return item
+		}
+	}
+	if priority := strings.ToLower(strings.TrimSpace(f.Priority)); priority != "" {
+		if task.Priority, err = model.ParseTaskPriority(priority); err != nil {
+			item.Err = fmt.Errorf("%w: %s", err, f.Priority)
+			return item
+		}
+	}
+	if task.DueDate, err = parseImportDate(f.DueDate); err != nil {
+		item.Err = err
+		return item
+	}
+	if recurrence := strings.TrimSpace(f.Recurrence); recurrence != "" {
+		task.Recurrence = &recurrence
+	}
+
+	item.Task = task
+	return item
+}
+
+// parseImportDate 解析导入文件中的日期，优先使用 RFC 3339，其他格式与创建任务相同
+func parseImportDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := parseDateString(value)
+	if err != nil || t == nil {
+		return nil, errInvalidDate
+	}
+	return t, nil
+}
+
+// parseCSVImport 解析 CSV，第一行为表头，mapping 为任务字段到列名的 JSON 对象，未映射的字段使用同名的列
+func parseCSVImport(data []byte, mapping string) ([]service.ImportItem, error) {
+	columns := map[string]string{}
+	if mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &columns); err != nil {
+			return nil, fmt.Errorf("mapping 应为 JSON 对象: %w", err)
+		}
+	}
+	for field := range columns {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("mapping 中的字段 %s 不存在，可用字段：%s", field, strings.Join(importFields, "、"))
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV 文件为空")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 字段在记录中的位置，列名不区分大小写
+	index := map[string]int{}
+	for _, field := range importFields {
+		column, mapped := columns[field]
+		if !mapped {
+			column = field
+		}
+		found := false
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				index[field] = i
+				found = true
+				break
+			}
+		}
+		if !found && mapped {
+			return nil, fmt.Errorf("CSV 中没有 %s 列", column)
+		}
+	}
+	if _, ok := index["title"]; !ok {
+		return nil, errors.New("CSV 中没有 title 列，请通过 mapping 指定标题所在的列")
+	}
+
+	var items []service.ImportItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row, _ := reader.FieldPos(0)
+
+		cell := func(field string) string {
+			if i, ok := index[field]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		fields := importTaskFields{
+			Title:       cell("title"),
+			Description: cell("description"),
+			Status:      cell("status"),
+			Priority:    cell("priority"),
+			DueDate:     cell("due_date"),
+			Recurrence:  cell("recurrence"),
+		}
+		if tags := cell("tags"); tags != "" {
+			fields.Tags = strings.Split(tags, ",")
+		}
+		items = append(items, fields.toItem(row))
+	}
+	return items, nil
+}
+
+// parseJSONImport 解析 GET /tasks/export.json 导出的文件
+func parseJSONImport(data []byte) ([]service.ImportItem, error) {
+	var file struct {
+		Version int `json:"version"`
+		Tasks   []struct {
+			Title       string   `json:"title"`
+			Description string   `json:"description"`
+			Status      string   `json:"status"`
+			Priority    string   `json:"priority"`
+			DueDate     string   `json:"due_date"`
+			Recurrence  string   `json:"recurrence"`
+			Tags        []string `json:"tags"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != taskExportVersion {
+		return nil, fmt.Errorf("不支持的导出文件版本: %d", file.Version)
+	}
+
+	items := make([]service.ImportItem, 0, len(file.Tasks))
+	for i, task := range file.Tasks {
+		fields := importTaskFields{
+			Title:       task.Title,
+			Description: task.Description,
+			Status:      task.Status,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+			Recurrence:  task.Recurrence,
+			Tags:        task.Tags,
+		}
+		items = append(items, fields.toItem(i+1))
+	}
+	return items, nil
+}
+
+// todoistBool Todoist 不同版本的导出中布尔值为 true/false 或 1/0
+type todoistBool bool
+
+// UnmarshalJSON 同时接受布尔值和数字
+func (b *todoistBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("无效的布尔值: %s", data)
+	}
+	return nil
+}
+
+// todoistTask Todoist 导出的任务，优先级 4 最高，1 为普通
+type todoistTask struct {
+	Content     string `json:"content"`
+	Description string `json:"description"`
+	Priority    int    `json:"priority"`
+	Due         *struct {
+		Date     string `json:"date"`
+		Datetime string `json:"datetime"`
+	} `json:"due"`
+	Labels      []string    `json:"labels"`
+	Checked     todoistBool `json:"checked"`
+	IsCompleted todoistBool `json:"is_completed"`
+	IsDeleted   todoistBool `json:"is_deleted"`
+}
+
+// todoistPriorities Todoist 优先级对应的优先级文本
+var todoistPriorities = map[int]string{4: "high", 3: "medium", 2: "low", 1: "none", 0: "none"}
+
+// parseTodoistImport 解析 Todoist 导出的任务，接受任务数组或含 items、tasks 的对象，已删除的任务不导入
+func parseTodoistImport(data []byte) ([]service.ImportItem, error) {
+	var tasks []todoistTask
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return nil, err
+		}
+	} else {
+		var export struct {
+			Items []todoistTask `json:"items"`
+			Tasks []todoistTask `json:"tasks"`
+		}
+		if err := json.Unmarshal(data, &export); err != nil {
+			return nil, err
+		}
+		if export.Items == nil && export.Tasks == nil {
+			return nil, errors.New("不是 Todoist 导出的任务，缺少 items 或 tasks")
+		}
+		tasks = append(export.Items, export.Tasks...)
+	}
+
+	items := make([]service.ImportItem, 0, len(tasks))
+	for i, task := range tasks {
+		if task.IsDeleted {
+			continue
+		}
+		priority, ok := todoistPriorities[task.Priority]
+		if !ok {
+			items = append(items, service.ImportItem{Row: i + 1, Err: fmt.Errorf("%w: %d", model.ErrInvalidTaskPriority, task.Priority)})
+			continue
+		}
+		fields := importTaskFields{
+			Title:       task.Content,
+			Description: task.Description,
+			Priority:    priority,
+			Tags:        task.Labels,
+		}
+		if task.Checked || task.IsCompleted {
+			fields.Status = "done"
+		}
+		if task.Due != nil {
+			fields.DueDate = task.Due.Date
+			if task.Due.Datetime != "" {
+				fields.DueDate = task.Due.Datetime
+			}
+		}
+		items = append(items, fields.toItem(i+1))
+	}
+	return items, nil
+}
+
+// trelloBoard Trello 看板导出的 JSON 中导入用到的部分
+type trelloBoard struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		Name        string  `json:"name"`
+		Desc        string  `json:"desc"`
+		Due         *string `json:"due"`
+		DueComplete bool    `json:"dueComplete"`
+		Closed      bool    `json:"closed"`
+		IDList      string  `json:"idList"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+}
+
+// parseTrelloImport 解析 Trello 看板导出的 JSON，序号为卡片在 cards 中的位置，已归档的卡片和列表中的卡片不导入
+func parseTrelloImport(data []byte) ([]service.ImportItem, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, err
+	}
+	if board.Cards == nil {
+		return nil, errors.New("不是 Trello 看板导出的文件，缺少 cards")
+	}
+
+	closedLists := map[string]bool{}
+	for _, list := range board.Lists {
+		if list.Closed {
+			closedLists[list.ID] = true
+		}
+	}
+
+	items := make([]service.ImportItem, 0, len(board.Cards))
+	for i, card := range board.Cards {
+		if card.Closed || closedLists[card.IDList] {
+			continue
+		}
+		fields := importTaskFields{
+			Title:       card.Name,
+			Description: card.Desc,
+		}
+		if card.Due != nil {
+			fields.DueDate = *card.Due
+		}
+		if card.DueComplete {
+			fields.Status = "done"
+		}
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			fields.Tags = append(fields.Tags, name)
+		}
+		items = append(items, fields.toItem(i+1))
+	}
+	return items, nil
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// mergePatchContentType JSON Merge Patch 的媒体类型，见 RFC 7396
+const mergePatchContentType = "application/merge-patch+json"
+
+// errPatchNotObject 合并补丁不是 JSON 对象
+var errPatchNotObject = errors.New("合并补丁必须是 JSON 对象")
+
+// patchValue 合并补丁中的字段，区分未提供、null 和具体的值
+type patchValue[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，字段出现在补丁中时才会被调用
+func (v *patchValue[T]) UnmarshalJSON(data []byte) error {
+	v.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		v.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &v.Value)
+}
+
+// PatchTaskRequest 部分更新任务请求，未出现的字段不修改，null 表示清空
+type PatchTaskRequest struct {
+	Title       patchValue[string] `json:"title" swaggertype:"string"`                                 // 不能为 null
+	Description patchValue[string] `json:"description" swaggertype:"string"`                           // null 表示清空描述
+	Status      patchValue[string] `json:"status" swaggertype:"string" enums:"todo,in_progress,done"`  // 不能为 null
+	Priority    patchValue[string] `json:"priority" swaggertype:"string" enums:"none,low,medium,high"` // null 表示 none
+	DueDate     patchValue[string] `json:"due_date" swaggertype:"string"`                              // null 表示清除截止日期
+	Recurrence  patchValue[string] `json:"recurrence" swaggertype:"string"`                            // null 表示取消重复
+	ParentID    patchValue[int]    `json:"parent_id" swaggertype:"integer"`                            // null 表示移动到顶层
+	ProjectID   patchValue[int]    `json:"project_id" swaggertype:"integer"`                           // null 表示移出项目
+	TagIDs      patchValue[[]int]  `json:"tag_ids" swaggertype:"array,integer"`                        // null 表示清空标签
+}
+
+// parsePatchTaskRequest 解析合并补丁，不支持的字段返回错误
+func parsePatchTaskRequest(data []byte) (*PatchTaskRequest, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, errPatchNotObject
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var req PatchTaskRequest
+	if err := decoder.Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// toPatch 将请求转换为任务服务的部分更新
+func (req *PatchTaskRequest) toPatch() (model.TaskPatch, error) {
+	var patch model.TaskPatch
+
+	if req.Title.Set {
+		if req.Title.Null {
+			return patch, errors.New("title 不能为 null")
+		}
+		patch.Title = model.Some(req.Title.Value)
+	}
+	if req.Description.Set {
+		patch.Description = model.Some(req.Description.Value)
+	}
+	if req.Status.Set {
+		if req.Status.Null {
+			return patch, errors.New("status 不能为 null")
+		}
+		status, err := model.ParseTaskStatus(req.Status.Value)
+		if err != nil {
+			return patch, err
+		}
+		patch.Status = model.Some(status)
+	}
+	if req.Priority.Set {
+		priority := model.TaskPriorityNone
+		if !req.Priority.Null {
+			var err error
+			if priority, err = model.ParseTaskPriority(req.Priority.Value); err != nil {
+				return patch, err
+			}
+		}
+		patch.Priority = model.Some(priority)
+	}
+	if req.DueDate.Set {
+		var dueDate *time.Time
+		if !req.DueDate.Null && req.DueDate.Value != "" {
+			var err error
+			if dueDate, err = parseDateString(req.DueDate.Value); err != nil || dueDate == nil {
+				return patch, errors.New("due_date 日期格式错误")
+			}
+		}
+		patch.DueDate = model.Some(dueDate)
+	}
+	if req.Recurrence.Set {
+		patch.Recurrence = model.Some(optionalValue(req.Recurrence))
+	}
+	if req.ParentID.Set {
+		patch.ParentID = model.Some(optionalValue(req.ParentID))
+	}
+	if req.ProjectID.Set {
+		patch.ProjectID = model.Some(optionalValue(req.ProjectID))
+	}
+	if req.TagIDs.Set {
+		ids := req.TagIDs.Value
+		if ids == nil {
+			ids = []int{}
+		}
+		patch.TagIDs = model.Some(ids)
+	}
+	return patch, nil
+}
+
+// optionalValue 将补丁字段转换为指针，null 转换为 nil
+func optionalValue[T any](v patchValue[T]) *T {
+	if v.Null {
+		return nil
+	}
+	return &v.Value
+}
+
+// Patch godoc
+// @Summary 部分更新任务
+// @Description 按 JSON Merge Patch（RFC 7396）更新任务，只修改补丁中出现的字段，值为 null 表示清空该字段。
+// @Description 提供 If-Match 时只有版本一致才会更新，否则返回 412 和任务的当前内容；响应的 ETag 为更新后的版本
+// @Tags 任务管理
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param If-Match header string false "获取任务时返回的 ETag"
+// @Param request body PatchTaskRequest true "合并补丁"
+// @Success 200 {object} Response{data=TaskResponse} "更新成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 412 {object} Response{data=TaskResponse} "任务已被修改，返回当前内容"
+// @Failure 415 {object} Response{} "不支持的请求格式"
+// @Failure 428 {object} Response{} "缺少 If-Match 请求头"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id} [patch]
+func (h *TaskHandler) Patch(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	// 兼容按普通 JSON 提交的客户端
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		c.JSON(http.StatusUnsupportedMediaType, Response{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "不支持的请求格式",
+			Error:   "Content-Type 应为 " + mergePatchContentType,
+		})
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	req, err := parsePatchTaskRequest(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	patch, err := req.toPatch()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	patch.Version = version
+
+	task, err := h.taskService.Patch(taskID, middleware.GetUserID(c), patch)
+	if err != nil {
+		if err == service.ErrVersionConflict {
+			h.respondVersionConflict(c, taskID)
+			return
+		}
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "更新任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "更新任务成功",
+		Data:    newTaskResponse(task),
+	})
+}
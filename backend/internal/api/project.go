@@ -0,0 +1,403 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// ProjectHandler 项目处理器
+type ProjectHandler struct {
+	projectService service.ProjectService
+	taskService    service.TaskService
+}
+
+// NewProjectHandler 创建项目处理器
+func NewProjectHandler(projectService service.ProjectService, taskService service.TaskService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+		taskService:    taskService,
+	}
+}
+
+// Create godoc
+// @Summary 创建项目
+// @Description 为当前用户创建项目，新项目排在最后；提供 X-Workspace-ID 请求头时在该工作区中创建，需要管理员权限
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时使用个人空间"
+// @Param request body ProjectRequest true "项目信息"
+// @Success 200 {object} Response{data=model.Project} "创建成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有工作区权限"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects [post]
+func (h *ProjectHandler) Create(c *gin.Context) {
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	workspaceID := middleware.GetWorkspaceID(c)
+	project := &model.Project{
+		UserID:      middleware.GetUserID(c),
+		Name:        req.Name,
+		Color:       req.Color,
+		WorkspaceID: &workspaceID,
+	}
+	if err := h.projectService.Create(project); err != nil {
+		respondProjectError(c, "创建项目失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "创建项目成功",
+		Data:    project,
+	})
+}
+
+// Update godoc
+// @Summary 更新项目
+// @Description 修改项目名称、颜色或归档状态
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Param request body UpdateProjectRequest true "项目信息"
+// @Success 200 {object} Response{data=model.Project} "更新成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "项目不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects/{id} [put]
+func (h *ProjectHandler) Update(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的项目ID",
+		})
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	project := &model.Project{
+		ID:     projectID,
+		UserID: userID,
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := h.projectService.Update(project); err != nil {
+		respondProjectError(c, "更新项目失败", err)
+		return
+	}
+	if req.Archived != nil {
+		if project, err = h.projectService.SetArchived(projectID, userID, *req.Archived); err != nil {
+			respondProjectError(c, "更新项目失败", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "更新项目成功",
+		Data:    project,
+	})
+}
+
+// Delete godoc
+// @Summary 删除项目
+// @Description 删除项目，项目下的任务移出项目但不会被删除
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Success 200 {object} Response{} "删除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "项目不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) Delete(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的项目ID",
+		})
+		return
+	}
+
+	if err := h.projectService.Delete(projectID, middleware.GetUserID(c)); err != nil {
+		respondProjectError(c, "删除项目失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "删除项目成功",
+	})
+}
+
+// Get godoc
+// @Summary 获取项目详情
+// @Description 获取指定项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Success 200 {object} Response{data=model.Project} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "项目不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) Get(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的项目ID",
+		})
+		return
+	}
+
+	project, err := h.projectService.Get(projectID, middleware.GetUserID(c))
+	if err != nil {
+		respondProjectError(c, "获取项目失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取项目成功",
+		Data:    project,
+	})
+}
+
+// List godoc
+// @Summary 获取项目列表
+// @Description 按排序获取当前用户的个人项目，提供 X-Workspace-ID 请求头时获取该工作区的项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时使用个人空间"
+// @Param include_archived query bool false "是否包含已归档项目"
+// @Success 200 {object} Response{data=[]model.Project} "获取成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects [get]
+func (h *ProjectHandler) List(c *gin.Context) {
+	includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+
+	projects, err := h.projectService.List(middleware.GetUserID(c), middleware.GetWorkspaceID(c), includeArchived)
+	if err != nil {
+		respondProjectError(c, "获取项目列表失败", err)
+		return
+	}
+	if projects == nil {
+		projects = []*model.Project{}
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取项目列表成功",
+		Data:    projects,
+	})
+}
+
+// Reorder godoc
+// @Summary 调整项目顺序
+// @Description 按给定顺序排列项目，未列出的项目保持原有顺序排在后面
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时使用个人空间"
+// @Param request body ReorderProjectsRequest true "项目ID顺序"
+// @Success 200 {object} Response{data=[]model.Project} "调整成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "项目不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects/order [put]
+func (h *ProjectHandler) Reorder(c *gin.Context) {
+	var req ReorderProjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	projects, err := h.projectService.Reorder(middleware.GetUserID(c), middleware.GetWorkspaceID(c), req.ProjectIDs)
+	if err != nil {
+		respondProjectError(c, "调整项目顺序失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "调整项目顺序成功",
+		Data:    projects,
+	})
+}
+
+// Tasks godoc
+// @Summary 获取项目下的任务
+// @Description 获取指定项目的任务列表，支持与任务列表相同的过滤和排序参数
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(10)
+// @Param status query []string false "任务状态，多个用逗号分隔或重复传参" collectionFormat(csv) Enums(todo,in_progress,done)
+// @Param sort query string false "排序字段，逗号分隔，前缀 - 表示降序，如 due_date,-created_at"
+// @Success 200 {object} Response{data=ListTasksResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "项目不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /projects/{id}/tasks [get]
+func (h *ProjectHandler) Tasks(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的项目ID",
+		})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	project, err := h.projectService.Get(projectID, userID)
+	if err != nil {
+		respondProjectError(c, "获取项目任务失败", err)
+		return
+	}
+
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	filter.UserID = userID
+	filter.ProjectID = &projectID
+	if project.WorkspaceID != nil {
+		filter.WorkspaceID = *project.WorkspaceID
+	}
+
+	tasks, total, err := h.taskService.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    500,
+			Message: "获取项目任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	responseTasks := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responseTasks = append(responseTasks, newTaskResponse(task))
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取项目任务成功",
+		Data: ListTasksResponse{
+			Total: total,
+			Items: responseTasks,
+		},
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *ProjectHandler) RegisterRoutes(r *gin.Engine) {
+	projects := r.Group("/api/v1/projects")
+	projects.Use(middleware.AuthMiddleware(), middleware.WorkspaceMiddleware())
+	{
+		projects.POST("", h.Create)
+		projects.PUT("/order", h.Reorder)
+		projects.PUT("/:id", h.Update)
+		projects.DELETE("/:id", h.Delete)
+		projects.GET("/:id", h.Get)
+		projects.GET("/:id/tasks", h.Tasks)
+		projects.GET("", h.List)
+	}
+}
+
+// respondProjectError 根据项目服务的错误返回对应的状态码
+func respondProjectError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrEmptyProjectName, service.ErrProjectNameTooLong, service.ErrInvalidProjectColor,
+		service.ErrDuplicateProjectSort:
+		status = http.StatusBadRequest
+	case service.ErrProjectNotFound, service.ErrProjectAccessDenied:
+		// 不区分不存在和无权访问，避免泄露其他用户的项目
+		status = http.StatusNotFound
+	case service.ErrWorkspacePermission, service.ErrNotWorkspaceMember:
+		status = http.StatusForbidden
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// ProjectRequest 创建项目请求
+type ProjectRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// UpdateProjectRequest 更新项目请求
+type UpdateProjectRequest struct {
+	Name     string `json:"name" binding:"omitempty,max=50"`
+	Color    string `json:"color" binding:"omitempty,hexcolor"`
+	Archived *bool  `json:"archived"` // 不传表示不修改
+}
+
+// ReorderProjectsRequest 调整项目顺序请求
+type ReorderProjectsRequest struct {
+	ProjectIDs []int `json:"project_ids" binding:"required"`
+}
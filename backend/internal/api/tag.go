@@ -0,0 +1,269 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// TagHandler 标签处理器
+type TagHandler struct {
+	tagService service.TagService
+}
+
+// NewTagHandler 创建标签处理器
+func NewTagHandler(tagService service.TagService) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// Create godoc
+// @Summary 创建标签
+// @Description 为当前用户创建标签
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body TagRequest true "标签信息"
+// @Success 200 {object} Response{data=model.Tag} "创建成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 409 {object} Response{} "标签已存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tags [post]
+func (h *TagHandler) Create(c *gin.Context) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	tag := &model.Tag{
+		UserID: middleware.GetUserID(c),
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := h.tagService.Create(tag); err != nil {
+		respondTagError(c, "创建标签失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "创建标签成功",
+		Data:    tag,
+	})
+}
+
+// Update godoc
+// @Summary 更新标签
+// @Description 修改标签名称或颜色
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "标签ID"
+// @Param request body UpdateTagRequest true "标签信息"
+// @Success 200 {object} Response{data=model.Tag} "更新成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "标签不存在"
+// @Failure 409 {object} Response{} "标签已存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tags/{id} [put]
+func (h *TagHandler) Update(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的标签ID",
+		})
+		return
+	}
+
+	var req UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	tag := &model.Tag{
+		ID:     tagID,
+		UserID: middleware.GetUserID(c),
+		Name:   req.Name,
+		Color:  req.Color,
+	}
+	if err := h.tagService.Update(tag); err != nil {
+		respondTagError(c, "更新标签失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "更新标签成功",
+		Data:    tag,
+	})
+}
+
+// Delete godoc
+// @Summary 删除标签
+// @Description 删除标签，并移除它与任务的关联
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "标签ID"
+// @Success 200 {object} Response{} "删除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "标签不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tags/{id} [delete]
+func (h *TagHandler) Delete(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的标签ID",
+		})
+		return
+	}
+
+	if err := h.tagService.Delete(tagID, middleware.GetUserID(c)); err != nil {
+		respondTagError(c, "删除标签失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "删除标签成功",
+	})
+}
+
+// Get godoc
+// @Summary 获取标签详情
+// @Description 获取指定标签
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "标签ID"
+// @Success 200 {object} Response{data=model.Tag} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "标签不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tags/{id} [get]
+func (h *TagHandler) Get(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的标签ID",
+		})
+		return
+	}
+
+	tag, err := h.tagService.Get(tagID, middleware.GetUserID(c))
+	if err != nil {
+		respondTagError(c, "获取标签失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取标签成功",
+		Data:    tag,
+	})
+}
+
+// List godoc
+// @Summary 获取标签列表
+// @Description 获取当前用户的全部标签
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]model.Tag} "获取成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tags [get]
+func (h *TagHandler) List(c *gin.Context) {
+	tags, err := h.tagService.List(middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    500,
+			Message: "获取标签列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if tags == nil {
+		tags = []*model.Tag{}
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取标签列表成功",
+		Data:    tags,
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *TagHandler) RegisterRoutes(r *gin.Engine) {
+	tags := r.Group("/api/v1/tags")
+	tags.Use(middleware.AuthMiddleware())
+	{
+		tags.POST("", h.Create)
+		tags.PUT("/:id", h.Update)
+		tags.DELETE("/:id", h.Delete)
+		tags.GET("/:id", h.Get)
+		tags.GET("", h.List)
+	}
+}
+
+// respondTagError 根据标签服务的错误返回对应的状态码
+func respondTagError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrEmptyTagName, service.ErrTagNameTooLong, service.ErrInvalidTagColor:
+		status = http.StatusBadRequest
+	case service.ErrTagNotFound, service.ErrTagAccessDenied:
+		// 不区分不存在和无权访问，避免泄露其他用户的标签
+		status = http.StatusNotFound
+	case service.ErrTagExists:
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// TagRequest 创建标签请求
+type TagRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// UpdateTagRequest 更新标签请求
+type UpdateTagRequest struct {
+	Name  string `json:"name" binding:"omitempty,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -118,13 +121,14 @@ func parseDateString(dateStr string) (*time.Time, error) {
 
 // Create godoc
 // @Summary 创建任务
-// @Description 创建新任务
+// @Description 创建新任务，提供 X-Workspace-ID 请求头时在该工作区中创建
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时使用个人空间"
 // @Param request body CreateTaskRequest true "任务信息"
-// @Success 200 {object} Response{data=model.Task} "创建成功"
+// @Success 200 {object} Response{data=TaskResponse} "创建成功"
 // @Failure 400 {object} Response{} "请求参数错误"
 // @Failure 401 {object} Response{} "未授权"
 // @Failure 500 {object} Response{} "服务器内部错误"
@@ -140,65 +144,50 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		return
 	}
 
-	var dueDate *time.Time
-	if req.DueDate != "" {
-		parsedTime, err := parseDateString(req.DueDate)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, Response{
-				Code:    400,
-				Message: "日期格式错误",
-				Error:   "支持的日期格式：YYYY-MM-DD、YYYY/MM/DD、YYYY年MM月DD日、MM/DD/YYYY等",
-			})
-			return
-		}
-		dueDate = parsedTime
-	}
-
-	task := &model.Task{
-		UserID:      middleware.GetUserID(c),
-		Title:       req.Title,
-		Description: req.Description,
-		DueDate:     dueDate,
-		Status:      model.TaskStatusTodo,
+	task, err := req.toTask(middleware.GetUserID(c), middleware.GetWorkspaceID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "日期格式错误",
+			Error:   err.Error(),
+		})
+		return
 	}
 
 	if err := h.taskService.Create(task); err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Code:    500,
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
 			Message: "创建任务失败",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	// 转换状态为文本形式
-	response := struct {
-		*model.Task
-		Status string `json:"status"`
-	}{
-		Task:   task,
-		Status: task.GetStatusText(),
-	}
-
 	c.JSON(http.StatusOK, Response{
 		Code:    200,
 		Message: "创建任务成功",
-		Data:    response,
+		Data:    newTaskResponse(task),
 	})
 }
 
 // Update godoc
 // @Summary 更新任务
-// @Description 更新任务信息
+// @Description 更新任务信息，未提供或为空的字段保持不变，priority 为 none 时清空优先级。提供 If-Match 时只有版本一致才会更新，否则返回 412 和任务的当前内容；响应的 ETag 为更新后的版本
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "任务ID"
+// @Param If-Match header string false "获取任务时返回的 ETag"
 // @Param request body UpdateTaskRequest true "任务信息"
-// @Success 200 {object} Response{data=model.Task} "更新成功"
+// @Success 200 {object} Response{data=TaskResponse} "更新成功"
 // @Failure 400 {object} Response{} "请求参数错误"
 // @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 412 {object} Response{data=TaskResponse} "任务已被修改，返回当前内容"
+// @Failure 428 {object} Response{} "缺少 If-Match 请求头"
 // @Failure 500 {object} Response{} "服务器内部错误"
 // @Router /tasks/{id} [put]
 func (h *TaskHandler) Update(c *gin.Context) {
@@ -211,6 +200,11 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	var req UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, Response{
@@ -235,55 +229,55 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		dueDate = parsedTime
 	}
 
-	task := &model.Task{
-		ID:          taskID,
-		UserID:      middleware.GetUserID(c),
-		Title:       req.Title,
-		Description: req.Description,
-		DueDate:     dueDate,
-	}
-
-	// 设置状态
-	if req.Status != "" {
-		task.SetStatusFromText(req.Status)
+	patch, err := req.toPatch(dueDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
 	}
+	patch.Version = version
 
-	if err := h.taskService.Update(task); err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Code:    500,
+	task, err := h.taskService.Patch(taskID, middleware.GetUserID(c), patch)
+	if err != nil {
+		if err == service.ErrVersionConflict {
+			h.respondVersionConflict(c, taskID)
+			return
+		}
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
 			Message: "更新任务失败",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	// 转换状态为文本形式
-	response := struct {
-		*model.Task
-		Status string `json:"status"`
-	}{
-		Task:   task,
-		Status: task.GetStatusText(),
-	}
-
+	c.Header("ETag", taskETag(task))
 	c.JSON(http.StatusOK, Response{
 		Code:    200,
 		Message: "更新任务成功",
-		Data:    response,
+		Data:    newTaskResponse(task),
 	})
 }
 
 // Delete godoc
 // @Summary 删除任务
-// @Description 删除指定任务
+// @Description 将任务移入回收站，可以通过恢复接口找回，超过保留期限后永久删除
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "任务ID"
+// @Param cascade query bool false "是否一并删除子任务，默认存在子任务时拒绝删除"
 // @Success 200 {object} Response{} "删除成功"
 // @Failure 400 {object} Response{} "请求参数错误"
 // @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 409 {object} Response{} "任务存在子任务"
 // @Failure 500 {object} Response{} "服务器内部错误"
 // @Router /tasks/{id} [delete]
 func (h *TaskHandler) Delete(c *gin.Context) {
@@ -296,9 +290,11 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.Delete(taskID, middleware.GetUserID(c)); err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Code:    500,
+	cascade, _ := strconv.ParseBool(c.Query("cascade"))
+	if err := h.taskService.Delete(taskID, middleware.GetUserID(c), service.DeleteOptions{Cascade: cascade}); err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
 			Message: "删除任务失败",
 			Error:   err.Error(),
 		})
@@ -313,15 +309,18 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 
 // Get godoc
 // @Summary 获取任务详情
-// @Description 获取指定任务的详细信息
+// @Description 获取指定任务的详细信息，响应的 ETag 为任务的当前版本，更新时可作为 If-Match 使用
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "任务ID"
-// @Success 200 {object} Response{data=model.Task} "获取成功"
+// @Param If-None-Match header string false "上次获取的 ETag，未修改时返回 304"
+// @Success 200 {object} Response{data=TaskResponse} "获取成功"
+// @Success 304 "任务未修改"
 // @Failure 400 {object} Response{} "请求参数错误"
 // @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "任务不存在"
 // @Failure 500 {object} Response{} "服务器内部错误"
 // @Router /tasks/{id} [get]
 func (h *TaskHandler) Get(c *gin.Context) {
@@ -336,53 +335,72 @@ func (h *TaskHandler) Get(c *gin.Context) {
 
 	task, err := h.taskService.Get(taskID, middleware.GetUserID(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Code:    500,
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
 			Message: "获取任务失败",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	// 转换状态为文本形式
-	response := struct {
-		*model.Task
-		Status string `json:"status"`
-	}{
-		Task:   task,
-		Status: task.GetStatusText(),
+	etag := taskETag(task)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
 	}
-
 	c.JSON(http.StatusOK, Response{
 		Code:    200,
 		Message: "获取任务成功",
-		Data:    response,
+		Data:    newTaskResponse(task),
 	})
 }
 
 // List godoc
 // @Summary 获取任务列表
-// @Description 获取当前用户的任务列表
+// @Description 获取当前用户的任务和共享给当前用户的任务，支持过滤、排序和关键字搜索，role 表示当前用户的角色
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时返回个人任务和共享给当前用户的任务"
 // @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(10)
-// @Param status query string false "任务状态" Enums(todo,in_progress,done)
+// @Param page_size query int false "每页数量，最大100" default(10)
+// @Param status query []string false "任务状态，多个用逗号分隔或重复传参" collectionFormat(csv) Enums(todo,in_progress,done)
+// @Param priority query []string false "任务优先级，多个用逗号分隔或重复传参" collectionFormat(csv) Enums(none,low,medium,high)
+// @Param tag_id query int false "标签ID"
+// @Param assignee query string false "负责人，me 表示当前用户，也可以是用户ID"
+// @Param parent_id query int false "父任务ID，0 表示只返回顶层任务"
+// @Param project_id query int false "项目ID，0 表示只返回未归入项目的任务"
+// @Param due_after query string false "截止日期不早于（含）"
+// @Param due_before query string false "截止日期早于（不含）"
+// @Param overdue query bool false "只返回已逾期且未完成的任务"
+// @Param q query string false "在标题和描述中搜索"
+// @Param sort query string false "排序字段，逗号分隔，前缀 - 表示降序，如 due_date,-created_at"
 // @Success 200 {object} Response{data=ListTasksResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
 // @Failure 401 {object} Response{} "未授权"
 // @Failure 500 {object} Response{} "服务器内部错误"
 // @Router /tasks [get]
 func (h *TaskHandler) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	status := c.Query("status")
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	filter.UserID = middleware.GetUserID(c)
+	filter.WorkspaceID = middleware.GetWorkspaceID(c)
 
-	tasks, total, err := h.taskService.List(middleware.GetUserID(c), status, page, pageSize)
+	tasks, total, err := h.taskService.List(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
-			Code:    500,
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
 			Message: "获取任务列表失败",
 			Error:   err.Error(),
 		})
@@ -390,18 +408,9 @@ func (h *TaskHandler) List(c *gin.Context) {
 	}
 
 	// 转换状态为文本形式
-	var responseTasks []struct {
-		*model.Task
-		Status string `json:"status"`
-	}
+	responseTasks := make([]TaskResponse, 0, len(tasks))
 	for _, task := range tasks {
-		responseTasks = append(responseTasks, struct {
-			*model.Task
-			Status string `json:"status"`
-		}{
-			Task:   task,
-			Status: task.GetStatusText(),
-		})
+		responseTasks = append(responseTasks, newTaskResponse(task))
 	}
 
 	c.JSON(http.StatusOK, Response{
@@ -414,32 +423,346 @@ func (h *TaskHandler) List(c *gin.Context) {
 	})
 }
 
+// Subtasks godoc
+// @Summary 获取子任务列表
+// @Description 获取指定任务的直接子任务
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} Response{data=[]TaskResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "任务不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/subtasks [get]
+func (h *TaskHandler) Subtasks(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	tasks, err := h.taskService.Subtasks(taskID, middleware.GetUserID(c))
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "获取子任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	responseTasks := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responseTasks = append(responseTasks, newTaskResponse(task))
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取子任务成功",
+		Data:    responseTasks,
+	})
+}
+
+// parseTaskFilter 从查询参数解析任务过滤条件
+func parseTaskFilter(c *gin.Context) (model.TaskFilter, error) {
+	var filter model.TaskFilter
+	var err error
+	if filter.Page, err = parseIntQuery(c, "page", 1, 1, math.MaxInt32); err != nil {
+		return filter, err
+	}
+	if filter.PageSize, err = parseIntQuery(c, "page_size", 10, 1, service.MaxPageSize); err != nil {
+		return filter, err
+	}
+	filter.TagID, _ = strconv.Atoi(c.Query("tag_id"))
+	if filter.ParentID, err = parseIDQuery(c, "parent_id"); err != nil {
+		return filter, err
+	}
+	if filter.ProjectID, err = parseIDQuery(c, "project_id"); err != nil {
+		return filter, err
+	}
+	filter.Keyword = strings.TrimSpace(c.Query("q"))
+	if assignee := c.Query("assignee"); assignee != "" {
+		if assignee == "me" {
+			filter.AssigneeID = middleware.GetUserID(c)
+		} else if filter.AssigneeID, err = strconv.Atoi(assignee); err != nil || filter.AssigneeID <= 0 {
+			return filter, errors.New("assignee 参数应为 me 或用户ID")
+		}
+	}
+
+	for _, text := range queryList(c, "status") {
+		status, err := model.ParseTaskStatus(text)
+		if err != nil {
+			return filter, err
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	for _, text := range queryList(c, "priority") {
+		priority, err := model.ParseTaskPriority(text)
+		if err != nil {
+			return filter, err
+		}
+		filter.Priorities = append(filter.Priorities, priority)
+	}
+
+	if filter.DueAfter, err = parseDateQuery(c, "due_after"); err != nil {
+		return filter, err
+	}
+	if filter.DueBefore, err = parseDateQuery(c, "due_before"); err != nil {
+		return filter, err
+	}
+	if overdue := c.Query("overdue"); overdue != "" {
+		if filter.Overdue, err = strconv.ParseBool(overdue); err != nil {
+			return filter, errors.New("overdue 参数应为 true 或 false")
+		}
+	}
+	if filter.Sort, err = model.ParseTaskSort(c.Query("sort")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// queryList 获取可重复且可用逗号分隔的查询参数
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// parseIntQuery 解析整数查询参数，参数为空时返回 def，不是 min 到 max 之间的整数时返回错误
+func parseIntQuery(c *gin.Context, key string, def, min, max int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s 参数应为 %d 到 %d 之间的整数", key, min, max)
+	}
+	return n, nil
+}
+
+// parseIDQuery 解析ID查询参数，参数为空时返回 nil
+func parseIDQuery(c *gin.Context, key string) (*int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return nil, fmt.Errorf("%s 参数应为非负整数", key)
+	}
+	return &id, nil
+}
+
+// parseDateQuery 解析日期查询参数，参数为空时返回 nil
+func parseDateQuery(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := parseDateString(value)
+	if err != nil || date == nil {
+		return nil, fmt.Errorf("%s 日期格式错误", key)
+	}
+	return date, nil
+}
+
+// taskErrorStatus 将任务服务的错误映射为 HTTP 状态码，未知错误按 500 处理
+func taskErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidRecurrence) {
+		return http.StatusBadRequest
+	}
+	switch err {
+	case service.ErrEmptyTitle, service.ErrTitleTooLong, service.ErrDescriptionTooLong,
+		service.ErrInvalidPriority, service.ErrInvalidTags, service.ErrInvalidParentTask,
+		service.ErrTaskDepthExceeded, service.ErrTaskCycle, service.ErrRecurrenceNoDue,
+		service.ErrInvalidProject, service.ErrSubtaskProject, service.ErrInvalidAssignee,
+		service.ErrTooManyAssignees, service.ErrInvalidStatus, service.ErrEmptyBatch,
+		service.ErrTooManyOperations, service.ErrInvalidBatchOp, service.ErrEmptyImport,
+		service.ErrTooManyImportItems:
+		return http.StatusBadRequest
+	case service.ErrTaskNotFound, service.ErrTaskAccessDenied, service.ErrAssigneeNotFound:
+		// 不区分不存在和无权访问，避免泄露其他用户的任务
+		return http.StatusNotFound
+	case service.ErrTaskReadOnly, service.ErrTaskOwnerOnly, service.ErrWorkspacePermission,
+		service.ErrNotWorkspaceMember:
+		return http.StatusForbidden
+	case service.ErrTaskHasSubtasks, service.ErrProjectArchived, service.ErrParentInTrash:
+		return http.StatusConflict
+	case service.ErrVersionConflict:
+		return http.StatusPreconditionFailed
+	case service.ErrBatchAborted:
+		return http.StatusFailedDependency
+	}
+	return http.StatusInternalServerError
+}
+
 // RegisterRoutes 注册路由
 func (h *TaskHandler) RegisterRoutes(r *gin.Engine) {
 	tasks := r.Group("/api/v1/tasks")
-	tasks.Use(middleware.AuthMiddleware())
+	tasks.Use(middleware.AuthMiddleware(), middleware.WorkspaceMiddleware(), middleware.IdempotencyMiddleware())
 	{
 		tasks.POST("", h.Create)
+		tasks.POST("/batch", h.Batch)
+		tasks.GET("/assigned", h.Assigned)
+		tasks.GET("/trash", h.Trash)
+		tasks.DELETE("/trash/:id", h.Purge)
+		tasks.POST("/:id/restore", h.Restore)
 		tasks.PUT("/:id", h.Update)
+		tasks.PATCH("/:id", h.Patch)
 		tasks.DELETE("/:id", h.Delete)
 		tasks.GET("/:id", h.Get)
+		tasks.GET("/:id/subtasks", h.Subtasks)
+		tasks.PUT("/:id/assignees", h.SetAssignees)
+		tasks.POST("/:id/assignees", h.AddAssignee)
+		tasks.DELETE("/:id/assignees/:user_id", h.RemoveAssignee)
 		tasks.GET("", h.List)
 	}
 }
 
 // CreateTaskRequest 创建任务请求
 type CreateTaskRequest struct {
-	Title       string `json:"title" binding:"required,min=1,max=100"`
-	Description string `json:"description" binding:"max=500"`
-	DueDate     string `json:"due_date"` // 移除 datetime 验证，我们将手动验证
+	Title       string  `json:"title" binding:"required,min=1,max=100"`
+	Description string  `json:"description" binding:"max=500"`
+	DueDate     string  `json:"due_date"` // 移除 datetime 验证，我们将手动验证
+	Priority    string  `json:"priority" binding:"omitempty,oneof=none low medium high"`
+	TagIDs      []int   `json:"tag_ids"`
+	ParentID    *int    `json:"parent_id"`  // 父任务ID，不传表示顶层任务
+	ProjectID   *int    `json:"project_id"` // 所属项目ID，子任务总是跟随父任务的项目
+	Recurrence  *string `json:"recurrence"` // 重复规则，RFC 5545 RRULE 格式，如 FREQ=WEEKLY;BYDAY=MO
+}
+
+// errInvalidDate 日期格式错误
+var errInvalidDate = errors.New("支持的日期格式：YYYY-MM-DD、YYYY/MM/DD、YYYY年MM月DD日、MM/DD/YYYY等")
+
+// toTask 将请求转换为待创建的任务，日期格式错误时返回 errInvalidDate
+func (req *CreateTaskRequest) toTask(userID, workspaceID int) (*model.Task, error) {
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		parsedTime, err := parseDateString(req.DueDate)
+		if err != nil {
+			return nil, errInvalidDate
+		}
+		dueDate = parsedTime
+	}
+
+	task := &model.Task{
+		UserID:      userID,
+		WorkspaceID: &workspaceID,
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     dueDate,
+		Status:      model.TaskStatusTodo,
+		ParentID:    req.ParentID,
+		ProjectID:   req.ProjectID,
+		Recurrence:  req.Recurrence,
+		Tags:        tagsFromIDs(req.TagIDs),
+	}
+	task.SetPriorityFromText(req.Priority)
+	return task, nil
 }
 
 // UpdateTaskRequest 更新任务请求
 type UpdateTaskRequest struct {
-	Title       string `json:"title" binding:"omitempty,min=1,max=100"`
-	Description string `json:"description" binding:"max=500"`
-	Status      string `json:"status" binding:"omitempty,oneof=todo in_progress done"`
-	DueDate     string `json:"due_date"` // 移除 datetime 验证，我们将手动验证
+	Title       string  `json:"title" binding:"omitempty,min=1,max=100"`
+	Description string  `json:"description" binding:"max=500"`
+	Status      string  `json:"status" binding:"omitempty,oneof=todo in_progress done"`
+	DueDate     string  `json:"due_date"` // 移除 datetime 验证，我们将手动验证
+	Priority    string  `json:"priority" binding:"omitempty,oneof=none low medium high"`
+	TagIDs      []int   `json:"tag_ids"`    // 不传表示不修改，传空数组表示清空标签
+	ParentID    *int    `json:"parent_id"`  // 不传表示不修改，传0表示移动到顶层
+	ProjectID   *int    `json:"project_id"` // 不传表示不修改，传0表示移出项目，子任务随之移动
+	Recurrence  *string `json:"recurrence"` // 不传表示不修改，传空字符串表示取消重复
+}
+
+// toPatch 将请求转换为任务服务的部分更新，只修改请求中提供的字段。
+// 空字符串表示不修改，状态和优先级未提供时保持不变，优先级为 none 时清空
+func (req *UpdateTaskRequest) toPatch(dueDate *time.Time) (model.TaskPatch, error) {
+	var patch model.TaskPatch
+	if req.Title != "" {
+		patch.Title = model.Some(req.Title)
+	}
+	if req.Description != "" {
+		patch.Description = model.Some(req.Description)
+	}
+	if req.Status != "" {
+		status, err := model.ParseTaskStatus(req.Status)
+		if err != nil {
+			return patch, err
+		}
+		patch.Status = model.Some(status)
+	}
+	if req.Priority != "" {
+		priority, err := model.ParseTaskPriority(req.Priority)
+		if err != nil {
+			return patch, err
+		}
+		patch.Priority = model.Some(priority)
+	}
+	if dueDate != nil {
+		patch.DueDate = model.Some(dueDate)
+	}
+	if req.TagIDs != nil {
+		patch.TagIDs = model.Some(req.TagIDs)
+	}
+	if req.ParentID != nil {
+		patch.ParentID = model.Some(req.ParentID)
+	}
+	if req.ProjectID != nil {
+		patch.ProjectID = model.Some(req.ProjectID)
+	}
+	if req.Recurrence != nil {
+		patch.Recurrence = model.Some(req.Recurrence)
+	}
+	return patch, nil
+}
+
+// TaskResponse 任务响应，状态和优先级以文本形式返回
+type TaskResponse struct {
+	*model.Task
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+	// NextOccurrence 完成重复任务时生成的下一次任务
+	NextOccurrence *TaskResponse `json:"next_occurrence,omitempty"`
+}
+
+// newTaskResponse 转换状态和优先级为文本形式
+func newTaskResponse(task *model.Task) TaskResponse {
+	response := TaskResponse{
+		Task:     task,
+		Status:   task.GetStatusText(),
+		Priority: task.GetPriorityText(),
+	}
+	if task.NextOccurrence != nil {
+		next := newTaskResponse(task.NextOccurrence)
+		response.NextOccurrence = &next
+	}
+	return response
+}
+
+// tagsFromIDs 根据标签ID构造标签引用，nil 表示未提供
+func tagsFromIDs(ids []int) []model.Tag {
+	if ids == nil {
+		return nil
+	}
+	tags := make([]model.Tag, 0, len(ids))
+	for _, id := range ids {
+		tags = append(tags, model.Tag{ID: id})
+	}
+	return tags
 }
 
 // ListTasksResponse 任务列表响应
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+)
+
+// Trash godoc
+// @Summary 获取回收站
+// @Description 获取已删除但尚未永久删除的任务，默认按删除时间倒序。个人空间为自己的任务，指定工作区时需要管理员权限
+// @Tags 回收站
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param X-Workspace-ID header int false "工作区ID，不提供时返回个人空间的回收站"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(10)
+// @Param q query string false "在标题和描述中搜索"
+// @Param sort query string false "排序字段，逗号分隔，前缀 - 表示降序，默认按删除时间倒序"
+// @Success 200 {object} Response{data=ListTasksResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/trash [get]
+func (h *TaskHandler) Trash(c *gin.Context) {
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	filter.UserID = middleware.GetUserID(c)
+	filter.WorkspaceID = middleware.GetWorkspaceID(c)
+
+	tasks, total, err := h.taskService.Trash(filter)
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "获取回收站失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	responseTasks := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responseTasks = append(responseTasks, newTaskResponse(task))
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取回收站成功",
+		Data: ListTasksResponse{
+			Total: total,
+			Items: responseTasks,
+		},
+	})
+}
+
+// Restore godoc
+// @Summary 恢复任务
+// @Description 从回收站恢复任务，与任务一起删除的子任务一并恢复。父任务仍在回收站中时需要先恢复父任务
+// @Tags 回收站
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} Response{data=TaskResponse} "恢复成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不在回收站中"
+// @Failure 409 {object} Response{} "父任务在回收站中"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/{id}/restore [post]
+func (h *TaskHandler) Restore(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	task, err := h.taskService.Restore(taskID, middleware.GetUserID(c))
+	if err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "恢复任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "恢复任务成功",
+		Data:    newTaskResponse(task),
+	})
+}
+
+// Purge godoc
+// @Summary 永久删除任务
+// @Description 永久删除回收站中的任务及其子任务，同时删除协作者、负责人和评论，无法恢复
+// @Tags 回收站
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} Response{} "删除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "任务不在回收站中"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /tasks/trash/{id} [delete]
+func (h *TaskHandler) Purge(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	if err := h.taskService.Purge(taskID, middleware.GetUserID(c)); err != nil {
+		status := taskErrorStatus(err)
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "永久删除任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "永久删除任务成功",
+	})
+}
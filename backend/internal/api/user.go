@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -66,7 +67,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "登录信息"
-// @Success 200 {object} Response{data=string} "登录成功"
+// @Success 200 {object} Response{data=service.TokenPair} "登录成功"
 // @Failure 400 {object} Response{} "请求参数错误"
 // @Failure 401 {object} Response{} "用户名或密码错误"
 // @Failure 500 {object} Response{} "服务器内部错误"
@@ -108,9 +109,117 @@ func (h *UserHandler) Login(c *gin.Context) {
 	})
 }
 
+// Refresh godoc
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "刷新令牌"
+// @Success 200 {object} Response{data=service.TokenPair} "刷新成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "刷新令牌无效"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /users/refresh [post]
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	token, err := h.userService.Refresh(req.RefreshToken)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRefreshToken, service.ErrRefreshTokenExpired,
+			service.ErrRefreshTokenReused, service.ErrUserNotFound:
+			c.JSON(http.StatusUnauthorized, Response{
+				Code:    401,
+				Message: "刷新令牌无效",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, Response{
+				Code:    500,
+				Message: "刷新令牌失败",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "刷新成功",
+		Data:    token,
+	})
+}
+
+// Logout godoc
+// @Summary 退出登录
+// @Description 吊销当前访问令牌，提供刷新令牌时同时结束该登录会话
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body LogoutRequest false "刷新令牌"
+// @Success 200 {object} Response{} "退出成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /users/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, Response{
+				Code:    400,
+				Message: "请求参数错误",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	var tokenID string
+	var expiresAt time.Time
+	if claims := middleware.GetClaims(c); claims != nil {
+		tokenID = claims.Id
+		expiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	err := h.userService.Logout(middleware.GetUserID(c), tokenID, expiresAt, req.RefreshToken)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRefreshToken:
+			c.JSON(http.StatusBadRequest, Response{
+				Code:    400,
+				Message: "刷新令牌无效",
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, Response{
+				Code:    500,
+				Message: "退出登录失败",
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "退出成功",
+	})
+}
+
 // UpdatePassword godoc
 // @Summary 更新密码
-// @Description 更新用户密码
+// @Description 更新用户密码，成功后用户全部登录会话的访问令牌和刷新令牌失效，需要重新登录
 // @Tags 用户管理
 // @Accept json
 // @Produce json
@@ -186,10 +295,14 @@ func (h *UserHandler) GetInfo(c *gin.Context) {
 func (h *UserHandler) RegisterRoutes(r *gin.Engine) {
 	users := r.Group("/api/v1/users")
 	{
+		// 注册、登录和刷新令牌没有登录用户，无法按用户区分幂等键，且响应中的令牌不能保存，不使用幂等中间件；
+		// 其他路由的幂等中间件在认证之后执行
 		users.POST("/register", h.Register)
 		users.POST("/login", h.Login)
+		users.POST("/refresh", h.Refresh)
+		users.POST("/logout", middleware.AuthMiddleware(), middleware.IdempotencyMiddleware(), h.Logout)
 		users.GET("/info", middleware.AuthMiddleware(), h.GetInfo)
-		users.PUT("/password", middleware.AuthMiddleware(), h.UpdatePassword)
+		users.PUT("/password", middleware.AuthMiddleware(), middleware.IdempotencyMiddleware(), h.UpdatePassword)
 	}
 }
 
@@ -213,6 +326,16 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 退出登录请求
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // UpdatePasswordRequest 更新密码请求
 type UpdatePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// WebhookHandler Webhook 处理器
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler 创建 Webhook 处理器
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// List godoc
+// @Summary 获取 Webhook 列表
+// @Description 获取当前用户注册的全部 Webhook，不返回密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]model.Webhook} "获取成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	webhooks, err := h.webhookService.List(middleware.GetUserID(c))
+	if err != nil {
+		respondWebhookError(c, "获取 Webhook 列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取 Webhook 列表成功",
+		Data:    webhooks,
+	})
+}
+
+// Create godoc
+// @Summary 注册 Webhook
+// @Description 当前用户可以看到的任务发生变更时，向 URL 发送 POST 请求，请求体为 JSON，包含事件类型、事件ID、发生时间、任务和字段变更。
+// @Description 请求头 X-Webhook-Event 为事件类型，X-Webhook-Delivery 为投递ID（重试时不变），
+// @Description X-Webhook-Signature-256 为 sha256= 加上以密钥对请求体计算的 HMAC-SHA256 十六进制值。
+// @Description 接收方返回 2xx 以外的状态码或超时时按指数退避重试，不跟随重定向，3xx 视为失败。
+// @Description URL 不能指向本机、内网或链路本地地址，投递时也会检查域名解析出的地址。未提供密钥时生成随机密钥，密钥只在注册时返回
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body CreateWebhookRequest true "Webhook 信息"
+// @Success 200 {object} Response{data=WebhookResponse} "注册成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	webhook := &model.Webhook{
+		UserID: middleware.GetUserID(c),
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Active: req.Active == nil || *req.Active,
+	}
+	if err := h.webhookService.Create(webhook); err != nil {
+		respondWebhookError(c, "注册 Webhook 失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "注册 Webhook 成功",
+		Data: WebhookResponse{
+			Webhook: webhook,
+			Secret:  webhook.Secret,
+		},
+	})
+}
+
+// Get godoc
+// @Summary 获取 Webhook
+// @Description 获取当前用户的 Webhook，不返回密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} Response{data=model.Webhook} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "Webhook 不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(webhookID, middleware.GetUserID(c))
+	if err != nil {
+		respondWebhookError(c, "获取 Webhook 失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取 Webhook 成功",
+		Data:    webhook,
+	})
+}
+
+// Update godoc
+// @Summary 修改 Webhook
+// @Description 修改 URL 和订阅的事件；未提供 active 时保持启用状态不变，未提供密钥时保留原来的密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook ID"
+// @Param request body UpdateWebhookRequest true "Webhook 信息"
+// @Success 200 {object} Response{data=model.Webhook} "修改成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "Webhook 不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	webhook, err := h.webhookService.Get(webhookID, userID)
+	if err != nil {
+		respondWebhookError(c, "修改 Webhook 失败", err)
+		return
+	}
+	webhook.URL = req.URL
+	webhook.Events = req.Events
+	webhook.Secret = req.Secret
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	webhook, err = h.webhookService.Update(webhook)
+	if err != nil {
+		respondWebhookError(c, "修改 Webhook 失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "修改 Webhook 成功",
+		Data:    webhook,
+	})
+}
+
+// Delete godoc
+// @Summary 删除 Webhook
+// @Description 删除 Webhook 及其投递记录，尚未投递的记录不再投递
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} Response{} "删除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "Webhook 不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(webhookID, middleware.GetUserID(c)); err != nil {
+		respondWebhookError(c, "删除 Webhook 失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "删除 Webhook 成功",
+	})
+}
+
+// Deliveries godoc
+// @Summary 获取 Webhook 投递记录
+// @Description 分页获取 Webhook 的投递记录，最新的排在前面。status 为 pending 时等待投递或重试，
+// @Description succeeded 表示接收方返回了 2xx，failed 表示重试次数已用尽
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(20)
+// @Success 200 {object} Response{data=ListWebhookDeliveriesResponse} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "Webhook 不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(service.DefaultPageSize)))
+
+	deliveries, total, err := h.webhookService.Deliveries(webhookID, middleware.GetUserID(c), page, pageSize)
+	if err != nil {
+		respondWebhookError(c, "获取投递记录失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取投递记录成功",
+		Data: ListWebhookDeliveriesResponse{
+			Total: total,
+			Items: deliveries,
+		},
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *WebhookHandler) RegisterRoutes(r *gin.Engine) {
+	webhooks := r.Group("/api/v1/webhooks")
+	webhooks.Use(middleware.AuthMiddleware())
+	{
+		webhooks.GET("", h.List)
+		webhooks.POST("", h.Create)
+		webhooks.GET("/:id", h.Get)
+		webhooks.PUT("/:id", h.Update)
+		webhooks.DELETE("/:id", h.Delete)
+		webhooks.GET("/:id/deliveries", h.Deliveries)
+	}
+}
+
+// parseWebhookID 解析路径中的 Webhook ID，失败时写入错误响应
+func parseWebhookID(c *gin.Context) (int, bool) {
+	webhookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的 Webhook ID",
+		})
+		return 0, false
+	}
+	return webhookID, true
+}
+
+// respondWebhookError 根据 Webhook 服务的错误返回对应的状态码
+func respondWebhookError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrInvalidWebhookURL, service.ErrInvalidWebhookEvents, service.ErrWebhookSecretTooLong, service.ErrTooManyWebhooks:
+		status = http.StatusBadRequest
+	case service.ErrWebhookNotFound:
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// CreateWebhookRequest 注册 Webhook 请求
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Events 订阅的事件类型：task.created、task.updated、task.completed、task.deleted
+	Events []string `json:"events" binding:"required,min=1"`
+	// Secret 计算签名的密钥，为空时生成随机密钥
+	Secret string `json:"secret"`
+	// Active 是否启用，默认启用
+	Active *bool `json:"active"`
+}
+
+// UpdateWebhookRequest 修改 Webhook 请求
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required,min=1"`
+	// Secret 新的密钥，为空时保留原来的密钥
+	Secret string `json:"secret"`
+	// Active 是否启用，为空时保持不变
+	Active *bool `json:"active"`
+}
+
+// WebhookResponse 注册 Webhook 的响应，包含计算签名的密钥
+type WebhookResponse struct {
+	*model.Webhook
+	Secret string `json:"secret"`
+}
+
+// ListWebhookDeliveriesResponse 投递记录列表响应
+type ListWebhookDeliveriesResponse struct {
+	Total int64                    `json:"total"`
+	Items []*model.WebhookDelivery `json:"items"`
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/internal/middleware"
+	"todolist/internal/model"
+	"todolist/internal/service"
+)
+
+// WorkspaceHandler 工作区处理器
+type WorkspaceHandler struct {
+	workspaceService service.WorkspaceService
+}
+
+// NewWorkspaceHandler 创建工作区处理器
+func NewWorkspaceHandler(workspaceService service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+	}
+}
+
+// Create godoc
+// @Summary 创建工作区
+// @Description 创建工作区，创建者成为所有者
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body WorkspaceRequest true "工作区信息"
+// @Success 200 {object} Response{data=model.Workspace} "创建成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces [post]
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	var req WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	workspace := &model.Workspace{
+		Name:    req.Name,
+		OwnerID: middleware.GetUserID(c),
+	}
+	if err := h.workspaceService.Create(workspace); err != nil {
+		respondWorkspaceError(c, "创建工作区失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "创建工作区成功",
+		Data:    workspace,
+	})
+}
+
+// List godoc
+// @Summary 获取工作区列表
+// @Description 获取当前用户加入的全部工作区及其角色
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]model.Workspace} "获取成功"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces [get]
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	workspaces, err := h.workspaceService.List(middleware.GetUserID(c))
+	if err != nil {
+		respondWorkspaceError(c, "获取工作区列表失败", err)
+		return
+	}
+	if workspaces == nil {
+		workspaces = []*model.Workspace{}
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取工作区列表成功",
+		Data:    workspaces,
+	})
+}
+
+// Get godoc
+// @Summary 获取工作区详情
+// @Description 获取指定工作区及当前用户的角色
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "工作区ID"
+// @Success 200 {object} Response{data=model.Workspace} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "工作区不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/{id} [get]
+func (h *WorkspaceHandler) Get(c *gin.Context) {
+	workspaceID, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	workspace, err := h.workspaceService.Get(workspaceID, middleware.GetUserID(c))
+	if err != nil {
+		respondWorkspaceError(c, "获取工作区失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取工作区成功",
+		Data:    workspace,
+	})
+}
+
+// Update godoc
+// @Summary 修改工作区
+// @Description 修改工作区名称，需要管理员权限
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "工作区ID"
+// @Param request body WorkspaceRequest true "工作区信息"
+// @Success 200 {object} Response{data=model.Workspace} "修改成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "工作区不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/{id} [put]
+func (h *WorkspaceHandler) Update(c *gin.Context) {
+	workspaceID, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	var req WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	workspace, err := h.workspaceService.Rename(workspaceID, middleware.GetUserID(c), req.Name)
+	if err != nil {
+		respondWorkspaceError(c, "修改工作区失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "修改工作区成功",
+		Data:    workspace,
+	})
+}
+
+// Members godoc
+// @Summary 获取工作区成员
+// @Description 获取工作区的全部成员，按加入顺序排列
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "工作区ID"
+// @Success 200 {object} Response{data=[]model.WorkspaceMember} "获取成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 404 {object} Response{} "工作区不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/{id}/members [get]
+func (h *WorkspaceHandler) Members(c *gin.Context) {
+	workspaceID, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.workspaceService.Members(workspaceID, middleware.GetUserID(c))
+	if err != nil {
+		respondWorkspaceError(c, "获取工作区成员失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "获取工作区成员成功",
+		Data:    members,
+	})
+}
+
+// UpdateMember godoc
+// @Summary 修改成员角色
+// @Description 修改工作区成员的角色，操作者的角色必须高于该成员当前的角色和新角色
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "工作区ID"
+// @Param user_id path int true "成员用户ID"
+// @Param request body UpdateWorkspaceMemberRequest true "成员角色"
+// @Success 200 {object} Response{data=model.WorkspaceMember} "修改成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "工作区或成员不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/{id}/members/{user_id} [put]
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	workspaceID, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	var req UpdateWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	member, err := h.workspaceService.UpdateMemberRole(workspaceID, middleware.GetUserID(c), memberID, req.Role)
+	if err != nil {
+		respondWorkspaceError(c, "修改成员角色失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "修改成员角色成功",
+		Data:    member,
+	})
+}
+
+// RemoveMember godoc
+// @Summary 移除成员
+// @Description 管理员可以移除角色低于自己的成员，成员可以移除自己以退出工作区，所有者不能退出
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "工作区ID"
+// @Param user_id path int true "成员用户ID"
+// @Success 200 {object} Response{} "移除成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "工作区或成员不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/{id}/members/{user_id} [delete]
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	workspaceID, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	if err := h.workspaceService.RemoveMember(workspaceID, middleware.GetUserID(c), memberID); err != nil {
+		respondWorkspaceError(c, "移除成员失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "移除成员成功",
+	})
+}
+
+// Invite godoc
+// @Summary 创建工作区邀请
+// @Description 创建邀请令牌，被邀请的用户凭令牌加入工作区。令牌只在本次响应中返回，7天后过期，只能使用一次
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "工作区ID"
+// @Param request body InviteWorkspaceMemberRequest true "邀请信息"
+// @Success 200 {object} Response{data=model.WorkspaceInvitation} "创建成功"
+// @Failure 400 {object} Response{} "请求参数错误"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 403 {object} Response{} "没有权限"
+// @Failure 404 {object} Response{} "工作区不存在"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/{id}/invitations [post]
+func (h *WorkspaceHandler) Invite(c *gin.Context) {
+	workspaceID, ok := parseWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	var req InviteWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	invitation, err := h.workspaceService.Invite(workspaceID, middleware.GetUserID(c), req.Role)
+	if err != nil {
+		respondWorkspaceError(c, "创建邀请失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "创建邀请成功",
+		Data:    invitation,
+	})
+}
+
+// Join godoc
+// @Summary 加入工作区
+// @Description 凭邀请令牌加入工作区
+// @Tags 工作区
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body JoinWorkspaceRequest true "邀请令牌"
+// @Success 200 {object} Response{data=model.Workspace} "加入成功"
+// @Failure 400 {object} Response{} "邀请无效"
+// @Failure 401 {object} Response{} "未授权"
+// @Failure 409 {object} Response{} "已是成员"
+// @Failure 410 {object} Response{} "邀请已过期"
+// @Failure 500 {object} Response{} "服务器内部错误"
+// @Router /workspaces/join [post]
+func (h *WorkspaceHandler) Join(c *gin.Context) {
+	var req JoinWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	workspace, err := h.workspaceService.AcceptInvitation(req.Token, middleware.GetUserID(c))
+	if err != nil {
+		respondWorkspaceError(c, "加入工作区失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    200,
+		Message: "加入工作区成功",
+		Data:    workspace,
+	})
+}
+
+// RegisterRoutes 注册路由
+func (h *WorkspaceHandler) RegisterRoutes(r *gin.Engine) {
+	workspaces := r.Group("/api/v1/workspaces")
+	workspaces.Use(middleware.AuthMiddleware())
+	{
+		workspaces.POST("", h.Create)
+		workspaces.GET("", h.List)
+		workspaces.POST("/join", h.Join)
+		workspaces.GET("/:id", h.Get)
+		workspaces.PUT("/:id", h.Update)
+		workspaces.GET("/:id/members", h.Members)
+		workspaces.PUT("/:id/members/:user_id", h.UpdateMember)
+		workspaces.DELETE("/:id/members/:user_id", h.RemoveMember)
+		workspaces.POST("/:id/invitations", h.Invite)
+	}
+}
+
+// parseWorkspaceID 解析路径中的工作区ID，无效时返回 400
+func parseWorkspaceID(c *gin.Context) (int, bool) {
+	workspaceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    400,
+			Message: "无效的工作区ID",
+		})
+		return 0, false
+	}
+	return workspaceID, true
+}
+
+// respondWorkspaceError 根据工作区服务的错误返回对应的状态码
+func respondWorkspaceError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch err {
+	case service.ErrEmptyWorkspaceName, service.ErrWorkspaceNameTooLong, service.ErrInvalidWorkspaceRole,
+		service.ErrInvalidInvitation:
+		status = http.StatusBadRequest
+	case service.ErrWorkspacePermission, service.ErrWorkspaceOwnerRole:
+		status = http.StatusForbidden
+	case service.ErrWorkspaceNotFound, service.ErrNotWorkspaceMember, service.ErrWorkspaceMemberNotFound:
+		// 不区分不存在和不是成员，避免泄露其他工作区
+		status = http.StatusNotFound
+	case service.ErrAlreadyWorkspaceMember:
+		status = http.StatusConflict
+	case service.ErrInvitationExpired:
+		status = http.StatusGone
+	}
+
+	c.JSON(status, Response{
+		Code:    status,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// WorkspaceRequest 创建或修改工作区请求
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// UpdateWorkspaceMemberRequest 修改成员角色请求
+type UpdateWorkspaceMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member guest"`
+}
+
+// InviteWorkspaceMemberRequest 创建工作区邀请请求
+type InviteWorkspaceMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member guest"`
+}
+
+// JoinWorkspaceRequest 加入工作区请求
+type JoinWorkspaceRequest struct {
+	Token string `json:"token" binding:"required"`
+}
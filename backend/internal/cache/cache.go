@@ -0,0 +1,119 @@
+// Package cache 提供仓库缓存使用的键值存储，生产环境使用 Redis，测试和单实例部署可以使用进程内实现
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"todolist/config"
+	"todolist/pkg/redis"
+)
+
+// Cache 键值缓存，多个实例共用同一个缓存时修改对所有实例可见
+type Cache interface {
+	// Get 获取键的值，键不存在或已过期时 ok 为 false
+	Get(key string) (value string, ok bool, err error)
+	// Set 设置键的值，ttl 大于0时在 ttl 后过期
+	Set(key, value string, ttl time.Duration) error
+	// Delete 删除键，不存在的键会被忽略
+	Delete(keys ...string) error
+	// Incr 将键的值加1并返回新值，键不存在时从0开始，不会过期
+	Incr(key string) (int64, error)
+}
+
+// Stats 缓存命中统计，可以在多个 goroutine 中使用
+type Stats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Hit 记录一次命中
+func (s *Stats) Hit() {
+	s.hits.Add(1)
+}
+
+// Miss 记录一次未命中
+func (s *Stats) Miss() {
+	s.misses.Add(1)
+}
+
+// Hits 返回命中次数
+func (s *Stats) Hits() int64 {
+	return s.hits.Load()
+}
+
+// Misses 返回未命中次数
+func (s *Stats) Misses() int64 {
+	return s.misses.Load()
+}
+
+// Snapshot 返回当前的命中和未命中次数，用于 expvar 等导出
+func (s *Stats) Snapshot() map[string]int64 {
+	return map[string]int64{"hits": s.Hits(), "misses": s.Misses()}
+}
+
+// New 根据配置中的 cache.driver 创建缓存，驱动为 none 时返回 nil 表示不使用缓存。
+// 使用 Redis 时会先检查服务器是否可用
+func New(cfg config.Config) (Cache, error) {
+	switch cfg.Cache.Driver {
+	case config.CacheDriverNone, "":
+		return nil, nil
+	case config.CacheDriverMemory:
+		return NewMemoryCache(), nil
+	case config.CacheDriverRedis:
+		client := redis.NewClient(redis.Options{
+			Addr:            cfg.Redis.Addr(),
+			Password:        cfg.Redis.Password,
+			DB:              cfg.Redis.DB,
+			PoolSize:        cfg.Redis.PoolSize,
+			MinIdleConns:    cfg.Redis.MinIdleConns,
+			MaxConnLifetime: cfg.Redis.MaxConnLifetime,
+		})
+		if err := client.Ping(); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("连接Redis失败: %v", err)
+		}
+		return NewRedisCache(client), nil
+	default:
+		return nil, fmt.Errorf("不支持的缓存驱动: %s", cfg.Cache.Driver)
+	}
+}
+
+// redisCache 基于 Redis 的缓存实现
+type redisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache 创建基于 Redis 的缓存
+func NewRedisCache(client *redis.Client) Cache {
+	return &redisCache{client: client}
+}
+
+// Get 获取键的值
+func (c *redisCache) Get(key string) (string, bool, error) {
+	value, err := c.client.Get(key)
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set 设置键的值
+func (c *redisCache) Set(key, value string, ttl time.Duration) error {
+	return c.client.Set(key, value, ttl)
+}
+
+// Delete 删除键
+func (c *redisCache) Delete(keys ...string) error {
+	return c.client.Del(keys...)
+}
+
+// Incr 将键的值加1
+func (c *redisCache) Incr(key string) (int64, error) {
+	return c.client.Incr(key)
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryEntry 进程内缓存的条目
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time // 为零值表示不过期
+}
+
+// memoryCache 进程内缓存实现，主要用于测试，多个实例之间不共享
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryCache 创建进程内缓存
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]memoryEntry)}
+}
+
+// Get 获取键的值，过期的键在读取时删除
+func (c *memoryCache) Get(key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set 设置键的值
+func (c *memoryCache) Set(key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.entries[key] = entry
+	return nil
+}
+
+// Delete 删除键
+func (c *memoryCache) Delete(keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Incr 将键的值加1，与 Redis 一致，已有的值不是整数时返回错误
+func (c *memoryCache) Incr(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	if entry, ok := c.entries[key]; ok && (entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt)) {
+		var err error
+		if n, err = strconv.ParseInt(entry.value, 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	n++
+	c.entries[key] = memoryEntry{value: strconv.FormatInt(n, 10)}
+	return n, nil
+}
@@ -0,0 +1,164 @@
+// Package event 实现进程内的任务事件总线，任务服务发布变更事件，实时推送等订阅者按用户接收。
+// 总线保留最近的事件，订阅时可以从上次收到的事件之后继续；多个实例之间不共享事件
+package event
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// 任务事件类型
+const (
+	TaskCreated = "task.created"
+	TaskUpdated = "task.updated"
+	TaskDeleted = "task.deleted"
+	// TaskCompleted 任务被标记为已完成，总线不单独发布，由 Webhook 等订阅者从更新事件中识别
+	TaskCompleted = "task.completed"
+)
+
+const (
+	// DefaultHistorySize 总线默认保留的事件数
+	DefaultHistorySize = 1000
+	// subscriptionBuffer 每个订阅者未读取的事件上限，超过后订阅被关闭，订阅者需要重新订阅并从断开处继续
+	subscriptionBuffer = 64
+)
+
+// Event 任务变更事件
+type Event struct {
+	// ID 事件序号，从1开始递增，服务重启后重新计数
+	ID   int64
+	Type string
+	// Task 变更后的任务，删除事件为删除时的任务；订阅者之间共享，不能修改
+	Task *model.Task
+	// UserIDs 可以看到该任务的用户，按ID排序
+	UserIDs []int
+	// Changes 与任务历史相同的字段级变更
+	Changes   []model.FieldChange
+	CreatedAt time.Time
+}
+
+// VisibleTo 判断用户能否收到该事件
+func (e *Event) VisibleTo(userID int) bool {
+	_, found := slices.BinarySearch(e.UserIDs, userID)
+	return found
+}
+
+// Completed 判断事件是否将任务标记为已完成
+func (e *Event) Completed() bool {
+	if e.Type != TaskUpdated {
+		return false
+	}
+	for _, change := range e.Changes {
+		if change.Field == "status" {
+			return e.Task.Status == model.TaskStatusDone
+		}
+	}
+	return false
+}
+
+// Bus 事件总线，可以在多个 goroutine 中使用；为 nil 时不发布事件
+type Bus struct {
+	mu          sync.Mutex
+	lastID      int64
+	historySize int
+	history     []Event
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBus 创建事件总线，保留最近 historySize 个事件用于断线续传
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{historySize: historySize, subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscription 事件订阅
+type Subscription struct {
+	bus    *Bus
+	userID int
+	events chan Event
+}
+
+// Events 返回按顺序接收事件的通道，订阅被关闭或因读取过慢被总线关闭时通道关闭
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close 取消订阅，可以重复调用
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// Publish 发布事件，为事件分配序号和时间并返回
+func (b *Bus) Publish(e Event) Event {
+	if b == nil {
+		return e
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	slices.Sort(e.UserIDs)
+	e.UserIDs = slices.Compact(e.UserIDs)
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = slices.Delete(b.history, 0, len(b.history)-b.historySize)
+	}
+
+	for sub := range b.subscribers {
+		if sub.userID != 0 && !e.VisibleTo(sub.userID) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+	return e
+}
+
+// Subscribe 订阅用户可以看到的事件，userID 为0时接收全部事件。
+// lastEventID 不为0时先返回之后错过的事件；complete 为 false 表示部分事件已不在保留范围内
+// 或 lastEventID 来自重启前，订阅者应重新加载全部数据
+func (b *Bus) Subscribe(userID int, lastEventID int64) (sub *Subscription, missed []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{bus: b, userID: userID, events: make(chan Event, subscriptionBuffer)}
+	b.subscribers[sub] = struct{}{}
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+
+	complete = lastEventID <= b.lastID
+	if len(b.history) > 0 && b.history[0].ID > lastEventID+1 {
+		complete = false
+	}
+	for _, e := range b.history {
+		if e.ID > lastEventID && (userID == 0 || e.VisibleTo(userID)) {
+			missed = append(missed, e)
+		}
+	}
+	return sub, missed, complete
+}
+
+// remove 移除订阅并关闭通道，调用方需要持有锁
+func (b *Bus) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
@@ -14,8 +14,32 @@ const (
 	ContextKeyUserID = "user_id"
 	// ContextKeyUsername 用户名的上下文键
 	ContextKeyUsername = "username"
+	// ContextKeyClaims 令牌声明的上下文键
+	ContextKeyClaims = "claims"
 )
 
+// TokenRevocationChecker 访问令牌吊销检查
+type TokenRevocationChecker interface {
+	IsTokenRevoked(tokenID string) (bool, error)
+}
+
+// revocationChecker 认证时使用的吊销检查，为 nil 时不检查
+var revocationChecker TokenRevocationChecker
+
+// SetTokenRevocationChecker 设置认证中间件使用的吊销检查
+func SetTokenRevocationChecker(checker TokenRevocationChecker) {
+	revocationChecker = checker
+}
+
+// IsTokenRevoked 判断访问令牌是否已被吊销，未设置吊销检查时返回 false。
+// 用于认证之后长时间保持的连接重新检查令牌
+func IsTokenRevoked(tokenID string) (bool, error) {
+	if revocationChecker == nil {
+		return false, nil
+	}
+	return revocationChecker.IsTokenRevoked(tokenID)
+}
+
 // AuthMiddleware 认证中间件
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -48,9 +72,41 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 没有唯一标识的令牌无法吊销，不予接受
+		if claims.Id == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "无效的认证信息",
+				"error":   "令牌缺少唯一标识",
+			})
+			c.Abort()
+			return
+		}
+
+		// 检查令牌是否已被吊销
+		revoked, err := IsTokenRevoked(claims.Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "校验认证信息失败",
+				"error":   err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "认证信息已失效",
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存入上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		c.Set(ContextKeyUserID, claims.UserID)
+		c.Set(ContextKeyUsername, claims.Username)
+		c.Set(ContextKeyClaims, claims)
 
 		c.Next()
 	}
@@ -58,7 +114,10 @@ func AuthMiddleware() gin.HandlerFunc {
 
 // GetUserID 从上下文中获取用户ID
 func GetUserID(c *gin.Context) int {
-	userID, _ := c.Get("user_id")
+	userID, exists := c.Get(ContextKeyUserID)
+	if !exists {
+		return 0
+	}
 	return userID.(int)
 }
 
@@ -71,6 +130,15 @@ func GetUsername(c *gin.Context) string {
 	return username.(string)
 }
 
+// GetClaims 从上下文中获取令牌声明
+func GetClaims(c *gin.Context) *jwt.CustomClaims {
+	claims, exists := c.Get(ContextKeyClaims)
+	if !exists {
+		return nil
+	}
+	return claims.(*jwt.CustomClaims)
+}
+
 // MustGetUserID 从上下文中获取用户ID，如果不存在则panic
 func MustGetUserID(c *gin.Context) int {
 	userID, exists := c.Get(ContextKeyUserID)
@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"todolist/config"
+	"todolist/internal/model"
+)
+
+const (
+	// IdempotencyKeyHeader 幂等键请求头，相同幂等键的重试返回首次请求的响应
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应是否为重放的首次请求响应
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders 随响应一起保存并在重试时返回的响应头
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyStore 幂等键存储
+type IdempotencyStore interface {
+	// Begin 为首次请求占用幂等键，占用成功时返回 nil，否则返回已保存的记录
+	Begin(record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	// Complete 保存首次请求的响应
+	Complete(record *model.IdempotencyRecord) error
+	// Release 释放幂等键
+	Release(userID int, key string) error
+}
+
+// idempotencyStore 幂等中间件使用的存储，为 nil 时忽略幂等键
+var idempotencyStore IdempotencyStore
+
+// SetIdempotencyStore 设置幂等中间件使用的存储
+func SetIdempotencyStore(store IdempotencyStore) {
+	idempotencyStore = store
+}
+
+// responseRecorder 在写出响应的同时保存响应体
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写出并保存响应体
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写出并保存响应体
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware 幂等中间件，对携带 Idempotency-Key 的 POST/PUT/PATCH/DELETE 请求，
+// 按用户保存幂等键、请求指纹和响应，重试时返回首次请求的响应；相同幂等键用于不同的请求时返回 422，
+// 首次请求仍在处理中时返回 409。首次请求返回 5xx 时不保存响应，客户端可以使用相同的幂等键重试。
+// 需要在认证中间件之后使用，未登录的请求忽略幂等键，避免不同的匿名请求共用幂等键
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || idempotencyStore == nil || !isMutatingMethod(c.Request.Method) || GetUserID(c) == 0 {
+			c.Next()
+			return
+		}
+		if len(key) > model.MaxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "幂等键不能超过255个字符",
+			})
+			c.Abort()
+			return
+		}
+
+		// 计算指纹需要读取整个请求体，限制长度避免过大的请求体占用内存
+		maxBody := config.GlobalConfig.Idempotency.MaxBodyMB << 20
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"code":    413,
+				"message": fmt.Sprintf("携带幂等键的请求体不能超过%dMB", config.GlobalConfig.Idempotency.MaxBodyMB),
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "读取请求体失败",
+				"error":   err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := GetUserID(c)
+		record := &model.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: requestFingerprint(c, body),
+		}
+		existing, err := idempotencyStore.Begin(record)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "保存幂等键失败",
+				"error":   err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replayResponse(c, existing, record.Fingerprint)
+			return
+		}
+
+		// 处理过程中 panic 时释放幂等键，避免重试一直返回 409
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if err := idempotencyStore.Release(userID, key); err != nil {
+					log.Printf("释放幂等键失败: %v", err)
+				}
+				panic(recovered)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := idempotencyStore.Release(userID, key); err != nil {
+				log.Printf("释放幂等键失败: %v", err)
+			}
+			return
+		}
+		record.StatusCode = status
+		record.Headers = make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		record.Body = recorder.body.String()
+		if err := idempotencyStore.Complete(record); err != nil {
+			log.Printf("保存幂等键响应失败: %v", err)
+		}
+	}
+}
+
+// replayResponse 返回已保存的首次请求响应，幂等键用于不同的请求或首次请求未完成时返回错误
+func replayResponse(c *gin.Context, existing *model.IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"code":    422,
+			"message": "幂等键已用于其他请求",
+		})
+		c.Abort()
+		return
+	}
+	if !existing.IsCompleted() {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    409,
+			"message": "相同幂等键的请求正在处理中",
+		})
+		c.Abort()
+		return
+	}
+
+	for name, value := range existing.Headers {
+		c.Header(name, value)
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(existing.StatusCode)
+	c.Writer.WriteString(existing.Body)
+	c.Abort()
+}
+
+// requestFingerprint 计算请求指纹，包括请求方法、地址、工作区和请求体
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	hash.Write([]byte(c.GetHeader(WorkspaceHeader) + "\n"))
+	if !writeMultipartFingerprint(hash, c.GetHeader("Content-Type"), body) {
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeMultipartFingerprint 按表单字段写入 multipart 请求体的指纹。
+// 客户端每次请求都会生成随机的 boundary，直接哈希原始请求体会让重试被当成不同的请求，
+// 因此只哈希各字段的名称、文件名和内容。不是 multipart 请求或解析失败时返回 false
+func writeMultipartFingerprint(w io.Writer, contentType string, body []byte) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return false
+	}
+	var parts bytes.Buffer
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return false
+		}
+		// 写入长度前缀，避免字段边界不同的请求得到相同的指纹
+		fmt.Fprintf(&parts, "%q %q %d\n", part.FormName(), part.FileName(), len(content))
+		parts.Write(content)
+	}
+	w.Write(parts.Bytes())
+	return true
+}
+
+// isMutatingMethod 判断请求方法是否会修改数据
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// WorkspaceHeader 指定当前工作区的请求头，未提供时使用个人空间
+	WorkspaceHeader = "X-Workspace-ID"
+	// ContextKeyWorkspaceID 当前工作区ID的上下文键
+	ContextKeyWorkspaceID = "workspace_id"
+	// ContextKeyWorkspaceRole 用户在当前工作区中角色的上下文键
+	ContextKeyWorkspaceRole = "workspace_role"
+)
+
+// WorkspaceMembershipChecker 工作区成员身份检查
+type WorkspaceMembershipChecker interface {
+	// MemberRole 获取用户在工作区中的角色，不是成员时返回空字符串
+	MemberRole(workspaceID, userID int) (string, error)
+}
+
+// membershipChecker 工作区中间件使用的成员身份检查，为 nil 时不检查，由服务层验证
+var membershipChecker WorkspaceMembershipChecker
+
+// SetWorkspaceMembershipChecker 设置工作区中间件使用的成员身份检查
+func SetWorkspaceMembershipChecker(checker WorkspaceMembershipChecker) {
+	membershipChecker = checker
+}
+
+// WorkspaceMiddleware 工作区中间件，从 X-Workspace-ID 请求头中读取当前工作区并验证成员身份
+// 需要在认证中间件之后使用
+func WorkspaceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(WorkspaceHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		workspaceID, err := strconv.Atoi(header)
+		if err != nil || workspaceID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "无效的工作区ID",
+			})
+			c.Abort()
+			return
+		}
+
+		if membershipChecker != nil {
+			role, err := membershipChecker.MemberRole(workspaceID, GetUserID(c))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"code":    500,
+					"message": "校验工作区成员身份失败",
+					"error":   err.Error(),
+				})
+				c.Abort()
+				return
+			}
+			if role == "" {
+				c.JSON(http.StatusForbidden, gin.H{
+					"code":    403,
+					"message": "不是该工作区的成员",
+				})
+				c.Abort()
+				return
+			}
+			c.Set(ContextKeyWorkspaceRole, role)
+		}
+
+		c.Set(ContextKeyWorkspaceID, workspaceID)
+		c.Next()
+	}
+}
+
+// GetWorkspaceID 从上下文中获取当前工作区ID，个人空间返回0
+func GetWorkspaceID(c *gin.Context) int {
+	workspaceID, exists := c.Get(ContextKeyWorkspaceID)
+	if !exists {
+		return 0
+	}
+	return workspaceID.(int)
+}
+
+// GetWorkspaceRole 从上下文中获取用户在当前工作区中的角色
+func GetWorkspaceRole(c *gin.Context) string {
+	role, exists := c.Get(ContextKeyWorkspaceRole)
+	if !exists {
+		return ""
+	}
+	return role.(string)
+}
@@ -0,0 +1,374 @@
+// Package migration 提供基于版本号的数据库表结构迁移
+//
+// 迁移脚本以 SQL 文件的形式嵌入二进制，按数据库方言分目录存放：
+//
+//	sql/<dialect>/<version>_<name>.up.sql
+//	sql/<dialect>/<version>_<name>.down.sql
+//
+// 已执行的迁移记录在 schema_migrations 表中，并保存 up 脚本的校验和，
+// 已发布的迁移脚本一旦被修改，启动时会校验失败。
+//
+// 每个迁移在一个事务中执行，但 MySQL 的 DDL 语句会隐式提交事务，
+// 包含多条 DDL 的迁移中途失败时，失败之前的语句不会回滚，迁移也不会被记录，
+// 需要根据错误手动恢复表结构后再重新执行。SQLite 的 DDL 支持事务，不存在这个问题。
+package migration
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var sqlFS embed.FS
+
+var (
+	ErrChecksumMismatch = errors.New("迁移脚本校验和不匹配")
+	ErrUnknownMigration = errors.New("数据库中存在未知的迁移版本")
+	ErrNoDownScript     = errors.New("迁移缺少回滚脚本")
+)
+
+// Migration 单个版本的迁移
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// String 返回迁移的可读名称
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status 迁移执行状态
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// schemaMigration schema_migrations 表记录
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定表名
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+
+	// DryRun 为 true 时只输出将要执行的 SQL，不修改数据库
+	DryRun bool
+	// Out 接收执行日志和 dry-run 输出，为 nil 时不输出
+	Out io.Writer
+}
+
+// New 根据数据库方言加载嵌入的迁移脚本
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load 加载指定方言的全部迁移，按版本号升序排列
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(sqlFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的数据库方言 %s: %v", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionText, migrationName, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", name)
+		}
+		version, err := strconv.Atoi(versionText)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("迁移文件版本号错误: %s", name)
+		}
+
+		content, err := fs.ReadFile(sqlFS, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = m
+		} else if m.Name != migrationName {
+			return nil, fmt.Errorf("迁移版本 %d 存在多个名称: %s, %s", version, m.Name, migrationName)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("迁移 %s 缺少 up 脚本", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrations 返回全部已加载的迁移
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status 返回每个迁移的执行状态
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Verify 校验已执行迁移的校验和与嵌入的脚本一致
+func (m *Migrator) Verify() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	return m.verify(applied)
+}
+
+// Up 执行未应用的迁移直到 target 版本，target 为 0 表示最新版本
+func (m *Migrator) Up(target int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(migration, migration.Up, true); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚 steps 个已执行的迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			return done, fmt.Errorf("%w: %s", ErrNoDownScript, migration)
+		}
+		if err := m.apply(migration, migration.Down, false); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// apply 在事务中执行迁移脚本并更新 schema_migrations。
+// MySQL 的 DDL 语句会隐式提交，事务只能保证 schema_migrations 的记录与最后一条语句一致，
+// 失败时返回的错误会提示已执行的语句需要手动恢复
+func (m *Migrator) apply(migration Migration, script string, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+
+	if m.DryRun {
+		m.printf("-- %s (%s, dry-run)\n", migration, direction)
+		for _, stmt := range splitStatements(script) {
+			m.printf("%s;\n", stmt)
+		}
+		return nil
+	}
+
+	m.printf("执行迁移 %s (%s)\n", migration, direction)
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now(),
+			}).Error
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		if m.db.Dialector.Name() == "mysql" {
+			return fmt.Errorf("执行迁移 %s (%s) 失败，MySQL 的 DDL 语句会隐式提交，失败之前已执行的语句没有回滚，请手动恢复后重新执行: %w", migration, direction, err)
+		}
+		return fmt.Errorf("执行迁移 %s (%s) 失败: %w", migration, direction, err)
+	}
+	return nil
+}
+
+// applied 读取已执行的迁移记录
+func (m *Migrator) applied() (map[int]schemaMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var records []schemaMigration
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		// 仅在 dry-run 下可能尚未建表
+		return map[int]schemaMigration{}, nil
+	}
+	if err := m.db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+
+	applied := make(map[int]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// ensureTable 创建 schema_migrations 表
+func (m *Migrator) ensureTable() error {
+	if m.DryRun || m.db.Migrator().HasTable(&schemaMigration{}) {
+		return nil
+	}
+	if err := m.db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+		return fmt.Errorf("创建 schema_migrations 表失败: %w", err)
+	}
+	return nil
+}
+
+// verify 比对已执行迁移与嵌入脚本的校验和
+func (m *Migrator) verify(applied map[int]schemaMigration) error {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, record := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: %04d_%s", ErrUnknownMigration, version, record.Name)
+		}
+		if migration.Checksum != record.Checksum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, migration)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) printf(format string, args ...interface{}) {
+	if m.Out != nil {
+		fmt.Fprintf(m.Out, format, args...)
+	}
+}
+
+// checksum 计算脚本内容的 SHA-256
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// splitStatements 将脚本拆分为单条语句
+// MySQL 驱动默认不支持一次执行多条语句，因此逐条执行；脚本中不使用触发器等包含分号的语句
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
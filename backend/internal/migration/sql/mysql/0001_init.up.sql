@@ -0,0 +1,22 @@
+-- 用户表
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 任务表
+-- status: 0 待办, 1 进行中, 2 已完成
+CREATE TABLE IF NOT EXISTS tasks (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description VARCHAR(500) NULL,
+    status TINYINT NOT NULL DEFAULT 0,
+    due_date DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_tasks_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,24 @@
+-- 刷新令牌，只保存令牌的 SHA-256 哈希
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    family_id VARCHAR(32) NOT NULL,
+    access_token_id VARCHAR(32) NULL,
+    access_token_expires_at DATETIME(3) NULL,
+    expires_at DATETIME(3) NOT NULL,
+    revoked_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_refresh_tokens_token_hash (token_hash),
+    INDEX idx_refresh_tokens_user_id (user_id),
+    INDEX idx_refresh_tokens_family_id (family_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 已吊销的访问令牌
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id VARCHAR(32) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3) NULL,
+    INDEX idx_revoked_tokens_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,21 @@
+-- 任务优先级: 0 无, 1 低, 2 中, 3 高
+ALTER TABLE tasks ADD COLUMN priority TINYINT NOT NULL DEFAULT 0 AFTER status;
+
+-- 标签表，标签名在同一用户下唯一
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_tags_user_name (user_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 任务与标签关联表
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    PRIMARY KEY (task_id, tag_id),
+    INDEX idx_task_tags_tag_id (tag_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+-- 父任务ID，为空表示顶层任务
+ALTER TABLE tasks ADD COLUMN parent_id BIGINT NULL AFTER user_id;
+CREATE INDEX idx_tasks_parent_id ON tasks (parent_id);
@@ -0,0 +1,16 @@
+-- 项目表，用于将任务分组
+CREATE TABLE IF NOT EXISTS projects (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20) NULL,
+    archived TINYINT(1) NOT NULL DEFAULT 0,
+    position INT NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_projects_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 任务所属项目，为空表示未归入任何项目
+ALTER TABLE tasks ADD COLUMN project_id BIGINT NULL AFTER parent_id;
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
@@ -0,0 +1,10 @@
+-- 任务协作者，任务所有者不在此表中
+-- role: editor 可编辑, viewer 只读
+CREATE TABLE IF NOT EXISTS task_collaborators (
+    task_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (task_id, user_id),
+    INDEX idx_task_collaborators_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,7 @@
+DROP INDEX idx_tasks_workspace_id ON tasks;
+ALTER TABLE tasks DROP COLUMN workspace_id;
+DROP INDEX idx_projects_workspace_id ON projects;
+ALTER TABLE projects DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,38 @@
+-- 工作区，团队共享项目和任务
+CREATE TABLE IF NOT EXISTS workspaces (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(50) NOT NULL,
+    owner_id BIGINT NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 工作区成员，role: owner, admin, member, guest
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (workspace_id, user_id),
+    INDEX idx_workspace_members_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 工作区邀请，只保存邀请令牌的 SHA-256 哈希
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    workspace_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    invited_by BIGINT NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    accepted_by BIGINT NULL,
+    accepted_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_workspace_invitations_token_hash (token_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 项目和任务所属工作区，为空表示属于个人
+ALTER TABLE projects ADD COLUMN workspace_id BIGINT NULL AFTER user_id;
+CREATE INDEX idx_projects_workspace_id ON projects (workspace_id);
+ALTER TABLE tasks ADD COLUMN workspace_id BIGINT NULL AFTER user_id;
+CREATE INDEX idx_tasks_workspace_id ON tasks (workspace_id);
@@ -0,0 +1,9 @@
+-- 任务负责人，与任务所有者（创建者）相互独立，一个任务可以有多个负责人
+CREATE TABLE IF NOT EXISTS task_assignees (
+    task_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    assigned_by BIGINT NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (task_id, user_id),
+    INDEX idx_task_assignees_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
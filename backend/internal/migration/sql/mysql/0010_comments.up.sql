@@ -0,0 +1,12 @@
+-- 任务评论，删除时只记录 deleted_at
+CREATE TABLE IF NOT EXISTS comments (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    task_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    body TEXT NOT NULL,
+    edited_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    INDEX idx_comments_task_id (task_id, deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
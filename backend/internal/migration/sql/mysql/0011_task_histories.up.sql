@@ -0,0 +1,10 @@
+-- 任务变更历史，只追加不修改，字段变更以 JSON 保存
+CREATE TABLE IF NOT EXISTS task_histories (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    task_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes TEXT,
+    created_at DATETIME(3) NULL,
+    INDEX idx_task_histories_task_id (task_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
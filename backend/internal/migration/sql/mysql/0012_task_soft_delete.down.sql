@@ -0,0 +1,2 @@
+DROP INDEX idx_tasks_deleted_at ON tasks;
+ALTER TABLE tasks DROP COLUMN deleted_at;
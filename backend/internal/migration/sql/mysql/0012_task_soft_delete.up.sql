@@ -0,0 +1,3 @@
+-- 软删除，deleted_at 不为空的任务在回收站中，超过保留期限后永久删除
+ALTER TABLE tasks ADD COLUMN deleted_at DATETIME(3) NULL AFTER updated_at;
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at);
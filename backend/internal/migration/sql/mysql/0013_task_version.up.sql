@@ -0,0 +1,2 @@
+-- 乐观锁版本号，每次修改任务时加1，作为 ETag 返回
+ALTER TABLE tasks ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER recurrence;
@@ -0,0 +1,14 @@
+-- 幂等键记录，保存首次请求的指纹和响应，同一用户的幂等键唯一
+CREATE TABLE IF NOT EXISTS idempotency_records (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    headers TEXT NULL,
+    body MEDIUMTEXT NULL,
+    created_at DATETIME(3) NULL,
+    expires_at DATETIME(3) NOT NULL,
+    UNIQUE INDEX idx_idempotency_records_user_key (user_id, idempotency_key),
+    INDEX idx_idempotency_records_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
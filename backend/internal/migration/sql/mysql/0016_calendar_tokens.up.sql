@@ -0,0 +1,7 @@
+-- 日历订阅令牌，每个用户最多一个，只保存令牌的 SHA-256
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id BIGINT PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_calendar_tokens_token_hash (token_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,16 @@
+-- CalDAV 任务集合中的对象，记录客户端使用的资源名称和 UID 与任务的对应关系。
+-- etag 为最后一次同步时任务的 ETag，sync_seq 为对象最后一次变化时的同步序号；
+-- 任务删除或不再可见后保留 deleted 为 1 的记录，供增量同步返回删除
+CREATE TABLE IF NOT EXISTS caldav_objects (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    task_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    uid VARCHAR(255) NOT NULL,
+    etag VARCHAR(64) NOT NULL,
+    sync_seq BIGINT NOT NULL DEFAULT 0,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at DATETIME(3) NULL,
+    UNIQUE INDEX idx_caldav_objects_user_name (user_id, name),
+    UNIQUE INDEX idx_caldav_objects_user_task (user_id, task_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- 用户表
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+-- 任务表
+-- status: 0 待办, 1 进行中, 2 已完成
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description VARCHAR(500) NULL,
+    status TINYINT NOT NULL DEFAULT 0,
+    due_date DATETIME NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks (user_id);
@@ -0,0 +1,23 @@
+-- 任务优先级: 0 无, 1 低, 2 中, 3 高
+ALTER TABLE tasks ADD COLUMN priority TINYINT NOT NULL DEFAULT 0;
+
+-- 标签表，标签名在同一用户下唯一
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20) NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags (user_id, name);
+
+-- 任务与标签关联表
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags (tag_id);
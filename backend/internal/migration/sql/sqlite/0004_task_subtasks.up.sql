@@ -0,0 +1,3 @@
+-- 父任务ID，为空表示顶层任务
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks (parent_id);
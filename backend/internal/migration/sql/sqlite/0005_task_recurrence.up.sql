@@ -0,0 +1,2 @@
+-- 重复规则，RFC 5545 RRULE 格式，为空表示不重复
+ALTER TABLE tasks ADD COLUMN recurrence VARCHAR(255) NULL;
@@ -0,0 +1,17 @@
+-- 项目表，用于将任务分组
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(20) NULL,
+    archived BOOLEAN NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects (user_id);
+
+-- 任务所属项目，为空表示未归入任何项目
+ALTER TABLE tasks ADD COLUMN project_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks (project_id);
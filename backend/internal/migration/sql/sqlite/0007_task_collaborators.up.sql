@@ -0,0 +1,11 @@
+-- 任务协作者，任务所有者不在此表中
+-- role: editor 可编辑, viewer 只读
+CREATE TABLE IF NOT EXISTS task_collaborators (
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_collaborators_user_id ON task_collaborators (user_id);
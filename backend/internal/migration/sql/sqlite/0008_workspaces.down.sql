@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_tasks_workspace_id;
+ALTER TABLE tasks DROP COLUMN workspace_id;
+DROP INDEX IF EXISTS idx_projects_workspace_id;
+ALTER TABLE projects DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,40 @@
+-- 工作区，团队共享项目和任务
+CREATE TABLE IF NOT EXISTS workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL,
+    owner_id INTEGER NOT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+-- 工作区成员，role: owner, admin, member, guest
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members (user_id);
+
+-- 工作区邀请，只保存邀请令牌的 SHA-256 哈希
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    invited_by INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    accepted_by INTEGER NULL,
+    accepted_at DATETIME NULL,
+    created_at DATETIME NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_invitations_token_hash ON workspace_invitations (token_hash);
+
+-- 项目和任务所属工作区，为空表示属于个人
+ALTER TABLE projects ADD COLUMN workspace_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_projects_workspace_id ON projects (workspace_id);
+ALTER TABLE tasks ADD COLUMN workspace_id INTEGER NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_workspace_id ON tasks (workspace_id);
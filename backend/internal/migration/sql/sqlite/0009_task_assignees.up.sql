@@ -0,0 +1,10 @@
+-- 任务负责人，与任务所有者（创建者）相互独立，一个任务可以有多个负责人
+CREATE TABLE IF NOT EXISTS task_assignees (
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    assigned_by INTEGER NOT NULL,
+    created_at DATETIME NULL,
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_assignees_user_id ON task_assignees (user_id);
@@ -0,0 +1,13 @@
+-- 任务评论，删除时只记录 deleted_at
+CREATE TABLE IF NOT EXISTS comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    edited_at DATETIME NULL,
+    deleted_at DATETIME NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_task_id ON comments (task_id, deleted_at);
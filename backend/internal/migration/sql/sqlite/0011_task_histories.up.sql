@@ -0,0 +1,11 @@
+-- 任务变更历史，只追加不修改，字段变更以 JSON 保存
+CREATE TABLE IF NOT EXISTS task_histories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes TEXT,
+    created_at DATETIME NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_histories_task_id ON task_histories (task_id, created_at);
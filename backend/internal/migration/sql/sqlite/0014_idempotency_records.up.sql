@@ -0,0 +1,15 @@
+-- 幂等键记录，保存首次请求的指纹和响应，同一用户的幂等键唯一
+CREATE TABLE IF NOT EXISTS idempotency_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    headers TEXT NULL,
+    body TEXT NULL,
+    created_at DATETIME NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_records_user_key ON idempotency_records (user_id, idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_records_expires_at ON idempotency_records (expires_at);
@@ -0,0 +1,8 @@
+-- 日历订阅令牌，每个用户最多一个，只保存令牌的 SHA-256
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at DATETIME NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_tokens_token_hash ON calendar_tokens (token_hash);
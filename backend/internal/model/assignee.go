@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// MaxTaskAssignees 单个任务最多的负责人数
+const MaxTaskAssignees = 10
+
+// TaskAssignee 任务负责人，与任务所有者相互独立，负责人必须有权访问该任务
+type TaskAssignee struct {
+	TaskID     int       `json:"task_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID     int       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	AssignedBy int       `json:"assigned_by" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// MaxCalDAVNameLength CalDAV 资源名称和 UID 的最大长度
+const MaxCalDAVNameLength = 255
+
+// CalDAVObject CalDAV 任务集合中的对象。
+// 客户端创建的对象保留客户端选择的资源名称和 UID，其他任务使用 task-{id}.ics；
+// 任务删除或不再可见时标记为 Deleted，保留记录以便增量同步返回删除
+type CalDAVObject struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"not null"`
+	TaskID    int       `json:"task_id" gorm:"not null"`
+	Name      string    `json:"name" gorm:"size:255;not null"`
+	UID       string    `json:"uid" gorm:"column:uid;size:255;not null"`
+	ETag      string    `json:"etag" gorm:"column:etag;size:64;not null"`
+	SyncSeq   int64     `json:"sync_seq" gorm:"not null"`
+	Deleted   bool      `json:"deleted" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CalDAVObject) TableName() string {
+	return "caldav_objects"
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// CalendarToken 日历订阅令牌，日历应用通过带令牌的地址订阅任务，无需登录。
+// 每个用户最多一个，重新生成时替换旧令牌；数据库中只保存令牌的哈希值
+type CalendarToken struct {
+	UserID    int       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	TokenHash string    `json:"-" gorm:"size:64;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// 用户对任务的角色
+const (
+	TaskRoleOwner  = "owner"  // 任务所有者，可以管理协作者和删除任务
+	TaskRoleEditor = "editor" // 可编辑任务内容
+	TaskRoleViewer = "viewer" // 只能查看
+)
+
+// TaskCollaborator 任务协作者，任务共享给协作者后其子任务一并共享
+// 任务所有者由 Task.UserID 决定，不保存在协作者表中
+type TaskCollaborator struct {
+	TaskID    int       `json:"task_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    int       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Username  string    `json:"username" gorm:"-"`
+	Role      string    `json:"role" gorm:"size:20;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CanEdit 角色是否可以编辑任务
+func CanEdit(role string) bool {
+	return role == TaskRoleOwner || role == TaskRoleEditor
+}
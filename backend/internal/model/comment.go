@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// MaxCommentLength 评论内容的最大字符数
+const MaxCommentLength = 2000
+
+// Comment 任务评论，删除时只标记 DeletedAt，表结构见 internal/migration/sql
+type Comment struct {
+	ID       int    `json:"id" gorm:"primaryKey"`
+	TaskID   int    `json:"task_id" gorm:"not null"`
+	UserID   int    `json:"user_id" gorm:"not null"`
+	Username string `json:"username" gorm:"-"`
+	Body     string `json:"body" gorm:"type:text;not null"`
+	// EditedAt 最后一次编辑内容的时间，未编辑过为空
+	EditedAt *time.Time `json:"edited_at" gorm:"default:null"`
+	// DeletedAt 删除时间，已删除的评论不再返回
+	DeletedAt *time.Time `json:"-" gorm:"default:null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
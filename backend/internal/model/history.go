@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// 任务历史操作类型
+const (
+	TaskHistoryActionCreate  = "create"
+	TaskHistoryActionUpdate  = "update"
+	TaskHistoryActionDelete  = "delete"
+	TaskHistoryActionRestore = "restore"
+)
+
+// TaskHistory 任务变更历史，创建后不再修改，任务删除后仍然保留，表结构见 internal/migration/sql
+type TaskHistory struct {
+	ID       int    `json:"id" gorm:"primaryKey"`
+	TaskID   int    `json:"task_id" gorm:"not null"`
+	UserID   int    `json:"user_id" gorm:"not null"`
+	Username string `json:"username" gorm:"-"`
+	Action   string `json:"action" gorm:"size:20;not null"`
+	// Changes 字段级变更，创建时只有 After，删除时只有 Before
+	Changes   []FieldChange `json:"changes" gorm:"type:text;serializer:json"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// FieldChange 单个字段的变更前后值，值为空时表示该字段没有值
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
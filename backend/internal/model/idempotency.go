@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// IdempotencyRecord 幂等键记录，保存首次请求的指纹和响应，相同幂等键的重试直接返回保存的响应
+type IdempotencyRecord struct {
+	ID          int               `json:"id" gorm:"primaryKey"`
+	UserID      int               `json:"user_id" gorm:"not null"` // 未登录的请求为0
+	Key         string            `json:"key" gorm:"column:idempotency_key;size:255;not null"`
+	Fingerprint string            `json:"-" gorm:"size:64;not null"`             // 请求方法、地址和请求体的 SHA-256
+	StatusCode  int               `json:"status_code" gorm:"not null;default:0"` // 为0表示首次请求仍在处理中
+	Headers     map[string]string `json:"-" gorm:"type:text;serializer:json"`    // 需要重放的响应头
+	Body        string            `json:"-" gorm:"type:text"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at" gorm:"not null"`
+}
+
+// MaxIdempotencyKeyLength 幂等键的最大长度
+const MaxIdempotencyKeyLength = 255
+
+// IsCompleted 判断首次请求是否已经完成
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
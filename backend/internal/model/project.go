@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Project 项目模型，用于将任务分组，项目属于用户或工作区
+type Project struct {
+	ID       int    `json:"id" gorm:"primaryKey"`
+	UserID   int    `json:"user_id" gorm:"not null"`
+	Name     string `json:"name" gorm:"size:50;not null"`
+	Color    string `json:"color" gorm:"size:20"`
+	Archived bool   `json:"archived" gorm:"not null;default:false"`
+	// WorkspaceID 所属工作区，为空表示个人项目
+	WorkspaceID *int `json:"workspace_id,omitempty" gorm:"default:null"`
+	// Position 在项目列表中的排序，越小越靠前
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Tag 标签模型，标签属于用户，通过 task_tags 与任务多对多关联
+type Tag struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string    `json:"name" gorm:"size:50;not null;uniqueIndex:idx_tags_user_name"`
+	Color     string    `json:"color" gorm:"size:20"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -2,31 +2,48 @@ package model
 
 import "time"
 
-/*
-CREATE TABLE tasks (
-
-	id BIGINT PRIMARY KEY AUTO_INCREMENT,
-	user_id BIGINT NOT NULL,
-	title VARCHAR(100) NOT NULL,
-	description TEXT,
-	status TINYINT DEFAULT 0, -- 0: 未完成, 1: 已完成
-	due_date TIMESTAMP,
-	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-
-);
-*/
-
-// Task 任务模型
+// Task 任务模型，表结构见 internal/migration/sql
 type Task struct {
 	ID          int        `json:"id" gorm:"primaryKey"`
 	UserID      int        `json:"user_id" gorm:"not null"`
+	WorkspaceID *int       `json:"workspace_id,omitempty" gorm:"default:null"`
+	ParentID    *int       `json:"parent_id" gorm:"default:null"`
+	ProjectID   *int       `json:"project_id" gorm:"default:null"`
 	Title       string     `json:"title" gorm:"size:100;not null"`
 	Description string     `json:"description" gorm:"size:500"`
 	Status      int        `json:"status" gorm:"type:tinyint;not null;default:0"`
+	Priority    int        `json:"priority" gorm:"type:tinyint;not null;default:0"`
 	DueDate     *time.Time `json:"due_date,omitempty" gorm:"default:null"`
+	Recurrence  *string    `json:"recurrence,omitempty" gorm:"size:255;default:null"`
+	Version     int        `json:"version" gorm:"not null;default:1"` // 乐观锁版本号，每次修改任务时加1
+	Tags        []Tag      `json:"tags" gorm:"many2many:task_tags"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"default:null"` // 移入回收站的时间，为空表示未删除；仓库查询默认不返回已删除的任务
+
+	// Subtasks 子任务完成情况，没有子任务时为空，不落库
+	Subtasks *SubtaskProgress `json:"subtasks,omitempty" gorm:"-"`
+	// Role 当前用户对任务的角色，见 TaskRoleOwner 等，不落库
+	Role string `json:"role,omitempty" gorm:"-"`
+	// Assignees 任务负责人的用户ID，按指派顺序排列，不落库
+	Assignees []int `json:"assignees,omitempty" gorm:"-"`
+	// NextOccurrence 完成重复任务时生成的下一次任务，只在本次更新的返回值中出现
+	NextOccurrence *Task `json:"-" gorm:"-"`
+}
+
+// MaxTaskDepth 任务最大层级，顶层任务为第1层
+const MaxTaskDepth = 3
+
+// SubtaskProgress 直接子任务的完成情况
+type SubtaskProgress struct {
+	Total int `json:"total"`
+	Done  int `json:"done"`
+}
+
+// TaskVersion 任务的ID和版本号，用于不加载任务内容时判断任务是否变化
+type TaskVersion struct {
+	ID      int
+	Version int
 }
 
 // 任务状态常量
@@ -63,3 +80,50 @@ func (t *Task) SetStatusFromText(status string) {
 		t.Status = TaskStatusTodo
 	}
 }
+
+// 任务优先级常量
+const (
+	TaskPriorityNone   = 0 // 无
+	TaskPriorityLow    = 1 // 低
+	TaskPriorityMedium = 2 // 中
+	TaskPriorityHigh   = 3 // 高
+)
+
+// GetPriorityText 获取优先级文本
+func (t *Task) GetPriorityText() string {
+	switch t.Priority {
+	case TaskPriorityNone:
+		return "none"
+	case TaskPriorityLow:
+		return "low"
+	case TaskPriorityMedium:
+		return "medium"
+	case TaskPriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// SetPriorityFromText 从文本设置优先级
+func (t *Task) SetPriorityFromText(priority string) {
+	switch priority {
+	case "low":
+		t.Priority = TaskPriorityLow
+	case "medium":
+		t.Priority = TaskPriorityMedium
+	case "high":
+		t.Priority = TaskPriorityHigh
+	default:
+		t.Priority = TaskPriorityNone
+	}
+}
+
+// TagIDs 返回任务关联的标签ID
+func (t *Task) TagIDs() []int {
+	ids := make([]int, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		ids = append(ids, tag.ID)
+	}
+	return ids
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTaskStatus   = errors.New("无效的任务状态")
+	ErrInvalidTaskPriority = errors.New("无效的任务优先级")
+	ErrInvalidSortField    = errors.New("不支持的排序字段")
+)
+
+// 可排序的任务字段
+const (
+	TaskSortID        = "id"
+	TaskSortTitle     = "title"
+	TaskSortStatus    = "status"
+	TaskSortPriority  = "priority"
+	TaskSortDueDate   = "due_date"
+	TaskSortCreatedAt = "created_at"
+	TaskSortUpdatedAt = "updated_at"
+	// TaskSortDeletedAt 回收站按删除时间排序，不接受作为查询参数
+	TaskSortDeletedAt = "deleted_at"
+)
+
+// TaskSort 排序条件
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+// TaskFilter 任务列表查询条件，零值字段表示不过滤
+type TaskFilter struct {
+	UserID     int
+	Statuses   []int
+	Priorities []int
+	TagID      int
+	// WorkspaceID 只返回该工作区的任务，为0时返回 UserID 的个人任务
+	WorkspaceID int
+	// SharedTaskIDs 共享给该用户的任务，与用户的个人任务一并返回，指定工作区时忽略
+	SharedTaskIDs []int
+	// AssigneeID 只返回指派给该用户的任务
+	AssigneeID int
+	// AssignedTaskIDs 指派给 AssigneeID 的全部任务，由服务层填充
+	AssignedTaskIDs []int
+	// AllScopes 为 true 时不限定个人空间或工作区，返回 AssignedTaskIDs 中的全部任务，用于"指派给我"视图
+	AllScopes bool
+	// IDs 只返回这些任务，为空时不限定
+	IDs []int
+	// ParentID 只返回该任务的直接子任务，指向0时只返回顶层任务
+	ParentID *int
+	// ProjectID 只返回该项目的任务，指向0时只返回未归入项目的任务
+	ProjectID *int
+	// DueAfter 截止日期不早于该时间（含）
+	DueAfter *time.Time
+	// DueBefore 截止日期早于该时间（不含）
+	DueBefore *time.Time
+	// Overdue 只返回已过截止日期且未完成的任务
+	Overdue bool
+	// Keyword 在标题和描述中搜索，不区分大小写
+	Keyword string
+	// Deleted 为 true 时只返回回收站中的任务，否则只返回未删除的任务
+	Deleted bool
+	// Sort 排序条件，为空时按ID升序；相同值之间总是再按ID升序
+	Sort     []TaskSort
+	Page     int
+	PageSize int
+}
+
+// ParseTaskStatus 将状态文本转换为状态值
+func ParseTaskStatus(text string) (int, error) {
+	switch text {
+	case "todo":
+		return TaskStatusTodo, nil
+	case "in_progress":
+		return TaskStatusInProgress, nil
+	case "done":
+		return TaskStatusDone, nil
+	default:
+		return 0, ErrInvalidTaskStatus
+	}
+}
+
+// ParseTaskPriority 将优先级文本转换为优先级值
+func ParseTaskPriority(text string) (int, error) {
+	switch text {
+	case "none":
+		return TaskPriorityNone, nil
+	case "low":
+		return TaskPriorityLow, nil
+	case "medium":
+		return TaskPriorityMedium, nil
+	case "high":
+		return TaskPriorityHigh, nil
+	default:
+		return 0, ErrInvalidTaskPriority
+	}
+}
+
+// ParseTaskSort 解析排序参数，如 "due_date,-created_at"，字段前加 "-" 表示降序
+func ParseTaskSort(text string) ([]TaskSort, error) {
+	var sorts []TaskSort
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		sort := TaskSort{Field: part}
+		if strings.HasPrefix(part, "-") {
+			sort = TaskSort{Field: part[1:], Desc: true}
+		}
+
+		switch sort.Field {
+		case TaskSortID, TaskSortTitle, TaskSortStatus, TaskSortPriority,
+			TaskSortDueDate, TaskSortCreatedAt, TaskSortUpdatedAt:
+		default:
+			return nil, ErrInvalidSortField
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
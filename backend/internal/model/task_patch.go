@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Optional 部分更新中的字段，Set 为 false 表示未提供，保留原值
+type Optional[T any] struct {
+	Set   bool
+	Value T
+}
+
+// Some 返回已提供值的字段
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Set: true, Value: value}
+}
+
+// TaskPatch 任务的部分更新，只修改 Set 为 true 的字段。
+// 可以清空的字段使用指针类型，提供 nil 表示清空
+type TaskPatch struct {
+	Title       Optional[string]
+	Description Optional[string] // 空字符串表示清空描述
+	Status      Optional[int]
+	Priority    Optional[int]
+	DueDate     Optional[*time.Time] // nil 表示清除截止日期
+	Recurrence  Optional[*string]    // nil 或空字符串表示取消重复
+	ParentID    Optional[*int]       // nil 或0表示移动到顶层
+	ProjectID   Optional[*int]       // nil 或0表示移出项目，子任务随之移动
+	TagIDs      Optional[[]int]      // 空列表表示清空标签
+	// Version 客户端读取到的版本号，不为0时必须与当前版本一致
+	Version int
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// RefreshToken 刷新令牌，数据库中只保存令牌的哈希值
+// 同一次登录轮换产生的令牌属于同一个 FamilyID，检测到重放时整族吊销
+type RefreshToken struct {
+	ID                   int        `json:"id" gorm:"primaryKey"`
+	UserID               int        `json:"user_id" gorm:"not null;index"`
+	TokenHash            string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	FamilyID             string     `json:"family_id" gorm:"size:32;not null;index"`
+	AccessTokenID        string     `json:"-" gorm:"size:32"` // 与该刷新令牌一同签发的访问令牌
+	AccessTokenExpiresAt time.Time  `json:"-"`                // 访问令牌过期时间，吊销记录保留到此时
+	ExpiresAt            time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt            *time.Time `json:"revoked_at,omitempty"` // 已轮换或已吊销
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// IsExpired 判断刷新令牌是否过期
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// RevokedToken 已吊销的访问令牌，过期后可以清理
+type RevokedToken struct {
+	TokenID   string    `json:"token_id" gorm:"primaryKey;size:32"`
+	UserID    int       `json:"user_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
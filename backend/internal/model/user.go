@@ -2,16 +2,7 @@ package model
 
 import "time"
 
-/*
-CREATE TABLE users (
-    id BIGINT PRIMARY KEY AUTO_INCREMENT,
-    username VARCHAR(50) UNIQUE NOT NULL,
-    password_hash VARCHAR(255) NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-);
-*/
-
+// User 用户模型，表结构见 internal/migration/sql
 type User struct {
 	ID           int       `json:"id" gorm:"primaryKey;autoIncrement" validate:"-"`                                   // 自增主键，无需验证
 	Username     string    `json:"username" gorm:"type:varchar(50);unique;not null" validate:"required,min=3,max=50"` // 用户名必填，3-50字符
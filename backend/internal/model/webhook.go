@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Webhook 用户注册的 Webhook，可以看到的任务发生变更时向 URL 投递签名的 JSON 请求，表结构见 internal/migration/sql
+type Webhook struct {
+	ID     int    `json:"id" gorm:"primaryKey"`
+	UserID int    `json:"user_id" gorm:"not null"`
+	URL    string `json:"url" gorm:"size:2048;not null"`
+	// Secret 计算请求签名的密钥，只在创建时返回
+	Secret string `json:"-" gorm:"size:255;not null"`
+	// Events 订阅的事件类型
+	Events    []string  `json:"events" gorm:"type:text;serializer:json;not null"`
+	Active    bool      `json:"active" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaxWebhookURLLength Webhook URL 的最大长度
+const MaxWebhookURLLength = 2048
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待投递或重试
+	WebhookDeliverySucceeded = "succeeded" // 接收方返回 2xx
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽
+)
+
+// WebhookDelivery Webhook 投递记录，重试时发送相同的请求体
+type WebhookDelivery struct {
+	ID        int    `json:"id" gorm:"primaryKey"`
+	WebhookID int    `json:"webhook_id" gorm:"not null"`
+	EventID   int64  `json:"event_id" gorm:"not null"`
+	EventType string `json:"event_type" gorm:"size:50;not null"`
+	Payload   string `json:"payload" gorm:"type:text;not null"`
+	Status    string `json:"status" gorm:"size:20;not null"`
+	Attempts  int    `json:"attempts" gorm:"not null;default:0"`
+	// ResponseStatus 最后一次投递的响应状态码，请求未完成时为0
+	ResponseStatus int    `json:"response_status" gorm:"not null;default:0"`
+	LastError      string `json:"last_error" gorm:"type:text"`
+	// NextAttemptAt 下一次投递的时间，投递成功或失败后为空
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"default:null"`
+	DeliveredAt   *time.Time `json:"delivered_at" gorm:"default:null"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// 工作区成员角色
+const (
+	WorkspaceRoleOwner  = "owner"  // 创建者，拥有全部权限
+	WorkspaceRoleAdmin  = "admin"  // 管理成员和项目，可以修改和删除任何任务
+	WorkspaceRoleMember = "member" // 创建任务，编辑工作区内的任务
+	WorkspaceRoleGuest  = "guest"  // 只能查看
+)
+
+// Workspace 工作区，成员共享工作区内的项目和任务
+type Workspace struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:50;not null"`
+	OwnerID   int       `json:"owner_id" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Role 当前用户在工作区中的角色，不落库
+	Role string `json:"role,omitempty" gorm:"-"`
+}
+
+// WorkspaceMember 工作区成员
+type WorkspaceMember struct {
+	WorkspaceID int       `json:"workspace_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID      int       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Username    string    `json:"username" gorm:"-"`
+	Role        string    `json:"role" gorm:"size:20;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceInvitation 工作区邀请，凭邀请令牌加入工作区，令牌只能使用一次
+type WorkspaceInvitation struct {
+	ID          int        `json:"id" gorm:"primaryKey"`
+	WorkspaceID int        `json:"workspace_id" gorm:"not null"`
+	TokenHash   string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Role        string     `json:"role" gorm:"size:20;not null"`
+	InvitedBy   int        `json:"invited_by" gorm:"not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedBy  *int       `json:"accepted_by,omitempty" gorm:"default:null"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty" gorm:"default:null"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Token 邀请令牌明文，只在创建时返回一次，不落库
+	Token string `json:"token,omitempty" gorm:"-"`
+}
+
+// WorkspaceRoleRank 角色的权限等级，越大权限越高，无效角色为0
+func WorkspaceRoleRank(role string) int {
+	switch role {
+	case WorkspaceRoleOwner:
+		return 4
+	case WorkspaceRoleAdmin:
+		return 3
+	case WorkspaceRoleMember:
+		return 2
+	case WorkspaceRoleGuest:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"todolist/internal/model"
+)
+
+// AssigneeRepository 任务负责人仓库接口
+type AssigneeRepository interface {
+	// Add 添加负责人，已是负责人时不做修改
+	Add(assignee *model.TaskAssignee) error
+	// Replace 将任务的负责人替换为 assignees，保留仍在列表中的负责人的指派记录
+	Replace(taskID int, assignees []*model.TaskAssignee) error
+	// Delete 移除负责人
+	Delete(taskID, userID int) error
+	// DeleteByTaskIDs 移除多个任务的全部负责人
+	DeleteByTaskIDs(taskIDs []int) error
+	// GetByTaskIDs 获取多个任务的负责人，按任务ID和指派顺序排序
+	GetByTaskIDs(taskIDs []int) ([]*model.TaskAssignee, error)
+	// GetByUserID 获取指派给用户的全部记录，按任务ID排序
+	GetByUserID(userID int) ([]*model.TaskAssignee, error)
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) AssigneeRepository
+}
+
+// assigneeRepository 任务负责人仓库实现
+type assigneeRepository struct {
+	db *gorm.DB
+}
+
+// NewAssigneeRepository 创建任务负责人仓库实例
+func NewAssigneeRepository(db *gorm.DB) AssigneeRepository {
+	return &assigneeRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *assigneeRepository) WithTx(tx Tx) AssigneeRepository {
+	return &assigneeRepository{db: tx.bind(r.db)}
+}
+
+// Add 添加负责人
+func (r *assigneeRepository) Add(assignee *model.TaskAssignee) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(assignee).Error
+}
+
+// Replace 替换任务的负责人
+func (r *assigneeRepository) Replace(taskID int, assignees []*model.TaskAssignee) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		keep := make([]int, 0, len(assignees))
+		for _, assignee := range assignees {
+			keep = append(keep, assignee.UserID)
+		}
+		remove := tx.Where("task_id = ?", taskID)
+		if len(keep) > 0 {
+			remove = remove.Where("user_id NOT IN ?", keep)
+		}
+		if err := remove.Delete(&model.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		if len(assignees) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(assignees).Error
+	})
+}
+
+// Delete 移除负责人
+func (r *assigneeRepository) Delete(taskID, userID int) error {
+	return r.db.Where("task_id = ? AND user_id = ?", taskID, userID).Delete(&model.TaskAssignee{}).Error
+}
+
+// DeleteByTaskIDs 移除多个任务的全部负责人
+func (r *assigneeRepository) DeleteByTaskIDs(taskIDs []int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("task_id IN ?", taskIDs).Delete(&model.TaskAssignee{}).Error
+}
+
+// GetByTaskIDs 获取多个任务的负责人
+func (r *assigneeRepository) GetByTaskIDs(taskIDs []int) ([]*model.TaskAssignee, error) {
+	var assignees []*model.TaskAssignee
+	if len(taskIDs) == 0 {
+		return assignees, nil
+	}
+	err := r.db.Where("task_id IN ?", taskIDs).Order("task_id").Order("created_at").Order("user_id").Find(&assignees).Error
+	return assignees, err
+}
+
+// GetByUserID 获取指派给用户的全部记录
+func (r *assigneeRepository) GetByUserID(userID int) ([]*model.TaskAssignee, error) {
+	var assignees []*model.TaskAssignee
+	err := r.db.Where("user_id = ?", userID).Order("task_id").Find(&assignees).Error
+	return assignees, err
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// assigneeKey 负责人记录的主键
+type assigneeKey struct {
+	taskID int
+	userID int
+}
+
+// memoryAssigneeRepository 基于内存的任务负责人仓库实现，主要用于测试
+type memoryAssigneeRepository struct {
+	mu        sync.RWMutex
+	assignees map[assigneeKey]*model.TaskAssignee
+}
+
+// NewMemoryAssigneeRepository 创建内存任务负责人仓库实例
+func NewMemoryAssigneeRepository() AssigneeRepository {
+	return &memoryAssigneeRepository{
+		assignees: make(map[assigneeKey]*model.TaskAssignee),
+	}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryAssigneeRepository) WithTx(tx Tx) AssigneeRepository {
+	return r
+}
+
+// Add 添加负责人
+func (r *memoryAssigneeRepository) Add(assignee *model.TaskAssignee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.add(assignee)
+	return nil
+}
+
+// add 添加负责人，调用方需持有写锁
+func (r *memoryAssigneeRepository) add(assignee *model.TaskAssignee) {
+	key := assigneeKey{taskID: assignee.TaskID, userID: assignee.UserID}
+	if _, ok := r.assignees[key]; ok {
+		return
+	}
+	if assignee.CreatedAt.IsZero() {
+		assignee.CreatedAt = time.Now()
+	}
+	clone := *assignee
+	r.assignees[key] = &clone
+}
+
+// Replace 替换任务的负责人
+func (r *memoryAssigneeRepository) Replace(taskID int, assignees []*model.TaskAssignee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keep := make([]int, 0, len(assignees))
+	for _, assignee := range assignees {
+		keep = append(keep, assignee.UserID)
+	}
+	for key := range r.assignees {
+		if key.taskID == taskID && !slices.Contains(keep, key.userID) {
+			delete(r.assignees, key)
+		}
+	}
+	for _, assignee := range assignees {
+		r.add(assignee)
+	}
+	return nil
+}
+
+// Delete 移除负责人
+func (r *memoryAssigneeRepository) Delete(taskID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.assignees, assigneeKey{taskID: taskID, userID: userID})
+	return nil
+}
+
+// DeleteByTaskIDs 移除多个任务的全部负责人
+func (r *memoryAssigneeRepository) DeleteByTaskIDs(taskIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.assignees {
+		if slices.Contains(taskIDs, key.taskID) {
+			delete(r.assignees, key)
+		}
+	}
+	return nil
+}
+
+// GetByTaskIDs 获取多个任务的负责人
+func (r *memoryAssigneeRepository) GetByTaskIDs(taskIDs []int) ([]*model.TaskAssignee, error) {
+	return r.find(func(a *model.TaskAssignee) bool { return slices.Contains(taskIDs, a.TaskID) }, func(a, b *model.TaskAssignee) bool {
+		if a.TaskID != b.TaskID {
+			return a.TaskID < b.TaskID
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.UserID < b.UserID
+	})
+}
+
+// GetByUserID 获取指派给用户的全部记录
+func (r *memoryAssigneeRepository) GetByUserID(userID int) ([]*model.TaskAssignee, error) {
+	return r.find(func(a *model.TaskAssignee) bool { return a.UserID == userID }, func(a, b *model.TaskAssignee) bool {
+		return a.TaskID < b.TaskID
+	})
+}
+
+// find 按条件查找负责人记录并排序
+func (r *memoryAssigneeRepository) find(match func(*model.TaskAssignee) bool, less func(a, b *model.TaskAssignee) bool) ([]*model.TaskAssignee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	assignees := []*model.TaskAssignee{}
+	for _, assignee := range r.assignees {
+		if match(assignee) {
+			clone := *assignee
+			assignees = append(assignees, &clone)
+		}
+	}
+	sort.Slice(assignees, func(i, j int) bool {
+		return less(assignees[i], assignees[j])
+	})
+	return assignees, nil
+}
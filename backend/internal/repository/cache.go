@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"todolist/internal/cache"
+)
+
+// 缓存装饰器按旁路缓存的方式工作：按ID读取时先查缓存，未命中时读取数据库并写入缓存；
+// 修改后删除对应的缓存。缓存不可用时直接读写数据库，不影响请求的结果
+
+// cachedGet 从缓存读取 key，未命中时调用 load 并写入缓存，load 返回 nil 时不缓存
+func cachedGet[T any](c cache.Cache, stats *cache.Stats, ttl time.Duration, key string, load func() (*T, error)) (*T, error) {
+	data, ok, err := c.Get(key)
+	if err != nil {
+		log.Printf("读取缓存失败: %v", err)
+	}
+	if ok {
+		var value T
+		if err := json.Unmarshal([]byte(data), &value); err == nil {
+			stats.Hit()
+			return &value, nil
+		}
+		log.Printf("缓存 %s 格式错误: %v", key, err)
+	}
+	stats.Miss()
+
+	value, err := load()
+	if err != nil || value == nil {
+		return value, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("编码缓存失败: %v", err)
+		return value, nil
+	}
+	if err := c.Set(key, string(encoded), ttl); err != nil {
+		log.Printf("写入缓存失败: %v", err)
+	}
+	return value, nil
+}
+
+// invalidate 删除缓存，失败时只记录日志，缓存在过期后失效
+func invalidate(c cache.Cache, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := c.Delete(keys...); err != nil {
+		log.Printf("删除缓存失败: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"todolist/internal/model"
+)
+
+// CalDAVRepository CalDAV 对象仓库接口
+type CalDAVRepository interface {
+	// ListByUserID 获取用户的全部对象，包括已删除的，按ID排序
+	ListByUserID(userID int) ([]*model.CalDAVObject, error)
+	// GetByName 根据资源名称获取对象，包括已删除的，不存在时返回 nil
+	GetByName(userID int, name string) (*model.CalDAVObject, error)
+	// Save 在同一事务中保存对象，ID 为0的对象新建，其他对象整体更新
+	Save(objects ...*model.CalDAVObject) error
+}
+
+// calDAVRepository CalDAV 对象仓库实现
+type calDAVRepository struct {
+	db *gorm.DB
+}
+
+// NewCalDAVRepository 创建 CalDAV 对象仓库实例
+func NewCalDAVRepository(db *gorm.DB) CalDAVRepository {
+	return &calDAVRepository{db: db}
+}
+
+// ListByUserID 获取用户的全部对象
+func (r *calDAVRepository) ListByUserID(userID int) ([]*model.CalDAVObject, error) {
+	var objects []*model.CalDAVObject
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&objects).Error
+	return objects, err
+}
+
+// GetByName 根据资源名称获取对象
+func (r *calDAVRepository) GetByName(userID int, name string) (*model.CalDAVObject, error) {
+	var object model.CalDAVObject
+	if err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&object).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &object, nil
+}
+
+// Save 在同一事务中保存对象
+func (r *calDAVRepository) Save(objects ...*model.CalDAVObject) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, object := range objects {
+			if err := tx.Save(object).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
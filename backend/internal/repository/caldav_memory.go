@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// ErrDuplicateCalDAVObject 资源名称或任务已有对象，对应数据库中的唯一索引冲突
+var ErrDuplicateCalDAVObject = errors.New("CalDAV 对象已存在")
+
+// memoryCalDAVRepository 基于内存的 CalDAV 对象仓库实现，主要用于测试
+type memoryCalDAVRepository struct {
+	mu      sync.RWMutex
+	objects map[int]*model.CalDAVObject
+	nextID  int
+}
+
+// NewMemoryCalDAVRepository 创建内存 CalDAV 对象仓库实例
+func NewMemoryCalDAVRepository() CalDAVRepository {
+	return &memoryCalDAVRepository{
+		objects: make(map[int]*model.CalDAVObject),
+		nextID:  1,
+	}
+}
+
+// ListByUserID 获取用户的全部对象
+func (r *memoryCalDAVRepository) ListByUserID(userID int) ([]*model.CalDAVObject, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var objects []*model.CalDAVObject
+	for _, object := range r.objects {
+		if object.UserID == userID {
+			clone := *object
+			objects = append(objects, &clone)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].ID < objects[j].ID })
+	return objects, nil
+}
+
+// GetByName 根据资源名称获取对象
+func (r *memoryCalDAVRepository) GetByName(userID int, name string) (*model.CalDAVObject, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, object := range r.objects {
+		if object.UserID == userID && object.Name == name {
+			clone := *object
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// Save 保存对象，任一对象冲突时都不保存
+func (r *memoryCalDAVRepository) Save(objects ...*model.CalDAVObject) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 先在副本上检查唯一约束
+	pending := make(map[int]*model.CalDAVObject, len(r.objects)+len(objects))
+	for id, object := range r.objects {
+		pending[id] = object
+	}
+	nextID := r.nextID
+	ids := make([]int, len(objects))
+	for i, object := range objects {
+		ids[i] = object.ID
+		if ids[i] == 0 {
+			ids[i] = nextID
+			nextID++
+		}
+		for id, other := range pending {
+			if id != ids[i] && other.UserID == object.UserID && (other.Name == object.Name || other.TaskID == object.TaskID) {
+				return ErrDuplicateCalDAVObject
+			}
+		}
+		clone := *object
+		clone.ID = ids[i]
+		pending[ids[i]] = &clone
+	}
+
+	now := time.Now()
+	for i, object := range objects {
+		object.ID = ids[i]
+		object.UpdatedAt = now
+		pending[object.ID].UpdatedAt = now
+	}
+	r.objects = pending
+	r.nextID = nextID
+	return nil
+}
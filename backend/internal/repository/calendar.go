@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"todolist/internal/model"
+)
+
+// CalendarTokenRepository 日历订阅令牌仓库接口
+type CalendarTokenRepository interface {
+	// Save 保存用户的令牌，已有令牌时替换
+	Save(token *model.CalendarToken) error
+	// GetByUserID 获取用户的令牌，没有令牌时返回 nil
+	GetByUserID(userID int) (*model.CalendarToken, error)
+	// GetByHash 根据令牌哈希获取令牌，不存在时返回 nil
+	GetByHash(tokenHash string) (*model.CalendarToken, error)
+	// Delete 删除用户的令牌
+	Delete(userID int) error
+}
+
+// calendarTokenRepository 日历订阅令牌仓库实现
+type calendarTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewCalendarTokenRepository 创建日历订阅令牌仓库实例
+func NewCalendarTokenRepository(db *gorm.DB) CalendarTokenRepository {
+	return &calendarTokenRepository{db: db}
+}
+
+// Save 保存用户的令牌
+func (r *calendarTokenRepository) Save(token *model.CalendarToken) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "created_at"}),
+	}).Create(token).Error
+}
+
+// GetByUserID 获取用户的令牌
+func (r *calendarTokenRepository) GetByUserID(userID int) (*model.CalendarToken, error) {
+	return r.first("user_id = ?", userID)
+}
+
+// GetByHash 根据令牌哈希获取令牌
+func (r *calendarTokenRepository) GetByHash(tokenHash string) (*model.CalendarToken, error) {
+	return r.first("token_hash = ?", tokenHash)
+}
+
+// first 获取第一个满足条件的令牌
+func (r *calendarTokenRepository) first(query string, args ...any) (*model.CalendarToken, error) {
+	var token model.CalendarToken
+	if err := r.db.Where(query, args...).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Delete 删除用户的令牌
+func (r *calendarTokenRepository) Delete(userID int) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.CalendarToken{}).Error
+}
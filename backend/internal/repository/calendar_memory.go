@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// memoryCalendarTokenRepository 基于内存的日历订阅令牌仓库实现，主要用于测试
+type memoryCalendarTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[int]*model.CalendarToken
+}
+
+// NewMemoryCalendarTokenRepository 创建内存日历订阅令牌仓库实例
+func NewMemoryCalendarTokenRepository() CalendarTokenRepository {
+	return &memoryCalendarTokenRepository{tokens: make(map[int]*model.CalendarToken)}
+}
+
+// Save 保存用户的令牌
+func (r *memoryCalendarTokenRepository) Save(token *model.CalendarToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, existing := range r.tokens {
+		if userID != token.UserID && existing.TokenHash == token.TokenHash {
+			return ErrDuplicateToken
+		}
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	clone := *token
+	r.tokens[token.UserID] = &clone
+	return nil
+}
+
+// GetByUserID 获取用户的令牌
+func (r *memoryCalendarTokenRepository) GetByUserID(userID int) (*model.CalendarToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[userID]
+	if !ok {
+		return nil, nil
+	}
+	clone := *token
+	return &clone, nil
+}
+
+// GetByHash 根据令牌哈希获取令牌
+func (r *memoryCalendarTokenRepository) GetByHash(tokenHash string) (*model.CalendarToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			clone := *token
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// Delete 删除用户的令牌
+func (r *memoryCalendarTokenRepository) Delete(userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tokens, userID)
+	return nil
+}
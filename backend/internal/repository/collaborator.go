@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"todolist/internal/model"
+)
+
+// CollaboratorRepository 任务协作者仓库接口
+type CollaboratorRepository interface {
+	// Save 添加协作者，已存在时更新角色
+	Save(collaborator *model.TaskCollaborator) error
+	// Delete 移除协作者
+	Delete(taskID, userID int) error
+	// DeleteByTaskIDs 移除多个任务的全部协作者
+	DeleteByTaskIDs(taskIDs []int) error
+	// Get 获取用户在任务上的协作记录，不存在时返回 nil
+	Get(taskID, userID int) (*model.TaskCollaborator, error)
+	// GetByTaskID 获取任务的全部协作者，按加入顺序排序
+	GetByTaskID(taskID int) ([]*model.TaskCollaborator, error)
+	// GetByUserID 获取共享给用户的全部协作记录
+	GetByUserID(userID int) ([]*model.TaskCollaborator, error)
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) CollaboratorRepository
+}
+
+// collaboratorRepository 任务协作者仓库实现
+type collaboratorRepository struct {
+	db *gorm.DB
+}
+
+// NewCollaboratorRepository 创建任务协作者仓库实例
+func NewCollaboratorRepository(db *gorm.DB) CollaboratorRepository {
+	return &collaboratorRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *collaboratorRepository) WithTx(tx Tx) CollaboratorRepository {
+	return &collaboratorRepository{db: tx.bind(r.db)}
+}
+
+// Save 添加协作者，已存在时更新角色
+func (r *collaboratorRepository) Save(collaborator *model.TaskCollaborator) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(collaborator).Error
+}
+
+// Delete 移除协作者
+func (r *collaboratorRepository) Delete(taskID, userID int) error {
+	return r.db.Where("task_id = ? AND user_id = ?", taskID, userID).Delete(&model.TaskCollaborator{}).Error
+}
+
+// DeleteByTaskIDs 移除多个任务的全部协作者
+func (r *collaboratorRepository) DeleteByTaskIDs(taskIDs []int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("task_id IN ?", taskIDs).Delete(&model.TaskCollaborator{}).Error
+}
+
+// Get 获取用户在任务上的协作记录
+func (r *collaboratorRepository) Get(taskID, userID int) (*model.TaskCollaborator, error) {
+	var collaborator model.TaskCollaborator
+	err := r.db.Where("task_id = ? AND user_id = ?", taskID, userID).First(&collaborator).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &collaborator, nil
+}
+
+// GetByTaskID 获取任务的全部协作者
+func (r *collaboratorRepository) GetByTaskID(taskID int) ([]*model.TaskCollaborator, error) {
+	var collaborators []*model.TaskCollaborator
+	err := r.db.Where("task_id = ?", taskID).Order("created_at").Order("user_id").Find(&collaborators).Error
+	return collaborators, err
+}
+
+// GetByUserID 获取共享给用户的全部协作记录
+func (r *collaboratorRepository) GetByUserID(userID int) ([]*model.TaskCollaborator, error) {
+	var collaborators []*model.TaskCollaborator
+	err := r.db.Where("user_id = ?", userID).Order("task_id").Find(&collaborators).Error
+	return collaborators, err
+}
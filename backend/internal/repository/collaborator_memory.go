@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// collaboratorKey 协作记录的主键
+type collaboratorKey struct {
+	taskID int
+	userID int
+}
+
+// memoryCollaboratorRepository 基于内存的任务协作者仓库实现，主要用于测试
+type memoryCollaboratorRepository struct {
+	mu            sync.RWMutex
+	collaborators map[collaboratorKey]*model.TaskCollaborator
+}
+
+// NewMemoryCollaboratorRepository 创建内存任务协作者仓库实例
+func NewMemoryCollaboratorRepository() CollaboratorRepository {
+	return &memoryCollaboratorRepository{
+		collaborators: make(map[collaboratorKey]*model.TaskCollaborator),
+	}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryCollaboratorRepository) WithTx(tx Tx) CollaboratorRepository {
+	return r
+}
+
+// Save 添加协作者，已存在时更新角色
+func (r *memoryCollaboratorRepository) Save(collaborator *model.TaskCollaborator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := collaboratorKey{taskID: collaborator.TaskID, userID: collaborator.UserID}
+	if existing, ok := r.collaborators[key]; ok {
+		existing.Role = collaborator.Role
+		return nil
+	}
+	if collaborator.CreatedAt.IsZero() {
+		collaborator.CreatedAt = time.Now()
+	}
+	clone := *collaborator
+	r.collaborators[key] = &clone
+	return nil
+}
+
+// Delete 移除协作者
+func (r *memoryCollaboratorRepository) Delete(taskID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.collaborators, collaboratorKey{taskID: taskID, userID: userID})
+	return nil
+}
+
+// DeleteByTaskIDs 移除多个任务的全部协作者
+func (r *memoryCollaboratorRepository) DeleteByTaskIDs(taskIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remove := make(map[int]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		remove[id] = true
+	}
+	for key := range r.collaborators {
+		if remove[key.taskID] {
+			delete(r.collaborators, key)
+		}
+	}
+	return nil
+}
+
+// Get 获取用户在任务上的协作记录
+func (r *memoryCollaboratorRepository) Get(taskID, userID int) (*model.TaskCollaborator, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	collaborator, ok := r.collaborators[collaboratorKey{taskID: taskID, userID: userID}]
+	if !ok {
+		return nil, nil
+	}
+	clone := *collaborator
+	return &clone, nil
+}
+
+// GetByTaskID 获取任务的全部协作者
+func (r *memoryCollaboratorRepository) GetByTaskID(taskID int) ([]*model.TaskCollaborator, error) {
+	return r.find(func(c *model.TaskCollaborator) bool { return c.TaskID == taskID }, func(a, b *model.TaskCollaborator) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.UserID < b.UserID
+	})
+}
+
+// GetByUserID 获取共享给用户的全部协作记录
+func (r *memoryCollaboratorRepository) GetByUserID(userID int) ([]*model.TaskCollaborator, error) {
+	return r.find(func(c *model.TaskCollaborator) bool { return c.UserID == userID }, func(a, b *model.TaskCollaborator) bool {
+		return a.TaskID < b.TaskID
+	})
+}
+
+// find 按条件查找协作记录并排序
+func (r *memoryCollaboratorRepository) find(match func(*model.TaskCollaborator) bool, less func(a, b *model.TaskCollaborator) bool) ([]*model.TaskCollaborator, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var collaborators []*model.TaskCollaborator
+	for _, collaborator := range r.collaborators {
+		if match(collaborator) {
+			clone := *collaborator
+			collaborators = append(collaborators, &clone)
+		}
+	}
+	sort.Slice(collaborators, func(i, j int) bool {
+		return less(collaborators[i], collaborators[j])
+	})
+	return collaborators, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"todolist/internal/model"
+)
+
+// CommentRepository 任务评论仓库接口，查询时不返回已删除的评论
+type CommentRepository interface {
+	// Create 创建评论
+	Create(comment *model.Comment) error
+	// Update 更新评论，删除评论时设置 DeletedAt 后调用
+	Update(comment *model.Comment) error
+	// GetByID 根据ID获取评论，不存在或已删除时返回 nil
+	GetByID(commentID int) (*model.Comment, error)
+	// ListByTaskID 分页获取任务的评论，按发表顺序排序
+	ListByTaskID(taskID, page, pageSize int) ([]*model.Comment, int64, error)
+	// DeleteByTaskIDs 删除多个任务的全部评论
+	DeleteByTaskIDs(taskIDs []int) error
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) CommentRepository
+}
+
+// commentRepository 任务评论仓库实现
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 创建任务评论仓库实例
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *commentRepository) WithTx(tx Tx) CommentRepository {
+	return &commentRepository{db: tx.bind(r.db)}
+}
+
+// Create 创建评论
+func (r *commentRepository) Create(comment *model.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+// Update 更新评论
+func (r *commentRepository) Update(comment *model.Comment) error {
+	return r.db.Save(comment).Error
+}
+
+// GetByID 根据ID获取评论
+func (r *commentRepository) GetByID(commentID int) (*model.Comment, error) {
+	var comment model.Comment
+	if err := r.db.Where("deleted_at IS NULL").First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListByTaskID 分页获取任务的评论
+func (r *commentRepository) ListByTaskID(taskID, page, pageSize int) ([]*model.Comment, int64, error) {
+	var comments []*model.Comment
+	var total int64
+
+	query := r.db.Model(&model.Comment{}).Where("task_id = ? AND deleted_at IS NULL", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&comments).Error
+	return comments, total, err
+}
+
+// DeleteByTaskIDs 删除多个任务的全部评论
+func (r *commentRepository) DeleteByTaskIDs(taskIDs []int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("task_id IN ?", taskIDs).Delete(&model.Comment{}).Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// memoryCommentRepository 基于内存的任务评论仓库实现，主要用于测试
+type memoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments map[int]*model.Comment
+	nextID   int
+}
+
+// NewMemoryCommentRepository 创建内存任务评论仓库实例
+func NewMemoryCommentRepository() CommentRepository {
+	return &memoryCommentRepository{
+		comments: make(map[int]*model.Comment),
+		nextID:   1,
+	}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryCommentRepository) WithTx(tx Tx) CommentRepository {
+	return r
+}
+
+// Create 创建评论
+func (r *memoryCommentRepository) Create(comment *model.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment.ID = r.nextID
+	r.nextID++
+	now := time.Now()
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = now
+	}
+	if comment.UpdatedAt.IsZero() {
+		comment.UpdatedAt = now
+	}
+	clone := *comment
+	clone.Username = ""
+	r.comments[comment.ID] = &clone
+	return nil
+}
+
+// Update 更新评论
+func (r *memoryCommentRepository) Update(comment *model.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment.UpdatedAt = time.Now()
+	clone := *comment
+	clone.Username = ""
+	r.comments[comment.ID] = &clone
+	return nil
+}
+
+// GetByID 根据ID获取评论
+func (r *memoryCommentRepository) GetByID(commentID int) (*model.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[commentID]
+	if !ok || comment.DeletedAt != nil {
+		return nil, nil
+	}
+	clone := *comment
+	return &clone, nil
+}
+
+// ListByTaskID 分页获取任务的评论
+func (r *memoryCommentRepository) ListByTaskID(taskID, page, pageSize int) ([]*model.Comment, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.Comment
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID && comment.DeletedAt == nil {
+			matched = append(matched, comment)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	total := int64(len(matched))
+	matched = paginate(matched, page, pageSize)
+
+	comments := make([]*model.Comment, 0, len(matched))
+	for _, comment := range matched {
+		clone := *comment
+		comments = append(comments, &clone)
+	}
+	return comments, total, nil
+}
+
+// DeleteByTaskIDs 删除多个任务的全部评论
+func (r *memoryCommentRepository) DeleteByTaskIDs(taskIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, comment := range r.comments {
+		if slices.Contains(taskIDs, comment.TaskID) {
+			delete(r.comments, id)
+		}
+	}
+	return nil
+}
@@ -1,41 +1,76 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"todolist/config"
+	"todolist/internal/migration"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
+// ErrSQLiteRequiresCGO 程序编译时未启用 CGO，无法使用 SQLite
+var ErrSQLiteRequiresCGO = errors.New("SQLite 驱动需要 CGO，请使用 CGO_ENABLED=1 重新编译（需要 C 编译器），或将 database.driver 设置为 mysql")
+
 // InitDB 初始化数据库连接
 func InitDB() error {
 	var err error
 
-	// 获取MySQL配置
-	mysqlConfig := config.GlobalConfig.MySQL
-
 	// 配置GORM
 	gormConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info), // 开发环境下打印SQL
 	}
 
 	// 连接数据库
-	dsn := mysqlConfig.DSN()
-	DB, err = gorm.Open(mysql.Open(dsn), gormConfig)
+	DB, err = OpenDB(config.GlobalConfig, gormConfig)
 	if err != nil {
-		return fmt.Errorf("连接数据库失败: %v", err)
+		return err
+	}
+
+	// 执行数据库迁移
+	if config.GlobalConfig.Database.AutoMigrate {
+		if err := Migrate(DB); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("数据库连接成功 (%s)", config.GlobalConfig.Database.Driver)
+	return nil
+}
+
+// OpenDB 根据配置中的 database.driver 打开数据库连接
+func OpenDB(cfg config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	switch cfg.Database.Driver {
+	case config.DriverMySQL, "":
+		return openMySQL(cfg.MySQL, gormConfig)
+	case config.DriverSQLite:
+		return openSQLite(cfg.SQLite, gormConfig)
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Database.Driver)
+	}
+}
+
+// openMySQL 连接MySQL并设置连接池
+func openMySQL(mysqlConfig config.MySQLConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(mysqlConfig.DSN()), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
 	}
 
 	// 获取底层的sqlDB
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("获取sqlDB失败: %v", err)
+		return nil, fmt.Errorf("获取sqlDB失败: %v", err)
 	}
 
 	// 设置连接池
@@ -43,6 +78,56 @@ func InitDB() error {
 	sqlDB.SetMaxOpenConns(mysqlConfig.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(mysqlConfig.ConnMaxLifetime)
 
-	log.Println("数据库连接成功")
+	return db, nil
+}
+
+// openSQLite 打开嵌入式SQLite数据库，无需外部服务。
+// 驱动基于 CGO，未启用 CGO 编译时直接返回 ErrSQLiteRequiresCGO，启动时即说明如何重新编译
+func openSQLite(sqliteConfig config.SQLiteConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
+	if !sqliteSupported {
+		return nil, ErrSQLiteRequiresCGO
+	}
+
+	// 文件数据库需要先创建所在目录
+	if path := sqliteConfig.Path; path != "" && !isSQLiteMemoryPath(path) {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("创建数据库目录失败: %v", err)
+			}
+		}
+	}
+
+	db, err := gorm.Open(sqlite.Open(sqliteConfig.DSN()), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取sqlDB失败: %v", err)
+	}
+
+	// SQLite 只允许单个写连接，内存数据库在多个连接之间也不共享数据
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// isSQLiteMemoryPath 判断是否为内存数据库
+func isSQLiteMemoryPath(path string) bool {
+	return path == ":memory:" || strings.Contains(path, "mode=memory")
+}
+
+// Migrate 执行全部未应用的数据库迁移
+func Migrate(db *gorm.DB) error {
+	migrator, err := migration.New(db)
+	if err != nil {
+		return fmt.Errorf("加载数据库迁移失败: %v", err)
+	}
+	migrator.Out = log.Writer()
+
+	if _, err := migrator.Up(0); err != nil {
+		return fmt.Errorf("执行数据库迁移失败: %v", err)
+	}
 	return nil
 }
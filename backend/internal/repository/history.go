@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"todolist/internal/model"
+)
+
+// HistoryRepository 任务变更历史仓库接口，历史记录只追加不修改
+type HistoryRepository interface {
+	// Create 追加一条历史记录
+	Create(entry *model.TaskHistory) error
+	// ListByTaskID 分页获取任务的历史记录，最新的排在前面
+	ListByTaskID(taskID, page, pageSize int) ([]*model.TaskHistory, int64, error)
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) HistoryRepository
+}
+
+// historyRepository 任务变更历史仓库实现
+type historyRepository struct {
+	db *gorm.DB
+}
+
+// NewHistoryRepository 创建任务变更历史仓库实例
+func NewHistoryRepository(db *gorm.DB) HistoryRepository {
+	return &historyRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *historyRepository) WithTx(tx Tx) HistoryRepository {
+	return &historyRepository{db: tx.bind(r.db)}
+}
+
+// Create 追加一条历史记录
+func (r *historyRepository) Create(entry *model.TaskHistory) error {
+	return r.db.Create(entry).Error
+}
+
+// ListByTaskID 分页获取任务的历史记录
+func (r *historyRepository) ListByTaskID(taskID, page, pageSize int) ([]*model.TaskHistory, int64, error) {
+	var entries []*model.TaskHistory
+	var total int64
+
+	query := r.db.Model(&model.TaskHistory{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC").Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error
+	return entries, total, err
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// memoryHistoryRepository 基于内存的任务变更历史仓库实现，主要用于测试
+type memoryHistoryRepository struct {
+	mu      sync.RWMutex
+	entries []*model.TaskHistory
+	nextID  int
+}
+
+// NewMemoryHistoryRepository 创建内存任务变更历史仓库实例
+func NewMemoryHistoryRepository() HistoryRepository {
+	return &memoryHistoryRepository{nextID: 1}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryHistoryRepository) WithTx(tx Tx) HistoryRepository {
+	return r
+}
+
+// Create 追加一条历史记录
+func (r *memoryHistoryRepository) Create(entry *model.TaskHistory) error {
+	// 与 gorm 的 json 序列化保持一致，变更值统一为 JSON 解码后的类型
+	changes, err := cloneChanges(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = r.nextID
+	r.nextID++
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	clone := *entry
+	clone.Username = ""
+	clone.Changes = changes
+	r.entries = append(r.entries, &clone)
+	return nil
+}
+
+// ListByTaskID 分页获取任务的历史记录
+func (r *memoryHistoryRepository) ListByTaskID(taskID, page, pageSize int) ([]*model.TaskHistory, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.TaskHistory
+	for _, entry := range r.entries {
+		if entry.TaskID == taskID {
+			matched = append(matched, entry)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := int64(len(matched))
+	matched = paginate(matched, page, pageSize)
+
+	entries := make([]*model.TaskHistory, 0, len(matched))
+	for _, entry := range matched {
+		clone := *entry
+		changes, err := cloneChanges(entry.Changes)
+		if err != nil {
+			return nil, 0, err
+		}
+		clone.Changes = changes
+		entries = append(entries, &clone)
+	}
+	return entries, total, nil
+}
+
+// cloneChanges 通过 JSON 编解码深拷贝字段变更
+func cloneChanges(changes []model.FieldChange) ([]model.FieldChange, error) {
+	if changes == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	var clone []model.FieldChange
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"todolist/internal/model"
+)
+
+// IdempotencyRepository 幂等键记录仓库接口
+type IdempotencyRepository interface {
+	// Create 保存新的幂等键记录，同一用户未过期的幂等键已存在时返回 false
+	Create(record *model.IdempotencyRecord) (bool, error)
+	// Get 获取用户未过期的幂等键记录，不存在时返回 nil
+	Get(userID int, key string, now time.Time) (*model.IdempotencyRecord, error)
+	// Complete 保存首次请求的响应
+	Complete(record *model.IdempotencyRecord) error
+	// Delete 删除幂等键记录，之后可以使用相同的幂等键重新请求
+	Delete(userID int, key string) error
+	// DeleteExpired 清理在 before 之前过期的记录
+	DeleteExpired(before time.Time) error
+}
+
+// idempotencyRepository 幂等键记录仓库实现
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository 创建幂等键记录仓库实例
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Create 保存新的幂等键记录，先清理同一幂等键已过期的记录，再依靠唯一索引保证并发请求只有一个成功
+func (r *idempotencyRepository) Create(record *model.IdempotencyRecord) (bool, error) {
+	err := r.db.Where("user_id = ? AND idempotency_key = ? AND expires_at <= ?", record.UserID, record.Key, record.CreatedAt).
+		Delete(&model.IdempotencyRecord{}).Error
+	if err != nil {
+		return false, err
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Get 获取用户未过期的幂等键记录
+func (r *idempotencyRepository) Get(userID int, key string, now time.Time) (*model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	err := r.db.Where("user_id = ? AND idempotency_key = ? AND expires_at > ?", userID, key, now).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete 保存首次请求的响应
+func (r *idempotencyRepository) Complete(record *model.IdempotencyRecord) error {
+	return r.db.Model(record).Select("status_code", "headers", "body").Updates(record).Error
+}
+
+// Delete 删除幂等键记录
+func (r *idempotencyRepository) Delete(userID int, key string) error {
+	return r.db.Where("user_id = ? AND idempotency_key = ?", userID, key).Delete(&model.IdempotencyRecord{}).Error
+}
+
+// DeleteExpired 清理过期的记录
+func (r *idempotencyRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&model.IdempotencyRecord{}).Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// idempotencyKey 内存仓库中幂等键记录的索引
+type idempotencyKey struct {
+	userID int
+	key    string
+}
+
+// memoryIdempotencyRepository 基于内存的幂等键记录仓库实现，主要用于测试
+type memoryIdempotencyRepository struct {
+	mu      sync.RWMutex
+	records map[idempotencyKey]*model.IdempotencyRecord
+	nextID  int
+}
+
+// NewMemoryIdempotencyRepository 创建内存幂等键记录仓库实例
+func NewMemoryIdempotencyRepository() IdempotencyRepository {
+	return &memoryIdempotencyRepository{
+		records: make(map[idempotencyKey]*model.IdempotencyRecord),
+		nextID:  1,
+	}
+}
+
+// Create 保存新的幂等键记录
+func (r *memoryIdempotencyRepository) Create(record *model.IdempotencyRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	k := idempotencyKey{userID: record.UserID, key: record.Key}
+	if existing, ok := r.records[k]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return false, nil
+	}
+	record.ID = r.nextID
+	r.nextID++
+	r.records[k] = cloneIdempotencyRecord(record)
+	return true, nil
+}
+
+// Get 获取用户未过期的幂等键记录
+func (r *memoryIdempotencyRepository) Get(userID int, key string, now time.Time) (*model.IdempotencyRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.records[idempotencyKey{userID: userID, key: key}]
+	if !ok || !record.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	return cloneIdempotencyRecord(record), nil
+}
+
+// Complete 保存首次请求的响应
+func (r *memoryIdempotencyRepository) Complete(record *model.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[idempotencyKey{userID: record.UserID, key: record.Key}]
+	if !ok || stored.ID != record.ID {
+		return nil
+	}
+	stored.StatusCode = record.StatusCode
+	stored.Headers = maps.Clone(record.Headers)
+	stored.Body = record.Body
+	return nil
+}
+
+// Delete 删除幂等键记录
+func (r *memoryIdempotencyRepository) Delete(userID int, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, idempotencyKey{userID: userID, key: key})
+	return nil
+}
+
+// DeleteExpired 清理过期的记录
+func (r *memoryIdempotencyRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, record := range r.records {
+		if record.ExpiresAt.Before(before) {
+			delete(r.records, k)
+		}
+	}
+	return nil
+}
+
+// cloneIdempotencyRecord 复制幂等键记录，避免调用方修改仓库中的数据
+func cloneIdempotencyRecord(record *model.IdempotencyRecord) *model.IdempotencyRecord {
+	clone := *record
+	clone.Headers = maps.Clone(record.Headers)
+	return &clone
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"todolist/internal/model"
+)
+
+// ProjectRepository 项目仓库接口
+type ProjectRepository interface {
+	// Create 创建项目
+	Create(project *model.Project) error
+	// Update 更新项目
+	Update(project *model.Project) error
+	// Delete 删除项目
+	Delete(projectID int) error
+	// GetByID 根据ID获取项目
+	GetByID(projectID int) (*model.Project, error)
+	// GetByUserID 获取用户的个人项目，按 position 和 ID 排序
+	GetByUserID(userID int, includeArchived bool) ([]*model.Project, error)
+	// GetByWorkspaceID 获取工作区的项目，按 position 和 ID 排序
+	GetByWorkspaceID(workspaceID int, includeArchived bool) ([]*model.Project, error)
+	// UpdatePositions 在同一事务中批量更新项目排序，键为项目ID
+	UpdatePositions(positions map[int]int) error
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) ProjectRepository
+}
+
+// projectRepository 项目仓库实现
+type projectRepository struct {
+	db *gorm.DB
+}
+
+// NewProjectRepository 创建项目仓库实例
+func NewProjectRepository(db *gorm.DB) ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *projectRepository) WithTx(tx Tx) ProjectRepository {
+	return &projectRepository{db: tx.bind(r.db)}
+}
+
+// Create 创建项目
+func (r *projectRepository) Create(project *model.Project) error {
+	return r.db.Create(project).Error
+}
+
+// Update 更新项目
+func (r *projectRepository) Update(project *model.Project) error {
+	return r.db.Save(project).Error
+}
+
+// Delete 删除项目
+func (r *projectRepository) Delete(projectID int) error {
+	return r.db.Delete(&model.Project{}, projectID).Error
+}
+
+// GetByID 根据ID获取项目
+func (r *projectRepository) GetByID(projectID int) (*model.Project, error) {
+	var project model.Project
+	if err := r.db.First(&project, projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetByUserID 获取用户的个人项目
+func (r *projectRepository) GetByUserID(userID int, includeArchived bool) ([]*model.Project, error) {
+	return r.find(r.db.Where("user_id = ? AND workspace_id IS NULL", userID), includeArchived)
+}
+
+// GetByWorkspaceID 获取工作区的项目
+func (r *projectRepository) GetByWorkspaceID(workspaceID int, includeArchived bool) ([]*model.Project, error) {
+	return r.find(r.db.Where("workspace_id = ?", workspaceID), includeArchived)
+}
+
+// find 按条件查询项目并排序
+func (r *projectRepository) find(query *gorm.DB, includeArchived bool) ([]*model.Project, error) {
+	var projects []*model.Project
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	err := query.Order("position").Order("id").Find(&projects).Error
+	return projects, err
+}
+
+// UpdatePositions 批量更新项目排序
+func (r *projectRepository) UpdatePositions(positions map[int]int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, position := range positions {
+			if err := tx.Model(&model.Project{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
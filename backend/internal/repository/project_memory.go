@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// memoryProjectRepository 基于内存的项目仓库实现，主要用于测试
+type memoryProjectRepository struct {
+	mu       sync.RWMutex
+	projects map[int]*model.Project
+	nextID   int
+}
+
+// NewMemoryProjectRepository 创建内存项目仓库实例
+func NewMemoryProjectRepository() ProjectRepository {
+	return &memoryProjectRepository{
+		projects: make(map[int]*model.Project),
+		nextID:   1,
+	}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryProjectRepository) WithTx(tx Tx) ProjectRepository {
+	return r
+}
+
+// Create 创建项目
+func (r *memoryProjectRepository) Create(project *model.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project.ID = r.nextID
+	r.nextID++
+	now := time.Now()
+	if project.CreatedAt.IsZero() {
+		project.CreatedAt = now
+	}
+	if project.UpdatedAt.IsZero() {
+		project.UpdatedAt = now
+	}
+
+	clone := *project
+	r.projects[project.ID] = &clone
+	return nil
+}
+
+// Update 更新项目
+func (r *memoryProjectRepository) Update(project *model.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project.UpdatedAt = time.Now()
+	clone := *project
+	r.projects[project.ID] = &clone
+	return nil
+}
+
+// Delete 删除项目
+func (r *memoryProjectRepository) Delete(projectID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.projects, projectID)
+	return nil
+}
+
+// GetByID 根据ID获取项目
+func (r *memoryProjectRepository) GetByID(projectID int) (*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[projectID]
+	if !ok {
+		return nil, nil
+	}
+	clone := *project
+	return &clone, nil
+}
+
+// GetByUserID 获取用户的个人项目
+func (r *memoryProjectRepository) GetByUserID(userID int, includeArchived bool) ([]*model.Project, error) {
+	return r.find(func(project *model.Project) bool {
+		return project.UserID == userID && project.WorkspaceID == nil
+	}, includeArchived)
+}
+
+// GetByWorkspaceID 获取工作区的项目
+func (r *memoryProjectRepository) GetByWorkspaceID(workspaceID int, includeArchived bool) ([]*model.Project, error) {
+	return r.find(func(project *model.Project) bool {
+		return project.WorkspaceID != nil && *project.WorkspaceID == workspaceID
+	}, includeArchived)
+}
+
+// find 按条件查询项目并排序
+func (r *memoryProjectRepository) find(match func(*model.Project) bool, includeArchived bool) ([]*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*model.Project
+	for _, project := range r.projects {
+		if !match(project) || (project.Archived && !includeArchived) {
+			continue
+		}
+		clone := *project
+		projects = append(projects, &clone)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Position != projects[j].Position {
+			return projects[i].Position < projects[j].Position
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+// UpdatePositions 批量更新项目排序
+func (r *memoryProjectRepository) UpdatePositions(positions map[int]int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, position := range positions {
+		if project, ok := r.projects[id]; ok {
+			project.Position = position
+		}
+	}
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build cgo
+
+package repository
+
+// sqliteSupported 是否可以打开 SQLite 数据库
+const sqliteSupported = true
@@ -0,0 +1,7 @@
+//go:build !cgo
+
+package repository
+
+// sqliteSupported 是否可以打开 SQLite 数据库。
+// gorm.io/driver/sqlite 依赖的 github.com/mattn/go-sqlite3 需要 CGO，CGO_ENABLED=0 编译时驱动只是一个打开即失败的占位实现
+const sqliteSupported = false
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"todolist/internal/model"
+)
+
+// TagRepository 标签仓库接口
+type TagRepository interface {
+	// Create 创建标签
+	Create(tag *model.Tag) error
+	// Update 更新标签
+	Update(tag *model.Tag) error
+	// Delete 删除标签及其任务关联
+	Delete(tagID int) error
+	// GetByID 根据ID获取标签
+	GetByID(tagID int) (*model.Tag, error)
+	// GetByName 获取用户下指定名称的标签
+	GetByName(userID int, name string) (*model.Tag, error)
+	// GetByUserID 获取用户的全部标签
+	GetByUserID(userID int) ([]*model.Tag, error)
+	// GetByIDs 批量获取标签，不存在的ID会被忽略
+	GetByIDs(tagIDs []int) ([]*model.Tag, error)
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) TagRepository
+}
+
+// tagRepository 标签仓库实现
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository 创建标签仓库实例
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *tagRepository) WithTx(tx Tx) TagRepository {
+	return &tagRepository{db: tx.bind(r.db)}
+}
+
+// Create 创建标签
+func (r *tagRepository) Create(tag *model.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+// Update 更新标签
+func (r *tagRepository) Update(tag *model.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// Delete 删除标签及其任务关联
+func (r *tagRepository) Delete(tagID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", tagID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Tag{}, tagID).Error
+	})
+}
+
+// GetByID 根据ID获取标签
+func (r *tagRepository) GetByID(tagID int) (*model.Tag, error) {
+	var tag model.Tag
+	if err := r.db.First(&tag, tagID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByName 获取用户下指定名称的标签
+func (r *tagRepository) GetByName(userID int, name string) (*model.Tag, error) {
+	var tag model.Tag
+	if err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByUserID 获取用户的全部标签
+func (r *tagRepository) GetByUserID(userID int) ([]*model.Tag, error) {
+	var tags []*model.Tag
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+// GetByIDs 批量获取标签
+func (r *tagRepository) GetByIDs(tagIDs []int) ([]*model.Tag, error) {
+	var tags []*model.Tag
+	if len(tagIDs) == 0 {
+		return tags, nil
+	}
+	err := r.db.Where("id IN ?", tagIDs).Order("id").Find(&tags).Error
+	return tags, err
+}
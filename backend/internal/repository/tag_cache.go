@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"todolist/internal/cache"
+	"todolist/internal/model"
+)
+
+// cachedTagRepository 标签仓库装饰器，标签本身不缓存。
+// 缓存的任务包含标签，修改或删除标签后使全部任务缓存失效
+type cachedTagRepository struct {
+	repo  TagRepository
+	cache cache.Cache
+}
+
+// NewCachedTagRepository 创建与 NewCachedTaskRepository 共用缓存的标签仓库
+func NewCachedTagRepository(repo TagRepository, c cache.Cache) TagRepository {
+	return &cachedTagRepository{repo: repo, cache: c}
+}
+
+// WithTx 返回在事务中读写的仓库，事务结束后任务仓库会使全部任务缓存失效
+func (r *cachedTagRepository) WithTx(tx Tx) TagRepository {
+	return r.repo.WithTx(tx)
+}
+
+// Create 创建标签
+func (r *cachedTagRepository) Create(tag *model.Tag) error {
+	return r.repo.Create(tag)
+}
+
+// Update 更新标签并使任务缓存失效
+func (r *cachedTagRepository) Update(tag *model.Tag) error {
+	err := r.repo.Update(tag)
+	bumpTaskCacheGeneration(r.cache)
+	return err
+}
+
+// Delete 删除标签及其任务关联并使任务缓存失效
+func (r *cachedTagRepository) Delete(tagID int) error {
+	err := r.repo.Delete(tagID)
+	bumpTaskCacheGeneration(r.cache)
+	return err
+}
+
+// GetByID 根据ID获取标签
+func (r *cachedTagRepository) GetByID(tagID int) (*model.Tag, error) {
+	return r.repo.GetByID(tagID)
+}
+
+// GetByName 获取用户下指定名称的标签
+func (r *cachedTagRepository) GetByName(userID int, name string) (*model.Tag, error) {
+	return r.repo.GetByName(userID, name)
+}
+
+// GetByUserID 获取用户的全部标签
+func (r *cachedTagRepository) GetByUserID(userID int) ([]*model.Tag, error) {
+	return r.repo.GetByUserID(userID)
+}
+
+// GetByIDs 批量获取标签
+func (r *cachedTagRepository) GetByIDs(tagIDs []int) ([]*model.Tag, error) {
+	return r.repo.GetByIDs(tagIDs)
+}
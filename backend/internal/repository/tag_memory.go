@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// ErrDuplicateTagName 标签名已存在，对应数据库中的唯一索引冲突
+var ErrDuplicateTagName = errors.New("标签名已存在")
+
+// memoryTagRepository 基于内存的标签仓库实现，主要用于测试
+type memoryTagRepository struct {
+	mu     sync.RWMutex
+	tags   map[int]*model.Tag
+	nextID int
+}
+
+// NewMemoryTagRepository 创建内存标签仓库实例
+func NewMemoryTagRepository() TagRepository {
+	return &memoryTagRepository{
+		tags:   make(map[int]*model.Tag),
+		nextID: 1,
+	}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryTagRepository) WithTx(tx Tx) TagRepository {
+	return r
+}
+
+// Create 创建标签
+func (r *memoryTagRepository) Create(tag *model.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(tag.UserID, tag.Name, 0) {
+		return ErrDuplicateTagName
+	}
+
+	tag.ID = r.nextID
+	r.nextID++
+	now := time.Now()
+	if tag.CreatedAt.IsZero() {
+		tag.CreatedAt = now
+	}
+	if tag.UpdatedAt.IsZero() {
+		tag.UpdatedAt = now
+	}
+
+	clone := *tag
+	r.tags[tag.ID] = &clone
+	return nil
+}
+
+// Update 更新标签
+func (r *memoryTagRepository) Update(tag *model.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(tag.UserID, tag.Name, tag.ID) {
+		return ErrDuplicateTagName
+	}
+
+	tag.UpdatedAt = time.Now()
+	clone := *tag
+	r.tags[tag.ID] = &clone
+	return nil
+}
+
+// Delete 删除标签
+func (r *memoryTagRepository) Delete(tagID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tags, tagID)
+	return nil
+}
+
+// GetByID 根据ID获取标签
+func (r *memoryTagRepository) GetByID(tagID int) (*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[tagID]
+	if !ok {
+		return nil, nil
+	}
+	clone := *tag
+	return &clone, nil
+}
+
+// GetByName 获取用户下指定名称的标签
+func (r *memoryTagRepository) GetByName(userID int, name string) (*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tag := range r.tags {
+		if tag.UserID == userID && tag.Name == name {
+			clone := *tag
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetByUserID 获取用户的全部标签
+func (r *memoryTagRepository) GetByUserID(userID int) ([]*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []*model.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			clone := *tag
+			tags = append(tags, &clone)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// GetByIDs 批量获取标签
+func (r *memoryTagRepository) GetByIDs(tagIDs []int) ([]*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []*model.Tag
+	for _, id := range tagIDs {
+		if tag, ok := r.tags[id]; ok {
+			clone := *tag
+			tags = append(tags, &clone)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].ID < tags[j].ID
+	})
+	return tags, nil
+}
+
+// nameTaken 判断用户下的标签名是否已被其他标签使用，调用方需持有锁
+func (r *memoryTagRepository) nameTaken(userID int, name string, exceptID int) bool {
+	for id, tag := range r.tags {
+		if id != exceptID && tag.UserID == userID && tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,23 +1,57 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 
 	"todolist/internal/model"
 )
 
 // TaskRepository 任务仓库接口
+// 已移入回收站的任务只能通过 GetDeletedByID、GetDeletedChildren 和 Deleted 过滤条件查询，其他查询均不返回
 type TaskRepository interface {
 	// Create 创建任务
 	Create(task *model.Task) error
-	// Update 更新任务
+	// Update 更新任务，不检查版本号，保存后 task.Version 加1
 	Update(task *model.Task) error
-	// Delete 删除任务
+	// UpdateIfVersion 仅当数据库中的版本号等于 version 时更新任务，成功时 task.Version 为 version+1；
+	// 版本号不一致或任务已删除时返回 false，数据库和 task 都不做修改
+	UpdateIfVersion(task *model.Task, version int) (bool, error)
+	// Delete 永久删除任务
 	Delete(taskID int) error
-	// GetByID 根据ID获取任务
+	// GetByID 根据ID获取任务，不存在或已删除时返回 nil
 	GetByID(taskID int) (*model.Task, error)
-	// GetByUserID 获取用户的任务列表
-	GetByUserID(userID int, status string, page, pageSize int) ([]*model.Task, int64, error)
+	// List 按条件查询任务列表，返回当前页的任务和符合条件的总数
+	List(filter model.TaskFilter) ([]*model.Task, int64, error)
+	// ListVersions 按与 List 相同的条件查询全部任务的ID和版本号，按ID排序，忽略分页和排序条件
+	ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error)
+	// GetChildren 获取直接子任务，按ID排序
+	GetChildren(parentID int) ([]*model.Task, error)
+	// CountSubtasks 统计直接子任务的数量和已完成数量，没有子任务的任务不在结果中
+	CountSubtasks(parentIDs []int) (map[int]model.SubtaskProgress, error)
+	// DeleteByIDs 在同一事务中永久删除多个任务及其标签关联
+	DeleteByIDs(taskIDs []int) error
+	// SoftDeleteByIDs 将多个任务移入回收站
+	SoftDeleteByIDs(taskIDs []int, deletedAt time.Time) error
+	// Restore 将多个任务移出回收站
+	Restore(taskIDs []int) error
+	// GetDeletedByID 获取回收站中的任务，不存在或未删除时返回 nil
+	GetDeletedByID(taskID int) (*model.Task, error)
+	// GetDeletedChildren 获取回收站中的直接子任务，按ID排序
+	GetDeletedChildren(parentID int) ([]*model.Task, error)
+	// ListDeletedBefore 获取删除时间早于 before 的任务ID
+	ListDeletedBefore(before time.Time) ([]int, error)
+	// UpdateProject 将多个任务移动到指定项目，projectID 为 nil 表示移出项目
+	UpdateProject(taskIDs []int, projectID *int) error
+	// ClearProject 将项目下的全部任务移出该项目，包括回收站中的任务
+	ClearProject(projectID int) error
+	// Transaction 在同一事务中执行 fn，fn 返回错误时回滚其中的全部修改。fn 中应通过 WithTx 绑定到 tx 的仓库读写；
+	// 在绑定到事务的仓库上再次调用 Transaction 时使用保存点，fn 返回错误时只回滚内层的修改
+	Transaction(fn func(tx Tx) error) error
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) TaskRepository
 }
 
 // taskRepository 任务仓库实现
@@ -32,25 +66,69 @@ func NewTaskRepository(db *gorm.DB) TaskRepository {
 	}
 }
 
-// Create 创建任务
+// Transaction 在事务中执行 fn，已在事务中时使用保存点
+func (r *taskRepository) Transaction(fn func(tx Tx) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(Tx{db: tx})
+	})
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *taskRepository) WithTx(tx Tx) TaskRepository {
+	return &taskRepository{db: tx.bind(r.db)}
+}
+
+// Create 创建任务，只关联已存在的标签，不修改标签本身
 func (r *taskRepository) Create(task *model.Task) error {
-	return r.db.Create(task).Error
+	if task.Version == 0 {
+		task.Version = 1
+	}
+	return r.db.Omit("Tags.*").Create(task).Error
 }
 
-// Update 更新任务
+// Update 更新任务，标签关联替换为 task.Tags
 func (r *taskRepository) Update(task *model.Task) error {
-	return r.db.Save(task).Error
+	task.Version++
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").Save(task).Error; err != nil {
+			return err
+		}
+		return tx.Model(task).Omit("Tags.*").Association("Tags").Replace(task.Tags)
+	})
 }
 
-// Delete 删除任务
+// UpdateIfVersion 按版本号条件更新任务，标签关联替换为 task.Tags
+func (r *taskRepository) UpdateIfVersion(task *model.Task, version int) (bool, error) {
+	original := task.Version
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		task.Version = version + 1
+		result := tx.Model(task).Select("*").Omit("Tags", "CreatedAt").
+			Where("version = ? AND deleted_at IS NULL", version).Updates(task)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		updated = true
+		return tx.Model(task).Omit("Tags.*").Association("Tags").Replace(task.Tags)
+	})
+	if !updated {
+		task.Version = original
+	}
+	return updated, err
+}
+
+// Delete 永久删除任务及其标签关联
 func (r *taskRepository) Delete(taskID int) error {
-	return r.db.Delete(&model.Task{}, taskID).Error
+	return r.db.Select("Tags").Delete(&model.Task{ID: taskID}).Error
 }
 
 // GetByID 根据ID获取任务
 func (r *taskRepository) GetByID(taskID int) (*model.Task, error) {
 	var task model.Task
-	err := r.db.First(&task, taskID).Error
+	err := r.db.Preload("Tags").Where("deleted_at IS NULL").First(&task, taskID).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -60,32 +138,28 @@ func (r *taskRepository) GetByID(taskID int) (*model.Task, error) {
 	return &task, nil
 }
 
-// GetByUserID 获取用户的任务列表
-func (r *taskRepository) GetByUserID(userID int, status string, page, pageSize int) ([]*model.Task, int64, error) {
+// List 按条件查询任务列表
+func (r *taskRepository) List(filter model.TaskFilter) ([]*model.Task, int64, error) {
 	var tasks []*model.Task
 	var total int64
 
-	query := r.db.Model(&model.Task{}).Where("user_id = ?", userID)
-	if status != "" {
-		// 将状态文本转换为数字
-		var statusInt int
-		switch status {
-		case "todo":
-			statusInt = model.TaskStatusTodo
-		case "in_progress":
-			statusInt = model.TaskStatusInProgress
-		case "done":
-			statusInt = model.TaskStatusDone
-		}
-		query = query.Where("status = ?", statusInt)
-	}
-
+	query := r.filter(filter)
 	err := query.Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	err = query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&tasks).Error
+	// 排序字段已在解析时校验，可直接拼接
+	for _, sort := range filter.Sort {
+		order := sort.Field
+		if sort.Desc {
+			order += " DESC"
+		}
+		query = query.Order(order)
+	}
+	query = query.Order("id")
+
+	err = query.Preload("Tags").Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize).Find(&tasks).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -93,6 +167,186 @@ func (r *taskRepository) GetByUserID(userID int, status string, page, pageSize i
 	return tasks, total, nil
 }
 
+// ListVersions 按条件查询任务的ID和版本号
+func (r *taskRepository) ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error) {
+	var versions []model.TaskVersion
+	err := r.filter(filter).Select("id", "version").Order("id").Scan(&versions).Error
+	return versions, err
+}
+
+// filter 按 List 的过滤条件构造查询，不包括排序和分页
+func (r *taskRepository) filter(filter model.TaskFilter) *gorm.DB {
+	query := r.db.Model(&model.Task{})
+	switch {
+	case filter.AllScopes && filter.AssigneeID != 0:
+		// 范围由下面的 AssignedTaskIDs 限定
+	case filter.WorkspaceID != 0:
+		query = query.Where("workspace_id = ?", filter.WorkspaceID)
+	case len(filter.SharedTaskIDs) > 0:
+		query = query.Where("((user_id = ? AND workspace_id IS NULL) OR id IN ?)", filter.UserID, filter.SharedTaskIDs)
+	default:
+		query = query.Where("user_id = ? AND workspace_id IS NULL", filter.UserID)
+	}
+	if filter.AssigneeID != 0 {
+		query = query.Where("id IN ?", filter.AssignedTaskIDs)
+	}
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if filter.Deleted {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Priorities) > 0 {
+		query = query.Where("priority IN ?", filter.Priorities)
+	}
+	if filter.ParentID != nil {
+		if *filter.ParentID == 0 {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *filter.ParentID)
+		}
+	}
+	if filter.ProjectID != nil {
+		if *filter.ProjectID == 0 {
+			query = query.Where("project_id IS NULL")
+		} else {
+			query = query.Where("project_id = ?", *filter.ProjectID)
+		}
+	}
+	if filter.TagID != 0 {
+		query = query.Where("id IN (?)", r.db.Table("task_tags").Select("task_id").Where("tag_id = ?", filter.TagID))
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("due_date >= ?", *filter.DueAfter)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due_date < ?", *filter.DueBefore)
+	}
+	if filter.Overdue {
+		query = query.Where("due_date < ? AND status <> ?", time.Now(), model.TaskStatusDone)
+	}
+	if filter.Keyword != "" {
+		pattern := "%" + escapeLike(filter.Keyword) + "%"
+		query = query.Where("(title LIKE ? ESCAPE '!' OR description LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	return query
+}
+
+// GetChildren 获取直接子任务
+func (r *taskRepository) GetChildren(parentID int) ([]*model.Task, error) {
+	var tasks []*model.Task
+	err := r.db.Preload("Tags").Where("parent_id = ? AND deleted_at IS NULL", parentID).Order("id").Find(&tasks).Error
+	return tasks, err
+}
+
+// CountSubtasks 统计直接子任务的数量和已完成数量
+func (r *taskRepository) CountSubtasks(parentIDs []int) (map[int]model.SubtaskProgress, error) {
+	progress := make(map[int]model.SubtaskProgress)
+	if len(parentIDs) == 0 {
+		return progress, nil
+	}
+
+	var rows []struct {
+		ParentID int
+		Total    int
+		Done     int
+	}
+	err := r.db.Model(&model.Task{}).
+		Select("parent_id, COUNT(*) AS total, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS done", model.TaskStatusDone).
+		Where("parent_id IN ? AND deleted_at IS NULL", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		progress[row.ParentID] = model.SubtaskProgress{Total: row.Total, Done: row.Done}
+	}
+	return progress, nil
+}
+
+// DeleteByIDs 在同一事务中永久删除多个任务及其标签关联
+func (r *taskRepository) DeleteByIDs(taskIDs []int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_tags WHERE task_id IN ?", taskIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", taskIDs).Delete(&model.Task{}).Error
+	})
+}
+
+// SoftDeleteByIDs 将多个任务移入回收站，不修改更新时间
+func (r *taskRepository) SoftDeleteByIDs(taskIDs []int, deletedAt time.Time) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.Task{}).Where("id IN ?", taskIDs).UpdateColumn("deleted_at", deletedAt).Error
+}
+
+// Restore 将多个任务移出回收站
+func (r *taskRepository) Restore(taskIDs []int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.Task{}).Where("id IN ?", taskIDs).UpdateColumn("deleted_at", nil).Error
+}
+
+// GetDeletedByID 获取回收站中的任务
+func (r *taskRepository) GetDeletedByID(taskID int) (*model.Task, error) {
+	var task model.Task
+	err := r.db.Preload("Tags").Where("deleted_at IS NOT NULL").First(&task, taskID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetDeletedChildren 获取回收站中的直接子任务
+func (r *taskRepository) GetDeletedChildren(parentID int) ([]*model.Task, error) {
+	var tasks []*model.Task
+	err := r.db.Preload("Tags").Where("parent_id = ? AND deleted_at IS NOT NULL", parentID).Order("id").Find(&tasks).Error
+	return tasks, err
+}
+
+// ListDeletedBefore 获取删除时间早于 before 的任务ID
+func (r *taskRepository) ListDeletedBefore(before time.Time) ([]int, error) {
+	var ids []int
+	err := r.db.Model(&model.Task{}).Where("deleted_at < ?", before).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateProject 将多个任务移动到指定项目
+func (r *taskRepository) UpdateProject(taskIDs []int, projectID *int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.Task{}).Where("id IN ?", taskIDs).
+		Updates(map[string]interface{}{"project_id": projectID, "version": gorm.Expr("version + 1"), "updated_at": time.Now()}).Error
+}
+
+// ClearProject 将项目下的全部任务移出该项目
+func (r *taskRepository) ClearProject(projectID int) error {
+	return r.db.Model(&model.Task{}).Where("project_id = ?", projectID).
+		Updates(map[string]interface{}{"project_id": nil, "version": gorm.Expr("version + 1"), "updated_at": time.Now()}).Error
+}
+
+// escapeLike 转义 LIKE 通配符，配合 ESCAPE '!' 使用
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
 // UpdateStatus 更新任务状态
 func (r *taskRepository) UpdateStatus(id int, status bool) error {
 	return r.db.Model(&model.Task{}).Where("id = ?", id).Update("status", status).Error
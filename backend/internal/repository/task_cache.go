@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"todolist/internal/cache"
+	"todolist/internal/model"
+)
+
+// taskCacheGenerationKey 任务缓存的代数，缓存键包含代数，代数加1后之前的缓存全部失效。
+// 用于无法确定受影响任务的修改，例如清空项目、修改标签和事务
+const taskCacheGenerationKey = "task:generation"
+
+// cachedTaskRepository 缓存 GetByID 的任务仓库装饰器，其他查询直接读取数据库
+type cachedTaskRepository struct {
+	repo  TaskRepository
+	cache cache.Cache
+	ttl   time.Duration
+	stats *cache.Stats
+}
+
+// NewCachedTaskRepository 创建带缓存的任务仓库，stats 为 nil 时不导出命中统计
+func NewCachedTaskRepository(repo TaskRepository, c cache.Cache, ttl time.Duration, stats *cache.Stats) TaskRepository {
+	if stats == nil {
+		stats = &cache.Stats{}
+	}
+	return &cachedTaskRepository{repo: repo, cache: c, ttl: ttl, stats: stats}
+}
+
+// Transaction 在事务中执行 fn，结束后使全部任务缓存失效。
+// 事务中通过 WithTx 读写的仓库不经过缓存，无法逐个失效
+func (r *cachedTaskRepository) Transaction(fn func(tx Tx) error) error {
+	err := r.repo.Transaction(fn)
+	bumpTaskCacheGeneration(r.cache)
+	return err
+}
+
+// WithTx 返回在事务中读写的仓库，不经过缓存，避免读到事务外的数据或缓存未提交的数据
+func (r *cachedTaskRepository) WithTx(tx Tx) TaskRepository {
+	return r.repo.WithTx(tx)
+}
+
+// Create 创建任务，不缓存不存在的任务，无需失效
+func (r *cachedTaskRepository) Create(task *model.Task) error {
+	return r.repo.Create(task)
+}
+
+// Update 更新任务并使缓存失效
+func (r *cachedTaskRepository) Update(task *model.Task) error {
+	err := r.repo.Update(task)
+	r.invalidate(task.ID)
+	return err
+}
+
+// UpdateIfVersion 按版本号条件更新任务，更新成功时使缓存失效
+func (r *cachedTaskRepository) UpdateIfVersion(task *model.Task, version int) (bool, error) {
+	updated, err := r.repo.UpdateIfVersion(task, version)
+	if updated {
+		r.invalidate(task.ID)
+	}
+	return updated, err
+}
+
+// Delete 永久删除任务并使缓存失效
+func (r *cachedTaskRepository) Delete(taskID int) error {
+	err := r.repo.Delete(taskID)
+	r.invalidate(taskID)
+	return err
+}
+
+// GetByID 根据ID获取任务，优先读取缓存
+func (r *cachedTaskRepository) GetByID(taskID int) (*model.Task, error) {
+	generation, _, err := r.cache.Get(taskCacheGenerationKey)
+	if err != nil {
+		log.Printf("读取缓存失败: %v", err)
+		r.stats.Miss()
+		return r.repo.GetByID(taskID)
+	}
+	return cachedGet(r.cache, r.stats, r.ttl, taskCacheKey(generation, taskID), func() (*model.Task, error) {
+		return r.repo.GetByID(taskID)
+	})
+}
+
+// List 按条件查询任务列表
+func (r *cachedTaskRepository) List(filter model.TaskFilter) ([]*model.Task, int64, error) {
+	return r.repo.List(filter)
+}
+
+// ListVersions 按条件查询任务的ID和版本号
+func (r *cachedTaskRepository) ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error) {
+	return r.repo.ListVersions(filter)
+}
+
+// GetChildren 获取直接子任务
+func (r *cachedTaskRepository) GetChildren(parentID int) ([]*model.Task, error) {
+	return r.repo.GetChildren(parentID)
+}
+
+// CountSubtasks 统计直接子任务的数量和已完成数量
+func (r *cachedTaskRepository) CountSubtasks(parentIDs []int) (map[int]model.SubtaskProgress, error) {
+	return r.repo.CountSubtasks(parentIDs)
+}
+
+// DeleteByIDs 永久删除多个任务并使缓存失效
+func (r *cachedTaskRepository) DeleteByIDs(taskIDs []int) error {
+	err := r.repo.DeleteByIDs(taskIDs)
+	r.invalidate(taskIDs...)
+	return err
+}
+
+// SoftDeleteByIDs 将多个任务移入回收站并使缓存失效
+func (r *cachedTaskRepository) SoftDeleteByIDs(taskIDs []int, deletedAt time.Time) error {
+	err := r.repo.SoftDeleteByIDs(taskIDs, deletedAt)
+	r.invalidate(taskIDs...)
+	return err
+}
+
+// Restore 将多个任务移出回收站并使缓存失效
+func (r *cachedTaskRepository) Restore(taskIDs []int) error {
+	err := r.repo.Restore(taskIDs)
+	r.invalidate(taskIDs...)
+	return err
+}
+
+// GetDeletedByID 获取回收站中的任务
+func (r *cachedTaskRepository) GetDeletedByID(taskID int) (*model.Task, error) {
+	return r.repo.GetDeletedByID(taskID)
+}
+
+// GetDeletedChildren 获取回收站中的直接子任务
+func (r *cachedTaskRepository) GetDeletedChildren(parentID int) ([]*model.Task, error) {
+	return r.repo.GetDeletedChildren(parentID)
+}
+
+// ListDeletedBefore 获取删除时间早于 before 的任务ID
+func (r *cachedTaskRepository) ListDeletedBefore(before time.Time) ([]int, error) {
+	return r.repo.ListDeletedBefore(before)
+}
+
+// UpdateProject 将多个任务移动到指定项目并使缓存失效
+func (r *cachedTaskRepository) UpdateProject(taskIDs []int, projectID *int) error {
+	err := r.repo.UpdateProject(taskIDs, projectID)
+	r.invalidate(taskIDs...)
+	return err
+}
+
+// ClearProject 将项目下的全部任务移出该项目，受影响的任务未知，使全部任务缓存失效
+func (r *cachedTaskRepository) ClearProject(projectID int) error {
+	err := r.repo.ClearProject(projectID)
+	bumpTaskCacheGeneration(r.cache)
+	return err
+}
+
+// invalidate 使任务的缓存失效
+func (r *cachedTaskRepository) invalidate(taskIDs ...int) {
+	if len(taskIDs) == 0 {
+		return
+	}
+	generation, _, err := r.cache.Get(taskCacheGenerationKey)
+	if err != nil {
+		log.Printf("读取缓存失败: %v", err)
+		bumpTaskCacheGeneration(r.cache)
+		return
+	}
+	keys := make([]string, 0, len(taskIDs))
+	for _, id := range taskIDs {
+		keys = append(keys, taskCacheKey(generation, id))
+	}
+	invalidate(r.cache, keys...)
+}
+
+// taskCacheKey 返回任务在指定代数下的缓存键，代数未设置时为空字符串
+func taskCacheKey(generation string, taskID int) string {
+	if generation == "" {
+		generation = "0"
+	}
+	return fmt.Sprintf("task:%s:%d", generation, taskID)
+}
+
+// bumpTaskCacheGeneration 使全部任务缓存失效，旧的缓存在过期后删除
+func bumpTaskCacheGeneration(c cache.Cache) {
+	if _, err := c.Incr(taskCacheGenerationKey); err != nil {
+		log.Printf("使任务缓存失效失败: %v", err)
+	}
+}
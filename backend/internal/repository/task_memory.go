@@ -0,0 +1,523 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// memoryTaskRepository 基于内存的任务仓库实现，主要用于测试
+type memoryTaskRepository struct {
+	mu     sync.RWMutex
+	tasks  map[int]*model.Task
+	nextID int
+}
+
+// NewMemoryTaskRepository 创建内存任务仓库实例
+func NewMemoryTaskRepository() TaskRepository {
+	return &memoryTaskRepository{
+		tasks:  make(map[int]*model.Task),
+		nextID: 1,
+	}
+}
+
+// Transaction 执行 fn，fn 返回错误时将任务恢复到执行前的状态。
+// 只能回滚本仓库中的任务，也不隔离 fn 执行期间的并发修改
+func (r *memoryTaskRepository) Transaction(fn func(tx Tx) error) error {
+	r.mu.RLock()
+	snapshot := make(map[int]*model.Task, len(r.tasks))
+	for id, task := range r.tasks {
+		snapshot[id] = cloneTask(task)
+	}
+	nextID := r.nextID
+	r.mu.RUnlock()
+
+	if err := fn(Tx{}); err != nil {
+		r.mu.Lock()
+		r.tasks = snapshot
+		r.nextID = nextID
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryTaskRepository) WithTx(tx Tx) TaskRepository {
+	return r
+}
+
+// Create 创建任务
+func (r *memoryTaskRepository) Create(task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task.ID == 0 {
+		task.ID = r.nextID
+	}
+	if task.ID >= r.nextID {
+		r.nextID = task.ID + 1
+	}
+	if task.Version == 0 {
+		task.Version = 1
+	}
+
+	now := time.Now()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = now
+	}
+
+	r.tasks[task.ID] = cloneTask(task)
+	return nil
+}
+
+// Update 更新任务，与 gorm 的 Save 一致，不存在时插入
+func (r *memoryTaskRepository) Update(task *model.Task) error {
+	if task.ID == 0 {
+		return r.Create(task)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task.UpdatedAt = time.Now()
+	task.Version++
+	if task.ID >= r.nextID {
+		r.nextID = task.ID + 1
+	}
+	r.tasks[task.ID] = cloneTask(task)
+	return nil
+}
+
+// UpdateIfVersion 按版本号条件更新任务
+func (r *memoryTaskRepository) UpdateIfVersion(task *model.Task, version int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[task.ID]
+	if !ok || stored.DeletedAt != nil || stored.Version != version {
+		return false, nil
+	}
+	task.Version = version + 1
+	task.UpdatedAt = time.Now()
+	clone := cloneTask(task)
+	clone.CreatedAt = stored.CreatedAt
+	r.tasks[task.ID] = clone
+	return true, nil
+}
+
+// Delete 删除任务
+func (r *memoryTaskRepository) Delete(taskID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tasks, taskID)
+	return nil
+}
+
+// GetByID 根据ID获取任务
+func (r *memoryTaskRepository) GetByID(taskID int) (*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[taskID]
+	if !ok || task.DeletedAt != nil {
+		return nil, nil
+	}
+	return cloneTask(task), nil
+}
+
+// List 按条件查询任务列表
+func (r *memoryTaskRepository) List(filter model.TaskFilter) ([]*model.Task, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := r.match(filter)
+	sort.Slice(matched, func(i, j int) bool {
+		return lessTask(matched[i], matched[j], filter.Sort)
+	})
+
+	total := int64(len(matched))
+	tasks := []*model.Task{}
+	for _, task := range paginate(matched, filter.Page, filter.PageSize) {
+		tasks = append(tasks, cloneTask(task))
+	}
+	return tasks, total, nil
+}
+
+// ListVersions 按条件查询任务的ID和版本号
+func (r *memoryTaskRepository) ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := []model.TaskVersion{}
+	for _, task := range r.match(filter) {
+		versions = append(versions, model.TaskVersion{ID: task.ID, Version: task.Version})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID < versions[j].ID
+	})
+	return versions, nil
+}
+
+// match 返回符合过滤条件的任务，不排序，调用方需要持有读锁
+func (r *memoryTaskRepository) match(filter model.TaskFilter) []*model.Task {
+	now := time.Now()
+	keyword := strings.ToLower(filter.Keyword)
+
+	var matched []*model.Task
+	for _, task := range r.tasks {
+		if (task.DeletedAt != nil) != filter.Deleted {
+			continue
+		}
+		if !inTaskScope(task, filter) {
+			continue
+		}
+		if filter.AssigneeID != 0 && !slices.Contains(filter.AssignedTaskIDs, task.ID) {
+			continue
+		}
+		if len(filter.IDs) > 0 && !slices.Contains(filter.IDs, task.ID) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, task.Status) {
+			continue
+		}
+		if len(filter.Priorities) > 0 && !slices.Contains(filter.Priorities, task.Priority) {
+			continue
+		}
+		if filter.ParentID != nil && parentIDOf(task) != *filter.ParentID {
+			continue
+		}
+		if filter.ProjectID != nil && projectIDOf(task) != *filter.ProjectID {
+			continue
+		}
+		if filter.TagID != 0 && !hasTag(task, filter.TagID) {
+			continue
+		}
+		if filter.DueAfter != nil && (task.DueDate == nil || task.DueDate.Before(*filter.DueAfter)) {
+			continue
+		}
+		if filter.DueBefore != nil && (task.DueDate == nil || !task.DueDate.Before(*filter.DueBefore)) {
+			continue
+		}
+		if filter.Overdue && (task.DueDate == nil || !task.DueDate.Before(now) || task.Status == model.TaskStatusDone) {
+			continue
+		}
+		if keyword != "" &&
+			!strings.Contains(strings.ToLower(task.Title), keyword) &&
+			!strings.Contains(strings.ToLower(task.Description), keyword) {
+			continue
+		}
+		matched = append(matched, task)
+	}
+	return matched
+}
+
+// GetChildren 获取直接子任务
+func (r *memoryTaskRepository) GetChildren(parentID int) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []*model.Task{}
+	for _, task := range r.tasks {
+		if parentIDOf(task) == parentID && task.DeletedAt == nil {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+// CountSubtasks 统计直接子任务的数量和已完成数量
+func (r *memoryTaskRepository) CountSubtasks(parentIDs []int) (map[int]model.SubtaskProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	progress := make(map[int]model.SubtaskProgress)
+	for _, task := range r.tasks {
+		parentID := parentIDOf(task)
+		if parentID == 0 || task.DeletedAt != nil || !slices.Contains(parentIDs, parentID) {
+			continue
+		}
+		p := progress[parentID]
+		p.Total++
+		if task.Status == model.TaskStatusDone {
+			p.Done++
+		}
+		progress[parentID] = p
+	}
+	return progress, nil
+}
+
+// DeleteByIDs 删除多个任务
+func (r *memoryTaskRepository) DeleteByIDs(taskIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range taskIDs {
+		delete(r.tasks, id)
+	}
+	return nil
+}
+
+// SoftDeleteByIDs 将多个任务移入回收站
+func (r *memoryTaskRepository) SoftDeleteByIDs(taskIDs []int, deletedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range taskIDs {
+		if task, ok := r.tasks[id]; ok {
+			t := deletedAt
+			task.DeletedAt = &t
+		}
+	}
+	return nil
+}
+
+// Restore 将多个任务移出回收站
+func (r *memoryTaskRepository) Restore(taskIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range taskIDs {
+		if task, ok := r.tasks[id]; ok {
+			task.DeletedAt = nil
+		}
+	}
+	return nil
+}
+
+// GetDeletedByID 获取回收站中的任务
+func (r *memoryTaskRepository) GetDeletedByID(taskID int) (*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[taskID]
+	if !ok || task.DeletedAt == nil {
+		return nil, nil
+	}
+	return cloneTask(task), nil
+}
+
+// GetDeletedChildren 获取回收站中的直接子任务
+func (r *memoryTaskRepository) GetDeletedChildren(parentID int) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []*model.Task{}
+	for _, task := range r.tasks {
+		if parentIDOf(task) == parentID && task.DeletedAt != nil {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+// ListDeletedBefore 获取删除时间早于 before 的任务ID
+func (r *memoryTaskRepository) ListDeletedBefore(before time.Time) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := []int{}
+	for _, task := range r.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(before) {
+			ids = append(ids, task.ID)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// UpdateProject 将多个任务移动到指定项目
+func (r *memoryTaskRepository) UpdateProject(taskIDs []int, projectID *int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range taskIDs {
+		if task, ok := r.tasks[id]; ok {
+			task.ProjectID = cloneIntPtr(projectID)
+			task.Version++
+			task.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// ClearProject 将项目下的全部任务移出该项目
+func (r *memoryTaskRepository) ClearProject(projectID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, task := range r.tasks {
+		if projectIDOf(task) == projectID {
+			task.ProjectID = nil
+			task.Version++
+			task.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// lessTask 按排序条件比较两个任务，相同时按ID升序
+// 与数据库一致，升序时没有截止日期的任务排在最前
+func lessTask(a, b *model.Task, sorts []model.TaskSort) bool {
+	for _, s := range sorts {
+		c := compareTaskField(a, b, s.Field)
+		if c == 0 {
+			continue
+		}
+		if s.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return a.ID < b.ID
+}
+
+// compareTaskField 比较任务的单个字段，返回 -1、0 或 1
+func compareTaskField(a, b *model.Task, field string) int {
+	switch field {
+	case model.TaskSortTitle:
+		return strings.Compare(a.Title, b.Title)
+	case model.TaskSortStatus:
+		return cmp.Compare(a.Status, b.Status)
+	case model.TaskSortPriority:
+		return cmp.Compare(a.Priority, b.Priority)
+	case model.TaskSortDueDate:
+		switch {
+		case a.DueDate == nil && b.DueDate == nil:
+			return 0
+		case a.DueDate == nil:
+			return -1
+		case b.DueDate == nil:
+			return 1
+		}
+		return a.DueDate.Compare(*b.DueDate)
+	case model.TaskSortCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case model.TaskSortUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case model.TaskSortDeletedAt:
+		switch {
+		case a.DeletedAt == nil && b.DeletedAt == nil:
+			return 0
+		case a.DeletedAt == nil:
+			return -1
+		case b.DeletedAt == nil:
+			return 1
+		}
+		return a.DeletedAt.Compare(*b.DeletedAt)
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+// cloneTask 复制任务，避免调用方修改仓库内部数据
+func cloneTask(task *model.Task) *model.Task {
+	clone := *task
+	if task.DueDate != nil {
+		dueDate := *task.DueDate
+		clone.DueDate = &dueDate
+	}
+	clone.WorkspaceID = cloneIntPtr(task.WorkspaceID)
+	clone.ParentID = cloneIntPtr(task.ParentID)
+	clone.ProjectID = cloneIntPtr(task.ProjectID)
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		clone.Recurrence = &recurrence
+	}
+	if task.DeletedAt != nil {
+		deletedAt := *task.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	clone.Tags = append([]model.Tag{}, task.Tags...)
+	clone.Subtasks = nil
+	clone.Role = ""
+	clone.NextOccurrence = nil
+	return &clone
+}
+
+// inTaskScope 判断任务是否在查询范围内：AllScopes 时不限定范围，指定工作区时为工作区的全部任务，
+// 否则为用户的个人任务和共享给用户的任务
+func inTaskScope(task *model.Task, filter model.TaskFilter) bool {
+	if filter.AllScopes && filter.AssigneeID != 0 {
+		return true
+	}
+	if filter.WorkspaceID != 0 {
+		return task.WorkspaceID != nil && *task.WorkspaceID == filter.WorkspaceID
+	}
+	if task.UserID == filter.UserID && task.WorkspaceID == nil {
+		return true
+	}
+	return slices.Contains(filter.SharedTaskIDs, task.ID)
+}
+
+// parentIDOf 返回任务的父任务ID，顶层任务返回0
+func parentIDOf(task *model.Task) int {
+	if task.ParentID == nil {
+		return 0
+	}
+	return *task.ParentID
+}
+
+// projectIDOf 返回任务所属项目ID，未归入项目返回0
+func projectIDOf(task *model.Task) int {
+	if task.ProjectID == nil {
+		return 0
+	}
+	return *task.ProjectID
+}
+
+// cloneIntPtr 复制整数指针
+func cloneIntPtr(p *int) *int {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// hasTag 判断任务是否关联了指定标签
+func hasTag(task *model.Task, tagID int) bool {
+	for _, tag := range task.Tags {
+		if tag.ID == tagID {
+			return true
+		}
+	}
+	return false
+}
+
+// paginate 按页码截取切片，页码从1开始
+// 与 gorm 的 Limit 一致：pageSize 为0时返回空，小于0时不限制数量
+func paginate[T any](items []T, page, pageSize int) []T {
+	if pageSize == 0 {
+		return nil
+	}
+	offset := (page - 1) * pageSize
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return nil
+	}
+	end := offset + pageSize
+	if pageSize < 0 || end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
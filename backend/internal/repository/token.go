@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"todolist/internal/model"
+)
+
+// TokenRepository 令牌仓储接口，管理刷新令牌和访问令牌吊销列表
+type TokenRepository interface {
+	// CreateRefreshToken 保存刷新令牌
+	CreateRefreshToken(token *model.RefreshToken) error
+	// GetRefreshTokenByHash 根据哈希获取刷新令牌
+	GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error)
+	// GetRefreshTokensByFamily 获取同一族的全部刷新令牌
+	GetRefreshTokensByFamily(familyID string) ([]*model.RefreshToken, error)
+	// RevokeRefreshToken 吊销单个刷新令牌，令牌已被吊销时返回 false
+	RevokeRefreshToken(id int, revokedAt time.Time) (bool, error)
+	// RevokeRefreshTokenFamily 吊销同一族中尚未吊销的刷新令牌
+	RevokeRefreshTokenFamily(familyID string, revokedAt time.Time) error
+	// GetRefreshTokensByUser 获取用户的全部刷新令牌
+	GetRefreshTokensByUser(userID int) ([]*model.RefreshToken, error)
+	// RevokeUserRefreshTokens 吊销用户尚未吊销的全部刷新令牌
+	RevokeUserRefreshTokens(userID int, revokedAt time.Time) error
+	// RevokeAccessToken 将访问令牌加入吊销列表
+	RevokeAccessToken(token *model.RevokedToken) error
+	// IsAccessTokenRevoked 判断访问令牌是否已吊销
+	IsAccessTokenRevoked(tokenID string) (bool, error)
+	// DeleteExpired 清理已过期的刷新令牌和吊销记录
+	DeleteExpired(before time.Time) error
+}
+
+// tokenRepository 令牌仓储实现
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenRepository 创建令牌仓储实例
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+// CreateRefreshToken 保存刷新令牌
+func (r *tokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetRefreshTokenByHash 根据哈希获取刷新令牌
+func (r *tokenRepository) GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetRefreshTokensByFamily 获取同一族的全部刷新令牌
+func (r *tokenRepository) GetRefreshTokensByFamily(familyID string) ([]*model.RefreshToken, error) {
+	var tokens []*model.RefreshToken
+	err := r.db.Where("family_id = ?", familyID).Order("id").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeRefreshToken 吊销单个刷新令牌
+// 使用条件更新保证并发刷新时只有一个请求能完成轮换
+func (r *tokenRepository) RevokeRefreshToken(id int, revokedAt time.Time) (bool, error) {
+	result := r.db.Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeRefreshTokenFamily 吊销同一族中尚未吊销的刷新令牌
+func (r *tokenRepository) RevokeRefreshTokenFamily(familyID string, revokedAt time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// GetRefreshTokensByUser 获取用户的全部刷新令牌
+func (r *tokenRepository) GetRefreshTokensByUser(userID int) ([]*model.RefreshToken, error) {
+	var tokens []*model.RefreshToken
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeUserRefreshTokens 吊销用户尚未吊销的全部刷新令牌
+func (r *tokenRepository) RevokeUserRefreshTokens(userID int, revokedAt time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeAccessToken 将访问令牌加入吊销列表，重复吊销不报错
+func (r *tokenRepository) RevokeAccessToken(token *model.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// IsAccessTokenRevoked 判断访问令牌是否已吊销
+func (r *tokenRepository) IsAccessTokenRevoked(tokenID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteExpired 清理已过期的刷新令牌和吊销记录
+func (r *tokenRepository) DeleteExpired(before time.Time) error {
+	if err := r.db.Where("expires_at < ?", before).Delete(&model.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("expires_at < ?", before).Delete(&model.RevokedToken{}).Error
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// ErrDuplicateToken 令牌哈希已存在，对应数据库中的唯一索引冲突
+var ErrDuplicateToken = errors.New("令牌已存在")
+
+// memoryTokenRepository 基于内存的令牌仓储实现，主要用于测试
+type memoryTokenRepository struct {
+	mu            sync.RWMutex
+	refreshTokens map[int]*model.RefreshToken
+	revokedTokens map[string]*model.RevokedToken
+	nextID        int
+}
+
+// NewMemoryTokenRepository 创建内存令牌仓储实例
+func NewMemoryTokenRepository() TokenRepository {
+	return &memoryTokenRepository{
+		refreshTokens: make(map[int]*model.RefreshToken),
+		revokedTokens: make(map[string]*model.RevokedToken),
+		nextID:        1,
+	}
+}
+
+// CreateRefreshToken 保存刷新令牌
+func (r *memoryTokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.refreshTokens {
+		if existing.TokenHash == token.TokenHash {
+			return ErrDuplicateToken
+		}
+	}
+
+	token.ID = r.nextID
+	r.nextID++
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.refreshTokens[token.ID] = cloneRefreshToken(token)
+	return nil
+}
+
+// GetRefreshTokenByHash 根据哈希获取刷新令牌
+func (r *memoryTokenRepository) GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return cloneRefreshToken(token), nil
+		}
+	}
+	return nil, nil
+}
+
+// GetRefreshTokensByFamily 获取同一族的全部刷新令牌
+func (r *memoryTokenRepository) GetRefreshTokensByFamily(familyID string) ([]*model.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*model.RefreshToken
+	for _, token := range r.refreshTokens {
+		if token.FamilyID == familyID {
+			tokens = append(tokens, cloneRefreshToken(token))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// RevokeRefreshToken 吊销单个刷新令牌
+func (r *memoryTokenRepository) RevokeRefreshToken(id int, revokedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refreshTokens[id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.RevokedAt = &revokedAt
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily 吊销同一族中尚未吊销的刷新令牌
+func (r *memoryTokenRepository) RevokeRefreshTokenFamily(familyID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			revoked := revokedAt
+			token.RevokedAt = &revoked
+		}
+	}
+	return nil
+}
+
+// GetRefreshTokensByUser 获取用户的全部刷新令牌
+func (r *memoryTokenRepository) GetRefreshTokensByUser(userID int) ([]*model.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*model.RefreshToken
+	for _, token := range r.refreshTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, cloneRefreshToken(token))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// RevokeUserRefreshTokens 吊销用户尚未吊销的全部刷新令牌
+func (r *memoryTokenRepository) RevokeUserRefreshTokens(userID int, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			revoked := revokedAt
+			token.RevokedAt = &revoked
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken 将访问令牌加入吊销列表
+func (r *memoryTokenRepository) RevokeAccessToken(token *model.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.revokedTokens[token.TokenID]; ok {
+		return nil
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	clone := *token
+	r.revokedTokens[token.TokenID] = &clone
+	return nil
+}
+
+// IsAccessTokenRevoked 判断访问令牌是否已吊销
+func (r *memoryTokenRepository) IsAccessTokenRevoked(tokenID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.revokedTokens[tokenID]
+	return ok, nil
+}
+
+// DeleteExpired 清理已过期的刷新令牌和吊销记录
+func (r *memoryTokenRepository) DeleteExpired(before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.refreshTokens {
+		if token.ExpiresAt.Before(before) {
+			delete(r.refreshTokens, id)
+		}
+	}
+	for id, token := range r.revokedTokens {
+		if token.ExpiresAt.Before(before) {
+			delete(r.revokedTokens, id)
+		}
+	}
+	return nil
+}
+
+// cloneRefreshToken 复制刷新令牌
+func cloneRefreshToken(token *model.RefreshToken) *model.RefreshToken {
+	clone := *token
+	if token.RevokedAt != nil {
+		revokedAt := *token.RevokedAt
+		clone.RevokedAt = &revokedAt
+	}
+	return &clone
+}
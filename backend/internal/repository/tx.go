@@ -0,0 +1,17 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Tx 数据库事务，由 TaskRepository.Transaction 创建，通过各仓库的 WithTx 方法在事务中读写。
+// 内存实现没有真正的事务，Tx 为零值，WithTx 返回仓库本身
+type Tx struct {
+	db *gorm.DB
+}
+
+// bind 返回事务的连接，零值事务返回 db
+func (tx Tx) bind(db *gorm.DB) *gorm.DB {
+	if tx.db == nil {
+		return db
+	}
+	return tx.db
+}
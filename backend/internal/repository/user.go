@@ -11,8 +11,13 @@ import (
 // UserRepository 用户仓储接口
 type UserRepository interface {
 	Create(user *model.User) error
+	// GetByID 根据ID获取用户，经过缓存时不含密码哈希
 	GetByID(id int) (*model.User, error)
+	// GetCredentials 根据ID获取包含密码哈希的用户，不经过缓存，用于验证和修改密码
+	GetCredentials(id int) (*model.User, error)
+	// GetByUsername 根据用户名获取包含密码哈希的用户，不经过缓存
 	GetByUsername(username string) (*model.User, error)
+	// Update 保存用户的全部字段，user 应通过 GetCredentials 或 GetByUsername 获取
 	Update(user *model.User) error
 	Delete(id int) error
 }
@@ -44,6 +49,11 @@ func (r *userRepository) GetByID(id int) (*model.User, error) {
 	return &user, nil
 }
 
+// GetCredentials 根据ID获取包含密码哈希的用户
+func (r *userRepository) GetCredentials(id int) (*model.User, error) {
+	return r.GetByID(id)
+}
+
 // GetByUsername 根据用户名获取用户
 func (r *userRepository) GetByUsername(username string) (*model.User, error) {
 	var user model.User
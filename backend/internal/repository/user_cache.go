@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"todolist/internal/cache"
+	"todolist/internal/model"
+)
+
+// cachedUserRepository 缓存 GetByID 的用户仓储装饰器
+type cachedUserRepository struct {
+	repo  UserRepository
+	cache cache.Cache
+	ttl   time.Duration
+	stats *cache.Stats
+}
+
+// NewCachedUserRepository 创建带缓存的用户仓储，stats 为 nil 时不导出命中统计
+func NewCachedUserRepository(repo UserRepository, c cache.Cache, ttl time.Duration, stats *cache.Stats) UserRepository {
+	if stats == nil {
+		stats = &cache.Stats{}
+	}
+	return &cachedUserRepository{repo: repo, cache: c, ttl: ttl, stats: stats}
+}
+
+// Create 创建用户
+func (r *cachedUserRepository) Create(user *model.User) error {
+	return r.repo.Create(user)
+}
+
+// cachedUser 缓存中的用户，不包含密码哈希
+type cachedUser struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetByID 根据ID获取用户，优先读取缓存，返回的用户不含密码哈希
+func (r *cachedUserRepository) GetByID(id int) (*model.User, error) {
+	cached, err := cachedGet(r.cache, r.stats, r.ttl, userCacheKey(id), func() (*cachedUser, error) {
+		user, err := r.repo.GetByID(id)
+		if err != nil || user == nil {
+			return nil, err
+		}
+		return &cachedUser{ID: user.ID, Username: user.Username, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt}, nil
+	})
+	if err != nil || cached == nil {
+		return nil, err
+	}
+	return &model.User{ID: cached.ID, Username: cached.Username, CreatedAt: cached.CreatedAt, UpdatedAt: cached.UpdatedAt}, nil
+}
+
+// GetCredentials 根据ID获取包含密码哈希的用户，不经过缓存
+func (r *cachedUserRepository) GetCredentials(id int) (*model.User, error) {
+	return r.repo.GetCredentials(id)
+}
+
+// GetByUsername 根据用户名获取用户，登录时使用，不经过缓存
+func (r *cachedUserRepository) GetByUsername(username string) (*model.User, error) {
+	return r.repo.GetByUsername(username)
+}
+
+// Update 更新用户信息并使缓存失效
+func (r *cachedUserRepository) Update(user *model.User) error {
+	err := r.repo.Update(user)
+	invalidate(r.cache, userCacheKey(user.ID))
+	return err
+}
+
+// Delete 删除用户并使缓存失效
+func (r *cachedUserRepository) Delete(id int) error {
+	err := r.repo.Delete(id)
+	invalidate(r.cache, userCacheKey(id))
+	return err
+}
+
+// userCacheKey 返回用户的缓存键
+func userCacheKey(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// ErrDuplicateUsername 用户名已存在，对应数据库中的唯一索引冲突
+var ErrDuplicateUsername = errors.New("用户名已存在")
+
+// memoryUserRepository 基于内存的用户仓储实现，主要用于测试
+type memoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[int]*model.User
+	nextID int
+}
+
+// NewMemoryUserRepository 创建内存用户仓储实例
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{
+		users:  make(map[int]*model.User),
+		nextID: 1,
+	}
+}
+
+// Create 创建用户
+func (r *memoryUserRepository) Create(user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.usernameTaken(user.Username, 0) {
+		return ErrDuplicateUsername
+	}
+
+	if user.ID == 0 {
+		user.ID = r.nextID
+	}
+	if user.ID >= r.nextID {
+		r.nextID = user.ID + 1
+	}
+
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+// GetByID 根据ID获取用户
+func (r *memoryUserRepository) GetByID(id int) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	clone := *user
+	return &clone, nil
+}
+
+// GetCredentials 根据ID获取包含密码哈希的用户
+func (r *memoryUserRepository) GetCredentials(id int) (*model.User, error) {
+	return r.GetByID(id)
+}
+
+// GetByUsername 根据用户名获取用户
+func (r *memoryUserRepository) GetByUsername(username string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			clone := *user
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// Update 更新用户信息，与 gorm 的 Save 一致，不存在时插入
+func (r *memoryUserRepository) Update(user *model.User) error {
+	if user.ID == 0 {
+		return r.Create(user)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.usernameTaken(user.Username, user.ID) {
+		return ErrDuplicateUsername
+	}
+
+	user.UpdatedAt = time.Now()
+	if user.ID >= r.nextID {
+		r.nextID = user.ID + 1
+	}
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+// Delete 删除用户
+func (r *memoryUserRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	return nil
+}
+
+// usernameTaken 判断用户名是否已被其他用户使用，调用方需持有锁
+func (r *memoryUserRepository) usernameTaken(username string, exceptID int) bool {
+	for id, user := range r.users {
+		if id != exceptID && user.Username == username {
+			return true
+		}
+	}
+	return false
+}
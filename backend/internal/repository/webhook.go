@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"todolist/internal/model"
+)
+
+// WebhookRepository Webhook 仓库接口，包括投递记录
+type WebhookRepository interface {
+	// Create 创建 Webhook
+	Create(webhook *model.Webhook) error
+	// Update 更新 Webhook
+	Update(webhook *model.Webhook) error
+	// Delete 删除 Webhook 及其投递记录
+	Delete(webhookID int) error
+	// GetByID 根据ID获取 Webhook，不存在时返回 nil
+	GetByID(webhookID int) (*model.Webhook, error)
+	// ListByUserID 获取用户的全部 Webhook，按ID排序
+	ListByUserID(userID int) ([]*model.Webhook, error)
+	// ListActiveByUserIDs 获取多个用户已启用的 Webhook，按ID排序
+	ListActiveByUserIDs(userIDs []int) ([]*model.Webhook, error)
+
+	// CreateDelivery 创建投递记录
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	// UpdateDelivery 保存投递结果
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	// ListDeliveries 分页获取 Webhook 的投递记录，最新的排在前面
+	ListDeliveries(webhookID, page, pageSize int) ([]*model.WebhookDelivery, int64, error)
+	// ClaimDueDeliveries 领取 next_attempt_at 不晚于 now 的待投递记录，最多 limit 条，
+	// 领取时将 next_attempt_at 推迟到 leaseUntil，多个实例不会同时投递同一条记录，投递中断的记录到期后重新领取
+	ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+}
+
+// webhookRepository Webhook 仓库实现
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建 Webhook 仓库实例
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// Create 创建 Webhook
+func (r *webhookRepository) Create(webhook *model.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// Update 更新 Webhook
+func (r *webhookRepository) Update(webhook *model.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// Delete 在同一事务中删除 Webhook 及其投递记录
+func (r *webhookRepository) Delete(webhookID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhookID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Webhook{}, webhookID).Error
+	})
+}
+
+// GetByID 根据ID获取 Webhook
+func (r *webhookRepository) GetByID(webhookID int) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := r.db.First(&webhook, webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListByUserID 获取用户的全部 Webhook
+func (r *webhookRepository) ListByUserID(userID int) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// ListActiveByUserIDs 获取多个用户已启用的 Webhook
+func (r *webhookRepository) ListActiveByUserIDs(userIDs []int) ([]*model.Webhook, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	var webhooks []*model.Webhook
+	err := r.db.Where("user_id IN ? AND active = ?", userIDs, true).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// CreateDelivery 创建投递记录
+func (r *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// UpdateDelivery 保存投递结果
+func (r *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+// ListDeliveries 分页获取 Webhook 的投递记录
+func (r *webhookRepository) ListDeliveries(webhookID, page, pageSize int) ([]*model.WebhookDelivery, int64, error) {
+	var deliveries []*model.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&model.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// ClaimDueDeliveries 先查询到期的记录，再逐条按条件推迟 next_attempt_at，只返回更新成功的记录
+func (r *webhookRepository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var due []*model.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		Order("next_attempt_at").Order("id").Limit(limit).Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]*model.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		result := r.db.Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, model.WebhookDeliveryPending, now).
+			Update("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		// 已被其他实例领取
+		if result.RowsAffected == 0 {
+			continue
+		}
+		delivery.NextAttemptAt = &leaseUntil
+		claimed = append(claimed, delivery)
+	}
+	return claimed, nil
+}
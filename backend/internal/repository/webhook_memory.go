@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// memoryWebhookRepository 基于内存的 Webhook 仓库实现，主要用于测试
+type memoryWebhookRepository struct {
+	mu             sync.RWMutex
+	webhooks       map[int]*model.Webhook
+	deliveries     map[int]*model.WebhookDelivery
+	nextID         int
+	nextDeliveryID int
+}
+
+// NewMemoryWebhookRepository 创建内存 Webhook 仓库实例
+func NewMemoryWebhookRepository() WebhookRepository {
+	return &memoryWebhookRepository{
+		webhooks:       make(map[int]*model.Webhook),
+		deliveries:     make(map[int]*model.WebhookDelivery),
+		nextID:         1,
+		nextDeliveryID: 1,
+	}
+}
+
+// Create 创建 Webhook
+func (r *memoryWebhookRepository) Create(webhook *model.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook.ID = r.nextID
+	r.nextID++
+	now := time.Now()
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = now
+	}
+	if webhook.UpdatedAt.IsZero() {
+		webhook.UpdatedAt = now
+	}
+	r.webhooks[webhook.ID] = cloneWebhook(webhook)
+	return nil
+}
+
+// Update 更新 Webhook
+func (r *memoryWebhookRepository) Update(webhook *model.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook.UpdatedAt = time.Now()
+	r.webhooks[webhook.ID] = cloneWebhook(webhook)
+	return nil
+}
+
+// Delete 删除 Webhook 及其投递记录
+func (r *memoryWebhookRepository) Delete(webhookID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.webhooks, webhookID)
+	for id, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			delete(r.deliveries, id)
+		}
+	}
+	return nil
+}
+
+// GetByID 根据ID获取 Webhook
+func (r *memoryWebhookRepository) GetByID(webhookID int) (*model.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhook, ok := r.webhooks[webhookID]
+	if !ok {
+		return nil, nil
+	}
+	return cloneWebhook(webhook), nil
+}
+
+// ListByUserID 获取用户的全部 Webhook
+func (r *memoryWebhookRepository) ListByUserID(userID int) ([]*model.Webhook, error) {
+	return r.list(func(webhook *model.Webhook) bool {
+		return webhook.UserID == userID
+	}), nil
+}
+
+// ListActiveByUserIDs 获取多个用户已启用的 Webhook
+func (r *memoryWebhookRepository) ListActiveByUserIDs(userIDs []int) ([]*model.Webhook, error) {
+	return r.list(func(webhook *model.Webhook) bool {
+		return webhook.Active && slices.Contains(userIDs, webhook.UserID)
+	}), nil
+}
+
+// list 按ID顺序返回满足条件的 Webhook
+func (r *memoryWebhookRepository) list(match func(webhook *model.Webhook) bool) []*model.Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var webhooks []*model.Webhook
+	for _, webhook := range r.webhooks {
+		if match(webhook) {
+			webhooks = append(webhooks, cloneWebhook(webhook))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+	return webhooks
+}
+
+// CreateDelivery 创建投递记录
+func (r *memoryWebhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery.ID = r.nextDeliveryID
+	r.nextDeliveryID++
+	now := time.Now()
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = now
+	}
+	if delivery.UpdatedAt.IsZero() {
+		delivery.UpdatedAt = now
+	}
+	r.deliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+	return nil
+}
+
+// UpdateDelivery 保存投递结果
+func (r *memoryWebhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery.UpdatedAt = time.Now()
+	r.deliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+	return nil
+}
+
+// ListDeliveries 分页获取 Webhook 的投递记录
+func (r *memoryWebhookRepository) ListDeliveries(webhookID, page, pageSize int) ([]*model.WebhookDelivery, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*model.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			matched = append(matched, delivery)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := int64(len(matched))
+	matched = paginate(matched, page, pageSize)
+
+	deliveries := make([]*model.WebhookDelivery, 0, len(matched))
+	for _, delivery := range matched {
+		deliveries = append(deliveries, cloneWebhookDelivery(delivery))
+	}
+	return deliveries, total, nil
+}
+
+// ClaimDueDeliveries 领取到期的待投递记录
+func (r *memoryWebhookRepository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*model.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == model.WebhookDeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(*due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*model.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		lease := leaseUntil
+		delivery.NextAttemptAt = &lease
+		claimed = append(claimed, cloneWebhookDelivery(delivery))
+	}
+	return claimed, nil
+}
+
+// cloneWebhook 复制 Webhook，避免调用方修改仓库中的数据
+func cloneWebhook(webhook *model.Webhook) *model.Webhook {
+	clone := *webhook
+	clone.Events = slices.Clone(webhook.Events)
+	return &clone
+}
+
+// cloneWebhookDelivery 复制投递记录，避免调用方修改仓库中的数据
+func cloneWebhookDelivery(delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	clone := *delivery
+	if delivery.NextAttemptAt != nil {
+		next := *delivery.NextAttemptAt
+		clone.NextAttemptAt = &next
+	}
+	if delivery.DeliveredAt != nil {
+		delivered := *delivery.DeliveredAt
+		clone.DeliveredAt = &delivered
+	}
+	return &clone
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"todolist/internal/model"
+)
+
+// WorkspaceRepository 工作区仓库接口，包括成员和邀请
+type WorkspaceRepository interface {
+	// Create 创建工作区，并在同一事务中将 owner 加入成员
+	Create(workspace *model.Workspace, owner *model.WorkspaceMember) error
+	// Update 更新工作区
+	Update(workspace *model.Workspace) error
+	// GetByID 根据ID获取工作区
+	GetByID(workspaceID int) (*model.Workspace, error)
+	// GetByUserID 获取用户加入的全部工作区，按ID排序
+	GetByUserID(userID int) ([]*model.Workspace, error)
+
+	// SaveMember 添加成员，已存在时更新角色
+	SaveMember(member *model.WorkspaceMember) error
+	// RemoveMember 移除成员
+	RemoveMember(workspaceID, userID int) error
+	// GetMember 获取成员，不是成员时返回 nil
+	GetMember(workspaceID, userID int) (*model.WorkspaceMember, error)
+	// GetMembers 获取工作区的全部成员，按加入顺序排序
+	GetMembers(workspaceID int) ([]*model.WorkspaceMember, error)
+
+	// CreateInvitation 创建邀请
+	CreateInvitation(invitation *model.WorkspaceInvitation) error
+	// GetInvitationByTokenHash 根据令牌哈希获取邀请
+	GetInvitationByTokenHash(tokenHash string) (*model.WorkspaceInvitation, error)
+	// AcceptInvitation 在同一事务中将邀请标记为已接受并加入成员，邀请已被接受时返回 false
+	AcceptInvitation(invitation *model.WorkspaceInvitation, member *model.WorkspaceMember) (bool, error)
+	// WithTx 返回在事务 tx 中读写的仓库
+	WithTx(tx Tx) WorkspaceRepository
+}
+
+// workspaceRepository 工作区仓库实现
+type workspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository 创建工作区仓库实例
+func NewWorkspaceRepository(db *gorm.DB) WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+// WithTx 返回在事务中读写的仓库
+func (r *workspaceRepository) WithTx(tx Tx) WorkspaceRepository {
+	return &workspaceRepository{db: tx.bind(r.db)}
+}
+
+// Create 创建工作区并加入所有者
+func (r *workspaceRepository) Create(workspace *model.Workspace, owner *model.WorkspaceMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		owner.WorkspaceID = workspace.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// Update 更新工作区
+func (r *workspaceRepository) Update(workspace *model.Workspace) error {
+	return r.db.Save(workspace).Error
+}
+
+// GetByID 根据ID获取工作区
+func (r *workspaceRepository) GetByID(workspaceID int) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := r.db.First(&workspace, workspaceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// GetByUserID 获取用户加入的全部工作区
+func (r *workspaceRepository) GetByUserID(userID int) ([]*model.Workspace, error) {
+	var workspaces []*model.Workspace
+	err := r.db.Where("id IN (?)", r.db.Table("workspace_members").Select("workspace_id").Where("user_id = ?", userID)).
+		Order("id").Find(&workspaces).Error
+	return workspaces, err
+}
+
+// SaveMember 添加成员，已存在时更新角色
+func (r *workspaceRepository) SaveMember(member *model.WorkspaceMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+// RemoveMember 移除成员
+func (r *workspaceRepository) RemoveMember(workspaceID, userID int) error {
+	return r.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&model.WorkspaceMember{}).Error
+}
+
+// GetMember 获取成员
+func (r *workspaceRepository) GetMember(workspaceID, userID int) (*model.WorkspaceMember, error) {
+	var member model.WorkspaceMember
+	err := r.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMembers 获取工作区的全部成员
+func (r *workspaceRepository) GetMembers(workspaceID int) ([]*model.WorkspaceMember, error) {
+	var members []*model.WorkspaceMember
+	err := r.db.Where("workspace_id = ?", workspaceID).Order("created_at").Order("user_id").Find(&members).Error
+	return members, err
+}
+
+// CreateInvitation 创建邀请
+func (r *workspaceRepository) CreateInvitation(invitation *model.WorkspaceInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// GetInvitationByTokenHash 根据令牌哈希获取邀请
+func (r *workspaceRepository) GetInvitationByTokenHash(tokenHash string) (*model.WorkspaceInvitation, error) {
+	var invitation model.WorkspaceInvitation
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// AcceptInvitation 接受邀请并加入成员
+// 使用条件更新保证同一邀请并发接受时只有一个请求成功
+func (r *workspaceRepository) AcceptInvitation(invitation *model.WorkspaceInvitation, member *model.WorkspaceMember) (bool, error) {
+	accepted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.WorkspaceInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Updates(map[string]interface{}{"accepted_by": invitation.AcceptedBy, "accepted_at": invitation.AcceptedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		accepted = true
+		return tx.Create(member).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return accepted, nil
+}
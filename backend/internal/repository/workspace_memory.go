@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+)
+
+// workspaceMemberKey 工作区成员的主键
+type workspaceMemberKey struct {
+	workspaceID int
+	userID      int
+}
+
+// memoryWorkspaceRepository 基于内存的工作区仓库实现，主要用于测试
+type memoryWorkspaceRepository struct {
+	mu               sync.RWMutex
+	workspaces       map[int]*model.Workspace
+	members          map[workspaceMemberKey]*model.WorkspaceMember
+	invitations      map[int]*model.WorkspaceInvitation
+	nextID           int
+	nextInvitationID int
+}
+
+// NewMemoryWorkspaceRepository 创建内存工作区仓库实例
+func NewMemoryWorkspaceRepository() WorkspaceRepository {
+	return &memoryWorkspaceRepository{
+		workspaces:       make(map[int]*model.Workspace),
+		members:          make(map[workspaceMemberKey]*model.WorkspaceMember),
+		invitations:      make(map[int]*model.WorkspaceInvitation),
+		nextID:           1,
+		nextInvitationID: 1,
+	}
+}
+
+// WithTx 内存实现没有事务，返回仓库本身
+func (r *memoryWorkspaceRepository) WithTx(tx Tx) WorkspaceRepository {
+	return r
+}
+
+// Create 创建工作区并加入所有者
+func (r *memoryWorkspaceRepository) Create(workspace *model.Workspace, owner *model.WorkspaceMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workspace.ID = r.nextID
+	r.nextID++
+	now := time.Now()
+	if workspace.CreatedAt.IsZero() {
+		workspace.CreatedAt = now
+	}
+	if workspace.UpdatedAt.IsZero() {
+		workspace.UpdatedAt = now
+	}
+	clone := *workspace
+	r.workspaces[workspace.ID] = &clone
+
+	owner.WorkspaceID = workspace.ID
+	r.saveMember(owner)
+	return nil
+}
+
+// Update 更新工作区
+func (r *memoryWorkspaceRepository) Update(workspace *model.Workspace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workspace.UpdatedAt = time.Now()
+	clone := *workspace
+	clone.Role = ""
+	r.workspaces[workspace.ID] = &clone
+	return nil
+}
+
+// GetByID 根据ID获取工作区
+func (r *memoryWorkspaceRepository) GetByID(workspaceID int) (*model.Workspace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workspace, ok := r.workspaces[workspaceID]
+	if !ok {
+		return nil, nil
+	}
+	clone := *workspace
+	return &clone, nil
+}
+
+// GetByUserID 获取用户加入的全部工作区
+func (r *memoryWorkspaceRepository) GetByUserID(userID int) ([]*model.Workspace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var workspaces []*model.Workspace
+	for key := range r.members {
+		if key.userID != userID {
+			continue
+		}
+		if workspace, ok := r.workspaces[key.workspaceID]; ok {
+			clone := *workspace
+			workspaces = append(workspaces, &clone)
+		}
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].ID < workspaces[j].ID
+	})
+	return workspaces, nil
+}
+
+// SaveMember 添加成员，已存在时更新角色
+func (r *memoryWorkspaceRepository) SaveMember(member *model.WorkspaceMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.saveMember(member)
+	return nil
+}
+
+// saveMember 添加或更新成员，调用方需持有写锁
+func (r *memoryWorkspaceRepository) saveMember(member *model.WorkspaceMember) {
+	key := workspaceMemberKey{workspaceID: member.WorkspaceID, userID: member.UserID}
+	if existing, ok := r.members[key]; ok {
+		existing.Role = member.Role
+		return
+	}
+	if member.CreatedAt.IsZero() {
+		member.CreatedAt = time.Now()
+	}
+	clone := *member
+	clone.Username = ""
+	r.members[key] = &clone
+}
+
+// RemoveMember 移除成员
+func (r *memoryWorkspaceRepository) RemoveMember(workspaceID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.members, workspaceMemberKey{workspaceID: workspaceID, userID: userID})
+	return nil
+}
+
+// GetMember 获取成员
+func (r *memoryWorkspaceRepository) GetMember(workspaceID, userID int) (*model.WorkspaceMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, ok := r.members[workspaceMemberKey{workspaceID: workspaceID, userID: userID}]
+	if !ok {
+		return nil, nil
+	}
+	clone := *member
+	return &clone, nil
+}
+
+// GetMembers 获取工作区的全部成员
+func (r *memoryWorkspaceRepository) GetMembers(workspaceID int) ([]*model.WorkspaceMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var members []*model.WorkspaceMember
+	for key, member := range r.members {
+		if key.workspaceID == workspaceID {
+			clone := *member
+			members = append(members, &clone)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members, nil
+}
+
+// CreateInvitation 创建邀请
+func (r *memoryWorkspaceRepository) CreateInvitation(invitation *model.WorkspaceInvitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation.ID = r.nextInvitationID
+	r.nextInvitationID++
+	if invitation.CreatedAt.IsZero() {
+		invitation.CreatedAt = time.Now()
+	}
+	clone := *invitation
+	clone.Token = ""
+	r.invitations[invitation.ID] = &clone
+	return nil
+}
+
+// GetInvitationByTokenHash 根据令牌哈希获取邀请
+func (r *memoryWorkspaceRepository) GetInvitationByTokenHash(tokenHash string) (*model.WorkspaceInvitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, invitation := range r.invitations {
+		if invitation.TokenHash == tokenHash {
+			clone := *invitation
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+// AcceptInvitation 接受邀请并加入成员
+func (r *memoryWorkspaceRepository) AcceptInvitation(invitation *model.WorkspaceInvitation, member *model.WorkspaceMember) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.invitations[invitation.ID]
+	if !ok || stored.AcceptedAt != nil {
+		return false, nil
+	}
+	stored.AcceptedBy = invitation.AcceptedBy
+	stored.AcceptedAt = invitation.AcceptedAt
+	r.saveMember(member)
+	return true, nil
+}
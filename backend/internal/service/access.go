@@ -0,0 +1,125 @@
+package service
+
+import (
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+// taskAccess 计算用户对任务的角色，供任务相关的服务共用
+// 角色来自任务所有权、工作区成员身份和任务协作记录，取其中最高的
+type taskAccess struct {
+	taskRepo      repository.TaskRepository
+	collabRepo    repository.CollaboratorRepository
+	workspaceRepo repository.WorkspaceRepository
+}
+
+// get 获取任务并验证用户有权访问，返回的任务 Role 为用户的角色
+func (a taskAccess) get(taskID, userID int) (*model.Task, error) {
+	task, err := a.taskRepo.GetByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	role, err := a.role(task, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrTaskAccessDenied
+	}
+	task.Role = role
+	return task, nil
+}
+
+// role 计算用户对任务的角色，无权访问时返回空字符串。
+// 工作区任务的角色以当前成员身份为准，创建者离开工作区或降为访客后不再是所有者
+func (a taskAccess) role(task *model.Task, userID int) (string, error) {
+	if task.WorkspaceID == nil && task.UserID == userID {
+		return model.TaskRoleOwner, nil
+	}
+
+	role := ""
+	if task.WorkspaceID != nil {
+		member, err := a.workspaceRepo.GetMember(*task.WorkspaceID, userID)
+		if err != nil {
+			return "", err
+		}
+		if member != nil {
+			role = memberTaskRole(member.Role, task.UserID == userID)
+		}
+	}
+	if role == model.TaskRoleOwner {
+		return role, nil
+	}
+
+	shared, err := a.sharedRole(task, userID)
+	if err != nil {
+		return "", err
+	}
+	if taskRoleRank(shared) > taskRoleRank(role) {
+		role = shared
+	}
+	return role, nil
+}
+
+// sharedRole 计算任务共享给用户的角色，共享任务时子任务一并共享，沿父任务链向上取最近的协作记录
+func (a taskAccess) sharedRole(task *model.Task, userID int) (string, error) {
+	current := task
+	for depth := 0; current != nil && depth < model.MaxTaskDepth; depth++ {
+		collaborator, err := a.collabRepo.Get(current.ID, userID)
+		if err != nil {
+			return "", err
+		}
+		if collaborator != nil {
+			return collaborator.Role, nil
+		}
+		if current.ParentID == nil {
+			break
+		}
+		if current, err = a.taskRepo.GetByID(*current.ParentID); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// workspaceTaskRole 工作区角色对应的任务角色：所有者和管理员可以管理全部任务，成员可以编辑，访客只读
+func workspaceTaskRole(role string) string {
+	switch role {
+	case model.WorkspaceRoleOwner, model.WorkspaceRoleAdmin:
+		return model.TaskRoleOwner
+	case model.WorkspaceRoleMember:
+		return model.TaskRoleEditor
+	case model.WorkspaceRoleGuest:
+		return model.TaskRoleViewer
+	default:
+		return ""
+	}
+}
+
+// memberTaskRole 工作区成员对任务的角色，creator 表示任务由该成员创建：
+// 成员对自己创建的任务有所有者权限，访客始终只读
+func memberTaskRole(role string, creator bool) string {
+	taskRole := workspaceTaskRole(role)
+	if creator && taskRole == model.TaskRoleEditor {
+		return model.TaskRoleOwner
+	}
+	return taskRole
+}
+
+// taskRoleRank 任务角色的权限等级，越大权限越高
+func taskRoleRank(role string) int {
+	switch role {
+	case model.TaskRoleOwner:
+		return 3
+	case model.TaskRoleEditor:
+		return 2
+	case model.TaskRoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
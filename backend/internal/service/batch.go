@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"todolist/internal/event"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+// 批量操作类型
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// MaxBatchOperations 单次批量操作的最大数量
+const MaxBatchOperations = 100
+
+var (
+	ErrEmptyBatch        = errors.New("批量操作不能为空")
+	ErrTooManyOperations = fmt.Errorf("批量操作不能超过%d项", MaxBatchOperations)
+	ErrInvalidBatchOp    = errors.New("不支持的批量操作类型")
+	ErrBatchAborted      = errors.New("其他操作失败，本操作已回滚")
+
+	// errBatchDryRun 预演结束后回滚事务
+	errBatchDryRun = errors.New("批量操作预演")
+)
+
+// BatchOperation 批量操作中的一项
+type BatchOperation struct {
+	// Op 操作类型，见 BatchOpCreate 等
+	Op string
+	// TaskID 更新或删除的任务ID
+	TaskID int
+	// Task 创建的任务，UserID 由 Batch 设置
+	Task *model.Task
+	// Patch 更新的字段
+	Patch model.TaskPatch
+	// Delete 删除选项
+	Delete DeleteOptions
+}
+
+// BatchOptions 批量操作选项
+type BatchOptions struct {
+	// Atomic 为 true 时任意一项失败则全部回滚，否则只回滚失败的项
+	Atomic bool
+	// DryRun 为 true 时执行全部操作后回滚，返回的结果与实际执行相同，但不保存也不发布事件
+	DryRun bool
+}
+
+// BatchResult 批量操作中一项的结果
+type BatchResult struct {
+	Op     string
+	TaskID int
+	// Task 创建或更新后的任务，删除和失败时为空
+	Task *model.Task
+	// Err 失败的原因，为 nil 表示成功
+	Err error
+}
+
+// Batch 批量操作任务
+func (s *taskService) Batch(userID int, ops []BatchOperation, opts BatchOptions) ([]BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(ops) > MaxBatchOperations {
+		return nil, ErrTooManyOperations
+	}
+
+	results := make([]BatchResult, len(ops))
+	failed := -1
+	var scoped *taskService
+	err := s.taskRepo.Transaction(func(tx repository.Tx) error {
+		scoped = s.withTx(tx)
+		for i, op := range ops {
+			results[i] = BatchResult{Op: op.Op, TaskID: op.TaskID}
+			if opts.Atomic {
+				if err := scoped.apply(userID, op, &results[i]); err != nil {
+					results[i] = BatchResult{Op: op.Op, TaskID: op.TaskID, Err: err}
+					failed = i
+					return err
+				}
+				continue
+			}
+			// 每一项使用单独的保存点，失败时只回滚该项，该项的事件也不会发布
+			var item *taskService
+			err := scoped.taskRepo.Transaction(func(tx repository.Tx) error {
+				item = scoped.withTx(tx)
+				return item.apply(userID, op, &results[i])
+			})
+			if err != nil {
+				results[i] = BatchResult{Op: op.Op, TaskID: op.TaskID, Err: err}
+				continue
+			}
+			scoped.flush(*item.pending)
+		}
+		if opts.DryRun {
+			return errBatchDryRun
+		}
+		return nil
+	})
+
+	// 整体回滚时其他项都没有生效
+	if failed >= 0 {
+		for i, op := range ops {
+			if i != failed {
+				results[i] = BatchResult{Op: op.Op, TaskID: op.TaskID, Err: ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+	if err == errBatchDryRun {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.flush(*scoped.pending)
+	return results, nil
+}
+
+// apply 执行批量操作中的一项，成功时将任务写入 result
+func (s *taskService) apply(userID int, op BatchOperation, result *BatchResult) error {
+	switch op.Op {
+	case BatchOpCreate:
+		if op.Task == nil {
+			return ErrInvalidBatchOp
+		}
+		// 复制一份，失败回滚后不会在调用方的任务上留下ID
+		task := *op.Task
+		task.UserID = userID
+		if err := s.Create(&task); err != nil {
+			return err
+		}
+		result.TaskID = task.ID
+		result.Task = &task
+	case BatchOpUpdate:
+		task, err := s.Patch(op.TaskID, userID, op.Patch)
+		if err != nil {
+			return err
+		}
+		result.Task = task
+	case BatchOpDelete:
+		return s.Delete(op.TaskID, userID, op.Delete)
+	default:
+		return ErrInvalidBatchOp
+	}
+	return nil
+}
+
+// withTx 返回在事务 tx 中读写的任务服务，发布的事件暂存在 pending 中，由调用方在提交后发布
+func (s *taskService) withTx(tx repository.Tx) *taskService {
+	scoped := NewTaskService(s.taskRepo.WithTx(tx), s.tagRepo.WithTx(tx), s.projectRepo.WithTx(tx), s.collabRepo.WithTx(tx),
+		s.workspaceRepo.WithTx(tx), s.assigneeRepo.WithTx(tx), s.commentRepo.WithTx(tx), s.historyRepo.WithTx(tx), s.events).(*taskService)
+	scoped.pending = &[]event.Event{}
+	return scoped
+}
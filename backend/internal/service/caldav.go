@@ -0,0 +1,499 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/pkg/ical"
+)
+
+var (
+	ErrCalDAVObjectNotFound = errors.New("CalDAV 对象不存在")
+	ErrCalDAVPrecondition   = errors.New("对象已被修改或已存在，请同步后重试")
+	ErrCalDAVUIDConflict    = errors.New("任务集合中已有相同 UID 的其他对象，或修改了对象的 UID")
+	ErrInvalidCalDAVName    = fmt.Errorf("资源名称不能为空且不能超过%d个字符", model.MaxCalDAVNameLength)
+	ErrInvalidCalendarData  = errors.New("请求体不是有效的 iCalendar 待办")
+	ErrUnsupportedComponent = errors.New("任务集合只支持 VTODO，每个对象包含一个待办")
+	ErrInvalidSyncToken     = errors.New("无效的同步令牌，请重新完整同步")
+)
+
+// CalDAVResource 任务集合中的资源
+type CalDAVResource struct {
+	Object *model.CalDAVObject
+	// Task 对象对应的任务，对象已删除时为 nil
+	Task *model.Task
+}
+
+// ETag 资源的当前 ETag，已删除时为空
+func (r CalDAVResource) ETag() string {
+	if r.Task == nil {
+		return ""
+	}
+	return calDAVETag(r.Task)
+}
+
+// Calendar 资源的日历数据，包含一个待办，UID 为对象的 UID
+func (r CalDAVResource) Calendar() *ical.Component {
+	calendar := ical.NewCalendar(calendarProdID)
+	calendar.Append(taskTodo(r.Task, r.Object.UID))
+	return calendar
+}
+
+// CalDAVService CalDAV 任务集合服务接口。
+// 每个用户有一个 VTODO 集合，包含任务列表中个人和共享给用户的任务。
+// 任务在其他地方修改后，下一次访问集合时对比任务的版本号得到变化，作为增量同步的依据
+type CalDAVService interface {
+	// Collection 返回集合中的全部资源和当前同步令牌，同步之后被删除的任务对应资源的 Task 为 nil
+	Collection(userID int) ([]CalDAVResource, int64, error)
+	// SyncToken 返回集合的当前同步令牌，不加载任务内容
+	SyncToken(userID int) (int64, error)
+	// Changes 返回同步令牌 since 之后变化的资源和当前同步令牌，已删除的资源 Task 为 nil。
+	// since 为0时返回全部资源，不是之前返回的令牌时返回 ErrInvalidSyncToken
+	Changes(userID int, since int64) ([]CalDAVResource, int64, error)
+	// Get 获取资源，不存在或已删除时返回 ErrCalDAVObjectNotFound
+	Get(userID int, name string) (*CalDAVResource, error)
+	// Put 用日历数据创建或替换资源，返回资源及是否为新建。
+	// ifMatch 不为空时资源必须存在且 ETag 一致（* 匹配任意 ETag），ifNoneMatch 为 * 时资源必须不存在
+	Put(userID int, name string, data []byte, ifMatch, ifNoneMatch string) (*CalDAVResource, bool, error)
+	// Delete 删除资源对应的任务，ifMatch 的含义与 Put 相同
+	Delete(userID int, name, ifMatch string) error
+}
+
+// calDAVService CalDAV 任务集合服务实现
+type calDAVService struct {
+	taskService TaskService
+	caldavRepo  repository.CalDAVRepository
+	// mu 保护 locks
+	mu sync.Mutex
+	// locks 串行化同一用户的对象同步和修改，避免同一任务生成两个对象；不同用户互不阻塞，没有请求时删除
+	locks map[int]*calDAVUserLock
+}
+
+// calDAVUserLock 一个用户的锁，refs 为持有或等待该锁的请求数
+type calDAVUserLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewCalDAVService 创建 CalDAV 任务集合服务实例，任务的创建、修改和删除都通过 taskService 完成
+func NewCalDAVService(taskService TaskService, caldavRepo repository.CalDAVRepository) CalDAVService {
+	return &calDAVService{
+		taskService: taskService,
+		caldavRepo:  caldavRepo,
+		locks:       make(map[int]*calDAVUserLock),
+	}
+}
+
+// lock 获取用户的锁，返回释放锁的函数
+func (s *calDAVService) lock(userID int) func() {
+	s.mu.Lock()
+	l := s.locks[userID]
+	if l == nil {
+		l = &calDAVUserLock{}
+		s.locks[userID] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, userID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// calDAVETag 任务的 ETag，由任务ID和版本号组成，任务每次修改版本号加一
+func calDAVETag(task *model.Task) string {
+	return calDAVVersionETag(task.ID, task.Version)
+}
+
+// calDAVVersionETag 由任务ID和版本号生成 ETag，不需要加载任务
+func calDAVVersionETag(taskID, version int) string {
+	return fmt.Sprintf(`"%d-%d"`, taskID, version)
+}
+
+// Collection 返回集合中的全部资源
+func (s *calDAVService) Collection(userID int) ([]CalDAVResource, int64, error) {
+	return s.Changes(userID, 0)
+}
+
+// SyncToken 返回集合的当前同步令牌
+func (s *calDAVService) SyncToken(userID int) (int64, error) {
+	defer s.lock(userID)()
+
+	_, _, seq, err := s.sync(userID)
+	return seq, err
+}
+
+// Changes 返回同步令牌之后变化的资源
+func (s *calDAVService) Changes(userID int, since int64) ([]CalDAVResource, int64, error) {
+	defer s.lock(userID)()
+
+	objects, _, seq, err := s.sync(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if since < 0 || since > seq {
+		return nil, 0, ErrInvalidSyncToken
+	}
+
+	// 只加载变化的对象对应的任务，首次同步不需要返回删除
+	var changed []*model.CalDAVObject
+	var taskIDs []int
+	for _, object := range objects {
+		if object.SyncSeq > since && (since > 0 || !object.Deleted) {
+			changed = append(changed, object)
+			if !object.Deleted {
+				taskIDs = append(taskIDs, object.TaskID)
+			}
+		}
+	}
+	tasks := make(map[int]*model.Task, len(taskIDs))
+	if len(taskIDs) > 0 {
+		filter := model.TaskFilter{UserID: userID, Page: 1, PageSize: -1}
+		if since > 0 {
+			filter.IDs = taskIDs
+		}
+		list, _, err := s.taskService.List(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, task := range list {
+			tasks[task.ID] = task
+		}
+	}
+
+	changes := make([]CalDAVResource, 0, len(changed))
+	for _, object := range changed {
+		// 同步之后被删除或取消共享的任务视为已删除，下一次同步时更新对象
+		changes = append(changes, CalDAVResource{Object: object, Task: tasks[object.TaskID]})
+	}
+	return changes, seq, nil
+}
+
+// sync 只查询可见任务的ID和版本号并与已有对象对比：为新任务创建对象，版本变化的对象和不再可见的任务的对象分配新的同步序号，
+// 只保存有变化的对象。返回全部对象（包括已删除的）、可见任务的版本号和当前同步序号，需要持有用户的锁
+func (s *calDAVService) sync(userID int) ([]*model.CalDAVObject, map[int]int, int64, error) {
+	versions, err := s.taskService.ListVersions(model.TaskFilter{UserID: userID})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	objects, err := s.caldavRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var seq int64
+	byTask := make(map[int]*model.CalDAVObject, len(objects))
+	names := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		seq = max(seq, object.SyncSeq)
+		byTask[object.TaskID] = object
+		names[object.Name] = true
+	}
+
+	next := seq + 1
+	var changed []*model.CalDAVObject
+	visible := make(map[int]int, len(versions))
+	for _, version := range versions {
+		visible[version.ID] = version.Version
+		etag := calDAVVersionETag(version.ID, version.Version)
+		object := byTask[version.ID]
+		if object == nil {
+			object = &model.CalDAVObject{
+				UserID: userID,
+				TaskID: version.ID,
+				Name:   calDAVObjectName(version.ID, names),
+				UID:    taskTodoUID(version.ID),
+			}
+			names[object.Name] = true
+			objects = append(objects, object)
+		} else if object.ETag == etag && !object.Deleted {
+			continue
+		}
+		object.ETag = etag
+		object.Deleted = false
+		object.SyncSeq = next
+		changed = append(changed, object)
+	}
+	for _, object := range objects {
+		if _, ok := visible[object.TaskID]; !ok && !object.Deleted {
+			object.Deleted = true
+			object.SyncSeq = next
+			changed = append(changed, object)
+		}
+	}
+
+	if len(changed) > 0 {
+		if err := s.caldavRepo.Save(changed...); err != nil {
+			return nil, nil, 0, err
+		}
+		seq = next
+	}
+	return objects, visible, seq, nil
+}
+
+// calDAVObjectName 服务端创建的对象的资源名称，与客户端选择的名称冲突时加上序号
+func calDAVObjectName(taskID int, names map[string]bool) string {
+	name := fmt.Sprintf("task-%d.ics", taskID)
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprintf("task-%d-%d.ics", taskID, i)
+	}
+	return name
+}
+
+// Get 获取资源
+func (s *calDAVService) Get(userID int, name string) (*CalDAVResource, error) {
+	object, err := s.caldavRepo.GetByName(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil || object.Deleted {
+		return nil, ErrCalDAVObjectNotFound
+	}
+
+	task, err := s.taskService.Get(object.TaskID, userID)
+	if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskAccessDenied) {
+		return nil, ErrCalDAVObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &CalDAVResource{Object: object, Task: task}, nil
+}
+
+// Put 创建或替换资源
+func (s *calDAVService) Put(userID int, name string, data []byte, ifMatch, ifNoneMatch string) (*CalDAVResource, bool, error) {
+	if name == "" || len(name) > model.MaxCalDAVNameLength || strings.Contains(name, "/") {
+		return nil, false, ErrInvalidCalDAVName
+	}
+	todo, err := parseCalDAVTodo(data)
+	if err != nil {
+		return nil, false, err
+	}
+	uid := todo.Get("UID").Value
+	fields, err := parseTodoFields(todo)
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer s.lock(userID)()
+
+	objects, versions, seq, err := s.sync(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	var current *model.CalDAVObject
+	for _, object := range objects {
+		if object.Name == name {
+			current = object
+		} else if !object.Deleted && object.UID == uid {
+			return nil, false, ErrCalDAVUIDConflict
+		}
+	}
+	exists := current != nil && !current.Deleted
+	if err := checkCalDAVPrecondition(current, exists, ifMatch, ifNoneMatch); err != nil {
+		return nil, false, err
+	}
+
+	var task *model.Task
+	object := &model.CalDAVObject{UserID: userID, Name: name, UID: uid}
+	if exists {
+		if current.UID != uid {
+			return nil, false, ErrCalDAVUIDConflict
+		}
+		object = current
+		task, err = s.taskService.Patch(object.TaskID, userID, model.TaskPatch{
+			Title:       model.Some(fields.Title),
+			Description: model.Some(fields.Description),
+			Status:      model.Some(fields.Status),
+			Priority:    model.Some(fields.Priority),
+			DueDate:     model.Some(fields.DueDate),
+			Recurrence:  model.Some(fields.Recurrence),
+			// 任务在同步之后被其他人修改时拒绝覆盖
+			Version: versions[object.TaskID],
+		})
+		if errors.Is(err, ErrVersionConflict) {
+			return nil, false, ErrCalDAVPrecondition
+		}
+	} else {
+		// 资源名称之前属于已删除的对象时复用该记录
+		if current != nil {
+			object = current
+			object.UID = uid
+		}
+		task = &model.Task{
+			UserID:      userID,
+			Title:       fields.Title,
+			Description: fields.Description,
+			Status:      fields.Status,
+			Priority:    fields.Priority,
+			DueDate:     fields.DueDate,
+			Recurrence:  fields.Recurrence,
+		}
+		err = s.taskService.Create(task)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	object.TaskID = task.ID
+	object.ETag = calDAVETag(task)
+	object.Deleted = false
+	object.SyncSeq = seq + 1
+	if err := s.caldavRepo.Save(object); err != nil {
+		return nil, false, err
+	}
+	return &CalDAVResource{Object: object, Task: task}, !exists, nil
+}
+
+// Delete 删除资源对应的任务
+func (s *calDAVService) Delete(userID int, name, ifMatch string) error {
+	defer s.lock(userID)()
+
+	objects, _, seq, err := s.sync(userID)
+	if err != nil {
+		return err
+	}
+	var current *model.CalDAVObject
+	for _, object := range objects {
+		if object.Name == name && !object.Deleted {
+			current = object
+		}
+	}
+	if current == nil {
+		return ErrCalDAVObjectNotFound
+	}
+	if err := checkCalDAVPrecondition(current, true, ifMatch, ""); err != nil {
+		return err
+	}
+
+	if err := s.taskService.Delete(current.TaskID, userID, DeleteOptions{}); err != nil {
+		return err
+	}
+	current.Deleted = true
+	current.SyncSeq = seq + 1
+	return s.caldavRepo.Save(current)
+}
+
+// checkCalDAVPrecondition 检查 If-Match 和 If-None-Match 条件，current 的 ETag 在同步后与任务的当前版本一致
+func checkCalDAVPrecondition(current *model.CalDAVObject, exists bool, ifMatch, ifNoneMatch string) error {
+	if ifNoneMatch == "*" && exists {
+		return ErrCalDAVPrecondition
+	}
+	if ifMatch != "" && (!exists || (ifMatch != "*" && ifMatch != current.ETag)) {
+		return ErrCalDAVPrecondition
+	}
+	return nil
+}
+
+// parseCalDAVTodo 解析请求体，返回唯一的主待办；重复待办的例外（带 RECURRENCE-ID 的 VTODO）被忽略
+func parseCalDAVTodo(data []byte) (*ical.Component, error) {
+	calendar, err := ical.Decode(bytes.NewReader(data))
+	if err != nil || calendar.Name != "VCALENDAR" {
+		return nil, ErrInvalidCalendarData
+	}
+
+	var todo *ical.Component
+	for _, component := range calendar.Components {
+		switch component.Name {
+		case "VTIMEZONE":
+		case CalendarComponentTodo:
+			if component.Get("RECURRENCE-ID") != nil {
+				continue
+			}
+			if todo != nil {
+				return nil, ErrUnsupportedComponent
+			}
+			todo = component
+		default:
+			if strings.HasPrefix(component.Name, "X-") {
+				continue
+			}
+			return nil, ErrUnsupportedComponent
+		}
+	}
+	if todo == nil {
+		return nil, ErrUnsupportedComponent
+	}
+	uid := todo.Get("UID")
+	if uid == nil || uid.Value == "" || len(uid.Value) > model.MaxCalDAVNameLength {
+		return nil, ErrInvalidCalendarData
+	}
+	return todo, nil
+}
+
+// calDAVTodoFields 待办中可以保存到任务的字段，其他属性（如 CATEGORIES、DTSTART）被忽略
+type calDAVTodoFields struct {
+	Title       string
+	Description string
+	Status      int
+	Priority    int
+	DueDate     *time.Time
+	Recurrence  *string
+}
+
+// parseTodoFields 将待办转换为任务字段，是 taskTodo 的逆映射。
+// 日期形式的 DUE 转换为本地时区的零点，与导出时的全天判断一致
+func parseTodoFields(todo *ical.Component) (calDAVTodoFields, error) {
+	var fields calDAVTodoFields
+	if p := todo.Get("SUMMARY"); p != nil {
+		fields.Title = strings.TrimSpace(p.Text())
+	}
+	if p := todo.Get("DESCRIPTION"); p != nil {
+		fields.Description = p.Text()
+	}
+
+	fields.Status = model.TaskStatusTodo
+	if p := todo.Get("STATUS"); p != nil {
+		switch strings.ToUpper(p.Value) {
+		case "COMPLETED", "CANCELLED":
+			fields.Status = model.TaskStatusDone
+		case "IN-PROCESS":
+			fields.Status = model.TaskStatusInProgress
+		}
+	} else if todo.Get("COMPLETED") != nil {
+		fields.Status = model.TaskStatusDone
+	}
+
+	fields.Priority = model.TaskPriorityNone
+	if p := todo.Get("PRIORITY"); p != nil {
+		priority, err := strconv.Atoi(p.Value)
+		if err != nil || priority < 0 || priority > 9 {
+			return fields, ErrInvalidCalendarData
+		}
+		switch {
+		case priority == 0:
+		case priority <= 4:
+			fields.Priority = model.TaskPriorityHigh
+		case priority == 5:
+			fields.Priority = model.TaskPriorityMedium
+		default:
+			fields.Priority = model.TaskPriorityLow
+		}
+	}
+
+	if p := todo.Get("DUE"); p != nil {
+		due, _, err := p.Time(time.Local)
+		if err != nil {
+			return fields, ErrInvalidCalendarData
+		}
+		fields.DueDate = &due
+	}
+	if p := todo.Get("RRULE"); p != nil {
+		rule := p.Value
+		fields.Recurrence = &rule
+	}
+	return fields, nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/pkg/ical"
+)
+
+// ErrInvalidCalendarToken 日历订阅令牌不存在或已被重新生成
+var ErrInvalidCalendarToken = errors.New("无效的日历订阅令牌")
+
+// 日历中输出的组件类型
+const (
+	CalendarComponentEvent = "VEVENT" // 日历应用中显示为截止日期当天的日程
+	CalendarComponentTodo  = "VTODO"  // 支持待办的应用中显示为带截止日期的待办
+)
+
+const (
+	// calendarProdID 生成日历的产品标识
+	calendarProdID = "-//TodoList//Tasks//ZH"
+	// calendarName 日历应用中显示的日历名称
+	calendarName = "TodoList"
+	// calendarRefreshInterval 建议日历应用刷新订阅的间隔
+	calendarRefreshInterval = "PT1H"
+	// calendarUIDDomain 组件 UID 的域名部分
+	calendarUIDDomain = "todolist"
+)
+
+// calendarTodoStatuses 任务状态文本对应的 VTODO 状态
+var calendarTodoStatuses = map[string]string{
+	"todo":        "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"done":        "COMPLETED",
+}
+
+// calendarPriorities 任务优先级对应的 PRIORITY，1 最高，9 最低，无优先级时不输出
+var calendarPriorities = map[int]string{
+	model.TaskPriorityHigh:   "1",
+	model.TaskPriorityMedium: "5",
+	model.TaskPriorityLow:    "9",
+}
+
+// CalendarService 任务日历服务接口，将有截止日期的任务导出为 iCalendar，
+// 并管理日历应用订阅时使用的令牌
+type CalendarService interface {
+	// Export 生成用户可以看到的有截止日期的任务的日历，范围与任务列表相同：
+	// workspaceID 为0时包括个人任务和共享给用户的任务，否则为该工作区的任务。
+	// components 为空时同时输出 VEVENT 和 VTODO
+	Export(userID, workspaceID int, components []string) (*ical.Component, error)
+	// CreateToken 生成新的订阅令牌并替换旧令牌，返回令牌明文，之后无法再次获取
+	CreateToken(userID int) (string, *model.CalendarToken, error)
+	// GetToken 获取用户的令牌信息，没有令牌时返回 nil
+	GetToken(userID int) (*model.CalendarToken, error)
+	// RevokeToken 吊销用户的令牌，之前的订阅地址失效
+	RevokeToken(userID int) error
+	// Authenticate 返回令牌所属的用户，令牌无效时返回 ErrInvalidCalendarToken
+	Authenticate(token string) (int, error)
+}
+
+// calendarService 任务日历服务实现
+type calendarService struct {
+	taskService TaskService
+	tokenRepo   repository.CalendarTokenRepository
+}
+
+// NewCalendarService 创建任务日历服务实例
+func NewCalendarService(taskService TaskService, tokenRepo repository.CalendarTokenRepository) CalendarService {
+	return &calendarService{
+		taskService: taskService,
+		tokenRepo:   tokenRepo,
+	}
+}
+
+// Export 生成任务日历
+func (s *calendarService) Export(userID, workspaceID int, components []string) (*ical.Component, error) {
+	if len(components) == 0 {
+		components = []string{CalendarComponentEvent, CalendarComponentTodo}
+	}
+
+	tasks, _, err := s.taskService.List(model.TaskFilter{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Sort:        []model.TaskSort{{Field: model.TaskSortDueDate}},
+		Page:        1,
+		PageSize:    -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := ical.NewCalendar(calendarProdID)
+	calendar.AddText("X-WR-CALNAME", calendarName)
+	calendar.Add("REFRESH-INTERVAL", calendarRefreshInterval, "VALUE=DURATION")
+	calendar.Add("X-PUBLISHED-TTL", calendarRefreshInterval)
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+		for _, component := range components {
+			switch component {
+			case CalendarComponentEvent:
+				calendar.Append(taskEvent(task))
+			case CalendarComponentTodo:
+				calendar.Append(taskTodo(task, taskTodoUID(task.ID)))
+			}
+		}
+	}
+	return calendar, nil
+}
+
+// taskEvent 将任务转换为截止日期的日程，不占用空闲时间
+func taskEvent(task *model.Task) *ical.Component {
+	event := &ical.Component{Name: CalendarComponentEvent}
+	addTaskProperties(event, task, fmt.Sprintf("task-%d-due@%s", task.ID, calendarUIDDomain))
+	if isAllDay(*task.DueDate) {
+		event.AddDate("DTSTART", *task.DueDate)
+		event.AddDate("DTEND", task.DueDate.AddDate(0, 0, 1))
+	} else {
+		event.AddDateTime("DTSTART", *task.DueDate)
+	}
+	event.Add("TRANSP", "TRANSPARENT")
+	addRecurrence(event, task)
+	return event
+}
+
+// taskTodoUID 导出的待办和服务端创建的 CalDAV 对象使用的 UID
+func taskTodoUID(taskID int) string {
+	return fmt.Sprintf("task-%d@%s", taskID, calendarUIDDomain)
+}
+
+// taskTodo 将任务转换为待办，状态由 GetStatusText 映射，没有截止日期时不输出 DUE
+func taskTodo(task *model.Task, uid string) *ical.Component {
+	todo := &ical.Component{Name: CalendarComponentTodo}
+	addTaskProperties(todo, task, uid)
+	switch {
+	case task.DueDate == nil:
+	case isAllDay(*task.DueDate):
+		todo.AddDate("DUE", *task.DueDate)
+	default:
+		todo.AddDateTime("DUE", *task.DueDate)
+	}
+	todo.Add("STATUS", calendarTodoStatuses[task.GetStatusText()])
+	if task.Status == model.TaskStatusDone {
+		todo.Add("PERCENT-COMPLETE", "100")
+		// 任务没有单独记录完成时间，使用最后修改时间
+		todo.AddDateTime("COMPLETED", task.UpdatedAt)
+	}
+	if priority, ok := calendarPriorities[task.Priority]; ok {
+		todo.Add("PRIORITY", priority)
+	}
+	addRecurrence(todo, task)
+	return todo
+}
+
+// addTaskProperties 添加日程和待办共有的属性，同一日历中日程和待办的 UID 不同
+func addTaskProperties(component *ical.Component, task *model.Task, uid string) {
+	component.Add("UID", uid)
+	// 没有 METHOD 的日历中 DTSTAMP 表示最后修改时间
+	component.AddDateTime("DTSTAMP", task.UpdatedAt)
+	component.AddDateTime("CREATED", task.CreatedAt)
+	component.AddDateTime("LAST-MODIFIED", task.UpdatedAt)
+	component.Add("SEQUENCE", strconv.Itoa(max(task.Version-1, 0)))
+	component.AddText("SUMMARY", task.Title)
+	if task.Description != "" {
+		component.AddText("DESCRIPTION", task.Description)
+	}
+	if len(task.Tags) > 0 {
+		names := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			names = append(names, ical.EscapeText(tag.Name))
+		}
+		component.Add("CATEGORIES", strings.Join(names, ","))
+	}
+}
+
+// addRecurrence 添加重复规则，任务的重复规则已是 RFC 5545 RRULE 格式
+func addRecurrence(component *ical.Component, task *model.Task) {
+	if task.Recurrence != nil && *task.Recurrence != "" {
+		component.Add("RRULE", *task.Recurrence)
+	}
+}
+
+// isAllDay 截止日期为所在时区的零点时视为全天，只按日期输出
+func isAllDay(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// CreateToken 生成新的订阅令牌
+func (s *calendarService) CreateToken(userID int) (string, *model.CalendarToken, error) {
+	raw, err := newRandomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := &model.CalendarToken{
+		UserID:    userID,
+		TokenHash: hashToken(raw),
+		CreatedAt: time.Now(),
+	}
+	if err := s.tokenRepo.Save(token); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// GetToken 获取用户的令牌信息
+func (s *calendarService) GetToken(userID int) (*model.CalendarToken, error) {
+	return s.tokenRepo.GetByUserID(userID)
+}
+
+// RevokeToken 吊销用户的令牌
+func (s *calendarService) RevokeToken(userID int) error {
+	return s.tokenRepo.Delete(userID)
+}
+
+// Authenticate 返回令牌所属的用户
+func (s *calendarService) Authenticate(token string) (int, error) {
+	if token == "" {
+		return 0, ErrInvalidCalendarToken
+	}
+	stored, err := s.tokenRepo.GetByHash(hashToken(token))
+	if err != nil {
+		return 0, err
+	}
+	if stored == nil {
+		return 0, ErrInvalidCalendarToken
+	}
+	return stored.UserID, nil
+}
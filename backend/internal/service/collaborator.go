@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+var (
+	ErrInvalidCollaboratorRole = errors.New("无效的协作者角色，应为 editor 或 viewer")
+	ErrCollaboratorIsOwner     = errors.New("不能邀请任务所有者")
+	ErrCollaboratorNotFound    = errors.New("协作者不存在")
+)
+
+// CollaboratorService 任务协作者服务接口
+type CollaboratorService interface {
+	// List 获取任务的所有者和协作者，有权访问任务的用户都可以查看
+	List(taskID, userID int) ([]*model.TaskCollaborator, error)
+	// Invite 按用户名邀请协作者，已是协作者时更新角色，只有任务所有者可以邀请
+	Invite(taskID, userID int, username, role string) (*model.TaskCollaborator, error)
+	// Remove 移除协作者，任务所有者可以移除任何协作者，协作者可以移除自己
+	Remove(taskID, userID, collaboratorID int) error
+}
+
+// collaboratorService 任务协作者服务实现
+type collaboratorService struct {
+	collabRepo repository.CollaboratorRepository
+	userRepo   repository.UserRepository
+	access     taskAccess
+}
+
+// NewCollaboratorService 创建任务协作者服务实例
+func NewCollaboratorService(taskRepo repository.TaskRepository, collabRepo repository.CollaboratorRepository, userRepo repository.UserRepository, workspaceRepo repository.WorkspaceRepository) CollaboratorService {
+	return &collaboratorService{
+		collabRepo: collabRepo,
+		userRepo:   userRepo,
+		access:     taskAccess{taskRepo: taskRepo, collabRepo: collabRepo, workspaceRepo: workspaceRepo},
+	}
+}
+
+// List 获取任务的所有者和协作者
+func (s *collaboratorService) List(taskID, userID int) ([]*model.TaskCollaborator, error) {
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.collabRepo.GetByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	owner := &model.TaskCollaborator{TaskID: taskID, UserID: task.UserID, Role: model.TaskRoleOwner, CreatedAt: task.CreatedAt}
+	collaborators = append([]*model.TaskCollaborator{owner}, collaborators...)
+
+	for _, collaborator := range collaborators {
+		user, err := s.userRepo.GetByID(collaborator.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			collaborator.Username = user.Username
+		}
+	}
+	return collaborators, nil
+}
+
+// Invite 按用户名邀请协作者
+func (s *collaboratorService) Invite(taskID, userID int, username, role string) (*model.TaskCollaborator, error) {
+	if role != model.TaskRoleEditor && role != model.TaskRoleViewer {
+		return nil, ErrInvalidCollaboratorRole
+	}
+
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Role != model.TaskRoleOwner {
+		return nil, ErrTaskOwnerOnly
+	}
+
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.ID == task.UserID {
+		return nil, ErrCollaboratorIsOwner
+	}
+
+	collaborator := &model.TaskCollaborator{
+		TaskID:    taskID,
+		UserID:    user.ID,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+	if err := s.collabRepo.Save(collaborator); err != nil {
+		return nil, err
+	}
+	collaborator.Username = user.Username
+	return collaborator, nil
+}
+
+// Remove 移除协作者
+func (s *collaboratorService) Remove(taskID, userID, collaboratorID int) error {
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return err
+	}
+	if task.Role != model.TaskRoleOwner && collaboratorID != userID {
+		return ErrTaskOwnerOnly
+	}
+
+	collaborator, err := s.collabRepo.Get(taskID, collaboratorID)
+	if err != nil {
+		return err
+	}
+	if collaborator == nil {
+		return ErrCollaboratorNotFound
+	}
+	return s.collabRepo.Delete(taskID, collaboratorID)
+}
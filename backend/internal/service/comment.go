@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+const (
+	// DefaultPageSize 评论和历史记录列表的默认每页数量
+	DefaultPageSize = 20
+	// MaxPageSize 评论和历史记录列表的每页数量上限
+	MaxPageSize = 100
+)
+
+var (
+	ErrEmptyComment        = errors.New("评论内容不能为空")
+	ErrCommentTooLong      = fmt.Errorf("评论内容不能超过%d个字符", model.MaxCommentLength)
+	ErrCommentNotFound     = errors.New("评论不存在")
+	ErrCommentAuthorOnly   = errors.New("只有评论作者可以编辑评论")
+	ErrCommentDeleteDenied = errors.New("只有评论作者或任务所有者可以删除评论")
+)
+
+// CommentService 任务评论服务接口
+// 有权访问任务的用户都可以查看和发表评论，访问权限与获取任务详情一致
+type CommentService interface {
+	// List 分页获取任务的评论，按发表顺序排序
+	List(taskID, userID, page, pageSize int) ([]*model.Comment, int64, error)
+	// Create 发表评论
+	Create(comment *model.Comment) error
+	// Update 编辑评论内容，只有作者可以编辑
+	Update(taskID, commentID, userID int, body string) (*model.Comment, error)
+	// Delete 删除评论，评论作者和任务所有者可以删除
+	Delete(taskID, commentID, userID int) error
+}
+
+// commentService 任务评论服务实现
+type commentService struct {
+	taskService TaskService
+	commentRepo repository.CommentRepository
+	userRepo    repository.UserRepository
+}
+
+// NewCommentService 创建任务评论服务实例
+func NewCommentService(taskService TaskService, commentRepo repository.CommentRepository, userRepo repository.UserRepository) CommentService {
+	return &commentService{
+		taskService: taskService,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// List 分页获取任务的评论
+func (s *commentService) List(taskID, userID, page, pageSize int) ([]*model.Comment, int64, error) {
+	if _, err := s.taskService.Get(taskID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	page, pageSize = normalizePage(page, pageSize)
+	comments, total, err := s.commentRepo.ListByTaskID(taskID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.fillUsernames(comments); err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// Create 发表评论
+func (s *commentService) Create(comment *model.Comment) error {
+	body, err := s.validateBody(comment.Body)
+	if err != nil {
+		return err
+	}
+	if _, err := s.taskService.Get(comment.TaskID, comment.UserID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = nil
+	comment.DeletedAt = nil
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	if err := s.commentRepo.Create(comment); err != nil {
+		return err
+	}
+	return s.fillUsernames([]*model.Comment{comment})
+}
+
+// Update 编辑评论内容
+func (s *commentService) Update(taskID, commentID, userID int, body string) (*model.Comment, error) {
+	body, err := s.validateBody(body)
+	if err != nil {
+		return nil, err
+	}
+	comment, _, err := s.get(taskID, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, ErrCommentAuthorOnly
+	}
+
+	if comment.Body != body {
+		now := time.Now()
+		comment.Body = body
+		comment.EditedAt = &now
+		comment.UpdatedAt = now
+		if err := s.commentRepo.Update(comment); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.fillUsernames([]*model.Comment{comment}); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Delete 删除评论，只标记删除时间
+func (s *commentService) Delete(taskID, commentID, userID int) error {
+	comment, task, err := s.get(taskID, commentID, userID)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID && task.Role != model.TaskRoleOwner {
+		return ErrCommentDeleteDenied
+	}
+
+	now := time.Now()
+	comment.DeletedAt = &now
+	comment.UpdatedAt = now
+	return s.commentRepo.Update(comment)
+}
+
+// get 获取任务下的评论并验证用户有权访问该任务
+func (s *commentService) get(taskID, commentID, userID int) (*model.Comment, *model.Task, error) {
+	task, err := s.taskService.Get(taskID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	comment, err := s.commentRepo.GetByID(commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comment == nil || comment.TaskID != taskID {
+		return nil, nil, ErrCommentNotFound
+	}
+	return comment, task, nil
+}
+
+// validateBody 去除首尾空白并验证评论内容
+func (s *commentService) validateBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", ErrEmptyComment
+	}
+	if len([]rune(body)) > model.MaxCommentLength {
+		return "", ErrCommentTooLong
+	}
+	return body, nil
+}
+
+// fillUsernames 填充评论作者的用户名
+func (s *commentService) fillUsernames(comments []*model.Comment) error {
+	userIDs := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	usernames, err := lookupUsernames(s.userRepo, userIDs)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.Username = usernames[comment.UserID]
+	}
+	return nil
+}
+
+// normalizePage 修正分页参数，页码从1开始，每页数量超出范围时使用默认值或上限
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return page, pageSize
+}
+
+// lookupUsernames 批量查询用户名，用户不存在时为空字符串
+func lookupUsernames(userRepo repository.UserRepository, userIDs []int) (map[int]string, error) {
+	usernames := make(map[int]string, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := usernames[userID]; ok {
+			continue
+		}
+		user, err := userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		usernames[userID] = ""
+		if user != nil {
+			usernames[userID] = user.Username
+		}
+	}
+	return usernames, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"log"
+
+	"todolist/internal/event"
+	"todolist/internal/model"
+)
+
+// eventTypes 任务历史动作对应的事件类型，恢复的任务对订阅者来说与新建的任务相同
+var eventTypes = map[string]string{
+	model.TaskHistoryActionCreate:  event.TaskCreated,
+	model.TaskHistoryActionUpdate:  event.TaskUpdated,
+	model.TaskHistoryActionDelete:  event.TaskDeleted,
+	model.TaskHistoryActionRestore: event.TaskCreated,
+}
+
+// publish 发布任务变更事件，由 record 在记录历史后调用。
+// 在 Batch 的事务中先暂存，提交后再发布，回滚的修改不会被推送
+func (s *taskService) publish(taskID int, action string, changes []model.FieldChange) error {
+	if s.events == nil {
+		return nil
+	}
+	eventType, ok := eventTypes[action]
+	if !ok {
+		return nil
+	}
+
+	task, err := s.taskIncludingDeleted(taskID)
+	if err != nil || task == nil {
+		return err
+	}
+	if err := s.fillDetails([]*model.Task{task}); err != nil {
+		return err
+	}
+	userIDs, err := s.audience(task)
+	if err != nil {
+		return err
+	}
+
+	e := event.Event{Type: eventType, Task: task, UserIDs: userIDs, Changes: changes}
+	if s.pending != nil {
+		*s.pending = append(*s.pending, e)
+		return nil
+	}
+	s.events.Publish(e)
+	return nil
+}
+
+// flush 发布事务提交前暂存的事件，仍在外层事务中时继续暂存
+func (s *taskService) flush(events []event.Event) {
+	if s.pending != nil {
+		*s.pending = append(*s.pending, events...)
+		return
+	}
+	for _, e := range events {
+		s.events.Publish(e)
+	}
+}
+
+// audience 计算可以看到任务的用户：个人任务的所有者、工作区成员以及任务和上级任务的协作者。
+// 负责人必须有权访问任务，已包含在其中
+func (s *taskService) audience(task *model.Task) ([]int, error) {
+	// 工作区任务的创建者离开工作区后不再接收事件，仍是成员时包含在成员中
+	var userIDs []int
+	if task.WorkspaceID == nil {
+		userIDs = append(userIDs, task.UserID)
+	} else {
+		members, err := s.workspaceRepo.GetMembers(*task.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			userIDs = append(userIDs, member.UserID)
+		}
+	}
+
+	// 共享任务时子任务一并共享，与 taskAccess.sharedRole 一致
+	current := task
+	for depth := 0; current != nil && depth < model.MaxTaskDepth; depth++ {
+		collaborators, err := s.collabRepo.GetByTaskID(current.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, collaborator := range collaborators {
+			userIDs = append(userIDs, collaborator.UserID)
+		}
+		if current.ParentID == nil {
+			break
+		}
+		if current, err = s.taskIncludingDeleted(*current.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	return userIDs, nil
+}
+
+// taskIncludingDeleted 获取任务，包括回收站中的任务，级联删除时父任务也已移入回收站
+func (s *taskService) taskIncludingDeleted(taskID int) (*model.Task, error) {
+	task, err := s.taskRepo.GetByID(taskID)
+	if err != nil || task != nil {
+		return task, err
+	}
+	return s.taskRepo.GetDeletedByID(taskID)
+}
+
+// logPublishError 发布事件失败不影响已完成的修改，只记录日志
+func logPublishError(taskID int, err error) {
+	log.Printf("发布任务 %d 的事件失败: %v", taskID, err)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+// HistoryService 任务变更历史服务接口
+type HistoryService interface {
+	// List 分页获取任务的变更历史，最新的排在前面，有权访问任务的用户都可以查看
+	List(taskID, userID, page, pageSize int) ([]*model.TaskHistory, int64, error)
+}
+
+// historyService 任务变更历史服务实现，历史记录由任务服务在变更任务时写入
+type historyService struct {
+	taskService TaskService
+	historyRepo repository.HistoryRepository
+	userRepo    repository.UserRepository
+}
+
+// NewHistoryService 创建任务变更历史服务实例
+func NewHistoryService(taskService TaskService, historyRepo repository.HistoryRepository, userRepo repository.UserRepository) HistoryService {
+	return &historyService{
+		taskService: taskService,
+		historyRepo: historyRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// List 分页获取任务的变更历史
+func (s *historyService) List(taskID, userID, page, pageSize int) ([]*model.TaskHistory, int64, error) {
+	if _, err := s.taskService.Get(taskID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	page, pageSize = normalizePage(page, pageSize)
+	entries, total, err := s.historyRepo.ListByTaskID(taskID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userIDs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+	usernames, err := lookupUsernames(s.userRepo, userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, entry := range entries {
+		entry.Username = usernames[entry.UserID]
+	}
+	return entries, total, nil
+}
+
+// taskField 任务的一个可追踪字段
+type taskField struct {
+	name  string
+	value any
+}
+
+// taskFields 按固定顺序列出任务的可追踪字段，值使用接口返回时的格式
+func taskFields(task *model.Task) []taskField {
+	var dueDate, recurrence any
+	if task.DueDate != nil {
+		dueDate = task.DueDate.Format(time.RFC3339)
+	}
+	if task.Recurrence != nil {
+		recurrence = *task.Recurrence
+	}
+	tags := make([]string, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assignees := append([]int{}, task.Assignees...)
+
+	return []taskField{
+		{"title", task.Title},
+		{"description", task.Description},
+		{"status", task.GetStatusText()},
+		{"priority", task.Priority},
+		{"due_date", dueDate},
+		{"recurrence", recurrence},
+		{"parent_id", idValue(task.ParentID)},
+		{"project_id", idValue(task.ProjectID)},
+		{"tags", tags},
+		{"assignees", assignees},
+	}
+}
+
+// diffTask 比较任务变更前后的字段，返回发生变化的字段
+func diffTask(before, after *model.Task) []model.FieldChange {
+	afterFields := taskFields(after)
+	var changes []model.FieldChange
+	for i, field := range taskFields(before) {
+		if !reflect.DeepEqual(field.value, afterFields[i].value) {
+			changes = append(changes, model.FieldChange{Field: field.name, Before: field.value, After: afterFields[i].value})
+		}
+	}
+	return changes
+}
+
+// snapshotTask 列出任务中有值的字段，用于创建和删除的历史记录
+// deleted 为 true 时字段值记录在 Before 中，否则记录在 After 中
+func snapshotTask(task *model.Task, deleted bool) []model.FieldChange {
+	var changes []model.FieldChange
+	for _, field := range taskFields(task) {
+		if isEmptyValue(field.value) {
+			continue
+		}
+		change := model.FieldChange{Field: field.name, After: field.value}
+		if deleted {
+			change = model.FieldChange{Field: field.name, Before: field.value}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// isEmptyValue 判断字段值是否为空
+func isEmptyValue(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// record 追加一条任务历史并发布任务事件，更新时没有字段变化则不记录
+func (s *taskService) record(taskID, userID int, action string, changes []model.FieldChange) error {
+	if action == model.TaskHistoryActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	err := s.historyRepo.Create(&model.TaskHistory{
+		TaskID:    taskID,
+		UserID:    userID,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.publish(taskID, action, changes); err != nil {
+		logPublishError(taskID, err)
+	}
+	return nil
+}
+
+// recordAssignees 重新读取负责人并记录变更，task.Assignees 为变更前的负责人
+func (s *taskService) recordAssignees(task *model.Task, userID int) error {
+	before := append([]int{}, task.Assignees...)
+	if err := s.fillAssignees([]*model.Task{task}); err != nil {
+		return err
+	}
+	after := append([]int{}, task.Assignees...)
+	if slices.Equal(before, after) {
+		return nil
+	}
+	return s.record(task.ID, userID, model.TaskHistoryActionUpdate, []model.FieldChange{{Field: "assignees", Before: before, After: after}})
+}
+
+// idValue 将可为空的ID转换为历史记录中的值
+func idValue(id *int) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
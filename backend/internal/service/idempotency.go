@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"todolist/config"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+// ErrIdempotencyKeyConflict 幂等键在重试期间被反复占用和释放
+var ErrIdempotencyKeyConflict = errors.New("幂等键冲突，请稍后重试")
+
+// IdempotencyService 幂等键服务接口，记录保存 config.GlobalConfig.Idempotency.TTLHours
+type IdempotencyService interface {
+	// Begin 为首次请求占用幂等键，占用成功时返回 nil；
+	// 幂等键已被占用时返回已保存的记录，首次请求仍在处理中时记录的 StatusCode 为0
+	Begin(record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	// Complete 保存首次请求的响应
+	Complete(record *model.IdempotencyRecord) error
+	// Release 释放幂等键，首次请求失败时客户端可以使用相同的幂等键重试
+	Release(userID int, key string) error
+	// PurgeExpired 清理在 before 之前过期的记录
+	PurgeExpired(before time.Time) error
+}
+
+// idempotencyService 幂等键服务实现
+type idempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepository
+}
+
+// NewIdempotencyService 创建幂等键服务实例
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{idempotencyRepo: idempotencyRepo}
+}
+
+// Begin 占用幂等键
+func (s *idempotencyService) Begin(record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	// 已有记录在读取前过期或被释放时重新占用一次
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		record.CreatedAt = now
+		record.ExpiresAt = now.Add(config.GlobalConfig.Idempotency.TTLHours)
+		created, err := s.idempotencyRepo.Create(record)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return nil, nil
+		}
+
+		existing, err := s.idempotencyRepo.Get(record.UserID, record.Key, now)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+	return nil, ErrIdempotencyKeyConflict
+}
+
+// Complete 保存首次请求的响应
+func (s *idempotencyService) Complete(record *model.IdempotencyRecord) error {
+	return s.idempotencyRepo.Complete(record)
+}
+
+// Release 释放幂等键
+func (s *idempotencyService) Release(userID int, key string) error {
+	return s.idempotencyRepo.Delete(userID, key)
+}
+
+// PurgeExpired 清理过期的记录
+func (s *idempotencyService) PurgeExpired(before time.Time) error {
+	return s.idempotencyRepo.DeleteExpired(before)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"todolist/internal/model"
+)
+
+// MaxImportItems 单次导入的最大任务数
+const MaxImportItems = 1000
+
+var (
+	ErrEmptyImport        = errors.New("没有可导入的任务")
+	ErrTooManyImportItems = fmt.Errorf("单次导入不能超过%d个任务", MaxImportItems)
+)
+
+// ImportItem 待导入的一个任务，由调用方从导入文件中解析
+type ImportItem struct {
+	// Row 在导入文件中的位置，CSV 为行号，JSON 为从1开始的序号
+	Row int
+	// Task 解析出的任务，UserID 由 Import 设置
+	Task *model.Task
+	// TagNames 按名称引用的标签，不存在的标签在导入时创建
+	TagNames []string
+	// Err 解析失败的原因，不为 nil 时不导入该项
+	Err error
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	// DryRun 为 true 时只校验并返回预览结果，不创建任务和标签
+	DryRun bool
+}
+
+// ImportItemResult 一个任务的导入结果
+type ImportItemResult struct {
+	Row int
+	// Task 创建的任务，预览时ID为0，新标签的ID也为0；失败时为空
+	Task *model.Task
+	// Err 失败的原因，为 nil 表示成功
+	Err error
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	// Items 与导入项一一对应
+	Items []ImportItemResult
+	// Created 成功的数量，预览时为可以导入的数量
+	Created int
+	Failed  int
+	// NewTags 新建的标签名，预览时为将要新建的标签
+	NewTags []string
+}
+
+// ImportService 任务导入服务接口
+type ImportService interface {
+	// Import 为用户创建任务，每一项单独校验，失败的项不影响其他项。
+	// 校验规则与创建任务相同，先整体预演一次，只为预演通过的任务创建新标签
+	Import(userID int, items []ImportItem, opts ImportOptions) (*ImportResult, error)
+}
+
+// importService 任务导入服务实现
+type importService struct {
+	taskService TaskService
+	tagService  TagService
+}
+
+// NewImportService 创建任务导入服务实例
+func NewImportService(taskService TaskService, tagService TagService) ImportService {
+	return &importService{
+		taskService: taskService,
+		tagService:  tagService,
+	}
+}
+
+// Import 导入任务
+func (s *importService) Import(userID int, items []ImportItem, opts ImportOptions) (*ImportResult, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyImport
+	}
+	if len(items) > MaxImportItems {
+		return nil, ErrTooManyImportItems
+	}
+
+	tags, err := s.tagService.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]model.Tag, len(tags))
+	for _, tag := range tags {
+		existing[tag.Name] = *tag
+	}
+
+	result := &ImportResult{Items: make([]ImportItemResult, len(items))}
+	// 预演时只关联已有的标签，新标签在预演通过后创建
+	pending := make([]int, 0, len(items))
+	for i, item := range items {
+		result.Items[i] = ImportItemResult{Row: item.Row, Err: item.Err}
+		if item.Err != nil {
+			continue
+		}
+		if err := validateTagNames(item.TagNames); err != nil {
+			result.Items[i].Err = err
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	if err := s.create(userID, items, pending, existing, true, result); err != nil {
+		return nil, err
+	}
+
+	// 通过预演的任务需要的新标签，按首次出现的顺序
+	var valid []int
+	for _, i := range pending {
+		if result.Items[i].Err != nil {
+			continue
+		}
+		valid = append(valid, i)
+		for _, name := range normalizeTagNames(items[i].TagNames) {
+			if _, ok := existing[name]; ok || slices.Contains(result.NewTags, name) {
+				continue
+			}
+			result.NewTags = append(result.NewTags, name)
+		}
+	}
+
+	if opts.DryRun {
+		for _, i := range valid {
+			task := result.Items[i].Task
+			task.ID = 0
+			for _, name := range normalizeTagNames(items[i].TagNames) {
+				if _, ok := existing[name]; !ok {
+					task.Tags = append(task.Tags, model.Tag{UserID: userID, Name: name})
+				}
+			}
+		}
+		result.count()
+		return result, nil
+	}
+
+	for _, name := range result.NewTags {
+		tag := &model.Tag{UserID: userID, Name: name}
+		if err := s.tagService.Create(tag); err != nil {
+			return nil, err
+		}
+		existing[name] = *tag
+	}
+	if err := s.create(userID, items, valid, existing, false, result); err != nil {
+		return nil, err
+	}
+	result.count()
+	return result, nil
+}
+
+// create 批量创建 indexes 指定的任务并将结果写入 result，每批不超过 MaxBatchOperations 项
+func (s *importService) create(userID int, items []ImportItem, indexes []int, tags map[string]model.Tag, dryRun bool, result *ImportResult) error {
+	for start := 0; start < len(indexes); start += MaxBatchOperations {
+		chunk := indexes[start:min(start+MaxBatchOperations, len(indexes))]
+		ops := make([]BatchOperation, len(chunk))
+		for j, i := range chunk {
+			task := *items[i].Task
+			task.Tags = nil
+			for _, name := range normalizeTagNames(items[i].TagNames) {
+				if tag, ok := tags[name]; ok {
+					task.Tags = append(task.Tags, tag)
+				}
+			}
+			ops[j] = BatchOperation{Op: BatchOpCreate, Task: &task}
+		}
+
+		results, err := s.taskService.Batch(userID, ops, BatchOptions{DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+		for j, i := range chunk {
+			result.Items[i].Task = results[j].Task
+			result.Items[i].Err = results[j].Err
+		}
+	}
+	return nil
+}
+
+// count 统计成功和失败的数量
+func (r *ImportResult) count() {
+	r.Created, r.Failed = 0, 0
+	for _, item := range r.Items {
+		if item.Err != nil {
+			r.Failed++
+		} else {
+			r.Created++
+		}
+	}
+}
+
+// validateTagNames 检查按名称引用的标签能否创建
+func validateTagNames(names []string) error {
+	for _, name := range normalizeTagNames(names) {
+		if len([]rune(name)) > 50 {
+			return ErrTagNameTooLong
+		}
+	}
+	return nil
+}
+
+// normalizeTagNames 去掉标签名两端的空白，忽略空名称和重复的名称
+func normalizeTagNames(names []string) []string {
+	var normalized []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(normalized, name) {
+			continue
+		}
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
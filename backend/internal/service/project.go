@@ -0,0 +1,274 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+var (
+	ErrProjectNotFound      = errors.New("项目不存在")
+	ErrProjectAccessDenied  = errors.New("无权访问该项目")
+	ErrProjectArchived      = errors.New("项目已归档")
+	ErrEmptyProjectName     = errors.New("项目名不能为空")
+	ErrProjectNameTooLong   = errors.New("项目名不能超过50个字符")
+	ErrInvalidProjectColor  = errors.New("项目颜色格式错误，应为 #RRGGBB")
+	ErrDuplicateProjectSort = errors.New("排序中存在重复的项目")
+)
+
+// ProjectService 项目服务接口
+type ProjectService interface {
+	// Create 创建项目，新项目排在最后；WorkspaceID 不为空时创建工作区项目，需要管理员权限
+	Create(project *model.Project) error
+	// Update 更新项目名称和颜色，空值表示不修改；project.UserID 为操作者
+	Update(project *model.Project) error
+	// SetArchived 归档或取消归档项目
+	SetArchived(projectID, userID int, archived bool) (*model.Project, error)
+	// Delete 删除项目，项目下的任务移出项目但不删除
+	Delete(projectID, userID int) error
+	// Get 获取项目详情
+	Get(projectID, userID int) (*model.Project, error)
+	// List 获取用户的个人项目，workspaceID 不为0时获取该工作区的项目；includeArchived 为 false 时不返回已归档项目
+	List(userID, workspaceID int, includeArchived bool) ([]*model.Project, error)
+	// Reorder 按给定顺序排列项目，未列出的项目保持原有顺序排在后面
+	Reorder(userID, workspaceID int, projectIDs []int) ([]*model.Project, error)
+}
+
+// projectService 项目服务实现
+// 个人项目只有创建者可以访问；工作区项目所有成员都可以查看，管理员可以修改
+type projectService struct {
+	projectRepo   repository.ProjectRepository
+	taskRepo      repository.TaskRepository
+	workspaceRepo repository.WorkspaceRepository
+}
+
+// NewProjectService 创建项目服务实例
+func NewProjectService(projectRepo repository.ProjectRepository, taskRepo repository.TaskRepository, workspaceRepo repository.WorkspaceRepository) ProjectService {
+	return &projectService{
+		projectRepo:   projectRepo,
+		taskRepo:      taskRepo,
+		workspaceRepo: workspaceRepo,
+	}
+}
+
+// Create 创建项目
+func (s *projectService) Create(project *model.Project) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if err := s.validateProject(project); err != nil {
+		return err
+	}
+
+	project.WorkspaceID = nilIfZero(project.WorkspaceID)
+	workspaceID := 0
+	if project.WorkspaceID != nil {
+		workspaceID = *project.WorkspaceID
+		if err := s.checkManage(workspaceID, project.UserID); err != nil {
+			return err
+		}
+	}
+
+	// 新项目排在最后
+	projects, err := s.scopeProjects(project.UserID, workspaceID, true)
+	if err != nil {
+		return err
+	}
+	project.Position = 1
+	for _, p := range projects {
+		if p.Position >= project.Position {
+			project.Position = p.Position + 1
+		}
+	}
+
+	now := time.Now()
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	return s.projectRepo.Create(project)
+}
+
+// Update 更新项目
+func (s *projectService) Update(project *model.Project) error {
+	oldProject, err := s.getManageable(project.ID, project.UserID)
+	if err != nil {
+		return err
+	}
+
+	if name := strings.TrimSpace(project.Name); name != "" {
+		oldProject.Name = name
+	}
+	if project.Color != "" {
+		oldProject.Color = project.Color
+	}
+	if err := s.validateProject(oldProject); err != nil {
+		return err
+	}
+
+	oldProject.UpdatedAt = time.Now()
+	if err := s.projectRepo.Update(oldProject); err != nil {
+		return err
+	}
+	*project = *oldProject
+	return nil
+}
+
+// SetArchived 归档或取消归档项目
+func (s *projectService) SetArchived(projectID, userID int, archived bool) (*model.Project, error) {
+	project, err := s.getManageable(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived == archived {
+		return project, nil
+	}
+
+	project.Archived = archived
+	project.UpdatedAt = time.Now()
+	if err := s.projectRepo.Update(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Delete 删除项目
+func (s *projectService) Delete(projectID, userID int) error {
+	if _, err := s.getManageable(projectID, userID); err != nil {
+		return err
+	}
+	if err := s.taskRepo.ClearProject(projectID); err != nil {
+		return err
+	}
+	return s.projectRepo.Delete(projectID)
+}
+
+// Get 获取项目详情
+func (s *projectService) Get(projectID, userID int) (*model.Project, error) {
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	// 个人项目验证所有权，工作区项目验证成员身份
+	if project.WorkspaceID == nil {
+		if project.UserID != userID {
+			return nil, ErrProjectAccessDenied
+		}
+		return project, nil
+	}
+	member, err := s.workspaceRepo.GetMember(*project.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrProjectAccessDenied
+	}
+	return project, nil
+}
+
+// getManageable 获取项目并验证用户可以修改，工作区项目需要管理员权限
+func (s *projectService) getManageable(projectID, userID int) (*model.Project, error) {
+	project, err := s.Get(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if project.WorkspaceID != nil {
+		if err := s.checkManage(*project.WorkspaceID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return project, nil
+}
+
+// checkManage 验证用户可以管理工作区的项目
+func (s *projectService) checkManage(workspaceID, userID int) error {
+	role, err := memberRole(s.workspaceRepo, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if model.WorkspaceRoleRank(role) < model.WorkspaceRoleRank(model.WorkspaceRoleAdmin) {
+		return ErrWorkspacePermission
+	}
+	return nil
+}
+
+// scopeProjects 获取用户的个人项目或工作区的项目
+func (s *projectService) scopeProjects(userID, workspaceID int, includeArchived bool) ([]*model.Project, error) {
+	if workspaceID == 0 {
+		return s.projectRepo.GetByUserID(userID, includeArchived)
+	}
+	return s.projectRepo.GetByWorkspaceID(workspaceID, includeArchived)
+}
+
+// List 获取用户的项目
+func (s *projectService) List(userID, workspaceID int, includeArchived bool) ([]*model.Project, error) {
+	if workspaceID != 0 {
+		if _, err := memberRole(s.workspaceRepo, workspaceID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.scopeProjects(userID, workspaceID, includeArchived)
+}
+
+// Reorder 按给定顺序排列项目
+func (s *projectService) Reorder(userID, workspaceID int, projectIDs []int) ([]*model.Project, error) {
+	if workspaceID != 0 {
+		if err := s.checkManage(workspaceID, userID); err != nil {
+			return nil, err
+		}
+	}
+	projects, err := s.scopeProjects(userID, workspaceID, true)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*model.Project, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = project
+	}
+
+	ordered := make([]*model.Project, 0, len(projects))
+	listed := make(map[int]bool, len(projectIDs))
+	for _, id := range projectIDs {
+		project, ok := byID[id]
+		if !ok {
+			return nil, ErrProjectNotFound
+		}
+		if listed[id] {
+			return nil, ErrDuplicateProjectSort
+		}
+		listed[id] = true
+		ordered = append(ordered, project)
+	}
+	for _, project := range projects {
+		if !listed[project.ID] {
+			ordered = append(ordered, project)
+		}
+	}
+
+	positions := make(map[int]int, len(ordered))
+	for i, project := range ordered {
+		project.Position = i + 1
+		positions[project.ID] = project.Position
+	}
+	if err := s.projectRepo.UpdatePositions(positions); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// validateProject 验证项目名和颜色
+func (s *projectService) validateProject(project *model.Project) error {
+	if project.Name == "" {
+		return ErrEmptyProjectName
+	}
+	if len([]rune(project.Name)) > 50 {
+		return ErrProjectNameTooLong
+	}
+	if project.Color != "" && !colorPattern.MatchString(project.Color) {
+		return ErrInvalidProjectColor
+	}
+	return nil
+}
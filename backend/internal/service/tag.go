@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+var (
+	ErrTagNotFound     = errors.New("标签不存在")
+	ErrTagAccessDenied = errors.New("无权访问该标签")
+	ErrTagExists       = errors.New("标签已存在")
+	ErrEmptyTagName    = errors.New("标签名不能为空")
+	ErrTagNameTooLong  = errors.New("标签名不能超过50个字符")
+	ErrInvalidTagColor = errors.New("标签颜色格式错误，应为 #RRGGBB")
+)
+
+// colorPattern 标签和项目的颜色格式
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// TagService 标签服务接口
+type TagService interface {
+	// Create 创建标签
+	Create(tag *model.Tag) error
+	// Update 更新标签
+	Update(tag *model.Tag) error
+	// Delete 删除标签
+	Delete(tagID, userID int) error
+	// Get 获取标签详情
+	Get(tagID, userID int) (*model.Tag, error)
+	// List 获取用户的全部标签
+	List(userID int) ([]*model.Tag, error)
+}
+
+// tagService 标签服务实现
+type tagService struct {
+	tagRepo repository.TagRepository
+}
+
+// NewTagService 创建标签服务实例
+func NewTagService(tagRepo repository.TagRepository) TagService {
+	return &tagService{
+		tagRepo: tagRepo,
+	}
+}
+
+// Create 创建标签
+func (s *tagService) Create(tag *model.Tag) error {
+	tag.Name = strings.TrimSpace(tag.Name)
+	if err := s.validateTag(tag); err != nil {
+		return err
+	}
+
+	// 检查标签名是否重复
+	existing, err := s.tagRepo.GetByName(tag.UserID, tag.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrTagExists
+	}
+
+	now := time.Now()
+	tag.CreatedAt = now
+	tag.UpdatedAt = now
+	return s.tagRepo.Create(tag)
+}
+
+// Update 更新标签
+func (s *tagService) Update(tag *model.Tag) error {
+	oldTag, err := s.Get(tag.ID, tag.UserID)
+	if err != nil {
+		return err
+	}
+
+	if name := strings.TrimSpace(tag.Name); name != "" && name != oldTag.Name {
+		existing, err := s.tagRepo.GetByName(tag.UserID, name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return ErrTagExists
+		}
+		oldTag.Name = name
+	}
+	if tag.Color != "" {
+		oldTag.Color = tag.Color
+	}
+	if err := s.validateTag(oldTag); err != nil {
+		return err
+	}
+
+	oldTag.UpdatedAt = time.Now()
+	if err := s.tagRepo.Update(oldTag); err != nil {
+		return err
+	}
+	*tag = *oldTag
+	return nil
+}
+
+// Delete 删除标签
+func (s *tagService) Delete(tagID, userID int) error {
+	if _, err := s.Get(tagID, userID); err != nil {
+		return err
+	}
+	return s.tagRepo.Delete(tagID)
+}
+
+// Get 获取标签详情
+func (s *tagService) Get(tagID, userID int) (*model.Tag, error) {
+	tag, err := s.tagRepo.GetByID(tagID)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+
+	// 验证标签所有权
+	if tag.UserID != userID {
+		return nil, ErrTagAccessDenied
+	}
+	return tag, nil
+}
+
+// List 获取用户的全部标签
+func (s *tagService) List(userID int) ([]*model.Tag, error) {
+	return s.tagRepo.GetByUserID(userID)
+}
+
+// validateTag 验证标签名和颜色
+func (s *tagService) validateTag(tag *model.Tag) error {
+	if tag.Name == "" {
+		return ErrEmptyTagName
+	}
+	if len([]rune(tag.Name)) > 50 {
+		return ErrTagNameTooLong
+	}
+	if tag.Color != "" && !colorPattern.MatchString(tag.Color) {
+		return ErrInvalidTagColor
+	}
+	return nil
+}
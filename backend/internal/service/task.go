@@ -2,10 +2,14 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
+	"todolist/internal/event"
 	"todolist/internal/model"
 	"todolist/internal/repository"
+	"todolist/pkg/rrule"
 )
 
 var (
@@ -15,31 +19,105 @@ var (
 	ErrEmptyTitle         = errors.New("任务标题不能为空")
 	ErrTitleTooLong       = errors.New("任务标题不能超过100个字符")
 	ErrDescriptionTooLong = errors.New("任务描述不能超过500个字符")
+	ErrInvalidPriority    = errors.New("无效的任务优先级")
+	ErrInvalidTags        = errors.New("标签不存在或无权使用")
+	ErrInvalidParentTask  = errors.New("父任务不存在或无权访问")
+	ErrTaskDepthExceeded  = errors.New("子任务层级超过限制")
+	ErrTaskCycle          = errors.New("不能将任务移动到自身或其子任务下")
+	ErrTaskHasSubtasks    = errors.New("任务存在子任务，请先删除子任务或使用级联删除")
+	ErrInvalidRecurrence  = errors.New("无效的重复规则")
+	ErrRecurrenceNoDue    = errors.New("重复任务必须设置截止日期")
+	ErrInvalidProject     = errors.New("项目不存在或无权访问")
+	ErrSubtaskProject     = errors.New("子任务必须与父任务属于同一项目")
+	ErrTaskReadOnly       = errors.New("只有查看权限，无法修改该任务")
+	ErrTaskOwnerOnly      = errors.New("只有任务所有者可以执行该操作")
+	ErrInvalidAssignee    = errors.New("负责人不存在或无权访问该任务")
+	ErrTooManyAssignees   = fmt.Errorf("任务负责人不能超过%d个", model.MaxTaskAssignees)
+	ErrAssigneeNotFound   = errors.New("该用户不是任务负责人")
+	ErrParentInTrash      = errors.New("父任务在回收站中，请先恢复父任务")
+	ErrVersionConflict    = errors.New("任务已被修改，请获取最新版本后重试")
+	ErrInvalidStatus      = errors.New("无效的任务状态")
 )
 
+// DeleteOptions 删除任务选项
+type DeleteOptions struct {
+	// Cascade 为 true 时一并删除全部子任务，否则存在子任务时拒绝删除
+	Cascade bool
+}
+
 // TaskService 任务服务接口
 type TaskService interface {
 	// Create 创建任务
 	Create(task *model.Task) error
-	// Update 更新任务
+	// Update 更新任务，ParentID 为 nil 表示不修改，指向0表示移动到顶层；
+	// Recurrence 为 nil 表示不修改，指向空字符串表示取消重复。
+	// Status 总是被写入（零值为待办），Priority 为无时不修改；只修改部分字段时使用 Patch。
+	// 重复任务被标记为已完成时会生成下一次任务，通过 task.NextOccurrence 返回。
+	// 协作者中 editor 可以修改任务内容，移动任务只有所有者可以操作。
+	// task.Version 不为0时必须与当前版本一致，否则返回 ErrVersionConflict
 	Update(task *model.Task) error
-	// Delete 删除任务
-	Delete(taskID, userID int) error
-	// Get 获取任务详情
+	// Patch 按 patch 部分更新任务，只修改提供的字段，可以清空描述、截止日期等可选字段；
+	// 权限、版本号和返回值与 Update 相同，生成的下一次任务通过返回值的 NextOccurrence 返回
+	Patch(taskID, userID int, patch model.TaskPatch) (*model.Task, error)
+	// Delete 将任务及其子任务移入回收站，只有任务所有者和工作区管理员可以删除
+	Delete(taskID, userID int, opts DeleteOptions) error
+	// Get 获取任务详情，所有者、协作者和工作区成员都可以访问
 	Get(taskID, userID int) (*model.Task, error)
-	// List 获取任务列表
-	List(userID int, status string, page, pageSize int) ([]*model.Task, int64, error)
+	// List 按条件获取用户自己的和共享给用户的任务列表，filter.WorkspaceID 不为0时获取该工作区的任务；
+	// filter.AllScopes 为 true 时获取各个空间中指派给用户且用户仍有权访问的任务
+	List(filter model.TaskFilter) ([]*model.Task, int64, error)
+	// ListVersions 获取 List 在相同条件下会返回的全部任务的ID和版本号，不加载任务内容，忽略分页
+	ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error)
+	// Subtasks 获取任务的直接子任务
+	Subtasks(taskID, userID int) ([]*model.Task, error)
+	// SetAssignees 将任务的负责人替换为 assigneeIDs，空列表表示取消全部指派；需要编辑权限，负责人必须有权访问该任务
+	SetAssignees(taskID, userID int, assigneeIDs []int) (*model.Task, error)
+	// AddAssignee 添加负责人，需要编辑权限
+	AddAssignee(taskID, userID, assigneeID int) (*model.Task, error)
+	// RemoveAssignee 移除负责人，需要编辑权限，负责人可以移除自己
+	RemoveAssignee(taskID, userID, assigneeID int) error
+	// Trash 获取回收站中的任务，默认按删除时间倒序；filter.WorkspaceID 不为0时获取工作区的回收站，需要管理员权限
+	Trash(filter model.TaskFilter) ([]*model.Task, int64, error)
+	// Restore 从回收站恢复任务，与任务一起删除的子任务一并恢复；父任务仍在回收站中时返回 ErrParentInTrash
+	Restore(taskID, userID int) (*model.Task, error)
+	// Purge 永久删除回收站中的任务及其子任务
+	Purge(taskID, userID int) error
+	// PurgeExpired 永久删除在 before 之前移入回收站的任务，返回删除的任务数
+	PurgeExpired(before time.Time) (int, error)
+	// Batch 在同一事务中按顺序执行批量操作，返回每一项的结果。opts.Atomic 为 true 时任意一项失败则全部回滚，
+	// 失败项返回原因，其他项返回 ErrBatchAborted；否则只回滚失败的项。返回的错误只表示整个请求无法执行
+	Batch(userID int, ops []BatchOperation, opts BatchOptions) ([]BatchResult, error)
 }
 
 // taskService 任务服务实现
 type taskService struct {
-	taskRepo repository.TaskRepository
+	taskRepo      repository.TaskRepository
+	tagRepo       repository.TagRepository
+	projectRepo   repository.ProjectRepository
+	collabRepo    repository.CollaboratorRepository
+	workspaceRepo repository.WorkspaceRepository
+	assigneeRepo  repository.AssigneeRepository
+	commentRepo   repository.CommentRepository
+	historyRepo   repository.HistoryRepository
+	access        taskAccess
+	events        *event.Bus
+	// pending 在 Batch 的事务中暂存待发布的事件，为 nil 时直接发布
+	pending *[]event.Event
 }
 
 // NewTaskService 创建任务服务实例
-func NewTaskService(taskRepo repository.TaskRepository) TaskService {
+func NewTaskService(taskRepo repository.TaskRepository, tagRepo repository.TagRepository, projectRepo repository.ProjectRepository, collabRepo repository.CollaboratorRepository, workspaceRepo repository.WorkspaceRepository, assigneeRepo repository.AssigneeRepository, commentRepo repository.CommentRepository, historyRepo repository.HistoryRepository, events *event.Bus) TaskService {
 	return &taskService{
-		taskRepo: taskRepo,
+		taskRepo:      taskRepo,
+		tagRepo:       tagRepo,
+		projectRepo:   projectRepo,
+		collabRepo:    collabRepo,
+		workspaceRepo: workspaceRepo,
+		assigneeRepo:  assigneeRepo,
+		commentRepo:   commentRepo,
+		historyRepo:   historyRepo,
+		access:        taskAccess{taskRepo: taskRepo, collabRepo: collabRepo, workspaceRepo: workspaceRepo},
+		events:        events,
 	}
 }
 
@@ -55,87 +133,1023 @@ func (s *taskService) Create(task *model.Task) error {
 		return err
 	}
 
+	// 在工作区中创建任务需要成员及以上角色，访客只读
+	task.WorkspaceID = nilIfZero(task.WorkspaceID)
+	if task.WorkspaceID != nil {
+		role, err := memberRole(s.workspaceRepo, *task.WorkspaceID, task.UserID)
+		if err != nil {
+			return err
+		}
+		if role == model.WorkspaceRoleGuest {
+			return ErrWorkspacePermission
+		}
+	}
+
+	// 验证优先级
+	if err := s.validatePriority(task.Priority); err != nil {
+		return err
+	}
+
+	// 验证标签归属
+	tags, err := s.resolveTags(task.UserID, task.Tags)
+	if err != nil {
+		return err
+	}
+	task.Tags = tags
+
+	// 验证重复规则
+	if task.Recurrence != nil && *task.Recurrence == "" {
+		task.Recurrence = nil
+	}
+	if err := s.normalizeRecurrence(task); err != nil {
+		return err
+	}
+
+	// 验证父任务和层级，子任务与父任务属于同一项目
+	task.ParentID = nilIfZero(task.ParentID)
+	task.ProjectID = nilIfZero(task.ProjectID)
+	if task.ParentID != nil {
+		parent, err := s.validateParent(task.UserID, task.WorkspaceID, *task.ParentID, 0, 1)
+		if err != nil {
+			return err
+		}
+		if task.ProjectID != nil && !sameID(task.ProjectID, parent.ProjectID) {
+			return ErrSubtaskProject
+		}
+		task.ProjectID = parent.ProjectID
+	} else if task.ProjectID != nil {
+		if err := s.validateProject(task.UserID, task.WorkspaceID, *task.ProjectID); err != nil {
+			return err
+		}
+	}
+
 	// 设置创建和更新时间
 	now := time.Now()
 	task.CreatedAt = now
 	task.UpdatedAt = now
 
-	return s.taskRepo.Create(task)
+	if err := s.taskRepo.Create(task); err != nil {
+		return err
+	}
+	if err := s.record(task.ID, task.UserID, model.TaskHistoryActionCreate, snapshotTask(task, false)); err != nil {
+		return err
+	}
+	task.Role = model.TaskRoleOwner
+	if task.ParentID != nil {
+		return s.rollupParent(*task.ParentID, task.UserID)
+	}
+	return nil
 }
 
 // Get 获取任务详情
 func (s *taskService) Get(taskID, userID int) (*model.Task, error) {
-	task, err := s.taskRepo.GetByID(taskID)
+	task, err := s.access.get(taskID, userID)
 	if err != nil {
 		return nil, err
 	}
-	if task == nil {
-		return nil, ErrTaskNotFound
+	if err := s.fillDetails([]*model.Task{task}); err != nil {
+		return nil, err
 	}
+	return task, nil
+}
 
-	// 验证任务所有权
-	if task.UserID != userID {
-		return nil, ErrTaskAccessDenied
+// getOwned 获取任务并验证所有者权限，协作者和普通工作区成员返回 ErrTaskOwnerOnly
+func (s *taskService) getOwned(taskID, userID int) (*model.Task, error) {
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Role != model.TaskRoleOwner {
+		return nil, ErrTaskOwnerOnly
 	}
-
 	return task, nil
 }
 
 // List 获取任务列表
-func (s *taskService) List(userID int, status string, page, pageSize int) ([]*model.Task, int64, error) {
-	return s.taskRepo.GetByUserID(userID, status, page, pageSize)
+func (s *taskService) List(filter model.TaskFilter) ([]*model.Task, int64, error) {
+	filter, roles, memberRoleInWorkspace, err := s.scopeFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tasks, total, err := s.taskRepo.List(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, task := range tasks {
+		switch {
+		case task.WorkspaceID == nil && task.UserID == filter.UserID:
+			task.Role = model.TaskRoleOwner
+		case filter.WorkspaceID != 0:
+			task.Role = memberTaskRole(memberRoleInWorkspace, task.UserID == filter.UserID)
+		default:
+			task.Role = roles[task.ID]
+		}
+	}
+	if err := s.fillDetails(tasks); err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// ListVersions 获取 List 会返回的全部任务的ID和版本号
+func (s *taskService) ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error) {
+	filter, _, _, err := s.scopeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.taskRepo.ListVersions(filter)
+}
+
+// scopeFilter 按用户可以访问的范围补充过滤条件，返回个人空间或指派给我视图中各任务的角色，以及用户在工作区中的角色
+func (s *taskService) scopeFilter(filter model.TaskFilter) (model.TaskFilter, map[int]string, string, error) {
+	// 工作区中的任务对全部成员可见，角色由成员角色决定；个人空间包括共享给用户的任务；
+	// 指派给我视图跨越全部空间，只包括用户仍有权访问的任务
+	var roles map[int]string
+	memberRoleInWorkspace := ""
+	var err error
+	switch {
+	case filter.AllScopes:
+		filter.AssigneeID = filter.UserID
+		filter.WorkspaceID = 0
+		filter.SharedTaskIDs = nil
+		if roles, err = s.assignedTaskRoles(filter.UserID); err != nil {
+			return filter, nil, "", err
+		}
+		filter.AssignedTaskIDs = sortedTaskIDs(roles)
+	case filter.WorkspaceID != 0:
+		if memberRoleInWorkspace, err = memberRole(s.workspaceRepo, filter.WorkspaceID, filter.UserID); err != nil {
+			return filter, nil, "", err
+		}
+		filter.SharedTaskIDs = nil
+	default:
+		if roles, err = s.sharedTaskRoles(filter.UserID); err != nil {
+			return filter, nil, "", err
+		}
+		filter.SharedTaskIDs = sortedTaskIDs(roles)
+	}
+
+	if filter.AssigneeID != 0 && !filter.AllScopes {
+		assignments, err := s.assigneeRepo.GetByUserID(filter.AssigneeID)
+		if err != nil {
+			return filter, nil, "", err
+		}
+		filter.AssignedTaskIDs = make([]int, 0, len(assignments))
+		for _, assignment := range assignments {
+			filter.AssignedTaskIDs = append(filter.AssignedTaskIDs, assignment.TaskID)
+		}
+	}
+	return filter, roles, memberRoleInWorkspace, nil
 }
 
-// Update 更新任务
+// Subtasks 获取任务的直接子任务
+func (s *taskService) Subtasks(taskID, userID int) ([]*model.Task, error) {
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := s.taskRepo.GetChildren(taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		// 子任务继承父任务上的角色，子任务自身的协作记录优先
+		child.Role = task.Role
+		if task.Role != model.TaskRoleOwner {
+			collaborator, err := s.collabRepo.Get(child.ID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if collaborator != nil {
+				child.Role = collaborator.Role
+			}
+		}
+	}
+	if err := s.fillDetails(children); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// Update 更新任务，空字段视为未提供，状态总是被修改，见 patchFromTask
 func (s *taskService) Update(task *model.Task) error {
-	// 获取任务
-	oldTask, err := s.Get(task.ID, task.UserID)
+	updated, err := s.Patch(task.ID, task.UserID, patchFromTask(task))
 	if err != nil {
 		return err
 	}
+	*task = *updated
+	return nil
+}
 
-	// 验证任务标题
+// patchFromTask 将 Update 的参数转换为部分更新，空字符串和 nil 表示不修改。
+// 状态的零值是待办，无法区分未提供，因此状态总是被修改；优先级为无时不修改。需要区分时使用 Patch
+func patchFromTask(task *model.Task) model.TaskPatch {
+	patch := model.TaskPatch{Version: task.Version}
 	if task.Title != "" {
-		if err := s.validateTaskTitle(task.Title); err != nil {
-			return err
+		patch.Title = model.Some(task.Title)
+	}
+	if task.Description != "" {
+		patch.Description = model.Some(task.Description)
+	}
+	if task.DueDate != nil && !task.DueDate.IsZero() {
+		patch.DueDate = model.Some(task.DueDate)
+	}
+	if task.Status >= model.TaskStatusTodo && task.Status <= model.TaskStatusDone {
+		patch.Status = model.Some(task.Status)
+	}
+	if task.Priority != model.TaskPriorityNone {
+		patch.Priority = model.Some(task.Priority)
+	}
+	if task.Tags != nil {
+		patch.TagIDs = model.Some(task.TagIDs())
+	}
+	if task.Recurrence != nil {
+		patch.Recurrence = model.Some(task.Recurrence)
+	}
+	if task.ParentID != nil {
+		patch.ParentID = model.Some(task.ParentID)
+	}
+	if task.ProjectID != nil {
+		patch.ProjectID = model.Some(task.ProjectID)
+	}
+	return patch
+}
+
+// Patch 部分更新任务
+func (s *taskService) Patch(taskID, userID int, patch model.TaskPatch) (*model.Task, error) {
+	// 获取任务并验证编辑权限
+	oldTask, err := s.access.get(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	role := oldTask.Role
+	if !model.CanEdit(role) {
+		return nil, ErrTaskReadOnly
+	}
+	// 客户端提供的版本号已过期时拒绝修改，避免覆盖其他人的修改
+	version := oldTask.Version
+	if patch.Version != 0 && patch.Version != version {
+		return nil, ErrVersionConflict
+	}
+	before := *oldTask
+	oldStatus := oldTask.Status
+	oldParentID := oldTask.ParentID
+	oldProjectID := oldTask.ProjectID
+
+	// 验证任务标题
+	if patch.Title.Set {
+		if err := s.validateTaskTitle(patch.Title.Value); err != nil {
+			return nil, err
 		}
-		oldTask.Title = task.Title
+		oldTask.Title = patch.Title.Value
 	}
 
-	// 验证任务描述
-	if task.Description != "" {
-		if err := s.validateTaskDescription(task.Description); err != nil {
-			return err
+	// 验证任务描述，空字符串表示清空
+	if patch.Description.Set {
+		if err := s.validateTaskDescription(patch.Description.Value); err != nil {
+			return nil, err
 		}
-		oldTask.Description = task.Description
+		oldTask.Description = patch.Description.Value
 	}
 
-	// 验证截止日期
-	if task.DueDate != nil && !task.DueDate.IsZero() {
-		if err := s.validateDueDate(task.DueDate); err != nil {
-			return err
+	// 验证截止日期，nil 表示清除
+	if patch.DueDate.Set {
+		dueDate := patch.DueDate.Value
+		if dueDate != nil && dueDate.IsZero() {
+			dueDate = nil
+		}
+		if err := s.validateDueDate(dueDate); err != nil {
+			return nil, err
 		}
-		oldTask.DueDate = task.DueDate
+		oldTask.DueDate = dueDate
 	}
 
 	// 更新状态
-	if task.Status >= model.TaskStatusTodo && task.Status <= model.TaskStatusDone {
-		oldTask.Status = task.Status
+	if patch.Status.Set {
+		if patch.Status.Value < model.TaskStatusTodo || patch.Status.Value > model.TaskStatusDone {
+			return nil, ErrInvalidStatus
+		}
+		oldTask.Status = patch.Status.Value
+	}
+
+	// 更新优先级
+	if patch.Priority.Set {
+		if err := s.validatePriority(patch.Priority.Value); err != nil {
+			return nil, err
+		}
+		oldTask.Priority = patch.Priority.Value
+	}
+
+	// 更新标签，空列表表示清空
+	if patch.TagIDs.Set {
+		tags := make([]model.Tag, 0, len(patch.TagIDs.Value))
+		for _, id := range patch.TagIDs.Value {
+			tags = append(tags, model.Tag{ID: id})
+		}
+		if tags, err = s.resolveTags(oldTask.UserID, tags); err != nil {
+			return nil, err
+		}
+		oldTask.Tags = tags
+	}
+
+	// 更新重复规则，nil 和空字符串表示取消重复
+	if patch.Recurrence.Set {
+		if patch.Recurrence.Value == nil || *patch.Recurrence.Value == "" {
+			oldTask.Recurrence = nil
+		} else {
+			oldTask.Recurrence = patch.Recurrence.Value
+		}
+	}
+	if err := s.normalizeRecurrence(oldTask); err != nil {
+		return nil, err
 	}
 
+	// 重复任务完成时计算下一次任务，当前任务不再重复，避免重新打开后再次完成时重复生成
+	var next *model.Task
+	if oldTask.Status == model.TaskStatusDone && oldStatus != model.TaskStatusDone && oldTask.Recurrence != nil {
+		if next, err = nextOccurrence(oldTask); err != nil {
+			return nil, err
+		}
+		oldTask.Recurrence = nil
+	}
+
+	// 移动任务，nil 和0表示移动到顶层；移动到其他任务下时跟随新父任务的项目
+	parentChanged := false
+	if patch.ParentID.Set {
+		newParentID := 0
+		if patch.ParentID.Value != nil {
+			newParentID = *patch.ParentID.Value
+		}
+		if newParentID != parentIDOf(oldTask) {
+			if role != model.TaskRoleOwner {
+				return nil, ErrTaskOwnerOnly
+			}
+			if newParentID == 0 {
+				oldTask.ParentID = nil
+			} else {
+				height, err := s.subtreeHeight(oldTask.ID)
+				if err != nil {
+					return nil, err
+				}
+				parent, err := s.validateParent(userID, oldTask.WorkspaceID, newParentID, oldTask.ID, height)
+				if err != nil {
+					return nil, err
+				}
+				oldTask.ParentID = &newParentID
+				oldTask.ProjectID = parent.ProjectID
+			}
+			parentChanged = true
+		}
+	}
+
+	// 移动到其他项目，nil 和0表示移出项目；子任务只能跟随父任务所在的项目
+	if patch.ProjectID.Set {
+		newProjectID := nilIfZero(patch.ProjectID.Value)
+		if oldTask.ParentID != nil {
+			if !sameID(newProjectID, oldTask.ProjectID) {
+				return nil, ErrSubtaskProject
+			}
+		} else if !sameID(newProjectID, oldTask.ProjectID) {
+			if role != model.TaskRoleOwner {
+				return nil, ErrTaskOwnerOnly
+			}
+			if newProjectID != nil {
+				if err := s.validateProject(oldTask.UserID, oldTask.WorkspaceID, *newProjectID); err != nil {
+					return nil, err
+				}
+			}
+			oldTask.ProjectID = newProjectID
+		}
+	}
+	projectChanged := !sameID(oldProjectID, oldTask.ProjectID)
+
+	// 读取后任务被并发修改时同样返回冲突
 	oldTask.UpdatedAt = time.Now()
-	return s.taskRepo.Update(oldTask)
+	updated, err := s.taskRepo.UpdateIfVersion(oldTask, version)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrVersionConflict
+	}
+	if err := s.record(oldTask.ID, userID, model.TaskHistoryActionUpdate, diffTask(&before, oldTask)); err != nil {
+		return nil, err
+	}
+
+	// 子任务随任务一起移动到新项目
+	if projectChanged {
+		descendants, err := s.descendantIDs(oldTask.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.taskRepo.UpdateProject(descendants, oldTask.ProjectID); err != nil {
+			return nil, err
+		}
+		change := []model.FieldChange{{Field: "project_id", Before: idValue(oldProjectID), After: idValue(oldTask.ProjectID)}}
+		for _, id := range descendants {
+			if err := s.record(id, userID, model.TaskHistoryActionUpdate, change); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 先创建下一次任务再汇总，父任务不会因最后一个子任务完成而被短暂标记为已完成
+	// 下一次任务直接复制已验证过的字段，即使所在项目已归档也照常生成
+	if next != nil {
+		next.CreatedAt = oldTask.UpdatedAt
+		next.UpdatedAt = oldTask.UpdatedAt
+		if err := s.taskRepo.Create(next); err != nil {
+			return nil, err
+		}
+		if err := s.record(next.ID, userID, model.TaskHistoryActionCreate, snapshotTask(next, false)); err != nil {
+			return nil, err
+		}
+		if err := s.copyCollaborators(oldTask.ID, next.ID); err != nil {
+			return nil, err
+		}
+		if err := s.copyAssignees(oldTask.ID, next.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 子任务状态或位置变化时，重新汇总父任务的完成状态
+	if parentChanged && oldParentID != nil {
+		if err := s.rollupParent(*oldParentID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if oldTask.ParentID != nil && (parentChanged || oldTask.Status != oldStatus) {
+		if err := s.rollupParent(*oldTask.ParentID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 将更新后的完整任务返回给调用方
+	if err := s.fillDetails([]*model.Task{oldTask}); err != nil {
+		return nil, err
+	}
+	oldTask.Role = role
+	oldTask.NextOccurrence = next
+	return oldTask, nil
 }
 
 // Delete 删除任务
-func (s *taskService) Delete(taskID, userID int) error {
+func (s *taskService) Delete(taskID, userID int, opts DeleteOptions) error {
 	// 验证任务所有权
-	_, err := s.Get(taskID, userID)
+	task, err := s.getOwned(taskID, userID)
 	if err != nil {
 		return err
 	}
 
-	return s.taskRepo.Delete(taskID)
+	descendants, err := s.descendantIDs(taskID)
+	if err != nil {
+		return err
+	}
+	if len(descendants) > 0 && !opts.Cascade {
+		return ErrTaskHasSubtasks
+	}
+
+	// 移入回收站，协作者、负责人和评论保留到永久删除时，恢复后不受影响
+	taskIDs := append(descendants, taskID)
+	deleted := make([]*model.Task, 0, len(taskIDs))
+	for _, id := range taskIDs {
+		deletedTask, err := s.taskRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if deletedTask != nil {
+			deleted = append(deleted, deletedTask)
+		}
+	}
+	if err := s.fillAssignees(deleted); err != nil {
+		return err
+	}
+	if err := s.taskRepo.SoftDeleteByIDs(taskIDs, time.Now()); err != nil {
+		return err
+	}
+	for _, deletedTask := range deleted {
+		if err := s.record(deletedTask.ID, userID, model.TaskHistoryActionDelete, snapshotTask(deletedTask, true)); err != nil {
+			return err
+		}
+	}
+	if task.ParentID != nil {
+		return s.rollupParent(*task.ParentID, userID)
+	}
+	return nil
+}
+
+// normalizeRecurrence 验证重复规则，并统一为规范格式
+func (s *taskService) normalizeRecurrence(task *model.Task) error {
+	if task.Recurrence == nil {
+		return nil
+	}
+	rule, err := rrule.Parse(*task.Recurrence)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	if task.DueDate == nil || task.DueDate.IsZero() {
+		return ErrRecurrenceNoDue
+	}
+	normalized := rule.String()
+	task.Recurrence = &normalized
+	return nil
+}
+
+// nextOccurrence 根据重复规则构造下一次任务，序列已结束时返回 nil
+// 下一次任务以新的截止日期为起点继续重复，COUNT 相应减一
+func nextOccurrence(task *model.Task) (*model.Task, error) {
+	rule, err := rrule.Parse(*task.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+
+	dueDate, ok := rule.After(*task.DueDate, *task.DueDate)
+	if !ok {
+		return nil, nil
+	}
+	if rule.Count > 0 {
+		rule.Count--
+	}
+	recurrence := rule.String()
+
+	return &model.Task{
+		UserID:      task.UserID,
+		WorkspaceID: task.WorkspaceID,
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      model.TaskStatusTodo,
+		Priority:    task.Priority,
+		DueDate:     &dueDate,
+		Recurrence:  &recurrence,
+		Tags:        append([]model.Tag{}, task.Tags...),
+	}, nil
+}
+
+// sharedTaskRoles 获取共享给用户的全部任务及用户在其上的角色
+// 共享任务的后代一并共享，后代自身的协作记录优先
+func (s *taskService) sharedTaskRoles(userID int) (map[int]string, error) {
+	collaborations, err := s.collabRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := make(map[int]string, len(collaborations))
+	for _, collaboration := range collaborations {
+		direct[collaboration.TaskID] = collaboration.Role
+	}
+
+	roles := make(map[int]string, len(direct))
+	var expand func(taskID int, role string) error
+	expand = func(taskID int, role string) error {
+		roles[taskID] = role
+		children, err := s.taskRepo.GetChildren(taskID)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			childRole := role
+			if r, ok := direct[child.ID]; ok {
+				childRole = r
+			}
+			if err := expand(child.ID, childRole); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for taskID, role := range direct {
+		if err := expand(taskID, role); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// SetAssignees 替换任务的负责人
+func (s *taskService) SetAssignees(taskID, userID int, assigneeIDs []int) (*model.Task, error) {
+	task, err := s.getEditable(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillAssignees([]*model.Task{task}); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(assigneeIDs))
+	assignees := make([]*model.TaskAssignee, 0, len(assigneeIDs))
+	now := time.Now()
+	for _, assigneeID := range assigneeIDs {
+		if seen[assigneeID] {
+			continue
+		}
+		seen[assigneeID] = true
+		if err := s.validateAssignee(task, assigneeID); err != nil {
+			return nil, err
+		}
+		assignees = append(assignees, &model.TaskAssignee{TaskID: taskID, UserID: assigneeID, AssignedBy: userID, CreatedAt: now})
+	}
+	if len(assignees) > model.MaxTaskAssignees {
+		return nil, ErrTooManyAssignees
+	}
+
+	if err := s.assigneeRepo.Replace(taskID, assignees); err != nil {
+		return nil, err
+	}
+	if err := s.recordAssignees(task, userID); err != nil {
+		return nil, err
+	}
+	if err := s.fillDetails([]*model.Task{task}); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// AddAssignee 添加负责人
+func (s *taskService) AddAssignee(taskID, userID, assigneeID int) (*model.Task, error) {
+	task, err := s.getEditable(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateAssignee(task, assigneeID); err != nil {
+		return nil, err
+	}
+	if err := s.fillAssignees([]*model.Task{task}); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.assigneeRepo.GetByTaskIDs([]int{taskID})
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(existing, func(a *model.TaskAssignee) bool { return a.UserID == assigneeID }) {
+		if len(existing) >= model.MaxTaskAssignees {
+			return nil, ErrTooManyAssignees
+		}
+		assignee := &model.TaskAssignee{TaskID: taskID, UserID: assigneeID, AssignedBy: userID, CreatedAt: time.Now()}
+		if err := s.assigneeRepo.Add(assignee); err != nil {
+			return nil, err
+		}
+		if err := s.recordAssignees(task, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.fillDetails([]*model.Task{task}); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// RemoveAssignee 移除负责人
+func (s *taskService) RemoveAssignee(taskID, userID, assigneeID int) error {
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return err
+	}
+	if !model.CanEdit(task.Role) && assigneeID != userID {
+		return ErrTaskReadOnly
+	}
+	if err := s.fillAssignees([]*model.Task{task}); err != nil {
+		return err
+	}
+
+	existing, err := s.assigneeRepo.GetByTaskIDs([]int{taskID})
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(existing, func(a *model.TaskAssignee) bool { return a.UserID == assigneeID }) {
+		return ErrAssigneeNotFound
+	}
+	if err := s.assigneeRepo.Delete(taskID, assigneeID); err != nil {
+		return err
+	}
+	return s.recordAssignees(task, userID)
+}
+
+// getEditable 获取任务并验证编辑权限
+func (s *taskService) getEditable(taskID, userID int) (*model.Task, error) {
+	task, err := s.access.get(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !model.CanEdit(task.Role) {
+		return nil, ErrTaskReadOnly
+	}
+	return task, nil
+}
+
+// validateAssignee 验证负责人有权访问该任务，不存在的用户没有任何角色
+func (s *taskService) validateAssignee(task *model.Task, assigneeID int) error {
+	role, err := s.access.role(task, assigneeID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrInvalidAssignee
+	}
+	return nil
+}
+
+// assignedTaskRoles 获取指派给用户且用户仍有权访问的全部任务及用户在其上的角色
+func (s *taskService) assignedTaskRoles(userID int) (map[int]string, error) {
+	assignments, err := s.assigneeRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[int]string, len(assignments))
+	for _, assignment := range assignments {
+		task, err := s.taskRepo.GetByID(assignment.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		if task == nil {
+			continue
+		}
+		role, err := s.access.role(task, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
+			roles[task.ID] = role
+		}
+	}
+	return roles, nil
+}
+
+// copyAssignees 将任务的负责人复制到另一个任务
+func (s *taskService) copyAssignees(fromTaskID, toTaskID int) error {
+	assignees, err := s.assigneeRepo.GetByTaskIDs([]int{fromTaskID})
+	if err != nil {
+		return err
+	}
+	for _, assignee := range assignees {
+		copied := &model.TaskAssignee{TaskID: toTaskID, UserID: assignee.UserID, AssignedBy: assignee.AssignedBy, CreatedAt: time.Now()}
+		if err := s.assigneeRepo.Add(copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyCollaborators 将任务的协作者复制到另一个任务
+func (s *taskService) copyCollaborators(fromTaskID, toTaskID int) error {
+	collaborators, err := s.collabRepo.GetByTaskID(fromTaskID)
+	if err != nil {
+		return err
+	}
+	for _, collaborator := range collaborators {
+		copied := &model.TaskCollaborator{TaskID: toTaskID, UserID: collaborator.UserID, Role: collaborator.Role, CreatedAt: time.Now()}
+		if err := s.collabRepo.Save(copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateParent 验证父任务可用，且挂载高度为 height 的子树后不超过层级限制，返回父任务
+// 父任务必须与任务属于同一工作区：个人任务的父任务必须属于该用户，工作区任务的父任务需要用户有编辑权限。
+// taskID 为被移动的任务，新父任务不能是它自身或它的后代；新建任务时传0
+func (s *taskService) validateParent(userID int, workspaceID *int, parentID, taskID, height int) (*model.Task, error) {
+	parent, err := s.taskRepo.GetByID(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil || !sameID(parent.WorkspaceID, workspaceID) {
+		return nil, ErrInvalidParentTask
+	}
+	if workspaceID == nil {
+		if parent.UserID != userID {
+			return nil, ErrInvalidParentTask
+		}
+	} else {
+		role, err := s.access.role(parent, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !model.CanEdit(role) {
+			return nil, ErrInvalidParentTask
+		}
+	}
+
+	// 沿父任务链向上查找，计算父任务所在层级并检测环
+	depth := 1
+	current := parent
+	for {
+		if current.ID == taskID {
+			return nil, ErrTaskCycle
+		}
+		if current.ParentID == nil || depth > model.MaxTaskDepth {
+			break
+		}
+		if current, err = s.taskRepo.GetByID(*current.ParentID); err != nil {
+			return nil, err
+		}
+		if current == nil {
+			break
+		}
+		depth++
+	}
+	if depth+height > model.MaxTaskDepth {
+		return nil, ErrTaskDepthExceeded
+	}
+	return parent, nil
+}
+
+// validateProject 验证项目与任务属于同一工作区且未归档，个人项目必须属于该用户
+func (s *taskService) validateProject(userID int, workspaceID *int, projectID int) error {
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil || !sameID(project.WorkspaceID, workspaceID) {
+		return ErrInvalidProject
+	}
+	if workspaceID == nil && project.UserID != userID {
+		return ErrInvalidProject
+	}
+	if project.Archived {
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+// subtreeHeight 计算以该任务为根的子树高度，叶子任务为1
+func (s *taskService) subtreeHeight(taskID int) (int, error) {
+	children, err := s.taskRepo.GetChildren(taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	height := 1
+	for _, child := range children {
+		h, err := s.subtreeHeight(child.ID)
+		if err != nil {
+			return 0, err
+		}
+		if h+1 > height {
+			height = h + 1
+		}
+	}
+	return height, nil
+}
+
+// descendantIDs 获取全部后代任务的ID，子任务在父任务之前
+func (s *taskService) descendantIDs(taskID int) ([]int, error) {
+	children, err := s.taskRepo.GetChildren(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, child := range children {
+		childIDs, err := s.descendantIDs(child.ID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, childIDs...)
+		ids = append(ids, child.ID)
+	}
+	return ids, nil
+}
+
+// rollupParent 根据子任务的完成情况更新父任务状态，并逐级向上汇总
+// 子任务全部完成时父任务标记为已完成，已完成的父任务出现未完成子任务时改为进行中
+// 父任务的状态变化记入历史，操作者为引起变化的用户
+func (s *taskService) rollupParent(parentID, userID int) error {
+	for depth := 0; parentID != 0 && depth < model.MaxTaskDepth; depth++ {
+		parent, err := s.taskRepo.GetByID(parentID)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return nil
+		}
+
+		progress, err := s.taskRepo.CountSubtasks([]int{parentID})
+		if err != nil {
+			return err
+		}
+		p, ok := progress[parentID]
+		if !ok {
+			return nil
+		}
+
+		status := parent.Status
+		if p.Done == p.Total {
+			status = model.TaskStatusDone
+		} else if parent.Status == model.TaskStatusDone {
+			status = model.TaskStatusInProgress
+		}
+		if status == parent.Status {
+			return nil
+		}
+
+		before := *parent
+		parent.Status = status
+		parent.UpdatedAt = time.Now()
+		if err := s.taskRepo.Update(parent); err != nil {
+			return err
+		}
+		if err := s.record(parent.ID, userID, model.TaskHistoryActionUpdate, diffTask(&before, parent)); err != nil {
+			return err
+		}
+		parentID = parentIDOf(parent)
+	}
+	return nil
+}
+
+// fillDetails 填充任务的子任务完成情况和负责人
+func (s *taskService) fillDetails(tasks []*model.Task) error {
+	if err := s.fillSubtaskProgress(tasks); err != nil {
+		return err
+	}
+	return s.fillAssignees(tasks)
+}
+
+// fillAssignees 填充任务的负责人
+func (s *taskService) fillAssignees(tasks []*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	assignees, err := s.assigneeRepo.GetByTaskIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	byTask := make(map[int][]int, len(tasks))
+	for _, assignee := range assignees {
+		byTask[assignee.TaskID] = append(byTask[assignee.TaskID], assignee.UserID)
+	}
+	for _, task := range tasks {
+		task.Assignees = byTask[task.ID]
+	}
+	return nil
+}
+
+// fillSubtaskProgress 填充任务的子任务完成情况
+func (s *taskService) fillSubtaskProgress(tasks []*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	progress, err := s.taskRepo.CountSubtasks(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if p, ok := progress[task.ID]; ok {
+			task.Subtasks = &p
+		}
+	}
+	return nil
+}
+
+// sortedTaskIDs 返回角色表中的任务ID，按升序排列
+func sortedTaskIDs(roles map[int]string) []int {
+	ids := make([]int, 0, len(roles))
+	for id := range roles {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// nilIfZero 将指向0的ID视为未设置
+func nilIfZero(id *int) *int {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
+
+// sameID 比较两个可为空的ID是否相同
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// parentIDOf 返回任务的父任务ID，顶层任务返回0
+func parentIDOf(task *model.Task) int {
+	if task.ParentID == nil {
+		return 0
+	}
+	return *task.ParentID
 }
 
 // validateTaskTitle 验证任务标题
@@ -157,6 +1171,47 @@ func (s *taskService) validateTaskDescription(description string) error {
 	return nil
 }
 
+// validatePriority 验证任务优先级
+func (s *taskService) validatePriority(priority int) error {
+	if priority < model.TaskPriorityNone || priority > model.TaskPriorityHigh {
+		return ErrInvalidPriority
+	}
+	return nil
+}
+
+// resolveTags 根据标签ID加载标签，并验证标签属于该用户
+func (s *taskService) resolveTags(userID int, tags []model.Tag) ([]model.Tag, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+
+	seen := make(map[int]bool, len(tags))
+	ids := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			ids = append(ids, tag.ID)
+		}
+	}
+
+	found, err := s.tagRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) != len(ids) {
+		return nil, ErrInvalidTags
+	}
+
+	resolved := make([]model.Tag, 0, len(found))
+	for _, tag := range found {
+		if tag.UserID != userID {
+			return nil, ErrInvalidTags
+		}
+		resolved = append(resolved, *tag)
+	}
+	return resolved, nil
+}
+
 // validateDueDate 验证截止日期
 func (s *taskService) validateDueDate(dueDate *time.Time) error {
 	if dueDate == nil || dueDate.IsZero() {
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"time"
+
+	"todolist/internal/model"
+)
+
+// Trash 获取回收站中的任务
+func (s *taskService) Trash(filter model.TaskFilter) ([]*model.Task, int64, error) {
+	// 只有能删除任务的用户可以查看回收站：个人空间为自己的任务，工作区需要管理员权限
+	if filter.WorkspaceID != 0 {
+		role, err := memberRole(s.workspaceRepo, filter.WorkspaceID, filter.UserID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if workspaceTaskRole(role) != model.TaskRoleOwner {
+			return nil, 0, ErrWorkspacePermission
+		}
+	}
+	filter.SharedTaskIDs = nil
+	filter.AssigneeID = 0
+	filter.AllScopes = false
+	filter.Deleted = true
+	if len(filter.Sort) == 0 {
+		filter.Sort = []model.TaskSort{{Field: model.TaskSortDeletedAt, Desc: true}}
+	}
+
+	tasks, total, err := s.taskRepo.List(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, task := range tasks {
+		task.Role = model.TaskRoleOwner
+	}
+	if err := s.fillAssignees(tasks); err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// Restore 从回收站恢复任务
+func (s *taskService) Restore(taskID, userID int) (*model.Task, error) {
+	task, err := s.getDeletedOwned(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if task.ParentID != nil {
+		parent, err := s.taskRepo.GetByID(*task.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, ErrParentInTrash
+		}
+	}
+
+	// 只恢复与任务同时删除的子任务，之前单独删除的子任务仍留在回收站中
+	taskIDs, err := s.deletedDescendantIDs(taskID, task.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	taskIDs = append(taskIDs, taskID)
+	if err := s.taskRepo.Restore(taskIDs); err != nil {
+		return nil, err
+	}
+	for _, id := range taskIDs {
+		if err := s.record(id, userID, model.TaskHistoryActionRestore, nil); err != nil {
+			return nil, err
+		}
+	}
+	if task.ParentID != nil {
+		if err := s.rollupParent(*task.ParentID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(taskID, userID)
+}
+
+// Purge 永久删除回收站中的任务及其子任务
+func (s *taskService) Purge(taskID, userID int) error {
+	if _, err := s.getDeletedOwned(taskID, userID); err != nil {
+		return err
+	}
+	taskIDs, err := s.deletedDescendantIDs(taskID, nil)
+	if err != nil {
+		return err
+	}
+	return s.purge(append(taskIDs, taskID))
+}
+
+// PurgeExpired 永久删除在 before 之前移入回收站的任务
+func (s *taskService) PurgeExpired(before time.Time) (int, error) {
+	// 子任务不会晚于父任务删除，过期任务的子任务也都已过期
+	taskIDs, err := s.taskRepo.ListDeletedBefore(before)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.purge(taskIDs); err != nil {
+		return 0, err
+	}
+	return len(taskIDs), nil
+}
+
+// getDeletedOwned 获取回收站中的任务并验证所有者权限
+func (s *taskService) getDeletedOwned(taskID, userID int) (*model.Task, error) {
+	task, err := s.taskRepo.GetDeletedByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+	role, err := s.access.role(task, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrTaskAccessDenied
+	}
+	if role != model.TaskRoleOwner {
+		return nil, ErrTaskOwnerOnly
+	}
+	return task, nil
+}
+
+// deletedDescendantIDs 获取回收站中任务的全部子孙任务ID，deletedAt 不为空时只返回同时删除的任务
+func (s *taskService) deletedDescendantIDs(taskID int, deletedAt *time.Time) ([]int, error) {
+	var ids []int
+	children, err := s.taskRepo.GetDeletedChildren(taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if deletedAt != nil && !child.DeletedAt.Equal(*deletedAt) {
+			continue
+		}
+		descendants, err := s.deletedDescendantIDs(child.ID, deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, descendants...)
+		ids = append(ids, child.ID)
+	}
+	return ids, nil
+}
+
+// purge 永久删除任务及其协作者、负责人和评论，变更历史保留
+func (s *taskService) purge(taskIDs []int) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	if err := s.taskRepo.DeleteByIDs(taskIDs); err != nil {
+		return err
+	}
+	if err := s.collabRepo.DeleteByTaskIDs(taskIDs); err != nil {
+		return err
+	}
+	if err := s.assigneeRepo.DeleteByTaskIDs(taskIDs); err != nil {
+		return err
+	}
+	return s.commentRepo.DeleteByTaskIDs(taskIDs)
+}
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
+	"todolist/config"
 	"todolist/internal/model"
 	"todolist/internal/repository"
 	"todolist/pkg/jwt"
@@ -16,25 +21,48 @@ var (
 	ErrUserExists       = errors.New("用户已存在")
 	ErrInvalidPassword  = errors.New("密码错误")
 	ErrPasswordTooShort = errors.New("密码长度不能小于6位")
+
+	ErrInvalidRefreshToken = errors.New("无效的刷新令牌")
+	ErrRefreshTokenExpired = errors.New("刷新令牌已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，该登录会话已失效")
 )
 
+// TokenPair 登录和刷新时签发的令牌对
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期，单位：秒
+}
+
 // UserService 用户服务接口
 type UserService interface {
 	Register(username, password string) error
-	Login(username, password string) (string, error)
+	Login(username, password string) (*TokenPair, error)
+	// Authenticate 校验用户名和密码，不签发令牌，用于 CalDAV 等只支持 Basic 认证的客户端
+	Authenticate(username, password string) (*model.User, error)
+	// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+	Refresh(refreshToken string) (*TokenPair, error)
+	// Logout 吊销当前访问令牌及刷新令牌所在的会话
+	Logout(userID int, accessTokenID string, accessExpiresAt time.Time, refreshToken string) error
+	// IsTokenRevoked 判断访问令牌是否已被吊销
+	IsTokenRevoked(tokenID string) (bool, error)
 	GetUserByID(id int) (*model.User, error)
+	// UpdatePassword 校验旧密码后更新密码，并吊销用户全部登录会话的刷新令牌和访问令牌
 	UpdatePassword(id int, oldPassword, newPassword string) error
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
 	}
 }
 
@@ -72,29 +100,205 @@ func (s *userService) Register(username, password string) error {
 }
 
 // Login 用户登录
-func (s *userService) Login(username, password string) (string, error) {
+func (s *userService) Login(username, password string) (*TokenPair, error) {
+	user, err := s.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每次登录开启新的令牌族
+	familyID, err := jwt.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(user, familyID)
+}
+
+// Authenticate 校验用户名和密码
+func (s *userService) Authenticate(username, password string) (*model.User, error) {
 	// 获取用户
 	user, err := s.userRepo.GetByUsername(username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if user == nil {
-		return "", ErrUserNotFound
+		return nil, ErrUserNotFound
 	}
 
 	// 验证密码
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return "", ErrInvalidPassword
+		return nil, ErrInvalidPassword
 	}
+	return user, nil
+}
 
-	// 生成 JWT token
-	token, err := jwt.GenerateToken(user.ID, user.Username)
+// Refresh 刷新令牌轮换
+// 已轮换过的刷新令牌再次出现说明令牌可能被盗用，此时吊销整个令牌族
+func (s *userService) Refresh(refreshToken string) (*TokenPair, error) {
+	token, err := s.tokenRepo.GetRefreshTokenByHash(hashToken(refreshToken))
 	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		if err := s.revokeFamily(token.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if token.IsExpired(now) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	// 条件更新失败说明并发请求已经使用了该令牌
+	rotated, err := s.tokenRepo.RevokeRefreshToken(token.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		if err := s.revokeFamily(token.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return s.issueTokenPair(user, token.FamilyID)
+}
+
+// Logout 退出登录
+func (s *userService) Logout(userID int, accessTokenID string, accessExpiresAt time.Time, refreshToken string) error {
+	if accessTokenID != "" {
+		err := s.tokenRepo.RevokeAccessToken(&model.RevokedToken{
+			TokenID:   accessTokenID,
+			UserID:    userID,
+			ExpiresAt: accessExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	token, err := s.tokenRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	return s.revokeFamily(token.FamilyID, time.Now())
+}
+
+// IsTokenRevoked 判断访问令牌是否已被吊销
+func (s *userService) IsTokenRevoked(tokenID string) (bool, error) {
+	return s.tokenRepo.IsAccessTokenRevoked(tokenID)
+}
+
+// issueTokenPair 签发访问令牌和刷新令牌
+func (s *userService) issueTokenPair(user *model.User, familyID string) (*TokenPair, error) {
+	accessToken, claims, err := jwt.GenerateAccessToken(user.ID, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.tokenRepo.CreateRefreshToken(&model.RefreshToken{
+		UserID:               user.ID,
+		TokenHash:            hashToken(refreshToken),
+		FamilyID:             familyID,
+		AccessTokenID:        claims.Id,
+		AccessTokenExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		ExpiresAt:            now.Add(config.GlobalConfig.JWT.RefreshExpireHours),
+		CreatedAt:            now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    claims.ExpiresAt - now.Unix(),
+	}, nil
+}
+
+// revokeFamily 吊销令牌族中的刷新令牌及其签发的访问令牌
+func (s *userService) revokeFamily(familyID string, now time.Time) error {
+	tokens, err := s.tokenRepo.GetRefreshTokensByFamily(familyID)
+	if err != nil {
+		return err
+	}
+	if err := s.tokenRepo.RevokeRefreshTokenFamily(familyID, now); err != nil {
+		return err
+	}
+	return s.revokeAccessTokens(tokens, now)
+}
+
+// revokeUserSessions 吊销用户全部登录会话的刷新令牌及其签发的访问令牌
+func (s *userService) revokeUserSessions(userID int, now time.Time) error {
+	tokens, err := s.tokenRepo.GetRefreshTokensByUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.tokenRepo.RevokeUserRefreshTokens(userID, now); err != nil {
+		return err
+	}
+	return s.revokeAccessTokens(tokens, now)
+}
+
+// revokeAccessTokens 吊销与刷新令牌一同签发且尚未过期的访问令牌。
+// 已轮换的刷新令牌签发的访问令牌在过期前仍然有效，同样需要吊销
+func (s *userService) revokeAccessTokens(tokens []*model.RefreshToken, now time.Time) error {
+	for _, token := range tokens {
+		if token.AccessTokenID == "" || !token.AccessTokenExpiresAt.After(now) {
+			continue
+		}
+		err := s.tokenRepo.RevokeAccessToken(&model.RevokedToken{
+			TokenID:   token.AccessTokenID,
+			UserID:    token.UserID,
+			ExpiresAt: token.AccessTokenExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRandomToken 生成随机令牌，用于刷新令牌和邀请令牌
+func newRandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
 		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
 
-	return token, nil
+// hashToken 计算令牌的哈希，数据库中不保存明文
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // GetUserByID 根据ID获取用户信息
@@ -109,10 +313,10 @@ func (s *userService) GetUserByID(id int) (*model.User, error) {
 	return user, nil
 }
 
-// UpdatePassword 更新用户密码
+// UpdatePassword 更新用户密码，吊销用户全部登录会话
 func (s *userService) UpdatePassword(id int, oldPassword, newPassword string) error {
-	// 获取用户
-	user, err := s.userRepo.GetByID(id)
+	// 获取用户，密码哈希不经过缓存
+	user, err := s.userRepo.GetCredentials(id)
 	if err != nil {
 		return err
 	}
@@ -138,8 +342,13 @@ func (s *userService) UpdatePassword(id int, oldPassword, newPassword string) er
 	}
 
 	// 更新用户信息
+	now := time.Now()
 	user.PasswordHash = string(hashedPassword)
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
 
-	return s.userRepo.Update(user)
+	// 修改密码后旧密码签发的令牌全部失效，包括当前会话，客户端需要重新登录
+	return s.revokeUserSessions(id, now)
 }
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"todolist/config"
+	"todolist/internal/event"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+const (
+	// MaxWebhooksPerUser 每个用户可以注册的 Webhook 数量上限
+	MaxWebhooksPerUser = 20
+	// maxWebhookSecretLength 自定义密钥的最大长度
+	maxWebhookSecretLength = 255
+)
+
+// WebhookEvents 可以订阅的事件类型，完成任务时同时产生 task.updated 和 task.completed
+var WebhookEvents = []string{event.TaskCreated, event.TaskUpdated, event.TaskCompleted, event.TaskDeleted}
+
+var (
+	ErrWebhookNotFound      = errors.New("Webhook 不存在")
+	ErrInvalidWebhookURL    = fmt.Errorf("Webhook URL 必须是不超过%d个字符的 http 或 https 地址，不能指向本机或内网", model.MaxWebhookURLLength)
+	ErrInvalidWebhookEvents = errors.New("至少需要订阅一种事件，可以订阅 task.created、task.updated、task.completed 和 task.deleted")
+	ErrWebhookSecretTooLong = fmt.Errorf("Webhook 密钥不能超过%d个字符", maxWebhookSecretLength)
+	ErrTooManyWebhooks      = fmt.Errorf("每个用户最多注册%d个 Webhook", MaxWebhooksPerUser)
+)
+
+// WebhookService Webhook 服务接口。
+// 用户可以看到的任务发生变更时，向订阅了该事件的 Webhook 投递签名的 JSON 请求，失败后按指数退避重试
+type WebhookService interface {
+	// List 获取用户的全部 Webhook
+	List(userID int) ([]*model.Webhook, error)
+	// Get 获取用户的 Webhook，不存在或属于其他用户时返回 ErrWebhookNotFound
+	Get(webhookID, userID int) (*model.Webhook, error)
+	// Create 注册 Webhook，未提供密钥时生成随机密钥
+	Create(webhook *model.Webhook) error
+	// Update 修改 URL、订阅的事件和启用状态，Secret 为空时保留原来的密钥
+	Update(webhook *model.Webhook) (*model.Webhook, error)
+	// Delete 删除 Webhook 及其投递记录
+	Delete(webhookID, userID int) error
+	// Deliveries 分页获取 Webhook 的投递记录，最新的排在前面
+	Deliveries(webhookID, userID, page, pageSize int) ([]*model.WebhookDelivery, int64, error)
+
+	// Enqueue 为订阅了事件的 Webhook 创建投递记录，由 StartWebhookDispatcher 调用
+	Enqueue(e event.Event) (int, error)
+	// DeliverDue 投递 now 之前到期的记录，返回投递的次数
+	DeliverDue(now time.Time) (int, error)
+}
+
+// webhookService Webhook 服务实现
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+}
+
+// NewWebhookService 创建 Webhook 服务实例，单次投递的超时时间为 config.GlobalConfig.Webhook.TimeoutSeconds
+func NewWebhookService(webhookRepo repository.WebhookRepository) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		client:      newWebhookClient(config.GlobalConfig.Webhook.TimeoutSeconds),
+	}
+}
+
+// List 获取用户的全部 Webhook
+func (s *webhookService) List(userID int) ([]*model.Webhook, error) {
+	return s.webhookRepo.ListByUserID(userID)
+}
+
+// Get 获取用户的 Webhook
+func (s *webhookService) Get(webhookID, userID int) (*model.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	// 不区分不存在和属于其他用户
+	if webhook == nil || webhook.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// Create 注册 Webhook
+func (s *webhookService) Create(webhook *model.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	existing, err := s.webhookRepo.ListByUserID(webhook.UserID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= MaxWebhooksPerUser {
+		return ErrTooManyWebhooks
+	}
+
+	if webhook.Secret == "" {
+		if webhook.Secret, err = newRandomToken(); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	return s.webhookRepo.Create(webhook)
+}
+
+// Update 修改 Webhook
+func (s *webhookService) Update(webhook *model.Webhook) (*model.Webhook, error) {
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	existing, err := s.Get(webhook.ID, webhook.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing.URL = webhook.URL
+	existing.Events = webhook.Events
+	existing.Active = webhook.Active
+	if webhook.Secret != "" {
+		existing.Secret = webhook.Secret
+	}
+	existing.UpdatedAt = time.Now()
+	if err := s.webhookRepo.Update(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Delete 删除 Webhook
+func (s *webhookService) Delete(webhookID, userID int) error {
+	if _, err := s.Get(webhookID, userID); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(webhookID)
+}
+
+// Deliveries 分页获取 Webhook 的投递记录
+func (s *webhookService) Deliveries(webhookID, userID, page, pageSize int) ([]*model.WebhookDelivery, int64, error) {
+	if _, err := s.Get(webhookID, userID); err != nil {
+		return nil, 0, err
+	}
+	page, pageSize = normalizePage(page, pageSize)
+	return s.webhookRepo.ListDeliveries(webhookID, page, pageSize)
+}
+
+// validateWebhook 检查 URL、事件类型和密钥，事件类型去重排序
+func validateWebhook(webhook *model.Webhook) error {
+	if len(webhook.URL) > model.MaxWebhookURLLength {
+		return ErrInvalidWebhookURL
+	}
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	if !webhookHostAllowed(u.Hostname()) {
+		return ErrInvalidWebhookURL
+	}
+
+	if len(webhook.Events) == 0 {
+		return ErrInvalidWebhookEvents
+	}
+	for _, eventType := range webhook.Events {
+		if !slices.Contains(WebhookEvents, eventType) {
+			return ErrInvalidWebhookEvents
+		}
+	}
+	events := slices.Clone(webhook.Events)
+	slices.Sort(events)
+	webhook.Events = slices.Compact(events)
+
+	if len(webhook.Secret) > maxWebhookSecretLength {
+		return ErrWebhookSecretTooLong
+	}
+	return nil
+}
+
+// webhookPayload 投递的请求体
+type webhookPayload struct {
+	Event      string              `json:"event"`
+	EventID    int64               `json:"event_id"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Task       webhookTask         `json:"task"`
+	Changes    []model.FieldChange `json:"changes,omitempty"`
+}
+
+// webhookTask 请求体中的任务，状态和优先级与接口返回的格式相同
+type webhookTask struct {
+	*model.Task
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+}
+
+// Enqueue 为订阅了事件的 Webhook 创建投递记录，完成任务的更新事件同时投递给订阅 task.completed 的 Webhook
+func (s *webhookService) Enqueue(e event.Event) (int, error) {
+	eventTypes := []string{e.Type}
+	if e.Completed() {
+		eventTypes = append(eventTypes, event.TaskCompleted)
+	}
+
+	webhooks, err := s.webhookRepo.ListActiveByUserIDs(e.UserIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	payloads := make(map[string]string, len(eventTypes))
+	now := time.Now()
+	created := 0
+	for _, webhook := range webhooks {
+		for _, eventType := range eventTypes {
+			if !slices.Contains(webhook.Events, eventType) {
+				continue
+			}
+			payload, ok := payloads[eventType]
+			if !ok {
+				data, err := json.Marshal(webhookPayload{
+					Event:      eventType,
+					EventID:    e.ID,
+					OccurredAt: e.CreatedAt,
+					Task:       webhookTask{Task: e.Task, Status: e.Task.GetStatusText(), Priority: e.Task.GetPriorityText()},
+					Changes:    e.Changes,
+				})
+				if err != nil {
+					return created, err
+				}
+				payload = string(data)
+				payloads[eventType] = payload
+			}
+
+			err := s.webhookRepo.CreateDelivery(&model.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				EventID:       e.ID,
+				EventType:     eventType,
+				Payload:       payload,
+				Status:        model.WebhookDeliveryPending,
+				NextAttemptAt: &now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
+			if err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"todolist/config"
+	"todolist/internal/event"
+	"todolist/internal/model"
+)
+
+// Webhook 请求头
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature-256"
+)
+
+const (
+	// webhookClaimBatch 每次领取的投递记录数
+	webhookClaimBatch = 100
+	// webhookLeaseMargin 领取的记录在超时时间之外多保留的时间，超过后视为投递中断并重新领取
+	webhookLeaseMargin = 30 * time.Second
+	// maxWebhookRetryDelay 两次重试之间的最长等待时间
+	maxWebhookRetryDelay = 24 * time.Hour
+	// webhookLookupTimeout 注册时解析 Webhook 域名的超时时间
+	webhookLookupTimeout = 5 * time.Second
+)
+
+// errWebhookAddressBlocked 投递时连接的地址不是公网地址
+var errWebhookAddressBlocked = errors.New("不允许连接本机或内网地址")
+
+// newWebhookClient 创建投递使用的 HTTP 客户端。
+// 建立连接时检查解析出的 IP，防止域名在注册后改为解析到内网地址；不使用代理，也不跟随重定向，3xx 视为投递失败
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !webhookIPAllowed(ip) {
+				return fmt.Errorf("%w: %s", errWebhookAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookIPAllowed 检查是否可以向 ip 投递，AllowPrivateNetworks 为 false 时拒绝本机、内网、链路本地和未指定地址
+func webhookIPAllowed(ip net.IP) bool {
+	if config.GlobalConfig.Webhook.AllowPrivateNetworks {
+		return true
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsUnspecified()
+}
+
+// webhookHostAllowed 注册时检查 Webhook 的主机，IP 直接检查，域名解析出的任一地址不允许时拒绝。
+// 解析失败时不拒绝，投递时建立连接前仍会检查
+func webhookHostAllowed(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return webhookIPAllowed(ip)
+	}
+	if config.GlobalConfig.Webhook.AllowPrivateNetworks {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return true
+	}
+	for _, addr := range addrs {
+		if !webhookIPAllowed(addr.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+// SignWebhookPayload 计算请求体的签名，格式为 sha256=<HMAC-SHA256 的十六进制>，接收方使用相同的密钥验证
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay 第 attempts 次投递失败后的等待时间，从 RetryBaseSeconds 开始每次翻倍
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := config.GlobalConfig.Webhook.RetryBaseSeconds
+	for i := 1; i < attempts && delay < maxWebhookRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookRetryDelay)
+}
+
+// DeliverDue 领取到期的记录并依次投递
+func (s *webhookService) DeliverDue(now time.Time) (int, error) {
+	leaseUntil := now.Add(s.client.Timeout + webhookLeaseMargin)
+	delivered := 0
+	for {
+		deliveries, err := s.webhookRepo.ClaimDueDeliveries(now, leaseUntil, webhookClaimBatch)
+		if err != nil {
+			return delivered, err
+		}
+		for _, delivery := range deliveries {
+			if err := s.deliver(delivery, now); err != nil {
+				return delivered, err
+			}
+			delivered++
+		}
+		if len(deliveries) < webhookClaimBatch {
+			return delivered, nil
+		}
+	}
+}
+
+// deliver 投递一次并保存结果，返回的错误只来自仓库
+func (s *webhookService) deliver(delivery *model.WebhookDelivery, now time.Time) error {
+	webhook, err := s.webhookRepo.GetByID(delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+	// Webhook 已删除时投递记录也已删除
+	if webhook == nil {
+		return nil
+	}
+
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+	if !webhook.Active {
+		delivery.LastError = "Webhook 已停用"
+	} else {
+		delivery.ResponseStatus, err = s.send(webhook, delivery)
+		if err != nil {
+			delivery.LastError = err.Error()
+		}
+	}
+
+	switch {
+	case delivery.LastError == "":
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	case !webhook.Active || delivery.Attempts >= config.GlobalConfig.Webhook.MaxAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(webhookRetryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+	delivery.UpdatedAt = now
+	return s.webhookRepo.UpdateDelivery(delivery)
+}
+
+// send 发送签名的请求，接收方返回 2xx 时视为成功
+func (s *webhookService) send(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TodoList-Webhook/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("接收方返回 %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// StartWebhookDispatcher 订阅事件总线，在后台为 Webhook 创建投递记录，并每隔 interval 投递到期的记录，直到 ctx 结束。
+// 返回前已完成订阅，之后发布的事件都会投递。读取过慢被总线关闭订阅时从最后处理的事件之后重新订阅；
+// 服务重启前未处理的事件不会投递
+func StartWebhookDispatcher(ctx context.Context, bus *event.Bus, webhooks WebhookService, interval time.Duration) {
+	// 创建投递记录后立即投递，不必等到下一次定时投递
+	wake := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+			if _, err := webhooks.DeliverDue(time.Now()); err != nil {
+				log.Printf("投递 Webhook 失败: %v", err)
+			}
+		}
+	}()
+
+	var lastID int64
+	enqueue := func(e event.Event) {
+		lastID = e.ID
+		created, err := webhooks.Enqueue(e)
+		if err != nil {
+			log.Printf("创建事件 %d 的 Webhook 投递记录失败: %v", e.ID, err)
+		}
+		if created > 0 {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	sub, _, _ := bus.Subscribe(0, 0)
+	go func() {
+		for {
+		receive:
+			for {
+				select {
+				case <-ctx.Done():
+					sub.Close()
+					return
+				case e, ok := <-sub.Events():
+					if !ok {
+						break receive
+					}
+					enqueue(e)
+				}
+			}
+
+			var missed []event.Event
+			var complete bool
+			sub, missed, complete = bus.Subscribe(0, lastID)
+			if !complete {
+				log.Printf("事件 %d 之后的部分事件已不在保留范围内，无法投递到 Webhook", lastID)
+			}
+			for _, e := range missed {
+				enqueue(e)
+			}
+		}
+	}()
+}
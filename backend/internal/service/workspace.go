@@ -0,0 +1,339 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+var (
+	ErrWorkspaceNotFound       = errors.New("工作区不存在")
+	ErrNotWorkspaceMember      = errors.New("不是该工作区的成员")
+	ErrWorkspacePermission     = errors.New("没有该工作区的操作权限")
+	ErrEmptyWorkspaceName      = errors.New("工作区名称不能为空")
+	ErrWorkspaceNameTooLong    = errors.New("工作区名称不能超过50个字符")
+	ErrInvalidWorkspaceRole    = errors.New("无效的工作区角色，应为 admin、member 或 guest")
+	ErrWorkspaceOwnerRole      = errors.New("不能修改或移除工作区所有者")
+	ErrWorkspaceMemberNotFound = errors.New("成员不存在")
+	ErrAlreadyWorkspaceMember  = errors.New("已是该工作区的成员")
+	ErrInvalidInvitation       = errors.New("邀请无效或已被使用")
+	ErrInvitationExpired       = errors.New("邀请已过期")
+)
+
+// WorkspaceInvitationTTL 工作区邀请的有效期
+const WorkspaceInvitationTTL = 7 * 24 * time.Hour
+
+// WorkspaceService 工作区服务接口
+// 权限按角色逐级递增：访客只读，成员可以创建和编辑任务，管理员管理成员和项目，所有者不能被修改或移除。
+// 修改成员角色、移除成员和邀请时，操作者的角色必须高于目标成员当前的角色和新角色
+type WorkspaceService interface {
+	// Create 创建工作区，创建者成为所有者
+	Create(workspace *model.Workspace) error
+	// Rename 修改工作区名称，需要管理员权限
+	Rename(workspaceID, userID int, name string) (*model.Workspace, error)
+	// Get 获取工作区详情，Role 为当前用户的角色
+	Get(workspaceID, userID int) (*model.Workspace, error)
+	// List 获取用户加入的全部工作区
+	List(userID int) ([]*model.Workspace, error)
+	// Members 获取工作区成员
+	Members(workspaceID, userID int) ([]*model.WorkspaceMember, error)
+	// UpdateMemberRole 修改成员角色
+	UpdateMemberRole(workspaceID, userID, memberID int, role string) (*model.WorkspaceMember, error)
+	// RemoveMember 移除成员，成员可以移除自己以退出工作区
+	RemoveMember(workspaceID, userID, memberID int) error
+	// Invite 创建邀请，邀请令牌只在返回值中出现一次
+	Invite(workspaceID, userID int, role string) (*model.WorkspaceInvitation, error)
+	// AcceptInvitation 凭邀请令牌加入工作区
+	AcceptInvitation(token string, userID int) (*model.Workspace, error)
+	// MemberRole 获取用户在工作区中的角色，不是成员时返回空字符串
+	MemberRole(workspaceID, userID int) (string, error)
+}
+
+// workspaceService 工作区服务实现
+type workspaceService struct {
+	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+}
+
+// NewWorkspaceService 创建工作区服务实例
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, userRepo repository.UserRepository) WorkspaceService {
+	return &workspaceService{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+	}
+}
+
+// Create 创建工作区
+func (s *workspaceService) Create(workspace *model.Workspace) error {
+	workspace.Name = strings.TrimSpace(workspace.Name)
+	if err := validateWorkspaceName(workspace.Name); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	workspace.CreatedAt = now
+	workspace.UpdatedAt = now
+	owner := &model.WorkspaceMember{UserID: workspace.OwnerID, Role: model.WorkspaceRoleOwner, CreatedAt: now}
+	if err := s.workspaceRepo.Create(workspace, owner); err != nil {
+		return err
+	}
+	workspace.Role = model.WorkspaceRoleOwner
+	return nil
+}
+
+// Rename 修改工作区名称
+func (s *workspaceService) Rename(workspaceID, userID int, name string) (*model.Workspace, error) {
+	workspace, err := s.Get(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if model.WorkspaceRoleRank(workspace.Role) < model.WorkspaceRoleRank(model.WorkspaceRoleAdmin) {
+		return nil, ErrWorkspacePermission
+	}
+
+	name = strings.TrimSpace(name)
+	if err := validateWorkspaceName(name); err != nil {
+		return nil, err
+	}
+	workspace.Name = name
+	workspace.UpdatedAt = time.Now()
+	if err := s.workspaceRepo.Update(workspace); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// Get 获取工作区详情
+func (s *workspaceService) Get(workspaceID, userID int) (*model.Workspace, error) {
+	workspace, err := s.workspaceRepo.GetByID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+
+	role, err := memberRole(s.workspaceRepo, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	workspace.Role = role
+	return workspace, nil
+}
+
+// List 获取用户加入的全部工作区
+func (s *workspaceService) List(userID int) ([]*model.Workspace, error) {
+	workspaces, err := s.workspaceRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, workspace := range workspaces {
+		member, err := s.workspaceRepo.GetMember(workspace.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			workspace.Role = member.Role
+		}
+	}
+	return workspaces, nil
+}
+
+// Members 获取工作区成员
+func (s *workspaceService) Members(workspaceID, userID int) ([]*model.WorkspaceMember, error) {
+	if _, err := s.Get(workspaceID, userID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.workspaceRepo.GetMembers(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		user, err := s.userRepo.GetByID(member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			member.Username = user.Username
+		}
+	}
+	return members, nil
+}
+
+// UpdateMemberRole 修改成员角色
+func (s *workspaceService) UpdateMemberRole(workspaceID, userID, memberID int, role string) (*model.WorkspaceMember, error) {
+	if !assignableWorkspaceRole(role) {
+		return nil, ErrInvalidWorkspaceRole
+	}
+
+	actorRole, member, err := s.getMemberForChange(workspaceID, userID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if model.WorkspaceRoleRank(actorRole) <= model.WorkspaceRoleRank(role) {
+		return nil, ErrWorkspacePermission
+	}
+
+	member.Role = role
+	if err := s.workspaceRepo.SaveMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember 移除成员
+func (s *workspaceService) RemoveMember(workspaceID, userID, memberID int) error {
+	// 成员退出工作区
+	if memberID == userID {
+		role, err := memberRole(s.workspaceRepo, workspaceID, userID)
+		if err != nil {
+			return err
+		}
+		if role == model.WorkspaceRoleOwner {
+			return ErrWorkspaceOwnerRole
+		}
+		return s.workspaceRepo.RemoveMember(workspaceID, userID)
+	}
+
+	if _, _, err := s.getMemberForChange(workspaceID, userID, memberID); err != nil {
+		return err
+	}
+	return s.workspaceRepo.RemoveMember(workspaceID, memberID)
+}
+
+// getMemberForChange 获取要修改的成员，并验证操作者的角色高于该成员
+func (s *workspaceService) getMemberForChange(workspaceID, userID, memberID int) (string, *model.WorkspaceMember, error) {
+	actorRole, err := memberRole(s.workspaceRepo, workspaceID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if model.WorkspaceRoleRank(actorRole) < model.WorkspaceRoleRank(model.WorkspaceRoleAdmin) {
+		return "", nil, ErrWorkspacePermission
+	}
+
+	member, err := s.workspaceRepo.GetMember(workspaceID, memberID)
+	if err != nil {
+		return "", nil, err
+	}
+	if member == nil {
+		return "", nil, ErrWorkspaceMemberNotFound
+	}
+	if member.Role == model.WorkspaceRoleOwner {
+		return "", nil, ErrWorkspaceOwnerRole
+	}
+	if model.WorkspaceRoleRank(actorRole) <= model.WorkspaceRoleRank(member.Role) {
+		return "", nil, ErrWorkspacePermission
+	}
+	return actorRole, member, nil
+}
+
+// Invite 创建邀请
+func (s *workspaceService) Invite(workspaceID, userID int, role string) (*model.WorkspaceInvitation, error) {
+	if !assignableWorkspaceRole(role) {
+		return nil, ErrInvalidWorkspaceRole
+	}
+
+	actorRole, err := memberRole(s.workspaceRepo, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if model.WorkspaceRoleRank(actorRole) < model.WorkspaceRoleRank(model.WorkspaceRoleAdmin) ||
+		model.WorkspaceRoleRank(actorRole) <= model.WorkspaceRoleRank(role) {
+		return nil, ErrWorkspacePermission
+	}
+
+	token, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	invitation := &model.WorkspaceInvitation{
+		WorkspaceID: workspaceID,
+		TokenHash:   hashToken(token),
+		Role:        role,
+		InvitedBy:   userID,
+		ExpiresAt:   now.Add(WorkspaceInvitationTTL),
+		CreatedAt:   now,
+	}
+	if err := s.workspaceRepo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+	invitation.Token = token
+	return invitation, nil
+}
+
+// AcceptInvitation 凭邀请令牌加入工作区
+func (s *workspaceService) AcceptInvitation(token string, userID int) (*model.Workspace, error) {
+	invitation, err := s.workspaceRepo.GetInvitationByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.AcceptedAt != nil {
+		return nil, ErrInvalidInvitation
+	}
+	now := time.Now()
+	if now.After(invitation.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+
+	existing, err := s.workspaceRepo.GetMember(invitation.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlreadyWorkspaceMember
+	}
+
+	invitation.AcceptedBy = &userID
+	invitation.AcceptedAt = &now
+	member := &model.WorkspaceMember{WorkspaceID: invitation.WorkspaceID, UserID: userID, Role: invitation.Role, CreatedAt: now}
+	accepted, err := s.workspaceRepo.AcceptInvitation(invitation, member)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvalidInvitation
+	}
+	return s.Get(invitation.WorkspaceID, userID)
+}
+
+// MemberRole 获取用户在工作区中的角色
+func (s *workspaceService) MemberRole(workspaceID, userID int) (string, error) {
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil || member == nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// memberRole 获取用户在工作区中的角色，不是成员时返回 ErrNotWorkspaceMember
+func memberRole(workspaceRepo repository.WorkspaceRepository, workspaceID, userID int) (string, error) {
+	member, err := workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return "", ErrNotWorkspaceMember
+	}
+	return member.Role, nil
+}
+
+// assignableWorkspaceRole 是否为可以分配给成员的角色，所有者角色不能分配
+func assignableWorkspaceRole(role string) bool {
+	return role == model.WorkspaceRoleAdmin || role == model.WorkspaceRoleMember || role == model.WorkspaceRoleGuest
+}
+
+// validateWorkspaceName 验证工作区名称
+func validateWorkspaceName(name string) error {
+	if name == "" {
+		return ErrEmptyWorkspaceName
+	}
+	if len([]rune(name)) > 50 {
+		return ErrWorkspaceNameTooLong
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -10,6 +13,8 @@ import (
 	"todolist/config"
 	"todolist/docs"
 	"todolist/internal/api"
+	"todolist/internal/cache"
+	"todolist/internal/event"
 	"todolist/internal/middleware"
 	"todolist/internal/repository"
 	"todolist/internal/service"
@@ -56,18 +61,127 @@ func main() {
 	// 创建仓储实例
 	userRepo := repository.NewUserRepository(repository.DB)
 	taskRepo := repository.NewTaskRepository(repository.DB)
+	tokenRepo := repository.NewTokenRepository(repository.DB)
+	tagRepo := repository.NewTagRepository(repository.DB)
+	projectRepo := repository.NewProjectRepository(repository.DB)
+	collabRepo := repository.NewCollaboratorRepository(repository.DB)
+	workspaceRepo := repository.NewWorkspaceRepository(repository.DB)
+	assigneeRepo := repository.NewAssigneeRepository(repository.DB)
+	commentRepo := repository.NewCommentRepository(repository.DB)
+	historyRepo := repository.NewHistoryRepository(repository.DB)
+	idempotencyRepo := repository.NewIdempotencyRepository(repository.DB)
+	webhookRepo := repository.NewWebhookRepository(repository.DB)
+	calendarTokenRepo := repository.NewCalendarTokenRepository(repository.DB)
+	caldavRepo := repository.NewCalDAVRepository(repository.DB)
+
+	// 按ID读取的任务和用户经过缓存，Redis 不可用时直接读取数据库
+	repoCache, err := cache.New(config.GlobalConfig)
+	if err != nil {
+		log.Printf("初始化缓存失败，不使用缓存: %v", err)
+	}
+	if repoCache != nil {
+		ttl := config.GlobalConfig.Cache.TTLSeconds
+		taskCacheStats, userCacheStats := &cache.Stats{}, &cache.Stats{}
+		taskRepo = repository.NewCachedTaskRepository(taskRepo, repoCache, ttl, taskCacheStats)
+		tagRepo = repository.NewCachedTagRepository(tagRepo, repoCache)
+		userRepo = repository.NewCachedUserRepository(userRepo, repoCache, ttl, userCacheStats)
+		expvar.Publish("cache", expvar.Func(func() any {
+			return map[string]map[string]int64{
+				"tasks": taskCacheStats.Snapshot(),
+				"users": userCacheStats.Snapshot(),
+			}
+		}))
+		log.Printf("已启用缓存 (%s)", config.GlobalConfig.Cache.Driver)
+	}
+
+	// 调试模式下通过 /debug/vars 查看缓存命中统计
+	if config.GlobalConfig.Server.Mode == gin.DebugMode {
+		r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
+	// 任务变更通过事件总线推送给订阅的客户端
+	eventBus := event.NewBus(event.DefaultHistorySize)
 
 	// 创建服务实例
-	userService := service.NewUserService(userRepo)
-	taskService := service.NewTaskService(taskRepo)
+	userService := service.NewUserService(userRepo, tokenRepo)
+	taskService := service.NewTaskService(taskRepo, tagRepo, projectRepo, collabRepo, workspaceRepo, assigneeRepo, commentRepo, historyRepo, eventBus)
+	tagService := service.NewTagService(tagRepo)
+	projectService := service.NewProjectService(projectRepo, taskRepo, workspaceRepo)
+	collaboratorService := service.NewCollaboratorService(taskRepo, collabRepo, userRepo, workspaceRepo)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, userRepo)
+	commentService := service.NewCommentService(taskService, commentRepo, userRepo)
+	historyService := service.NewHistoryService(taskService, historyRepo, userRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo)
+	webhookService := service.NewWebhookService(webhookRepo)
+	calendarService := service.NewCalendarService(taskService, calendarTokenRepo)
+	caldavService := service.NewCalDAVService(taskService, caldavRepo)
+	importService := service.NewImportService(taskService, tagService)
+
+	// 认证时检查令牌是否已被吊销
+	middleware.SetTokenRevocationChecker(userService)
+
+	// 切换工作区时检查成员身份
+	middleware.SetWorkspaceMembershipChecker(workspaceService)
+
+	// 携带 Idempotency-Key 的重试返回首次请求的响应
+	middleware.SetIdempotencyStore(idempotencyService)
+
+	// 定期清理过期的令牌和幂等键记录
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := tokenRepo.DeleteExpired(time.Now()); err != nil {
+				log.Printf("清理过期令牌失败: %v", err)
+			}
+			if err := idempotencyService.PurgeExpired(time.Now()); err != nil {
+				log.Printf("清理过期幂等键失败: %v", err)
+			}
+		}
+	}()
+
+	// 定期永久删除超过保留期限的回收站任务
+	go func() {
+		for range time.Tick(time.Hour) {
+			before := time.Now().Add(-config.GlobalConfig.Trash.RetentionDays)
+			if n, err := taskService.PurgeExpired(before); err != nil {
+				log.Printf("清理回收站失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已永久删除 %d 个过期的回收站任务", n)
+			}
+		}
+	}()
+
+	// 任务事件投递到用户注册的 Webhook，失败的投递定期重试
+	service.StartWebhookDispatcher(context.Background(), eventBus, webhookService, 5*time.Second)
 
 	// 创建处理器实例
 	userHandler := api.NewUserHandler(userService)
 	taskHandler := api.NewTaskHandler(taskService)
+	tagHandler := api.NewTagHandler(tagService)
+	projectHandler := api.NewProjectHandler(projectService, taskService)
+	collaboratorHandler := api.NewCollaboratorHandler(collaboratorService)
+	workspaceHandler := api.NewWorkspaceHandler(workspaceService)
+	commentHandler := api.NewCommentHandler(commentService)
+	historyHandler := api.NewHistoryHandler(historyService)
+	eventHandler := api.NewEventHandler(eventBus)
+	webhookHandler := api.NewWebhookHandler(webhookService)
+	calendarHandler := api.NewCalendarHandler(calendarService)
+	caldavHandler := api.NewCalDAVHandler(caldavService, userService)
+	importHandler := api.NewImportHandler(importService, taskService)
 
 	// 注册路由
 	userHandler.RegisterRoutes(r)
 	taskHandler.RegisterRoutes(r)
+	tagHandler.RegisterRoutes(r)
+	projectHandler.RegisterRoutes(r)
+	collaboratorHandler.RegisterRoutes(r)
+	workspaceHandler.RegisterRoutes(r)
+	commentHandler.RegisterRoutes(r)
+	historyHandler.RegisterRoutes(r)
+	eventHandler.RegisterRoutes(r)
+	webhookHandler.RegisterRoutes(r)
+	calendarHandler.RegisterRoutes(r)
+	caldavHandler.RegisterRoutes(r)
+	importHandler.RegisterRoutes(r)
 
 	// 启动服务器
 	r.Run(":8080")
@@ -0,0 +1,208 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalidData 数据不是有效的 iCalendar
+var ErrInvalidData = errors.New("无效的 iCalendar 数据")
+
+// maxNesting 组件的最大嵌套层数
+const maxNesting = 16
+
+// Decode 解析 iCalendar 数据，返回最外层的组件，通常为 VCALENDAR。
+// 接受 CRLF 和 LF 换行，续行以空格或制表符开头；属性值保持转义后的原文，文本值使用 UnescapeText 还原
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for i, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 第%d行: %v", ErrInvalidData, i+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			if root != nil && len(stack) == 0 {
+				return nil, fmt.Errorf("%w: 只能有一个顶层组件", ErrInvalidData)
+			}
+			if len(stack) >= maxNesting {
+				return nil, fmt.Errorf("%w: 组件嵌套过深", ErrInvalidData)
+			}
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) == 0 {
+				root = component
+			} else {
+				stack[len(stack)-1].Append(component)
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: 第%d行: END:%s 没有对应的 BEGIN", ErrInvalidData, i+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: 第%d行: 属性不在组件中", ErrInvalidData, i+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("%w: 没有组件", ErrInvalidData)
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: 组件 %s 没有结束", ErrInvalidData, stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold 读取全部行并合并续行，忽略空行
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("%w: 第一行不能是续行", ErrInvalidData)
+			}
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseLine 解析 "NAME;PARAM=value;PARAM=\"quoted\":value" 形式的内容行，属性名和参数名转为大写
+func parseLine(line string) (Property, error) {
+	var prop Property
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return prop, errors.New("缺少属性名")
+	}
+	prop.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return prop, errors.New("参数格式错误")
+		}
+		name := strings.ToUpper(rest[:eq])
+		j := eq + 1
+		// 参数值可以用双引号包含分号和冒号
+		for {
+			if j < len(rest) && rest[j] == '"' {
+				end := strings.IndexByte(rest[j+1:], '"')
+				if end < 0 {
+					return prop, errors.New("参数值缺少结束的引号")
+				}
+				j += end + 2
+			} else {
+				for j < len(rest) && rest[j] != ',' && rest[j] != ';' && rest[j] != ':' {
+					j++
+				}
+			}
+			if j < len(rest) && rest[j] == ',' {
+				j++
+				continue
+			}
+			break
+		}
+		if j >= len(rest) {
+			return prop, errors.New("缺少属性值")
+		}
+		prop.Params = append(prop.Params, name+"="+rest[eq+1:j])
+		i += j + 1
+	}
+
+	prop.Value = line[i+1:]
+	return prop, nil
+}
+
+// Get 返回第一个同名属性，不存在时返回 nil
+func (c *Component) Get(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Children 返回指定名称的子组件
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Param 返回参数值，去掉两端的双引号，不存在时返回空字符串
+func (p *Property) Param(name string) string {
+	prefix := name + "="
+	for _, param := range p.Params {
+		if strings.HasPrefix(param, prefix) {
+			return strings.Trim(param[len(prefix):], `"`)
+		}
+	}
+	return ""
+}
+
+// Text 返回还原转义后的文本值
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// Time 解析 DATE 或 DATE-TIME 类型的值，allDay 表示值为日期。
+// 日期和不带时区的时间按 loc 解析；TZID 指定的时区无法加载时同样使用 loc
+func (p *Property) Time(loc *time.Location) (t time.Time, allDay bool, err error) {
+	value := p.Value
+	if p.Param("VALUE") == "DATE" || len(value) == len(dateLayout) {
+		t, err = time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(dateTimeLayout, value)
+		return t, false, err
+	}
+	if tzid := p.Param("TZID"); tzid != "" {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			loc = zone
+		}
+	}
+	t, err = time.ParseInLocation(localDateTimeLayout, value, loc)
+	return t, false, err
+}
+
+// textUnescaper 还原 EscapeText 转义的字符
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// UnescapeText 还原 TEXT 类型的属性值
+func UnescapeText(text string) string {
+	return textUnescaper.Replace(text)
+}
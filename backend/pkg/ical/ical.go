@@ -0,0 +1,138 @@
+// Package ical 生成和解析 RFC 5545 iCalendar 数据，用于导出、订阅和同步任务日历
+//
+// 只负责组件、属性、文本转义和长行折叠，组件的内容由调用方决定。
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType iCalendar 数据的 MIME 类型
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets 折叠前每行的最大字节数，不含换行符
+const maxLineOctets = 75
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	// localDateTimeLayout 不带 Z 的浮动时间或带 TZID 的时间
+	localDateTimeLayout = "20060102T150405"
+)
+
+// Property 组件的属性，Params 为 "VALUE=DATE" 形式的参数
+type Property struct {
+	Name   string
+	Params []string
+	Value  string
+}
+
+// Component 日历组件，如 VCALENDAR、VEVENT、VTODO
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// NewCalendar 创建 VCALENDAR 组件，prodID 为生成日历的产品标识
+func NewCalendar(prodID string) *Component {
+	calendar := &Component{Name: "VCALENDAR"}
+	calendar.Add("VERSION", "2.0")
+	calendar.Add("PRODID", prodID)
+	calendar.Add("CALSCALE", "GREGORIAN")
+	return calendar
+}
+
+// Add 添加属性，value 原样输出，文本值应使用 AddText
+func (c *Component) Add(name, value string, params ...string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText 添加文本属性，转义逗号、分号、反斜杠和换行
+func (c *Component) AddText(name, text string, params ...string) {
+	c.Add(name, EscapeText(text), params...)
+}
+
+// AddDate 添加日期属性，只保留 t 所在时区的年月日
+func (c *Component) AddDate(name string, t time.Time) {
+	c.Add(name, FormatDate(t), "VALUE=DATE")
+}
+
+// AddDateTime 添加 UTC 日期时间属性
+func (c *Component) AddDateTime(name string, t time.Time) {
+	c.Add(name, FormatDateTime(t))
+}
+
+// Append 添加子组件
+func (c *Component) Append(child *Component) {
+	c.Components = append(c.Components, child)
+}
+
+// Encode 按 RFC 5545 输出组件，行以 CRLF 结尾，超过75字节的行折叠
+func (c *Component) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	c.encode(bw)
+	return bw.Flush()
+}
+
+// String 返回编码后的组件
+func (c *Component) String() string {
+	var sb strings.Builder
+	c.Encode(&sb)
+	return sb.String()
+}
+
+func (c *Component) encode(w *bufio.Writer) {
+	writeLine(w, "BEGIN:"+c.Name)
+	for _, p := range c.Properties {
+		line := p.Name
+		for _, param := range p.Params {
+			line += ";" + param
+		}
+		writeLine(w, line+":"+p.Value)
+	}
+	for _, child := range c.Components {
+		child.encode(w)
+	}
+	writeLine(w, "END:"+c.Name)
+}
+
+// writeLine 写出一行，超长时在 UTF-8 字符边界折叠，续行以一个空格开头
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// 续行开头的空格占一个字节
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// textEscaper 文本值需要转义的字符，回车换行统一转换为 \n
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// EscapeText 转义 TEXT 类型的属性值
+func EscapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// FormatDate 格式化 DATE 类型的值
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// FormatDateTime 格式化 UTC 的 DATE-TIME 类型的值
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -18,25 +20,50 @@ var (
 )
 
 // CustomClaims 自定义 JWT 声明
+// StandardClaims.Id 为令牌唯一标识，用于服务端吊销
 type CustomClaims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
 	jwt.StandardClaims
 }
 
-// GenerateToken 生成 JWT 令牌
+// GenerateToken 生成 JWT 令牌，有效期为 jwt.expire_hours
 func GenerateToken(userID int, username string) (string, error) {
+	token, _, err := generateToken(userID, username, config.GlobalConfig.JWT.ExpireHours)
+	return token, err
+}
+
+// GenerateAccessToken 生成短期访问令牌，有效期为 jwt.access_expire_minutes
+// 未配置时回退到 jwt.expire_hours
+func GenerateAccessToken(userID int, username string) (string, *CustomClaims, error) {
+	jwtConfig := config.GlobalConfig.JWT
+	expire := jwtConfig.AccessExpireMinutes
+	if expire == 0 {
+		expire = jwtConfig.ExpireHours
+	}
+	return generateToken(userID, username, expire)
+}
+
+// generateToken 生成指定有效期的令牌
+func generateToken(userID int, username string, expire time.Duration) (string, *CustomClaims, error) {
 	// 获取配置
 	jwtConfig := config.GlobalConfig.JWT
 
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
 	// 创建 claims
-	claims := CustomClaims{
+	now := time.Now()
+	claims := &CustomClaims{
 		UserID:   userID,
 		Username: username,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(jwtConfig.ExpireHours).Unix(),
-			IssuedAt:  time.Now().Unix(),
-			NotBefore: time.Now().Unix(),
+			Id:        tokenID,
+			ExpiresAt: now.Add(expire).Unix(),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
 			Issuer:    jwtConfig.Issuer,
 		},
 	}
@@ -45,7 +72,20 @@ func GenerateToken(userID int, username string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// 签名并获得完整的编码后的字符串令牌
-	return token.SignedString([]byte(jwtConfig.SecretKey))
+	signed, err := token.SignedString([]byte(jwtConfig.SecretKey))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// NewTokenID 生成随机的令牌标识
+func NewTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 // ParseToken 解析 JWT 令牌
@@ -0,0 +1,353 @@
+// Package redis 实现缓存所需的最小 Redis 客户端，使用 RESP2 协议和固定大小的连接池
+//
+// 只支持字符串命令（GET、SET、DEL、INCR）和 PING，不支持发布订阅、管道和集群。
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// Nil 键不存在
+	Nil = errors.New("redis: nil")
+	// ErrClosed 客户端已关闭
+	ErrClosed = errors.New("redis: 客户端已关闭")
+	// ErrProtocol 无法解析服务器的响应
+	ErrProtocol = errors.New("redis: 无效的响应")
+)
+
+// defaultDialTimeout 未配置时的连接超时
+const defaultDialTimeout = 5 * time.Second
+
+// Error 服务器返回的错误
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Options 客户端选项
+type Options struct {
+	Addr            string
+	Password        string
+	DB              int
+	PoolSize        int           // 最大连接数，默认10
+	MinIdleConns    int           // 创建客户端时预先建立的空闲连接数
+	MaxConnLifetime time.Duration // 连接的最长使用时间，为0表示不限制
+	DialTimeout     time.Duration // 建立连接和每次命令读写的超时，默认5秒
+}
+
+// conn 一个到服务器的连接
+type conn struct {
+	netConn   net.Conn
+	reader    *bufio.Reader
+	createdAt time.Time
+}
+
+// Client Redis 客户端，可以在多个 goroutine 中使用
+type Client struct {
+	opts  Options
+	slots chan struct{} // 限制同时打开的连接数
+	idle  chan *conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewClient 创建客户端，不会等待连接建立，可以用 Ping 检查服务器是否可用
+func NewClient(opts Options) *Client {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.MinIdleConns > opts.PoolSize {
+		opts.MinIdleConns = opts.PoolSize
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+
+	c := &Client{
+		opts:  opts,
+		slots: make(chan struct{}, opts.PoolSize),
+		idle:  make(chan *conn, opts.PoolSize),
+	}
+	if opts.MinIdleConns > 0 {
+		go c.fillIdle()
+	}
+	return c
+}
+
+// fillIdle 预先建立 MinIdleConns 个空闲连接，失败时放弃，之后按需建立
+func (c *Client) fillIdle() {
+	for i := 0; i < c.opts.MinIdleConns; i++ {
+		c.slots <- struct{}{}
+		cn, err := c.dial()
+		if err != nil {
+			<-c.slots
+			return
+		}
+		c.put(cn, nil)
+	}
+}
+
+// Get 获取键的值，键不存在时返回 Nil
+func (c *Client) Get(key string) (string, error) {
+	reply, err := c.Do("GET", key)
+	if err != nil {
+		return "", err
+	}
+	if reply == nil {
+		return "", Nil
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return "", ErrProtocol
+	}
+	return value, nil
+}
+
+// Set 设置键的值，ttl 大于0时在 ttl 后过期
+func (c *Client) Set(key, value string, ttl time.Duration) error {
+	args := []string{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.Do(args...)
+	return err
+}
+
+// Del 删除键，不存在的键会被忽略
+func (c *Client) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.Do(append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Incr 将键的值加1并返回新值，键不存在时从0开始
+func (c *Client) Incr(key string) (int64, error) {
+	reply, err := c.Do("INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, ErrProtocol
+	}
+	return n, nil
+}
+
+// Ping 检查服务器是否可用
+func (c *Client) Ping() error {
+	_, err := c.Do("PING")
+	return err
+}
+
+// Do 执行命令并返回响应：简单字符串和批量字符串为 string，整数为 int64，数组为 []any，空值为 nil。
+// 服务器返回的错误为 Error 类型，连接可以继续使用；其他错误会关闭该连接
+func (c *Client) Do(args ...string) (any, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(c.opts.DialTimeout, args)
+	c.put(cn, err)
+	return reply, err
+}
+
+// Close 关闭客户端和全部空闲连接，正在使用的连接在归还时关闭
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.netConn.Close()
+			<-c.slots
+		default:
+			return nil
+		}
+	}
+}
+
+// get 取出空闲连接，没有空闲连接且未达到连接数上限时建立新连接，否则等待其他连接归还
+func (c *Client) get() (*conn, error) {
+	for {
+		if c.isClosed() {
+			return nil, ErrClosed
+		}
+		select {
+		case cn := <-c.idle:
+			if c.expired(cn) {
+				cn.netConn.Close()
+				<-c.slots
+				continue
+			}
+			return cn, nil
+		case c.slots <- struct{}{}:
+			cn, err := c.dial()
+			if err != nil {
+				<-c.slots
+				return nil, err
+			}
+			return cn, nil
+		}
+	}
+}
+
+// put 归还连接，出现网络或协议错误、连接过期或客户端已关闭时关闭连接
+func (c *Client) put(cn *conn, err error) {
+	var serverErr Error
+	broken := err != nil && !errors.As(err, &serverErr) && !errors.Is(err, Nil)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if broken || c.closed || c.expired(cn) {
+		cn.netConn.Close()
+		<-c.slots
+		return
+	}
+	c.idle <- cn
+}
+
+// expired 判断连接是否超过最长使用时间
+func (c *Client) expired(cn *conn) bool {
+	return c.opts.MaxConnLifetime > 0 && time.Since(cn.createdAt) >= c.opts.MaxConnLifetime
+}
+
+// isClosed 判断客户端是否已关闭
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// dial 建立连接，配置了密码和数据库时完成认证并切换数据库
+func (c *Client) dial() (*conn, error) {
+	netConn, err := net.DialTimeout("tcp", c.opts.Addr, c.opts.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{netConn: netConn, reader: bufio.NewReader(netConn), createdAt: time.Now()}
+	if c.opts.Password != "" {
+		if _, err := cn.do(c.opts.DialTimeout, []string{"AUTH", c.opts.Password}); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do(c.opts.DialTimeout, []string{"SELECT", strconv.Itoa(c.opts.DB)}); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+// do 发送一条命令并读取响应
+func (cn *conn) do(timeout time.Duration, args []string) (any, error) {
+	if err := cn.netConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := cn.netConn.Write(encodeCommand(args)); err != nil {
+		return nil, err
+	}
+	return readReply(cn.reader)
+}
+
+// encodeCommand 将命令编码为 RESP 批量字符串数组
+func encodeCommand(args []string) []byte {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	return buf
+}
+
+// readReply 读取一个 RESP 响应
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, ErrProtocol
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		return n, nil
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < -1 {
+			return nil, ErrProtocol
+		}
+		if size == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < -1 {
+			return nil, ErrProtocol
+		}
+		if count == -1 {
+			return nil, nil
+		}
+		items := make([]any, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(r)
+			var serverErr Error
+			if err != nil && !errors.As(err, &serverErr) {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrProtocol, line)
+	}
+}
+
+// readLine 读取以 \r\n 结尾的一行，不包括行尾
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", ErrProtocol
+	}
+	return line[:len(line)-2], nil
+}
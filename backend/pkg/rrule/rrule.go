@@ -0,0 +1,433 @@
+// Package rrule 实现 RFC 5545 RRULE 的常用子集，用于生成重复任务的下一次发生时间
+//
+// 支持的规则部分：FREQ（DAILY/WEEKLY/MONTHLY/YEARLY）、INTERVAL、BYDAY、UNTIL、COUNT、WKST。
+// 与 RFC 一致，DTSTART 总是第一次发生，并计入 COUNT。
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRule       = errors.New("无效的重复规则")
+	ErrMissingFreq       = errors.New("重复规则缺少 FREQ")
+	ErrUntilAndCount     = errors.New("UNTIL 和 COUNT 不能同时使用")
+	ErrUnsupportedRule   = errors.New("不支持的重复规则部分")
+	ErrOrdinalNotAllowed = errors.New("只有 MONTHLY 和 YEARLY 规则的 BYDAY 可以带序号")
+)
+
+// Frequency 重复频率
+type Frequency string
+
+// 支持的重复频率
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods 查找下一次发生时最多遍历的周期数，防止规则永远无法匹配时死循环
+const maxPeriods = 10000
+
+// UNTIL 的格式：UTC 日期时间、浮动日期时间和纯日期
+const (
+	untilLayout         = "20060102T150405Z"
+	floatingUntilLayout = "20060102T150405"
+	dateUntilLayout     = "20060102"
+)
+
+// weekdayNames RRULE 中的星期缩写
+var weekdayNames = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum BYDAY 中的一项，如 MO、2TU、-1FR
+// N 为0表示周期内所有该星期几，正数表示第N个，负数表示倒数第N个
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// String 返回 RRULE 格式
+func (w WeekdayNum) String() string {
+	name := weekdayName(w.Weekday)
+	if w.N == 0 {
+		return name
+	}
+	return strconv.Itoa(w.N) + name
+}
+
+// Rule 重复规则
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	// Until 最后一次发生的时间上限（含），为空表示不限制。
+	// 浮动日期时间和纯日期没有时区，Parse 将钟面时间保存在 UTC 中，计算时按 DTSTART 的时区解释
+	Until *time.Time
+	// Count 总发生次数，0 表示不限制
+	Count int
+	// WeekStart 每周的第一天，默认周一
+	WeekStart time.Weekday
+
+	// untilFloating UNTIL 为浮动日期时间或纯日期
+	untilFloating bool
+	// untilDate UNTIL 为纯日期
+	untilDate bool
+}
+
+// Parse 解析 RRULE 文本，可带 "RRULE:" 前缀，如 "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"
+func Parse(text string) (*Rule, error) {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "RRULE:")
+	if text == "" {
+		return nil, ErrMissingFreq
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(text, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s 重复出现", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = f
+			default:
+				return nil, fmt.Errorf("%w: FREQ=%s", ErrUnsupportedRule, value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err != nil || rule.Interval < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL 应为正整数", ErrInvalidRule)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err != nil || rule.Count < 1 {
+				return nil, fmt.Errorf("%w: COUNT 应为正整数", ErrInvalidRule)
+			}
+		case "UNTIL":
+			until, layout, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+			rule.untilFloating = layout != untilLayout
+			rule.untilDate = layout == dateUntilLayout
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				day, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "WKST":
+			day, ok := weekdayNames[value]
+			if !ok {
+				return nil, fmt.Errorf("%w: WKST=%s", ErrInvalidRule, value)
+			}
+			rule.WeekStart = day
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRule, key)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Validate 验证规则各部分是否相容
+func (r *Rule) Validate() error {
+	if r.Freq == "" {
+		return ErrMissingFreq
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("%w: INTERVAL 应为正整数", ErrInvalidRule)
+	}
+	if r.Until != nil && r.Count > 0 {
+		return ErrUntilAndCount
+	}
+	if r.Freq == Daily || r.Freq == Weekly {
+		for _, day := range r.ByDay {
+			if day.N != 0 {
+				return ErrOrdinalNotAllowed
+			}
+		}
+	}
+	return nil
+}
+
+// String 返回不带 "RRULE:" 前缀的规则文本
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayName(r.WeekStart))
+	}
+	switch {
+	case r.Until == nil:
+	case r.untilDate:
+		parts = append(parts, "UNTIL="+r.Until.Format(dateUntilLayout))
+	case r.untilFloating:
+		parts = append(parts, "UNTIL="+r.Until.Format(floatingUntilLayout))
+	default:
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// After 返回以 dtstart 为起点的序列中，第一个严格晚于 t 的发生时间
+// 序列已结束（超过 UNTIL 或 COUNT）时第二个返回值为 false
+func (r *Rule) After(dtstart, t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if occurrence.After(t) {
+			next, found = occurrence, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// Occurrences 返回以 dtstart 为起点的前 limit 次发生时间
+func (r *Rule) Occurrences(dtstart time.Time, limit int) []time.Time {
+	var occurrences []time.Time
+	if limit <= 0 {
+		return occurrences
+	}
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		occurrences = append(occurrences, occurrence)
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// iterate 按时间顺序依次回调每次发生时间，回调返回 false 时停止
+func (r *Rule) iterate(dtstart time.Time, fn func(time.Time) bool) {
+	until := r.until(dtstart.Location())
+	count := 0
+	emit := func(occurrence time.Time) bool {
+		if until != nil && occurrence.After(*until) {
+			return false
+		}
+		count++
+		if !fn(occurrence) {
+			return false
+		}
+		return r.Count == 0 || count < r.Count
+	}
+
+	// DTSTART 总是第一次发生
+	if !emit(dtstart) {
+		return
+	}
+	for period := 0; period < maxPeriods; period++ {
+		for _, occurrence := range r.expand(dtstart, period*r.Interval) {
+			if !occurrence.After(dtstart) {
+				continue
+			}
+			if !emit(occurrence) {
+				return
+			}
+		}
+	}
+}
+
+// until 返回 UNTIL 对应的时刻，浮动日期时间和纯日期按 loc 解释
+func (r *Rule) until(loc *time.Location) *time.Time {
+	if r.Until == nil || !r.untilFloating {
+		return r.Until
+	}
+	u := *r.Until
+	t := time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), u.Nanosecond(), loc)
+	return &t
+}
+
+// expand 返回从 dtstart 所在周期起第 offset 个周期内的全部发生时间，按时间升序
+func (r *Rule) expand(dtstart time.Time, offset int) []time.Time {
+	year, month, day := dtstart.Date()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+	}
+
+	var occurrences []time.Time
+	switch r.Freq {
+	case Daily:
+		occurrence := at(year, month, day+offset)
+		if len(r.ByDay) == 0 || r.matchWeekday(occurrence.Weekday()) {
+			occurrences = append(occurrences, occurrence)
+		}
+
+	case Weekly:
+		shift := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(year, month, day-shift+offset*7)
+		if len(r.ByDay) == 0 {
+			occurrences = append(occurrences, weekStart.AddDate(0, 0, shift))
+			break
+		}
+		for i := 0; i < 7; i++ {
+			occurrence := weekStart.AddDate(0, 0, i)
+			if r.matchWeekday(occurrence.Weekday()) {
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+
+	case Monthly:
+		first := at(year, month+time.Month(offset), 1)
+		if len(r.ByDay) == 0 {
+			// 当月没有该日期时跳过，如每月31日
+			if occurrence := first.AddDate(0, 0, day-1); occurrence.Month() == first.Month() {
+				occurrences = append(occurrences, occurrence)
+			}
+			break
+		}
+		occurrences = r.expandByDay(first, first.AddDate(0, 1, 0))
+
+	case Yearly:
+		if len(r.ByDay) == 0 {
+			// 非闰年跳过2月29日
+			if occurrence := at(year+offset, month, day); occurrence.Month() == month {
+				occurrences = append(occurrences, occurrence)
+			}
+			break
+		}
+		first := at(year+offset, time.January, 1)
+		occurrences = r.expandByDay(first, first.AddDate(1, 0, 0))
+	}
+	return occurrences
+}
+
+// expandByDay 在 [start, end) 范围内展开 BYDAY，用于月和年周期
+func (r *Rule) expandByDay(start, end time.Time) []time.Time {
+	seen := make(map[time.Time]bool)
+	var occurrences []time.Time
+	add := func(t time.Time) {
+		if !seen[t] {
+			seen[t] = true
+			occurrences = append(occurrences, t)
+		}
+	}
+
+	for _, byDay := range r.ByDay {
+		var matches []time.Time
+		for t := start; t.Before(end); t = t.AddDate(0, 0, 1) {
+			if t.Weekday() == byDay.Weekday {
+				matches = append(matches, t)
+			}
+		}
+
+		switch {
+		case byDay.N == 0:
+			for _, t := range matches {
+				add(t)
+			}
+		case byDay.N > 0 && byDay.N <= len(matches):
+			add(matches[byDay.N-1])
+		case byDay.N < 0 && -byDay.N <= len(matches):
+			add(matches[len(matches)+byDay.N])
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Before(occurrences[j])
+	})
+	return occurrences
+}
+
+// matchWeekday 判断星期几是否在 BYDAY 中
+func (r *Rule) matchWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWeekdayNum 解析 BYDAY 中的一项
+func parseWeekdayNum(text string) (WeekdayNum, error) {
+	text = strings.TrimSpace(text)
+	if len(text) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, text)
+	}
+
+	weekday, ok := weekdayNames[text[len(text)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, text)
+	}
+
+	n := 0
+	if prefix := text[:len(text)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("%w: BYDAY=%s", ErrInvalidRule, text)
+		}
+	}
+	return WeekdayNum{N: n, Weekday: weekday}, nil
+}
+
+// parseUntil 解析 UNTIL，支持 UTC 日期时间、浮动日期时间和纯日期，返回时间和匹配的格式。
+// 浮动日期时间和纯日期的钟面时间保存在 UTC 中，纯日期表示当天结束前都有效
+func parseUntil(value string) (time.Time, string, error) {
+	for _, layout := range []string{untilLayout, floatingUntilLayout, dateUntilLayout} {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if layout == dateUntilLayout {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t, layout, nil
+	}
+	return time.Time{}, "", fmt.Errorf("%w: UNTIL=%s", ErrInvalidRule, value)
+}
+
+// weekdayName 返回星期几的 RRULE 缩写
+func weekdayName(weekday time.Weekday) string {
+	for name, day := range weekdayNames {
+		if day == weekday {
+			return name
+		}
+	}
+	return ""
+}
@@ -1,19 +1,31 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"todolist/config"
 	"todolist/internal/api"
+	"todolist/internal/event"
+	"todolist/internal/middleware"
 	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/internal/service"
 	"todolist/pkg/jwt"
 )
 
@@ -32,8 +44,16 @@ func (m *MockTaskService) Update(task *model.Task) error {
 	return args.Error(0)
 }
 
-func (m *MockTaskService) Delete(taskID, userID int) error {
-	args := m.Called(taskID, userID)
+func (m *MockTaskService) Patch(taskID, userID int, patch model.TaskPatch) (*model.Task, error) {
+	args := m.Called(taskID, userID, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Task), args.Error(1)
+}
+
+func (m *MockTaskService) Delete(taskID, userID int, opts service.DeleteOptions) error {
+	args := m.Called(taskID, userID, opts)
 	return args.Error(0)
 }
 
@@ -45,11 +65,79 @@ func (m *MockTaskService) Get(taskID, userID int) (*model.Task, error) {
 	return args.Get(0).(*model.Task), args.Error(1)
 }
 
-func (m *MockTaskService) List(userID int, status string, page, pageSize int) ([]*model.Task, int64, error) {
-	args := m.Called(userID, status, page, pageSize)
+func (m *MockTaskService) List(filter model.TaskFilter) ([]*model.Task, int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]*model.Task), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockTaskService) ListVersions(filter model.TaskFilter) ([]model.TaskVersion, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.TaskVersion), args.Error(1)
+}
+
+func (m *MockTaskService) Subtasks(taskID, userID int) ([]*model.Task, error) {
+	args := m.Called(taskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Task), args.Error(1)
+}
+
+func (m *MockTaskService) SetAssignees(taskID, userID int, assigneeIDs []int) (*model.Task, error) {
+	args := m.Called(taskID, userID, assigneeIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Task), args.Error(1)
+}
+
+func (m *MockTaskService) AddAssignee(taskID, userID, assigneeID int) (*model.Task, error) {
+	args := m.Called(taskID, userID, assigneeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Task), args.Error(1)
+}
+
+func (m *MockTaskService) RemoveAssignee(taskID, userID, assigneeID int) error {
+	args := m.Called(taskID, userID, assigneeID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) Trash(filter model.TaskFilter) ([]*model.Task, int64, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
 	return args.Get(0).([]*model.Task), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockTaskService) Restore(taskID, userID int) (*model.Task, error) {
+	args := m.Called(taskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Task), args.Error(1)
+}
+
+func (m *MockTaskService) Purge(taskID, userID int) error {
+	args := m.Called(taskID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) Batch(userID int, ops []service.BatchOperation, opts service.BatchOptions) ([]service.BatchResult, error) {
+	args := m.Called(userID, ops, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.BatchResult), args.Error(1)
+}
+
+func (m *MockTaskService) PurgeExpired(before time.Time) (int, error) {
+	args := m.Called(before)
+	return args.Int(0), args.Error(1)
+}
+
 func setupTestRouter(taskService *MockTaskService) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -108,7 +196,7 @@ func TestTaskHandler_Create(t *testing.T) {
 			tt.setupMock()
 
 			body, _ := json.Marshal(tt.reqBody)
-			req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewBuffer(body))
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
 			tt.setupAuth(req)
 
@@ -146,7 +234,30 @@ func TestTaskHandler_Update(t *testing.T) {
 				r.Header.Set("Authorization", "Bearer "+token)
 			},
 			setupMock: func() {
-				taskService.On("Update", mock.AnythingOfType("*model.Task")).Return(nil)
+				taskService.On("Patch", 1, 1, model.TaskPatch{
+					Title:       model.Some("Updated Task"),
+					Description: model.Some("Updated Description"),
+					Status:      model.Some(model.TaskStatusDone),
+				}).Return(&model.Task{ID: 1, UserID: 1, Title: "Updated Task", Status: model.TaskStatusDone}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "未提供状态时不修改，优先级为 none 时清空",
+			taskID: "2",
+			reqBody: map[string]interface{}{
+				"title":    "Updated Task",
+				"priority": "none",
+			},
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock: func() {
+				taskService.On("Patch", 2, 1, model.TaskPatch{
+					Title:    model.Some("Updated Task"),
+					Priority: model.Some(model.TaskPriorityNone),
+				}).Return(&model.Task{ID: 2, UserID: 1, Title: "Updated Task", Status: model.TaskStatusInProgress}, nil)
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -170,7 +281,7 @@ func TestTaskHandler_Update(t *testing.T) {
 			tt.setupMock()
 
 			body, _ := json.Marshal(tt.reqBody)
-			req := httptest.NewRequest(http.MethodPut, "/api/tasks/"+tt.taskID, bytes.NewBuffer(body))
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/tasks/"+tt.taskID, bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
 			tt.setupAuth(req)
 
@@ -183,6 +294,328 @@ func TestTaskHandler_Update(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_UpdateIfMatch(t *testing.T) {
+	current := &model.Task{ID: 1, UserID: 1, Title: "别人改过的标题", Version: 4}
+	tests := []struct {
+		name       string
+		ifMatch    string
+		setupMock  func(taskService *MockTaskService)
+		wantStatus int
+		wantETag   string
+	}{
+		{
+			name:    "版本一致时更新",
+			ifMatch: `"3"`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.MatchedBy(func(patch model.TaskPatch) bool { return patch.Version == 3 })).
+					Return(&model.Task{ID: 1, UserID: 1, Title: "标题", Version: 4}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"4"`,
+		},
+		{
+			name:    "版本过期时返回当前内容",
+			ifMatch: `"3"`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.AnythingOfType("model.TaskPatch")).Return(nil, service.ErrVersionConflict)
+				taskService.On("Get", 1, 1).Return(current, nil)
+			},
+			wantStatus: http.StatusPreconditionFailed,
+			wantETag:   `"4"`,
+		},
+		{
+			name:    "星号不检查版本",
+			ifMatch: "*",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.MatchedBy(func(patch model.TaskPatch) bool { return patch.Version == 0 })).
+					Return(&model.Task{ID: 1, UserID: 1, Title: "标题", Version: 5}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "弱 ETag 永远不匹配",
+			ifMatch: `W/"4"`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.MatchedBy(func(patch model.TaskPatch) bool { return patch.Version == -1 })).Return(nil, service.ErrVersionConflict)
+				taskService.On("Get", 1, 1).Return(current, nil)
+			},
+			wantStatus: http.StatusPreconditionFailed,
+			wantETag:   `"4"`,
+		},
+		{
+			name:       "格式错误",
+			ifMatch:    "4",
+			setupMock:  func(taskService *MockTaskService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskService := new(MockTaskService)
+			router := setupTestRouter(taskService)
+			tt.setupMock(taskService)
+
+			body, _ := json.Marshal(map[string]interface{}{"title": "我的标题"})
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/tasks/1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			token, _ := jwt.GenerateToken(1, "testuser")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantETag != "" {
+				assert.Equal(t, tt.wantETag, w.Header().Get("ETag"))
+			}
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				var resp struct {
+					Data api.TaskResponse `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "别人改过的标题", resp.Data.Title)
+			}
+			taskService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_GetETag(t *testing.T) {
+	taskService := new(MockTaskService)
+	router := setupTestRouter(taskService)
+	taskService.On("Get", 1, 1).Return(&model.Task{ID: 1, UserID: 1, Title: "任务", Version: 2}, nil)
+	token, _ := jwt.GenerateToken(1, "testuser")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-None-Match", `"2"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestTaskHandler_Patch(t *testing.T) {
+	updated := &model.Task{ID: 1, UserID: 1, Title: "任务", Version: 5}
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		setupMock   func(taskService *MockTaskService)
+		wantStatus  int
+	}{
+		{
+			name:        "null 清空字段，未出现的字段不修改",
+			contentType: "application/merge-patch+json",
+			body:        `{"description": null, "due_date": null, "status": "todo", "tag_ids": null}`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.MatchedBy(func(patch model.TaskPatch) bool {
+					return !patch.Title.Set &&
+						patch.Description.Set && patch.Description.Value == "" &&
+						patch.DueDate.Set && patch.DueDate.Value == nil &&
+						patch.Status.Set && patch.Status.Value == model.TaskStatusTodo &&
+						patch.TagIDs.Set && len(patch.TagIDs.Value) == 0 &&
+						!patch.ParentID.Set && !patch.Priority.Set
+				})).Return(updated, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "移出项目并带上版本号",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			ifMatch:     `"4"`,
+			body:        `{"project_id": null, "parent_id": 3, "priority": null}`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.MatchedBy(func(patch model.TaskPatch) bool {
+					return patch.Version == 4 &&
+						patch.ProjectID.Set && patch.ProjectID.Value == nil &&
+						patch.ParentID.Set && *patch.ParentID.Value == 3 &&
+						patch.Priority.Set && patch.Priority.Value == model.TaskPriorityNone
+				})).Return(updated, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "标题不能为 null",
+			contentType: "application/merge-patch+json",
+			body:        `{"title": null}`,
+			setupMock:   func(taskService *MockTaskService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "不支持的字段",
+			contentType: "application/merge-patch+json",
+			body:        `{"user_id": 2}`,
+			setupMock:   func(taskService *MockTaskService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "补丁不是对象",
+			contentType: "application/merge-patch+json",
+			body:        `[{"title": "任务"}]`,
+			setupMock:   func(taskService *MockTaskService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "无效的状态",
+			contentType: "application/merge-patch+json",
+			body:        `{"status": "archived"}`,
+			setupMock:   func(taskService *MockTaskService) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "不支持的请求格式",
+			contentType: "text/plain",
+			body:        `{"title": "任务"}`,
+			setupMock:   func(taskService *MockTaskService) {},
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "版本冲突",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"3"`,
+			body:        `{"title": "任务"}`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Patch", 1, 1, mock.AnythingOfType("model.TaskPatch")).Return(nil, service.ErrVersionConflict)
+				taskService.On("Get", 1, 1).Return(updated, nil)
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskService := new(MockTaskService)
+			router := setupTestRouter(taskService)
+			tt.setupMock(taskService)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/tasks/1", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			token, _ := jwt.GenerateToken(1, "testuser")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK || tt.wantStatus == http.StatusPreconditionFailed {
+				assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+			}
+			taskService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Batch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		setupMock  func(taskService *MockTaskService)
+		wantStatus int
+		wantFailed int
+	}{
+		{
+			name: "部分成功",
+			body: `{"operations": [
+				{"op": "create", "task": {"title": "新任务", "priority": "high"}},
+				{"op": "update", "id": 2, "version": 3, "task": {"status": "done", "due_date": null}},
+				{"op": "delete", "id": 3, "cascade": true}
+			]}`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Batch", 1, mock.MatchedBy(func(ops []service.BatchOperation) bool {
+					return len(ops) == 3 &&
+						ops[0].Op == service.BatchOpCreate && ops[0].Task.Title == "新任务" && ops[0].Task.Priority == model.TaskPriorityHigh &&
+						ops[1].TaskID == 2 && ops[1].Patch.Version == 3 && ops[1].Patch.Status.Value == model.TaskStatusDone &&
+						ops[1].Patch.DueDate.Set && ops[1].Patch.DueDate.Value == nil &&
+						ops[2].TaskID == 3 && ops[2].Delete.Cascade
+				}), service.BatchOptions{}).Return([]service.BatchResult{
+					{Op: service.BatchOpCreate, TaskID: 4, Task: &model.Task{ID: 4, Title: "新任务"}},
+					{Op: service.BatchOpUpdate, TaskID: 2, Task: &model.Task{ID: 2, Title: "任务"}},
+					{Op: service.BatchOpDelete, TaskID: 3, Err: service.ErrTaskNotFound},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantFailed: 1,
+		},
+		{
+			name: "原子执行失败时返回失败项的状态码",
+			body: `{"atomic": true, "operations": [{"op": "delete", "id": 3}, {"op": "update", "id": 2, "task": {"title": "改名"}}]}`,
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Batch", 1, mock.Anything, service.BatchOptions{Atomic: true}).Return([]service.BatchResult{
+					{Op: service.BatchOpDelete, TaskID: 3, Err: service.ErrBatchAborted},
+					{Op: service.BatchOpUpdate, TaskID: 2, Err: service.ErrTaskReadOnly},
+				}, nil)
+			},
+			wantStatus: http.StatusForbidden,
+			wantFailed: 2,
+		},
+		{
+			name:       "不支持的操作",
+			body:       `{"operations": [{"op": "archive", "id": 1}]}`,
+			setupMock:  func(taskService *MockTaskService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "创建缺少标题",
+			body:       `{"operations": [{"op": "create", "task": {"description": "没有标题"}}]}`,
+			setupMock:  func(taskService *MockTaskService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "更新缺少任务ID",
+			body:       `{"operations": [{"op": "update", "task": {"title": "改名"}}]}`,
+			setupMock:  func(taskService *MockTaskService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "操作列表为空",
+			body:       `{"operations": []}`,
+			setupMock:  func(taskService *MockTaskService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskService := new(MockTaskService)
+			router := setupTestRouter(taskService)
+			tt.setupMock(taskService)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/batch", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			token, _ := jwt.GenerateToken(1, "testuser")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantFailed > 0 {
+				var resp struct {
+					Data api.BatchResponse `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantFailed, resp.Data.Failed)
+				assert.Equal(t, len(resp.Data.Results)-tt.wantFailed, resp.Data.Succeeded)
+			}
+			taskService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestTaskHandler_List(t *testing.T) {
 	taskService := new(MockTaskService)
 	router := setupTestRouter(taskService)
@@ -203,17 +636,177 @@ func TestTaskHandler_List(t *testing.T) {
 			},
 			setupMock: func() {
 				tasks := []*model.Task{{ID: 1, Title: "Test Task"}}
-				taskService.On("List", 1, "todo", 1, 10).Return(tasks, int64(1), nil)
+				filter := model.TaskFilter{UserID: 1, Statuses: []int{model.TaskStatusTodo}, Page: 1, PageSize: 10}
+				taskService.On("List", filter).Return(tasks, int64(1), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "按优先级和标签过滤",
+			query: "?priority=high&tag_id=3",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock: func() {
+				filter := model.TaskFilter{UserID: 1, Priorities: []int{model.TaskPriorityHigh}, TagID: 3, Page: 1, PageSize: 10}
+				taskService.On("List", filter).Return([]*model.Task{}, int64(0), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "按项目过滤",
+			query: "?project_id=4",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock: func() {
+				projectID := 4
+				filter := model.TaskFilter{UserID: 1, ProjectID: &projectID, Page: 1, PageSize: 10}
+				taskService.On("List", filter).Return([]*model.Task{}, int64(0), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "按负责人过滤",
+			query: "?assignee=me",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock: func() {
+				filter := model.TaskFilter{UserID: 1, AssigneeID: 1, Page: 1, PageSize: 10}
+				taskService.On("List", filter).Return([]*model.Task{}, int64(0), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "无效的负责人",
+			query: "?assignee=someone",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "无效的优先级",
+			query: "?priority=urgent",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "页码小于1",
+			query: "?page=0",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "无效的页码",
+			query: "?page=abc",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "每页数量为负数",
+			query: "?page_size=-1",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "每页数量超过上限",
+			query: "?page_size=101",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "多状态、逾期、搜索和排序",
+			query: "?status=todo,in_progress&overdue=true&q=%E6%8A%A5%E5%91%8A&sort=due_date,-created_at&page=2&page_size=5",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock: func() {
+				filter := model.TaskFilter{
+					UserID:   1,
+					Statuses: []int{model.TaskStatusTodo, model.TaskStatusInProgress},
+					Overdue:  true,
+					Keyword:  "报告",
+					Sort: []model.TaskSort{
+						{Field: model.TaskSortDueDate},
+						{Field: model.TaskSortCreatedAt, Desc: true},
+					},
+					Page:     2,
+					PageSize: 5,
+				}
+				taskService.On("List", filter).Return([]*model.Task{}, int64(0), nil)
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:  "截止日期范围",
+			query: "?due_after=2024-01-01&due_before=2024-02-01",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock: func() {
+				after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+				before := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+				filter := model.TaskFilter{UserID: 1, DueAfter: &after, DueBefore: &before, Page: 1, PageSize: 10}
+				taskService.On("List", filter).Return([]*model.Task{}, int64(0), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "不支持的排序字段",
+			query: "?sort=-password",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "无效的截止日期",
+			query: "?due_before=tomorrow",
+			setupAuth: func(r *http.Request) {
+				token, _ := jwt.GenerateToken(1, "testuser")
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+			setupMock:  func() {},
+			wantStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMock()
 
-			req := httptest.NewRequest(http.MethodGet, "/api/tasks"+tt.query, nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks"+tt.query, nil)
 			tt.setupAuth(req)
 
 			w := httptest.NewRecorder()
@@ -224,3 +817,702 @@ func TestTaskHandler_List(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(taskService *MockTaskService)
+		wantStatus int
+	}{
+		{
+			name: "存在子任务时拒绝删除",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Delete", 1, 1, service.DeleteOptions{}).Return(service.ErrTaskHasSubtasks)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:  "级联删除",
+			query: "?cascade=true",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Delete", 1, 1, service.DeleteOptions{Cascade: true}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "任务不存在",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Delete", 1, 1, service.DeleteOptions{}).Return(service.ErrTaskNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskService := new(MockTaskService)
+			router := setupTestRouter(taskService)
+			tt.setupMock(taskService)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/tasks/1"+tt.query, nil)
+			token, _ := jwt.GenerateToken(1, "testuser")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			taskService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskHandler_Trash(t *testing.T) {
+	deletedAt := time.Now()
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		setupMock  func(taskService *MockTaskService)
+		wantStatus int
+	}{
+		{
+			name:   "获取回收站",
+			method: http.MethodGet,
+			path:   "/api/v1/tasks/trash?page=1&page_size=20",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Trash", model.TaskFilter{UserID: 1, Page: 1, PageSize: 20}).
+					Return([]*model.Task{{ID: 1, UserID: 1, Title: "已删除", DeletedAt: &deletedAt}}, int64(1), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "恢复任务",
+			method: http.MethodPost,
+			path:   "/api/v1/tasks/1/restore",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Restore", 1, 1).Return(&model.Task{ID: 1, UserID: 1, Title: "已恢复"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "父任务在回收站中",
+			method: http.MethodPost,
+			path:   "/api/v1/tasks/2/restore",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Restore", 2, 1).Return(nil, service.ErrParentInTrash)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "永久删除",
+			method: http.MethodDelete,
+			path:   "/api/v1/tasks/trash/1",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Purge", 1, 1).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "永久删除不在回收站中的任务",
+			method: http.MethodDelete,
+			path:   "/api/v1/tasks/trash/3",
+			setupMock: func(taskService *MockTaskService) {
+				taskService.On("Purge", 3, 1).Return(service.ErrTaskNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskService := new(MockTaskService)
+			router := setupTestRouter(taskService)
+			tt.setupMock(taskService)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			token, _ := jwt.GenerateToken(1, "testuser")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			taskService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEventHandler_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := event.NewBus(3)
+	router := gin.New()
+	api.NewEventHandler(bus).RegisterRoutes(router)
+	token, _ := jwt.GenerateToken(1, "testuser")
+
+	bus.Publish(event.Event{Type: event.TaskCreated, Task: &model.Task{ID: 1, UserID: 1, Title: "任务"}, UserIDs: []int{1}})
+	bus.Publish(event.Event{Type: event.TaskUpdated, Task: &model.Task{ID: 1, UserID: 1, Title: "改名"}, UserIDs: []int{1}})
+	bus.Publish(event.Event{Type: event.TaskCreated, Task: &model.Task{ID: 2, UserID: 2, Title: "其他用户"}, UserIDs: []int{2}})
+
+	// 请求在订阅后立即结束，只返回补发的事件
+	stream := func(setup func(r *http.Request)) *httptest.ResponseRecorder {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil).WithContext(ctx)
+		setup(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("未认证", func(t *testing.T) {
+		w := stream(func(r *http.Request) {})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("从 Last-Event-ID 之后补发", func(t *testing.T) {
+		w := stream(func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+			r.Header.Set("Last-Event-ID", "1")
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "id: 2\nevent: task.updated\ndata: {")
+		assert.Contains(t, body, `"title":"改名"`)
+		assert.NotContains(t, body, "reset")
+		assert.NotContains(t, body, "其他用户")
+		assert.NotContains(t, body, "id: 1\n")
+	})
+
+	t.Run("通过参数传递令牌和事件ID", func(t *testing.T) {
+		w := stream(func(r *http.Request) {
+			r.URL.RawQuery = "access_token=" + token + "&last_event_id=0"
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "event: task.")
+	})
+
+	t.Run("错过的事件无法补发", func(t *testing.T) {
+		// 只保留最近3个事件，第2个事件已被丢弃
+		bus.Publish(event.Event{Type: event.TaskDeleted, Task: &model.Task{ID: 1, UserID: 1}, UserIDs: []int{1}})
+		bus.Publish(event.Event{Type: event.TaskCreated, Task: &model.Task{ID: 3, UserID: 2}, UserIDs: []int{2}})
+		w := stream(func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+			r.Header.Set("Last-Event-ID", "1")
+		})
+		body := w.Body.String()
+		assert.Contains(t, body, "event: reset\ndata: {}\n\n")
+		assert.Contains(t, body, "id: 4\nevent: task.deleted")
+	})
+
+	t.Run("无效的事件ID", func(t *testing.T) {
+		w := stream(func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+			r.Header.Set("Last-Event-ID", "abc")
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestEventHandler_StreamClosesWhenTokenInvalid(t *testing.T) {
+	userService, _ := setupTestService(t)
+	assert.NoError(t, userService.Register("testuser", "password123"))
+	middleware.SetTokenRevocationChecker(userService)
+	defer middleware.SetTokenRevocationChecker(nil)
+
+	gin.SetMode(gin.TestMode)
+	bus := event.NewBus(10)
+	router := gin.New()
+	api.NewEventHandler(bus).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	// connect 建立事件流，读到服务端写出的 retry 之后订阅已经完成
+	connect := func(accessToken string) (*http.Response, *bufio.Reader) {
+		resp, err := client.Get(server.URL + "/api/v1/events?access_token=" + accessToken)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "retry: 3000\n", line)
+		return resp, reader
+	}
+
+	t.Run("退出登录后关闭", func(t *testing.T) {
+		pair, err := userService.Login("testuser", "password123")
+		require.NoError(t, err)
+		claims, err := jwt.ParseToken(pair.AccessToken)
+		require.NoError(t, err)
+
+		resp, reader := connect(pair.AccessToken)
+		defer resp.Body.Close()
+		require.NoError(t, userService.Logout(claims.UserID, claims.Id, time.Unix(claims.ExpiresAt, 0), pair.RefreshToken))
+		bus.Publish(event.Event{Type: event.TaskCreated, Task: &model.Task{ID: 1, UserID: 1, Title: "退出后的任务"}, UserIDs: []int{1}})
+
+		rest, err := io.ReadAll(reader)
+		assert.NoError(t, err, "吊销令牌后服务端应关闭连接")
+		assert.NotContains(t, string(rest), "退出后的任务")
+	})
+
+	t.Run("访问令牌过期时关闭", func(t *testing.T) {
+		config.GlobalConfig.JWT.AccessExpireMinutes = 2 * time.Second
+		defer config.LoadConfig("../config/config.yaml")
+		pair, err := userService.Login("testuser", "password123")
+		require.NoError(t, err)
+
+		resp, reader := connect(pair.AccessToken)
+		defer resp.Body.Close()
+		_, err = io.ReadAll(reader)
+		assert.NoError(t, err, "令牌过期后服务端应关闭连接")
+	})
+}
+
+func TestWebhookHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api.NewWebhookHandler(service.NewWebhookService(repository.NewMemoryWebhookRepository())).RegisterRoutes(router)
+	owner, _ := jwt.GenerateToken(1, "owner")
+	other, _ := jwt.GenerateToken(2, "other")
+
+	request := func(method, path, token string, body any) (*httptest.ResponseRecorder, map[string]any) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp struct {
+			Data map[string]any `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp.Data
+	}
+
+	w, _ := request(http.MethodPost, "/api/v1/webhooks", owner, map[string]any{"url": "not-a-url", "events": []string{"task.created"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = request(http.MethodPost, "/api/v1/webhooks", owner, map[string]any{"url": "https://example.com/hook", "events": []string{}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 注册时返回生成的密钥，默认启用
+	w, created := request(http.MethodPost, "/api/v1/webhooks", owner, map[string]any{"url": "https://example.com/hook", "events": []string{"task.completed"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, created["secret"])
+	assert.Equal(t, true, created["active"])
+	path := fmt.Sprintf("/api/v1/webhooks/%v", created["id"])
+
+	// 之后不再返回密钥
+	w, found := request(http.MethodGet, path, owner, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, found, "secret")
+	w, _ = request(http.MethodGet, path, other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, updated := request(http.MethodPut, path, owner, map[string]any{"url": "https://example.com/v2", "events": []string{"task.created", "task.deleted"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://example.com/v2", updated["url"])
+	assert.Equal(t, true, updated["active"], "未提供 active 时保持不变")
+	w, updated = request(http.MethodPut, path, owner, map[string]any{"url": "https://example.com/v2", "events": []string{"task.created"}, "active": false})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, updated["active"])
+
+	w, deliveries := request(http.MethodGet, path+"/deliveries", owner, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(0), deliveries["total"])
+
+	w, _ = request(http.MethodDelete, path, other, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = request(http.MethodDelete, path, owner, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w, _ = request(http.MethodGet, path, owner, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCalendarHandler(t *testing.T) {
+	_, taskService := setupTestService(t)
+	dueDate := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	assert.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "提交报告", Priority: model.TaskPriorityMedium, DueDate: &dueDate}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// 导出地址与任务详情的路由共存
+	api.NewTaskHandler(taskService).RegisterRoutes(router)
+	api.NewCalendarHandler(service.NewCalendarService(taskService, repository.NewMemoryCalendarTokenRepository())).RegisterRoutes(router)
+	token, _ := jwt.GenerateToken(1, "owner")
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/api/v1/tasks/export.ics", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = request(http.MethodGet, "/api/v1/tasks/export.ics", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Contains(t, w.Body.String(), "BEGIN:VCALENDAR\r\n")
+	assert.Contains(t, w.Body.String(), "SUMMARY:提交报告\r\n")
+	w = request(http.MethodGet, "/api/v1/tasks/export.ics?component=vtodo", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "BEGIN:VEVENT")
+	w = request(http.MethodGet, "/api/v1/tasks/export.ics?component=bad", token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(http.MethodGet, "/api/v1/calendar/token", token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request(http.MethodPost, "/api/v1/calendar/token", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data api.CalendarTokenResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "http://example.com/api/v1/calendar/feed/"+resp.Data.Token+".ics", resp.Data.URL)
+
+	// 订阅地址不需要访问令牌
+	w = request(http.MethodGet, "/api/v1/calendar/feed/"+resp.Data.Token+".ics", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "SUMMARY:提交报告\r\n")
+	w = request(http.MethodGet, "/api/v1/calendar/feed/invalid.ics", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request(http.MethodGet, "/api/v1/calendar/token", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), resp.Data.Token)
+
+	w = request(http.MethodDelete, "/api/v1/calendar/token", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(http.MethodGet, "/api/v1/calendar/feed/"+resp.Data.Token+".ics", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCalDAVHandler(t *testing.T) {
+	userService, taskService := setupTestService(t)
+	assert.NoError(t, userService.Register("alice", "password123"))
+	assert.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "已有任务"}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.CORS())
+	api.NewCalDAVHandler(service.NewCalDAVService(taskService, repository.NewMemoryCalDAVRepository()), userService).RegisterRoutes(router)
+
+	request := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.SetBasicAuth("alice", "password123")
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("测试认证和服务发现", func(t *testing.T) {
+		req := httptest.NewRequest("PROPFIND", "/caldav/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Basic")
+
+		req = httptest.NewRequest("PROPFIND", "/caldav/", nil)
+		req.SetBasicAuth("alice", "wrong")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = request("PROPFIND", "/.well-known/caldav", "")
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/caldav/", w.Header().Get("Location"))
+
+		w = request(http.MethodOptions, "/caldav/1/tasks/", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("DAV"), "calendar-access")
+
+		w = request("PROPFIND", "/caldav/", `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop><D:current-user-principal/></D:prop></D:propfind>`, "Depth", "0")
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "<D:current-user-principal><D:href>/caldav/1/</D:href></D:current-user-principal>")
+
+		w = request("PROPFIND", "/caldav/1/", `<?xml version="1.0"?><D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><C:calendar-home-set/><D:unknown/></D:prop></D:propfind>`, "Depth", "0")
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "<C:calendar-home-set><D:href>/caldav/1/</D:href></C:calendar-home-set>")
+		assert.Contains(t, w.Body.String(), "<D:unknown/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+		w = request("PROPFIND", "/caldav/2/tasks/", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	var syncToken string
+	t.Run("测试列出集合", func(t *testing.T) {
+		w := request("PROPFIND", "/caldav/1/tasks/", `<?xml version="1.0"?><D:propfind xmlns:D="DAV:" xmlns:CS="http://calendarserver.org/ns/"><D:prop><D:resourcetype/><D:getetag/><D:sync-token/><CS:getctag/></D:prop></D:propfind>`, "Depth", "1")
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "<D:resourcetype><D:collection/><C:calendar/></D:resourcetype>")
+		assert.Contains(t, body, "<D:href>/caldav/1/tasks/task-1.ics</D:href>")
+		assert.Contains(t, body, "<D:getetag>&#34;1-1&#34;</D:getetag>")
+		start := strings.Index(body, "<D:sync-token>") + len("<D:sync-token>")
+		syncToken = body[start : start+strings.Index(body[start:], "<")]
+		assert.Contains(t, body, "<CS:getctag>"+syncToken+"</CS:getctag>")
+	})
+
+	t.Run("测试创建、获取和同步", func(t *testing.T) {
+		w := request(http.MethodPut, "/caldav/1/tasks/new.ics", string(vtodo("new-1", "SUMMARY:手机上创建")), "If-None-Match", "*", "Content-Type", "text/calendar")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+
+		w = request(http.MethodGet, "/caldav/1/tasks/new.ics", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "UID:new-1\r\n")
+		assert.Contains(t, w.Body.String(), "SUMMARY:手机上创建\r\n")
+		etag := w.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		w = request("REPORT", "/caldav/1/tasks/", `<?xml version="1.0"?><D:sync-collection xmlns:D="DAV:"><D:sync-token>`+syncToken+`</D:sync-token><D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`)
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "<D:href>/caldav/1/tasks/new.ics</D:href>")
+		assert.NotContains(t, w.Body.String(), "task-1.ics")
+
+		w = request("REPORT", "/caldav/1/tasks/", `<?xml version="1.0"?><C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/><C:calendar-data/></D:prop><D:href>/caldav/1/tasks/new.ics</D:href><D:href>/caldav/1/tasks/missing.ics</D:href></C:calendar-multiget>`)
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "SUMMARY:手机上创建")
+		assert.Contains(t, w.Body.String(), "<D:href>/caldav/1/tasks/missing.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+
+		w = request("REPORT", "/caldav/1/tasks/", `<?xml version="1.0"?><C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/></D:prop><C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"/></C:comp-filter></C:filter></C:calendar-query>`)
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.NotContains(t, w.Body.String(), "<D:response>")
+
+		w = request("REPORT", "/caldav/1/tasks/", `<?xml version="1.0"?><D:sync-collection xmlns:D="DAV:"><D:sync-token>invalid</D:sync-token></D:sync-collection>`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "<D:valid-sync-token/>")
+
+		w = request(http.MethodPut, "/caldav/1/tasks/new.ics", string(vtodo("new-1", "SUMMARY:改名")), "If-Match", `"0-0"`)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		w = request(http.MethodPut, "/caldav/1/tasks/new.ics", string(vtodo("new-1", "SUMMARY:改名")), "If-Match", etag)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		w = request(http.MethodPut, "/caldav/1/tasks/event.ics", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:e\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "<C:supported-calendar-component/>")
+		w = request(http.MethodPut, "/caldav/1/tasks/empty.ics", string(vtodo("empty")))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("测试删除", func(t *testing.T) {
+		w := request(http.MethodDelete, "/caldav/1/tasks/new.ics", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		w = request(http.MethodGet, "/caldav/1/tasks/new.ics", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = request("REPORT", "/caldav/1/tasks/", `<?xml version="1.0"?><D:sync-collection xmlns:D="DAV:"><D:sync-token>`+syncToken+`</D:sync-token><D:prop><D:getetag/></D:prop></D:sync-collection>`)
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "<D:href>/caldav/1/tasks/new.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+	})
+}
+
+func TestImportHandler(t *testing.T) {
+	taskService, _, importService := setupImportService(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// 导入导出的地址与任务详情的路由共存
+	api.NewTaskHandler(taskService).RegisterRoutes(router)
+	api.NewImportHandler(importService, taskService).RegisterRoutes(router)
+	token, _ := jwt.GenerateToken(1, "owner")
+
+	upload := func(format, content string, fields map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		if format != "" {
+			writer.WriteField("format", format)
+		}
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		part, _ := writer.CreateFormFile("file", "tasks."+format)
+		part.Write([]byte(content))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/import", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) api.ImportResponse {
+		var resp struct {
+			Data api.ImportResponse `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data
+	}
+	count := func(t *testing.T) int64 {
+		_, total, err := taskService.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: -1})
+		assert.NoError(t, err)
+		return total
+	}
+
+	t.Run("测试 CSV 列映射和预览", func(t *testing.T) {
+		csv := "\xef\xbb\xbf任务名称,Priority,截止日期,标签\n" +
+			"写周报,high,2026-10-20,\"工作,周报\"\n" +
+			",low,,\n" +
+			"\"多行\n备注\",urgent,,\n" +
+			"买菜,,2026/13/45,\n"
+		mapping := `{"title":"任务名称","due_date":"截止日期","tags":"标签"}`
+		w := upload("csv", csv, map[string]string{"mapping": mapping, "dry_run": "true"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := decode(t, w)
+		assert.True(t, data.DryRun)
+		assert.Equal(t, 4, data.Total)
+		assert.Equal(t, 1, data.Created)
+		assert.Equal(t, []string{"工作", "周报"}, data.NewTags)
+		assert.Equal(t, 2, data.Rows[0].Row)
+		assert.Equal(t, "high", data.Rows[0].Task.Priority)
+		assert.Equal(t, service.ErrEmptyTitle.Error(), data.Rows[1].Error)
+		// 行号为源文件中的行号，多行的单元格占用多行
+		assert.Equal(t, 4, data.Rows[2].Row)
+		assert.Contains(t, data.Rows[2].Error, model.ErrInvalidTaskPriority.Error())
+		assert.Equal(t, 6, data.Rows[3].Row)
+		assert.NotEmpty(t, data.Rows[3].Error)
+		assert.Zero(t, count(t))
+
+		w = upload("csv", csv, map[string]string{"mapping": mapping})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, decode(t, w).Created)
+		assert.Equal(t, int64(1), count(t))
+
+		w = upload("csv", "name\n写周报\n", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = upload("csv", csv, map[string]string{"mapping": `{"owner":"负责人"}`})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("测试导出后重新导入", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/export.json", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+		var file api.TaskExportFile
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &file))
+		assert.Equal(t, 1, file.Version)
+		if assert.Len(t, file.Tasks, 1) {
+			assert.Equal(t, "写周报", file.Tasks[0].Title)
+			assert.Equal(t, "high", file.Tasks[0].Priority)
+			assert.ElementsMatch(t, []string{"工作", "周报"}, file.Tasks[0].Tags)
+		}
+
+		w = upload("json", w.Body.String(), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := decode(t, w)
+		assert.Equal(t, 1, data.Created)
+		assert.Empty(t, data.NewTags)
+		assert.Equal(t, file.Tasks[0].DueDate.Unix(), data.Rows[0].Task.DueDate.Unix())
+		assert.Equal(t, int64(2), count(t))
+
+		w = upload("json", `{"version":2,"tasks":[]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("测试 Todoist 导出", func(t *testing.T) {
+		todoist := `{"items":[
+			{"content":"紧急任务","priority":4,"labels":["家"],"due":{"date":"2026-10-21","string":"every day","is_recurring":true}},
+			{"content":"已完成","priority":1,"checked":1},
+			{"content":"已删除","is_deleted":true},
+			{"content":"定时","priority":2,"due":{"date":"2026-10-22","datetime":"2026-10-22T09:30:00Z"}}
+		]}`
+		w := upload("todoist", todoist, map[string]string{"dry_run": "1"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := decode(t, w)
+		assert.Equal(t, 3, data.Total)
+		assert.Equal(t, "high", data.Rows[0].Task.Priority)
+		assert.Equal(t, "家", data.Rows[0].Task.Tags[0].Name)
+		assert.Nil(t, data.Rows[0].Task.Recurrence)
+		assert.Equal(t, "done", data.Rows[1].Task.Status)
+		assert.Equal(t, 4, data.Rows[2].Row)
+		assert.Equal(t, "low", data.Rows[2].Task.Priority)
+		assert.True(t, time.Date(2026, 10, 22, 9, 30, 0, 0, time.UTC).Equal(*data.Rows[2].Task.DueDate))
+
+		w = upload("todoist", `{"projects":[]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("测试 Trello 看板导出", func(t *testing.T) {
+		trello := `{"name":"看板","lists":[{"id":"l1","name":"待办"},{"id":"l2","name":"旧列表","closed":true}],"cards":[
+			{"name":"设计首页","desc":"草图","idList":"l1","due":"2026-10-23T08:00:00.000Z","dueComplete":true,"labels":[{"name":"设计","color":"green"},{"name":"","color":"red"}]},
+			{"name":"已归档","idList":"l1","closed":true},
+			{"name":"归档列表中的卡片","idList":"l2"}
+		]}`
+		w := upload("trello", trello, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := decode(t, w)
+		assert.Equal(t, 1, data.Total)
+		assert.Equal(t, 1, data.Created)
+		assert.Equal(t, []string{"设计", "red"}, data.NewTags)
+		assert.Equal(t, "草图", data.Rows[0].Task.Description)
+		assert.Equal(t, "done", data.Rows[0].Task.Status)
+		assert.NotZero(t, data.Rows[0].Task.ID)
+
+		// 没有可导入的卡片
+		w = upload("trello", `{"cards":[{"name":"已归档","closed":true}]}`, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("测试请求参数错误", func(t *testing.T) {
+		w := upload("", "title\n任务\n", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = upload("xlsx", "title\n任务\n", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = upload("json", "not json", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// missingTaskCalDAVService 模拟同步之后、加载任务之前任务被删除，集合中出现 Task 为 nil 的资源
+type missingTaskCalDAVService struct {
+	service.CalDAVService
+}
+
+func (s missingTaskCalDAVService) Collection(userID int) ([]service.CalDAVResource, int64, error) {
+	resources, seq, err := s.CalDAVService.Collection(userID)
+	missing := service.CalDAVResource{Object: &model.CalDAVObject{UserID: userID, Name: "missing.ics", TaskID: 99}}
+	return append(resources, missing), seq, err
+}
+
+func TestCalDAVHandlerMissingTask(t *testing.T) {
+	userService, taskService := setupTestService(t)
+	assert.NoError(t, userService.Register("alice", "password123"))
+	assert.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "已有任务"}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	caldavService := missingTaskCalDAVService{service.NewCalDAVService(taskService, repository.NewMemoryCalDAVRepository())}
+	api.NewCalDAVHandler(caldavService, userService).RegisterRoutes(router)
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/caldav/1/tasks/", strings.NewReader(body))
+		req.SetBasicAuth("alice", "password123")
+		req.Header.Set("Depth", "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("PROPFIND", `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop><D:getetag/><D:getlastmodified/></D:prop></D:propfind>`)
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+	assert.Contains(t, w.Body.String(), "/caldav/1/tasks/task-1.ics")
+	assert.NotContains(t, w.Body.String(), "missing.ics")
+
+	w = request("REPORT", `<?xml version="1.0"?><C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/><C:calendar-data/></D:prop><C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter></C:filter></C:calendar-query>`)
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+	assert.Contains(t, w.Body.String(), "/caldav/1/tasks/task-1.ics")
+	assert.NotContains(t, w.Body.String(), "missing.ics")
+}
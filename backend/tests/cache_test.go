@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/internal/cache"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/pkg/redis"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := cache.NewMemoryCache()
+
+	_, ok, err := c.Get("key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set("key", "value", 0))
+	require.NoError(t, c.Set("short", "value", 10*time.Millisecond))
+	value, ok, err := c.Get("key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = c.Get("short")
+	assert.False(t, ok, "过期的键不再返回")
+
+	n, err := c.Incr("counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = c.Incr("key")
+	assert.Error(t, err, "非整数的值不能加1")
+
+	require.NoError(t, c.Delete("key", "counter", "missing"))
+	_, ok, _ = c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestRedisCache(t *testing.T) {
+	server := startFakeRedis(t, "")
+	client := redis.NewClient(redis.Options{Addr: server.listener.Addr().String()})
+	defer client.Close()
+	c := cache.NewRedisCache(client)
+
+	_, ok, err := c.Get("key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set("key", "value", time.Minute))
+	value, ok, err := c.Get("key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	require.NoError(t, c.Delete("key"))
+	_, ok, _ = c.Get("key")
+	assert.False(t, ok)
+}
+
+// failingCache 所有操作都失败的缓存，模拟 Redis 不可用
+type failingCache struct{}
+
+var errCacheUnavailable = errors.New("cache unavailable")
+
+func (failingCache) Get(string) (string, bool, error)        { return "", false, errCacheUnavailable }
+func (failingCache) Set(string, string, time.Duration) error { return errCacheUnavailable }
+func (failingCache) Delete(...string) error                  { return errCacheUnavailable }
+func (failingCache) Incr(string) (int64, error)              { return 0, errCacheUnavailable }
+
+func TestCachedTaskRepository(t *testing.T) {
+	newRepos := func(t *testing.T) (repository.TaskRepository, repository.TagRepository, *cache.Stats) {
+		db := initTestDB(t)
+		c := cache.NewMemoryCache()
+		stats := &cache.Stats{}
+		return repository.NewCachedTaskRepository(repository.NewTaskRepository(db), c, time.Minute, stats),
+			repository.NewCachedTagRepository(repository.NewTagRepository(db), c), stats
+	}
+
+	t.Run("命中统计", func(t *testing.T) {
+		repo, _, stats := newRepos(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+
+		for i := 0; i < 3; i++ {
+			found, err := repo.GetByID(task.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "task", found.Title)
+		}
+		assert.Equal(t, int64(1), stats.Misses())
+		assert.Equal(t, int64(2), stats.Hits())
+
+		// 不存在的任务不缓存
+		for i := 0; i < 2; i++ {
+			found, err := repo.GetByID(999)
+			require.NoError(t, err)
+			assert.Nil(t, found)
+		}
+		assert.Equal(t, int64(3), stats.Misses())
+	})
+
+	t.Run("修改后失效", func(t *testing.T) {
+		repo, _, _ := newRepos(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+		_, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+
+		task.Title = "updated"
+		require.NoError(t, repo.Update(task))
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "updated", found.Title)
+
+		found.Title = "versioned"
+		updated, err := repo.UpdateIfVersion(found, found.Version)
+		require.NoError(t, err)
+		require.True(t, updated)
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "versioned", found.Title)
+
+		projectID := 7
+		require.NoError(t, repo.UpdateProject([]int{task.ID}, &projectID))
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.ProjectID)
+		assert.Equal(t, projectID, *found.ProjectID)
+
+		require.NoError(t, repo.ClearProject(projectID))
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.ProjectID)
+
+		require.NoError(t, repo.SoftDeleteByIDs([]int{task.ID}, time.Now()))
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("修改标签后失效", func(t *testing.T) {
+		repo, tagRepo, _ := newRepos(t)
+		tag := &model.Tag{UserID: 1, Name: "work"}
+		require.NoError(t, tagRepo.Create(tag))
+		task := &model.Task{UserID: 1, Title: "task", Tags: []model.Tag{*tag}}
+		require.NoError(t, repo.Create(task))
+		_, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+
+		tag.Name = "office"
+		require.NoError(t, tagRepo.Update(tag))
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		require.Len(t, found.Tags, 1)
+		assert.Equal(t, "office", found.Tags[0].Name)
+
+		require.NoError(t, tagRepo.Delete(tag.ID))
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Empty(t, found.Tags)
+	})
+
+	t.Run("事务中的修改在提交后失效", func(t *testing.T) {
+		repo, _, _ := newRepos(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+		_, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+
+		err = repo.Transaction(func(tx repository.Tx) error {
+			scoped := repo.WithTx(tx)
+			found, err := scoped.GetByID(task.ID)
+			if err != nil {
+				return err
+			}
+			found.Title = "in transaction"
+			return scoped.Update(found)
+		})
+		require.NoError(t, err)
+
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "in transaction", found.Title)
+	})
+
+	t.Run("缓存不可用时读写数据库", func(t *testing.T) {
+		db := initTestDB(t)
+		stats := &cache.Stats{}
+		repo := repository.NewCachedTaskRepository(repository.NewTaskRepository(db), failingCache{}, time.Minute, stats)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+
+		task.Title = "updated"
+		require.NoError(t, repo.Update(task))
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "updated", found.Title)
+		assert.Equal(t, int64(1), stats.Misses())
+	})
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	db := initTestDB(t)
+	stats := &cache.Stats{}
+	c := cache.NewMemoryCache()
+	repo := repository.NewCachedUserRepository(repository.NewUserRepository(db), c, time.Minute, stats)
+
+	user := &model.User{Username: "alice", PasswordHash: "hash"}
+	require.NoError(t, repo.Create(user))
+
+	found, err := repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", found.Username)
+	found, err = repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", found.Username)
+	assert.Empty(t, found.PasswordHash, "缓存中不保存密码哈希")
+	cached, ok, err := c.Get(fmt.Sprintf("user:%d", user.ID))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotContains(t, cached, "hash")
+	assert.Equal(t, int64(1), stats.Hits())
+
+	// 修改密码时读取的用户不经过缓存
+	found, err = repo.GetCredentials(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", found.PasswordHash)
+	found.PasswordHash = "changed"
+	require.NoError(t, repo.Update(found))
+	found, err = repo.GetCredentials(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "changed", found.PasswordHash)
+	assert.Equal(t, int64(1), stats.Hits())
+
+	require.NoError(t, repo.Delete(user.ID))
+	found, err = repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/config"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/internal/service"
+)
+
+// vtodo 生成只包含一个待办的日历对象，props 为待办中的其他属性行
+func vtodo(uid string, props ...string) []byte {
+	data := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\n"
+	for _, prop := range props {
+		data += prop + "\r\n"
+	}
+	return []byte(data + "END:VTODO\r\nEND:VCALENDAR\r\n")
+}
+
+// resourceNames 返回资源名称到是否已删除的映射
+func resourceNames(resources []service.CalDAVResource) map[string]bool {
+	names := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		names[resource.Object.Name] = resource.Task == nil
+	}
+	return names
+}
+
+func TestCalDAVService(t *testing.T) {
+	_, taskService := setupTestService(t)
+	caldavService := service.NewCalDAVService(taskService, repository.NewMemoryCalDAVRepository())
+
+	existing := &model.Task{UserID: 1, Title: "已有任务", Priority: model.TaskPriorityMedium}
+	require.NoError(t, taskService.Create(existing))
+	require.NoError(t, taskService.Create(&model.Task{UserID: 2, Title: "其他用户的任务"}))
+	existingName := fmt.Sprintf("task-%d.ics", existing.ID)
+
+	var token int64
+	t.Run("测试集合包含已有任务", func(t *testing.T) {
+		resources, seq, err := caldavService.Collection(1)
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		assert.Equal(t, existingName, resources[0].Object.Name)
+		assert.Equal(t, fmt.Sprintf("task-%d@todolist", existing.ID), resources[0].Object.UID)
+		// 没有截止日期的任务也在集合中
+		assert.NotContains(t, resources[0].Calendar().String(), "DUE")
+		assert.Positive(t, seq)
+
+		// 没有变化时同步令牌不变
+		_, again, err := caldavService.Collection(1)
+		require.NoError(t, err)
+		assert.Equal(t, seq, again)
+		token = seq
+	})
+
+	t.Run("测试客户端创建任务", func(t *testing.T) {
+		data := vtodo("client-1", "SUMMARY:买菜\\, 做饭", "DESCRIPTION:牛奶\\n鸡蛋", "DUE;VALUE=DATE:20261020", "PRIORITY:1", "STATUS:IN-PROCESS", "RRULE:FREQ=WEEKLY")
+		resource, created, err := caldavService.Put(1, "client-1.ics", data, "", "*")
+		require.NoError(t, err)
+		assert.True(t, created)
+		task := resource.Task
+		assert.Equal(t, "买菜, 做饭", task.Title)
+		assert.Equal(t, "牛奶\n鸡蛋", task.Description)
+		assert.Equal(t, model.TaskPriorityHigh, task.Priority)
+		assert.Equal(t, model.TaskStatusInProgress, task.Status)
+		assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local), *task.DueDate)
+		assert.Equal(t, "FREQ=WEEKLY", *task.Recurrence)
+
+		// 获取时保留客户端的 UID，全天的截止日期按日期输出
+		found, err := caldavService.Get(1, "client-1.ics")
+		require.NoError(t, err)
+		calendar := found.Calendar().String()
+		assert.Contains(t, calendar, "UID:client-1\r\n")
+		assert.Contains(t, calendar, "DUE;VALUE=DATE:20261020\r\n")
+		assert.Equal(t, resource.ETag(), found.ETag())
+
+		// 已存在时 If-None-Match: * 失败
+		_, _, err = caldavService.Put(1, "client-1.ics", data, "", "*")
+		assert.Equal(t, service.ErrCalDAVPrecondition, err)
+		// UID 不能与其他对象重复
+		_, _, err = caldavService.Put(1, "client-2.ics", data, "", "")
+		assert.Equal(t, service.ErrCalDAVUIDConflict, err)
+	})
+
+	t.Run("测试客户端修改任务", func(t *testing.T) {
+		current, err := caldavService.Get(1, "client-1.ics")
+		require.NoError(t, err)
+
+		_, _, err = caldavService.Put(1, "client-1.ics", vtodo("client-1", "SUMMARY:买菜"), `"0-0"`, "")
+		assert.Equal(t, service.ErrCalDAVPrecondition, err)
+
+		// 待办中没有的属性清空
+		resource, created, err := caldavService.Put(1, "client-1.ics", vtodo("client-1", "SUMMARY:买菜", "STATUS:COMPLETED"), current.ETag(), "")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "买菜", resource.Task.Title)
+		assert.Empty(t, resource.Task.Description)
+		assert.Nil(t, resource.Task.DueDate)
+		assert.Nil(t, resource.Task.Recurrence)
+		assert.Equal(t, model.TaskStatusDone, resource.Task.Status)
+		assert.Equal(t, model.TaskPriorityNone, resource.Task.Priority)
+		assert.NotEqual(t, current.ETag(), resource.ETag())
+
+		// 不能修改对象的 UID
+		_, _, err = caldavService.Put(1, "client-1.ics", vtodo("client-x", "SUMMARY:买菜"), "", "")
+		assert.Equal(t, service.ErrCalDAVUIDConflict, err)
+	})
+
+	t.Run("测试无效的日历数据", func(t *testing.T) {
+		_, _, err := caldavService.Put(1, "bad.ics", []byte("not a calendar"), "", "")
+		assert.Equal(t, service.ErrInvalidCalendarData, err)
+		_, _, err = caldavService.Put(1, "bad.ics", vtodo("", "SUMMARY:没有 UID"), "", "")
+		assert.Equal(t, service.ErrInvalidCalendarData, err)
+		event := []byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:e\r\nSUMMARY:日程\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+		_, _, err = caldavService.Put(1, "event.ics", event, "", "")
+		assert.Equal(t, service.ErrUnsupportedComponent, err)
+		_, _, err = caldavService.Put(1, "bad.ics", vtodo("bad", "SUMMARY:优先级", "PRIORITY:high"), "", "")
+		assert.Equal(t, service.ErrInvalidCalendarData, err)
+		// 任务标题按任务接口的规则校验
+		_, _, err = caldavService.Put(1, "bad.ics", vtodo("bad"), "", "")
+		assert.Equal(t, service.ErrEmptyTitle, err)
+	})
+
+	t.Run("测试增量同步", func(t *testing.T) {
+		changes, seq, err := caldavService.Changes(1, token)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"client-1.ics": false}, resourceNames(changes))
+		token = seq
+
+		// 通过任务接口的修改和删除在下一次同步时出现
+		_, err = taskService.Patch(existing.ID, 1, model.TaskPatch{Title: model.Some("改名")})
+		require.NoError(t, err)
+		added := &model.Task{UserID: 1, Title: "新任务"}
+		require.NoError(t, taskService.Create(added))
+		changes, seq, err = caldavService.Changes(1, token)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{existingName: false, fmt.Sprintf("task-%d.ics", added.ID): false}, resourceNames(changes))
+		assert.Greater(t, seq, token)
+		token = seq
+
+		require.NoError(t, caldavService.Delete(1, existingName, ""))
+		require.NoError(t, taskService.Delete(added.ID, 1, service.DeleteOptions{}))
+		changes, seq, err = caldavService.Changes(1, token)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{existingName: true, fmt.Sprintf("task-%d.ics", added.ID): true}, resourceNames(changes))
+
+		// 首次同步不返回已删除的对象
+		changes, _, err = caldavService.Changes(1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"client-1.ics": false}, resourceNames(changes))
+
+		_, _, err = caldavService.Changes(1, seq+1)
+		assert.Equal(t, service.ErrInvalidSyncToken, err)
+	})
+
+	t.Run("测试删除", func(t *testing.T) {
+		err := caldavService.Delete(1, existingName, "")
+		assert.Equal(t, service.ErrCalDAVObjectNotFound, err)
+		_, err = caldavService.Get(1, existingName)
+		assert.Equal(t, service.ErrCalDAVObjectNotFound, err)
+
+		err = caldavService.Delete(1, "client-1.ics", `"0-0"`)
+		assert.Equal(t, service.ErrCalDAVPrecondition, err)
+
+		// 已删除对象的名称可以重新使用
+		resource, created, err := caldavService.Put(1, existingName, vtodo("reused", "SUMMARY:重新创建"), "", "*")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, existing.ID, resource.Task.ID)
+	})
+
+	t.Run("测试不能看到其他用户的任务", func(t *testing.T) {
+		resources, _, err := caldavService.Collection(2)
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		assert.Equal(t, "其他用户的任务", resources[0].Task.Title)
+		_, err = caldavService.Get(2, "client-1.ics")
+		assert.Equal(t, service.ErrCalDAVObjectNotFound, err)
+	})
+}
+
+func TestCalDAVServiceSync(t *testing.T) {
+	require.NoError(t, config.LoadConfig("../config/config.yaml"))
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+	caldavService := service.NewCalDAVService(taskService, repository.NewMemoryCalDAVRepository())
+
+	t.Run("测试共享和取消共享在增量同步中出现", func(t *testing.T) {
+		shared := &model.Task{UserID: 1, Title: "共享任务"}
+		require.NoError(t, taskService.Create(shared))
+		token, err := caldavService.SyncToken(2)
+		require.NoError(t, err)
+
+		require.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: shared.ID, UserID: 2, Role: model.TaskRoleViewer}))
+		changes, seq, err := caldavService.Changes(2, token)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "共享任务", changes[0].Task.Title)
+
+		// 没有变化时同步令牌不变，也不返回资源
+		changes, again, err := caldavService.Changes(2, seq)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+		assert.Equal(t, seq, again)
+
+		require.NoError(t, collabRepo.Delete(shared.ID, 2))
+		changes, _, err = caldavService.Changes(2, seq)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{fmt.Sprintf("task-%d.ics", shared.ID): true}, resourceNames(changes))
+	})
+
+	t.Run("测试并发同步不会重复创建对象", func(t *testing.T) {
+		for userID := 10; userID < 13; userID++ {
+			for i := 0; i < 5; i++ {
+				require.NoError(t, taskService.Create(&model.Task{UserID: userID, Title: "任务"}))
+			}
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func(userID int) {
+				defer wg.Done()
+				_, _, err := caldavService.Collection(userID)
+				assert.NoError(t, err)
+			}(10 + i%3)
+		}
+		wg.Wait()
+
+		for userID := 10; userID < 13; userID++ {
+			resources, _, err := caldavService.Collection(userID)
+			require.NoError(t, err)
+			assert.Len(t, resources, 5)
+			assert.Len(t, resourceNames(resources), 5)
+		}
+	})
+}
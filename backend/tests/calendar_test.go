@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/internal/service"
+	"todolist/pkg/ical"
+)
+
+// findComponent 返回 UID 相同的组件
+func findComponent(calendar *ical.Component, uid string) *ical.Component {
+	for _, component := range calendar.Components {
+		if calendarProperty(component, "UID") == uid {
+			return component
+		}
+	}
+	return nil
+}
+
+// calendarProperty 返回组件中第一个同名属性的值，带参数时以 ";参数:值" 的形式返回
+func calendarProperty(component *ical.Component, name string) string {
+	for _, p := range component.Properties {
+		if p.Name == name {
+			if len(p.Params) > 0 {
+				return ";" + strings.Join(p.Params, ";") + ":" + p.Value
+			}
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func TestCalendarService(t *testing.T) {
+	_, taskService := setupTestService(t)
+	calendarService := service.NewCalendarService(taskService, repository.NewMemoryCalendarTokenRepository())
+
+	allDay := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	timed := time.Date(2026, 10, 21, 9, 30, 0, 0, time.UTC)
+	weekly := "FREQ=WEEKLY;BYDAY=TU"
+
+	meeting := &model.Task{UserID: 1, Title: "周会, 准备材料", Description: "第一行\n第二行", Priority: model.TaskPriorityHigh, DueDate: &timed, Recurrence: &weekly}
+	report := &model.Task{UserID: 1, Title: "提交报告", Priority: model.TaskPriorityLow, DueDate: &allDay}
+	noDue := &model.Task{UserID: 1, Title: "没有截止日期", Priority: model.TaskPriorityMedium}
+	other := &model.Task{UserID: 2, Title: "其他用户的任务", Priority: model.TaskPriorityMedium, DueDate: &allDay}
+	for _, task := range []*model.Task{meeting, report, noDue, other} {
+		require.NoError(t, taskService.Create(task))
+	}
+	require.NoError(t, taskService.Update(&model.Task{ID: report.ID, UserID: 1, Status: model.TaskStatusDone}))
+
+	t.Run("测试导出日程和待办", func(t *testing.T) {
+		calendar, err := calendarService.Export(1, 0, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "VCALENDAR", calendar.Name)
+		assert.Equal(t, "2.0", calendarProperty(calendar, "VERSION"))
+		// 没有截止日期和其他用户的任务不导出
+		assert.Len(t, calendar.Components, 4)
+
+		event := findComponent(calendar, fmt.Sprintf("task-%d-due@todolist", meeting.ID))
+		require.NotNil(t, event)
+		assert.Equal(t, service.CalendarComponentEvent, event.Name)
+		assert.Equal(t, "20261021T093000Z", calendarProperty(event, "DTSTART"))
+		assert.Empty(t, calendarProperty(event, "DTEND"))
+		assert.Equal(t, `周会\, 准备材料`, calendarProperty(event, "SUMMARY"))
+		assert.Equal(t, `第一行\n第二行`, calendarProperty(event, "DESCRIPTION"))
+		assert.Equal(t, weekly, calendarProperty(event, "RRULE"))
+
+		todo := findComponent(calendar, fmt.Sprintf("task-%d@todolist", meeting.ID))
+		require.NotNil(t, todo)
+		assert.Equal(t, service.CalendarComponentTodo, todo.Name)
+		assert.Equal(t, "20261021T093000Z", calendarProperty(todo, "DUE"))
+		assert.Equal(t, "NEEDS-ACTION", calendarProperty(todo, "STATUS"))
+		assert.Equal(t, "1", calendarProperty(todo, "PRIORITY"))
+		assert.Equal(t, weekly, calendarProperty(todo, "RRULE"))
+
+		// 零点截止的任务按全天输出
+		event = findComponent(calendar, fmt.Sprintf("task-%d-due@todolist", report.ID))
+		require.NotNil(t, event)
+		assert.Equal(t, ";VALUE=DATE:20261020", calendarProperty(event, "DTSTART"))
+		assert.Equal(t, ";VALUE=DATE:20261021", calendarProperty(event, "DTEND"))
+
+		todo = findComponent(calendar, fmt.Sprintf("task-%d@todolist", report.ID))
+		require.NotNil(t, todo)
+		assert.Equal(t, ";VALUE=DATE:20261020", calendarProperty(todo, "DUE"))
+		assert.Equal(t, "COMPLETED", calendarProperty(todo, "STATUS"))
+		assert.Equal(t, "100", calendarProperty(todo, "PERCENT-COMPLETE"))
+		assert.NotEmpty(t, calendarProperty(todo, "COMPLETED"))
+		assert.Equal(t, "9", calendarProperty(todo, "PRIORITY"))
+		assert.Equal(t, "1", calendarProperty(todo, "SEQUENCE"))
+	})
+
+	t.Run("测试进行中的任务", func(t *testing.T) {
+		require.NoError(t, taskService.Update(&model.Task{ID: meeting.ID, UserID: 1, Status: model.TaskStatusInProgress}))
+		calendar, err := calendarService.Export(1, 0, []string{service.CalendarComponentTodo})
+		require.NoError(t, err)
+		todo := findComponent(calendar, fmt.Sprintf("task-%d@todolist", meeting.ID))
+		require.NotNil(t, todo)
+		assert.Equal(t, "IN-PROCESS", calendarProperty(todo, "STATUS"))
+		assert.Empty(t, calendarProperty(todo, "PERCENT-COMPLETE"))
+	})
+
+	t.Run("测试只导出一种组件", func(t *testing.T) {
+		calendar, err := calendarService.Export(1, 0, []string{service.CalendarComponentEvent})
+		require.NoError(t, err)
+		assert.Len(t, calendar.Components, 2)
+		for _, component := range calendar.Components {
+			assert.Equal(t, service.CalendarComponentEvent, component.Name)
+		}
+	})
+
+	t.Run("测试订阅令牌", func(t *testing.T) {
+		token, err := calendarService.GetToken(1)
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		raw, token, err := calendarService.CreateToken(1)
+		require.NoError(t, err)
+		assert.NotEmpty(t, raw)
+		assert.NotEqual(t, raw, token.TokenHash)
+
+		userID, err := calendarService.Authenticate(raw)
+		require.NoError(t, err)
+		assert.Equal(t, 1, userID)
+
+		// 重新生成后旧令牌失效
+		rotated, _, err := calendarService.CreateToken(1)
+		require.NoError(t, err)
+		assert.NotEqual(t, raw, rotated)
+		_, err = calendarService.Authenticate(raw)
+		assert.Equal(t, service.ErrInvalidCalendarToken, err)
+		userID, err = calendarService.Authenticate(rotated)
+		require.NoError(t, err)
+		assert.Equal(t, 1, userID)
+
+		require.NoError(t, calendarService.RevokeToken(1))
+		_, err = calendarService.Authenticate(rotated)
+		assert.Equal(t, service.ErrInvalidCalendarToken, err)
+		_, err = calendarService.Authenticate("")
+		assert.Equal(t, service.ErrInvalidCalendarToken, err)
+	})
+}
@@ -37,15 +37,15 @@ func TestLoadConfig(t *testing.T) {
 		if mysql.Username != "root" {
 			t.Errorf("MySQL用户名配置错误: 期望 root, 实际 %s", mysql.Username)
 		}
-		if mysql.Password != "1234" {
-			t.Errorf("MySQL密码配置错误: 期望 1234, 实际 %s", mysql.Password)
+		if mysql.Password != "root" {
+			t.Errorf("MySQL密码配置错误: 期望 root, 实际 %s", mysql.Password)
 		}
 		if mysql.Database != "todolist" {
 			t.Errorf("MySQL数据库配置错误: 期望 todolist, 实际 %s", mysql.Database)
 		}
 
 		// 测试DSN格式
-		expectedDSN := "root:1234@tcp(localhost:3306)/todolist?charset=utf8mb4&parseTime=true&loc=Local"
+		expectedDSN := "root:root@tcp(localhost:3306)/todolist?charset=utf8mb4&parseTime=true&loc=Local"
 		if dsn := mysql.DSN(); dsn != expectedDSN {
 			t.Errorf("MySQL DSN格式错误:\n期望: %s\n实际: %s", expectedDSN, dsn)
 		}
@@ -95,4 +95,49 @@ func TestLoadConfig(t *testing.T) {
 			t.Error("日志压缩配置错误: 期望 true, 实际 false")
 		}
 	})
+
+	// 7. 测试回收站配置
+	t.Run("测试回收站配置", func(t *testing.T) {
+		if retention := config.GlobalConfig.Trash.RetentionDays; retention != 30*24*time.Hour {
+			t.Errorf("回收站保留期限配置错误: 期望 720h, 实际 %v", retention)
+		}
+	})
+
+	// 8. 测试幂等键配置
+	t.Run("测试幂等键配置", func(t *testing.T) {
+		if maxBody := config.GlobalConfig.Idempotency.MaxBodyMB; maxBody != 10 {
+			t.Errorf("幂等键请求体长度配置错误: 期望 10, 实际 %d", maxBody)
+		}
+		if ttl := config.GlobalConfig.Idempotency.TTLHours; ttl != 24*time.Hour {
+			t.Errorf("幂等键保留时间配置错误: 期望 24h, 实际 %v", ttl)
+		}
+	})
+
+	// 9. 测试缓存配置
+	t.Run("测试缓存配置", func(t *testing.T) {
+		cache := config.GlobalConfig.Cache
+		if cache.Driver != config.CacheDriverRedis {
+			t.Errorf("缓存驱动配置错误: 期望 redis, 实际 %s", cache.Driver)
+		}
+		if cache.TTLSeconds != 5*time.Minute {
+			t.Errorf("缓存过期时间配置错误: 期望 5m, 实际 %v", cache.TTLSeconds)
+		}
+	})
+
+	// 10. 测试 Webhook 配置
+	t.Run("测试Webhook配置", func(t *testing.T) {
+		webhook := config.GlobalConfig.Webhook
+		if webhook.TimeoutSeconds != 10*time.Second {
+			t.Errorf("Webhook 超时时间配置错误: 期望 10s, 实际 %v", webhook.TimeoutSeconds)
+		}
+		if webhook.MaxAttempts != 8 {
+			t.Errorf("Webhook 投递次数配置错误: 期望 8, 实际 %d", webhook.MaxAttempts)
+		}
+		if webhook.RetryBaseSeconds != 30*time.Second {
+			t.Errorf("Webhook 重试等待时间配置错误: 期望 30s, 实际 %v", webhook.RetryBaseSeconds)
+		}
+		if webhook.AllowPrivateNetworks {
+			t.Error("Webhook 默认不应允许投递到内网地址")
+		}
+	})
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/internal/event"
+	"todolist/internal/model"
+)
+
+func TestEventBus(t *testing.T) {
+	publish := func(bus *event.Bus, taskID int, userIDs ...int) event.Event {
+		return bus.Publish(event.Event{Type: event.TaskUpdated, Task: &model.Task{ID: taskID}, UserIDs: userIDs})
+	}
+
+	t.Run("按用户过滤", func(t *testing.T) {
+		bus := event.NewBus(10)
+		alice, _, _ := bus.Subscribe(1, 0)
+		defer alice.Close()
+		all, _, _ := bus.Subscribe(0, 0)
+		defer all.Close()
+
+		publish(bus, 100, 2, 1)
+		publish(bus, 200, 2)
+
+		e := <-alice.Events()
+		assert.Equal(t, int64(1), e.ID)
+		assert.Equal(t, 100, e.Task.ID)
+		assert.Equal(t, []int{1, 2}, e.UserIDs)
+		assert.Empty(t, alice.Events())
+		assert.Len(t, all.Events(), 2)
+	})
+
+	t.Run("从最后收到的事件继续", func(t *testing.T) {
+		bus := event.NewBus(10)
+		for i := 1; i <= 4; i++ {
+			publish(bus, i, 1)
+		}
+		publish(bus, 5, 2)
+
+		sub, missed, complete := bus.Subscribe(1, 2)
+		defer sub.Close()
+		assert.True(t, complete)
+		require.Len(t, missed, 2)
+		assert.Equal(t, int64(3), missed[0].ID)
+		assert.Equal(t, int64(4), missed[1].ID)
+
+		// 订阅后发布的事件通过通道接收，不会与补发的事件重复或遗漏
+		publish(bus, 6, 1)
+		e := <-sub.Events()
+		assert.Equal(t, int64(6), e.ID)
+	})
+
+	t.Run("错过的事件已不在保留范围内", func(t *testing.T) {
+		bus := event.NewBus(3)
+		for i := 1; i <= 5; i++ {
+			publish(bus, i, 1)
+		}
+
+		sub, missed, complete := bus.Subscribe(1, 1)
+		sub.Close()
+		assert.False(t, complete)
+		assert.Len(t, missed, 3)
+
+		// 已收到保留范围之前的最后一个事件时不需要重新加载
+		sub, missed, complete = bus.Subscribe(1, 2)
+		sub.Close()
+		assert.True(t, complete)
+		assert.Len(t, missed, 3)
+
+		// 事件ID来自重启前
+		sub, _, complete = bus.Subscribe(1, 99)
+		sub.Close()
+		assert.False(t, complete)
+	})
+
+	t.Run("读取过慢时关闭订阅", func(t *testing.T) {
+		bus := event.NewBus(10)
+		sub, _, _ := bus.Subscribe(1, 0)
+		for i := 0; i < 100; i++ {
+			publish(bus, i, 1)
+		}
+
+		received := 0
+		for range sub.Events() {
+			received++
+		}
+		assert.Less(t, received, 100)
+		// 重复关闭不会出错
+		sub.Close()
+	})
+
+	t.Run("为 nil 时不发布", func(t *testing.T) {
+		var bus *event.Bus
+		assert.NotPanics(t, func() { publish(bus, 1, 1) })
+	})
+}
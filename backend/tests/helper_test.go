@@ -6,36 +6,43 @@ import (
 	"testing"
 
 	"todolist/config"
+	"todolist/internal/repository"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-// initTestDB 初始化测试数据库连接
+// initTestDB 初始化测试数据库连接并执行迁移
 func initTestDB(t *testing.T) *gorm.DB {
+	db := openTestDB(t)
+
+	// 执行数据库迁移
+	if err := repository.Migrate(db); err != nil {
+		t.Fatalf("执行数据库迁移失败: %v", err)
+	}
+	return db
+}
+
+// openTestDB 打开空的测试数据库
+// 测试使用嵌入式SQLite内存数据库，每个测试独占一个库，无需外部服务
+func openTestDB(t *testing.T) *gorm.DB {
 	// 加载配置
 	err := config.LoadConfig("../config/config.yaml")
 	if err != nil {
 		t.Fatalf("加载配置失败: %v", err)
 	}
 
-	// 获取MySQL配置
-	mysqlConfig := config.GlobalConfig.MySQL
-
-	// 使用测试数据库
-	mysqlConfig.Database = fmt.Sprintf("%s_test", mysqlConfig.Database)
-
-	// 配置GORM
-	gormConfig := &gorm.Config{}
+	// 使用SQLite内存数据库
+	cfg := config.GlobalConfig
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.SQLite.Path = fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
 
 	// 连接数据库
-	dsn := mysqlConfig.DSN()
-	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
+	db, err := repository.OpenDB(cfg, &gorm.Config{})
 	if err != nil {
 		t.Fatalf("连接数据库失败: %v", err)
 	}
 
-	log.Printf("成功连接到测试数据库: %s\n", mysqlConfig.Database)
+	log.Printf("成功连接到测试数据库: %s\n", cfg.SQLite.Path)
 	return db
 }
 
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/pkg/ical"
+)
+
+func TestICalEncode(t *testing.T) {
+	t.Run("组件和属性", func(t *testing.T) {
+		calendar := ical.NewCalendar("-//Test//EN")
+		todo := &ical.Component{Name: "VTODO"}
+		todo.Add("UID", "task-1@test")
+		todo.AddDate("DUE", time.Date(2026, 10, 18, 0, 0, 0, 0, time.FixedZone("CST", 8*3600)))
+		todo.AddDateTime("DTSTAMP", time.Date(2026, 10, 18, 8, 30, 0, 0, time.FixedZone("CST", 8*3600)))
+		todo.AddText("SUMMARY", "买菜, 做饭; 洗碗\\n")
+		calendar.Append(todo)
+
+		assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+			"VERSION:2.0\r\n"+
+			"PRODID:-//Test//EN\r\n"+
+			"CALSCALE:GREGORIAN\r\n"+
+			"BEGIN:VTODO\r\n"+
+			"UID:task-1@test\r\n"+
+			"DUE;VALUE=DATE:20261018\r\n"+
+			"DTSTAMP:20261018T003000Z\r\n"+
+			`SUMMARY:买菜\, 做饭\; 洗碗\\n`+"\r\n"+
+			"END:VTODO\r\n"+
+			"END:VCALENDAR\r\n", calendar.String())
+	})
+
+	t.Run("换行转义", func(t *testing.T) {
+		assert.Equal(t, `第一行\n第二行\n第三行`, ical.EscapeText("第一行\r\n第二行\n第三行"))
+	})
+
+	t.Run("长行折叠", func(t *testing.T) {
+		component := &ical.Component{Name: "VTODO"}
+		component.AddText("DESCRIPTION", strings.Repeat("任务", 40))
+		encoded := component.String()
+
+		lines := strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n")
+		assert.Greater(t, len(lines), 3)
+		unfolded := ""
+		for i, line := range lines {
+			assert.LessOrEqual(t, len(line), 75, "每行不超过75字节")
+			assert.True(t, utf8.ValidString(line), "不拆分多字节字符")
+			if i > 0 && strings.HasPrefix(line, " ") {
+				unfolded += line[1:]
+				continue
+			}
+			if unfolded != "" {
+				unfolded += "\n"
+			}
+			unfolded += line
+		}
+		assert.Contains(t, unfolded, "DESCRIPTION:"+strings.Repeat("任务", 40))
+	})
+}
+
+func TestICalDecode(t *testing.T) {
+	t.Run("解析组件、参数和续行", func(t *testing.T) {
+		data := "BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"BEGIN:VTODO\r\n" +
+			"UID:abc@example.com\r\n" +
+			"summary:买菜\\, 做饭\r\n" +
+			"DESCRIPTION:第一行\\n第二\r\n" +
+			" 行\r\n" +
+			"DUE;TZID=\"Asia/Shanghai\";X-NOTE=\"a;b:c\":20261020T090000\r\n" +
+			"END:VTODO\r\n" +
+			"END:VCALENDAR\r\n"
+		calendar, err := ical.Decode(strings.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "VCALENDAR", calendar.Name)
+		assert.Equal(t, "2.0", calendar.Get("VERSION").Value)
+
+		todos := calendar.Children("VTODO")
+		require.Len(t, todos, 1)
+		todo := todos[0]
+		assert.Equal(t, "买菜, 做饭", todo.Get("SUMMARY").Text())
+		assert.Equal(t, "第一行\n第二行", todo.Get("DESCRIPTION").Text())
+		assert.Nil(t, todo.Get("RRULE"))
+
+		due := todo.Get("DUE")
+		assert.Equal(t, "Asia/Shanghai", due.Param("TZID"))
+		assert.Equal(t, "a;b:c", due.Param("X-NOTE"))
+		value, allDay, err := due.Time(time.UTC)
+		require.NoError(t, err)
+		assert.False(t, allDay)
+		assert.Equal(t, time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC), value.UTC())
+	})
+
+	t.Run("解析日期和时间", func(t *testing.T) {
+		loc := time.FixedZone("CST", 8*3600)
+		cases := []struct {
+			prop   ical.Property
+			want   time.Time
+			allDay bool
+		}{
+			{ical.Property{Value: "20261020", Params: []string{"VALUE=DATE"}}, time.Date(2026, 10, 20, 0, 0, 0, 0, loc), true},
+			{ical.Property{Value: "20261020T093000Z"}, time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC), false},
+			{ical.Property{Value: "20261020T093000"}, time.Date(2026, 10, 20, 9, 30, 0, 0, loc), false},
+		}
+		for _, c := range cases {
+			value, allDay, err := c.prop.Time(loc)
+			require.NoError(t, err)
+			assert.True(t, c.want.Equal(value), c.prop.Value)
+			assert.Equal(t, c.allDay, allDay)
+		}
+		_, _, err := (&ical.Property{Value: "tomorrow"}).Time(loc)
+		assert.Error(t, err)
+	})
+
+	t.Run("编码后可以解析回来", func(t *testing.T) {
+		calendar := ical.NewCalendar("-//Test//EN")
+		todo := &ical.Component{Name: "VTODO"}
+		todo.AddText("SUMMARY", strings.Repeat("很长的任务标题; ", 10))
+		calendar.Append(todo)
+
+		decoded, err := ical.Decode(strings.NewReader(calendar.String()))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("很长的任务标题; ", 10), decoded.Children("VTODO")[0].Get("SUMMARY").Text())
+	})
+
+	t.Run("无效的数据", func(t *testing.T) {
+		for _, data := range []string{
+			"",
+			"VERSION:2.0\r\n",
+			"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+			"BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\nBEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n",
+			"BEGIN:VCALENDAR\r\nDUE;TZID=\"x:20261020\r\nEND:VCALENDAR\r\n",
+			"BEGIN:VCALENDAR\r\n",
+		} {
+			_, err := ical.Decode(strings.NewReader(data))
+			assert.ErrorIs(t, err, ical.ErrInvalidData, data)
+		}
+	})
+}
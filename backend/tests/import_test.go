@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/config"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/internal/service"
+)
+
+// setupImportService 创建共用标签仓库的任务、标签和导入服务
+func setupImportService(t *testing.T) (service.TaskService, service.TagService, service.ImportService) {
+	require.NoError(t, config.LoadConfig("../config/config.yaml"))
+	tagRepo := repository.NewMemoryTagRepository()
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), tagRepo, repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+	tagService := service.NewTagService(tagRepo)
+	return taskService, tagService, service.NewImportService(taskService, tagService)
+}
+
+func TestImportService(t *testing.T) {
+	taskService, tagService, importService := setupImportService(t)
+	require.NoError(t, tagService.Create(&model.Tag{UserID: 1, Name: "工作"}))
+
+	items := func() []service.ImportItem {
+		return []service.ImportItem{
+			{Row: 2, Task: &model.Task{Title: "写周报", Priority: model.TaskPriorityHigh}, TagNames: []string{"工作", " 周报 ", "周报"}},
+			{Row: 3, Task: &model.Task{Title: strings.Repeat("长", 101)}, TagNames: []string{"不会创建"}},
+			{Row: 4, Err: errors.New("日期格式错误")},
+			{Row: 5, Task: &model.Task{Title: "标签名过长"}, TagNames: []string{strings.Repeat("标", 51)}},
+			{Row: 6, Task: &model.Task{Title: "买菜", Status: model.TaskStatusDone}},
+		}
+	}
+
+	t.Run("测试预览不创建任务和标签", func(t *testing.T) {
+		result, err := importService.Import(1, items(), service.ImportOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, 3, result.Failed)
+		assert.Equal(t, []string{"周报"}, result.NewTags)
+
+		rows := result.Items
+		require.Len(t, rows, 5)
+		assert.NoError(t, rows[0].Err)
+		assert.Zero(t, rows[0].Task.ID)
+		require.Len(t, rows[0].Task.Tags, 2)
+		assert.Equal(t, "工作", rows[0].Task.Tags[0].Name)
+		assert.NotZero(t, rows[0].Task.Tags[0].ID)
+		assert.Equal(t, "周报", rows[0].Task.Tags[1].Name)
+		assert.Zero(t, rows[0].Task.Tags[1].ID)
+		assert.Equal(t, service.ErrTitleTooLong, rows[1].Err)
+		assert.EqualError(t, rows[2].Err, "日期格式错误")
+		assert.Equal(t, service.ErrTagNameTooLong, rows[3].Err)
+		assert.NoError(t, rows[4].Err)
+		assert.Equal(t, model.TaskStatusDone, rows[4].Task.Status)
+
+		_, total, err := taskService.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: -1})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		tags, err := tagService.List(1)
+		require.NoError(t, err)
+		assert.Len(t, tags, 1)
+	})
+
+	t.Run("测试导入只为成功的任务创建标签", func(t *testing.T) {
+		result, err := importService.Import(1, items(), service.ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, 3, result.Failed)
+		assert.Equal(t, []string{"周报"}, result.NewTags)
+		assert.NotZero(t, result.Items[0].Task.ID)
+		assert.Equal(t, service.ErrTitleTooLong, result.Items[1].Err)
+
+		task, err := taskService.Get(result.Items[0].Task.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "写周报", task.Title)
+		assert.Equal(t, model.TaskPriorityHigh, task.Priority)
+		assert.Len(t, task.Tags, 2)
+
+		tags, err := tagService.List(1)
+		require.NoError(t, err)
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		assert.ElementsMatch(t, []string{"工作", "周报"}, names)
+	})
+
+	t.Run("测试超过单次批量操作数量时分批导入", func(t *testing.T) {
+		many := make([]service.ImportItem, service.MaxBatchOperations+5)
+		for i := range many {
+			many[i] = service.ImportItem{Row: i + 1, Task: &model.Task{Title: "批量导入"}}
+		}
+		many[service.MaxBatchOperations+2].Task.Title = ""
+
+		result, err := importService.Import(2, many, service.ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, service.MaxBatchOperations+4, result.Created)
+		assert.Equal(t, service.ErrEmptyTitle, result.Items[service.MaxBatchOperations+2].Err)
+		_, total, err := taskService.List(model.TaskFilter{UserID: 2, Page: 1, PageSize: -1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(service.MaxBatchOperations+4), total)
+	})
+
+	t.Run("测试请求校验", func(t *testing.T) {
+		_, err := importService.Import(1, nil, service.ImportOptions{})
+		assert.Equal(t, service.ErrEmptyImport, err)
+		_, err = importService.Import(1, make([]service.ImportItem, service.MaxImportItems+1), service.ImportOptions{})
+		assert.Equal(t, service.ErrTooManyImportItems, err)
+	})
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
 
+	"todolist/config"
 	"todolist/internal/middleware"
+	"todolist/internal/repository"
+	"todolist/internal/service"
 	"todolist/pkg/jwt"
 )
 
@@ -144,3 +152,257 @@ func TestGetUserFunctions(t *testing.T) {
 		})
 	})
 }
+
+// revokedTokens 测试用的吊销列表
+type revokedTokens map[string]bool
+
+func (r revokedTokens) IsTokenRevoked(tokenID string) (bool, error) {
+	return r[tokenID], nil
+}
+
+func TestAuthMiddlewareRevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token, claims, err := jwt.GenerateAccessToken(1, "testuser")
+	assert.NoError(t, err)
+
+	revoked := revokedTokens{}
+	middleware.SetTokenRevocationChecker(revoked)
+	defer middleware.SetTokenRevocationChecker(nil)
+
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware())
+	r.GET("/test", func(c *gin.Context) {
+		assert.Equal(t, claims.Id, middleware.GetClaims(c).Id)
+		c.Status(http.StatusOK)
+	})
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// 未吊销的令牌可以通过认证
+	assert.Equal(t, http.StatusOK, request())
+
+	// 吊销后拒绝访问
+	revoked[claims.Id] = true
+	assert.Equal(t, http.StatusUnauthorized, request())
+
+	// 没有唯一标识的令牌无法吊销，直接拒绝
+	withoutID := claims.StandardClaims
+	withoutID.Id = ""
+	token, err = gojwt.NewWithClaims(gojwt.SigningMethodHS256, &jwt.CustomClaims{UserID: 1, Username: "testuser", StandardClaims: withoutID}).
+		SignedString([]byte(config.GlobalConfig.JWT.SecretKey))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, request())
+}
+
+// workspaceRoles 测试用的工作区成员角色，键为工作区ID
+type workspaceRoles map[int]string
+
+func (r workspaceRoles) MemberRole(workspaceID, userID int) (string, error) {
+	return r[workspaceID], nil
+}
+
+func TestWorkspaceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token, err := jwt.GenerateToken(1, "testuser")
+	assert.NoError(t, err)
+
+	middleware.SetWorkspaceMembershipChecker(workspaceRoles{1: "member"})
+	defer middleware.SetWorkspaceMembershipChecker(nil)
+
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware(), middleware.WorkspaceMiddleware())
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"workspace_id": middleware.GetWorkspaceID(c),
+			"role":         middleware.GetWorkspaceRole(c),
+		})
+	})
+
+	request := func(workspace string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if workspace != "" {
+			req.Header.Set(middleware.WorkspaceHeader, workspace)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 未指定工作区时使用个人空间
+	rec := request("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"workspace_id":0,"role":""}`, rec.Body.String())
+
+	rec = request("1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"workspace_id":1,"role":"member"}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, request("2").Code)
+	assert.Equal(t, http.StatusBadRequest, request("abc").Code)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := config.LoadConfig("../config/config.yaml"); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	middleware.SetIdempotencyStore(service.NewIdempotencyService(repository.NewMemoryIdempotencyRepository()))
+	defer middleware.SetIdempotencyStore(nil)
+
+	created := 0
+	failures := 1
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware(), middleware.IdempotencyMiddleware())
+	r.POST("/tasks", func(c *gin.Context) {
+		created++
+		c.Header("Location", fmt.Sprintf("/tasks/%d", created))
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+	r.POST("/flaky", func(c *gin.Context) {
+		if failures > 0 {
+			failures--
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"code": 200})
+	})
+	r.POST("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(userID int, path, key, body string) *httptest.ResponseRecorder {
+		token, err := jwt.GenerateToken(userID, "testuser")
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 重试返回首次请求的响应，不会重复创建
+	first := request(1, "/tasks", "key-1", `{"title":"任务"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	retry := request(1, "/tasks", "key-1", `{"title":"任务"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/tasks/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 1, created)
+
+	// 相同幂等键用于不同的请求体
+	assert.Equal(t, http.StatusUnprocessableEntity, request(1, "/tasks", "key-1", `{"title":"其他任务"}`).Code)
+	assert.Equal(t, 1, created)
+
+	// 幂等键按用户区分，未提供幂等键时每次都执行
+	assert.Equal(t, http.StatusCreated, request(2, "/tasks", "key-1", `{"title":"任务"}`).Code)
+	assert.Equal(t, http.StatusCreated, request(1, "/tasks", "", `{"title":"任务"}`).Code)
+	assert.Equal(t, 3, created)
+
+	// 首次请求返回 5xx 时可以使用相同的幂等键重试
+	assert.Equal(t, http.StatusInternalServerError, request(1, "/flaky", "key-2", "{}").Code)
+	assert.Equal(t, http.StatusOK, request(1, "/flaky", "key-2", "{}").Code)
+	assert.Equal(t, "true", request(1, "/flaky", "key-2", "{}").Header().Get(middleware.IdempotentReplayedHeader))
+
+	// 首次请求仍在处理中
+	done := make(chan int)
+	go func() {
+		done <- request(1, "/slow", "key-3", "").Code
+	}()
+	<-started
+	assert.Equal(t, http.StatusConflict, request(1, "/slow", "key-3", "").Code)
+	close(release)
+	assert.Equal(t, http.StatusNoContent, <-done)
+
+	// 幂等键过长
+	assert.Equal(t, http.StatusBadRequest, request(1, "/tasks", strings.Repeat("k", 256), "{}").Code)
+
+	// 请求体超过限制时不读入内存，也不执行处理器
+	config.GlobalConfig.Idempotency.MaxBodyMB = 1
+	assert.Equal(t, http.StatusRequestEntityTooLarge, request(1, "/tasks", "key-4", strings.Repeat("x", 1<<20+1)).Code)
+	assert.Equal(t, 3, created)
+	assert.Equal(t, http.StatusCreated, request(1, "/tasks", "key-4", `{"title":"任务"}`).Code, "拒绝过大的请求时不占用幂等键")
+	assert.Equal(t, 4, created)
+
+	// 未登录的请求忽略幂等键，不保存响应
+	logins := 0
+	public := gin.New()
+	public.POST("/login", middleware.IdempotencyMiddleware(), func(c *gin.Context) {
+		logins++
+		c.JSON(http.StatusOK, gin.H{"access_token": fmt.Sprintf("token-%d", logins)})
+	})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("{}"))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "login-key")
+		rec := httptest.NewRecorder()
+		public.ServeHTTP(rec, req)
+		assert.Empty(t, rec.Header().Get(middleware.IdempotentReplayedHeader))
+	}
+	assert.Equal(t, 2, logins)
+}
+
+func TestIdempotencyMiddlewareMultipart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := config.LoadConfig("../config/config.yaml"); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	middleware.SetIdempotencyStore(service.NewIdempotencyService(repository.NewMemoryIdempotencyRepository()))
+	defer middleware.SetIdempotencyStore(nil)
+
+	imported := 0
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware(), middleware.IdempotencyMiddleware())
+	r.POST("/import", func(c *gin.Context) {
+		imported++
+		c.JSON(http.StatusOK, gin.H{"imported": imported})
+	})
+
+	// 每次构造请求体都会生成新的随机 boundary，和客户端重试时一样
+	request := func(content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		assert.NoError(t, writer.WriteField("format", "csv"))
+		file, err := writer.CreateFormFile("file", "tasks.csv")
+		assert.NoError(t, err)
+		file.Write([]byte(content))
+		assert.NoError(t, writer.Close())
+
+		token, err := jwt.GenerateToken(1, "testuser")
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/import", &body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set(middleware.IdempotencyKeyHeader, "import-1")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("title\n任务\n").Code)
+	retry := request("title\n任务\n")
+	assert.Equal(t, http.StatusOK, retry.Code, "boundary 不同的重试应返回首次请求的响应")
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 1, imported)
+
+	// 文件内容不同时仍然视为不同的请求
+	assert.Equal(t, http.StatusUnprocessableEntity, request("title\n其他任务\n").Code)
+	assert.Equal(t, 1, imported)
+}
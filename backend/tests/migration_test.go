@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todolist/internal/migration"
+)
+
+func TestMigration(t *testing.T) {
+	db := openTestDB(t)
+
+	migrator, err := migration.New(db)
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	latest := migrator.Migrations()[len(migrator.Migrations())-1].Version
+
+	// 测试 dry-run 不修改数据库
+	t.Run("测试dry-run", func(t *testing.T) {
+		var out bytes.Buffer
+		migrator.DryRun = true
+		migrator.Out = &out
+		defer func() {
+			migrator.DryRun = false
+			migrator.Out = nil
+		}()
+
+		done, err := migrator.Up(0)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, done)
+		assert.Contains(t, out.String(), "CREATE TABLE IF NOT EXISTS tasks")
+		assert.False(t, db.Migrator().HasTable("tasks"))
+	})
+
+	// 测试执行迁移
+	t.Run("测试执行迁移", func(t *testing.T) {
+		done, err := migrator.Up(0)
+		assert.NoError(t, err)
+		assert.Len(t, done, len(migrator.Migrations()))
+		assert.True(t, db.Migrator().HasTable("users"))
+		assert.True(t, db.Migrator().HasTable("tasks"))
+
+		// 重复执行不会再次应用
+		done, err = migrator.Up(0)
+		assert.NoError(t, err)
+		assert.Empty(t, done)
+
+		statuses, err := migrator.Status()
+		assert.NoError(t, err)
+		for _, status := range statuses {
+			assert.True(t, status.Applied, status.Migration.String())
+		}
+	})
+
+	// 测试回滚
+	t.Run("测试回滚", func(t *testing.T) {
+		done, err := migrator.Down(1)
+		assert.NoError(t, err)
+		assert.Len(t, done, 1)
+		assert.Equal(t, latest, done[0].Version)
+
+		done, err = migrator.Up(0)
+		assert.NoError(t, err)
+		assert.Len(t, done, 1)
+	})
+
+	// 测试校验和
+	t.Run("测试校验和不匹配", func(t *testing.T) {
+		err := db.Exec("UPDATE schema_migrations SET checksum = ? WHERE version = ?", "tampered", latest).Error
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, migrator.Verify(), migration.ErrChecksumMismatch)
+		_, err = migrator.Up(0)
+		assert.ErrorIs(t, err, migration.ErrChecksumMismatch)
+	})
+}
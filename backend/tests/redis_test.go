@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/pkg/redis"
+)
+
+// fakeRedisServer 支持少量字符串命令的进程内 RESP 服务器，不处理过期时间
+type fakeRedisServer struct {
+	listener net.Listener
+	password string
+
+	mu    sync.Mutex
+	data  map[int]map[string]string
+	conns int
+}
+
+// startFakeRedis 启动测试用的 Redis 服务器，测试结束时关闭
+func startFakeRedis(t *testing.T, password string) *fakeRedisServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeRedisServer{listener: listener, password: password, data: make(map[int]map[string]string)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// serve 处理一个连接上的命令
+func (s *fakeRedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+	db := 0
+	for {
+		args, err := readFakeCommand(reader)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+
+		s.mu.Lock()
+		if s.data[db] == nil {
+			s.data[db] = make(map[string]string)
+		}
+		data := s.data[db]
+		var reply string
+		switch {
+		case name == "AUTH":
+			authed = args[1] == s.password
+			reply = "+OK\r\n"
+			if !authed {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case name == "PING":
+			reply = "+PONG\r\n"
+		case name == "SELECT":
+			db, _ = strconv.Atoi(args[1])
+			reply = "+OK\r\n"
+		case name == "GET":
+			if value, ok := data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case name == "SET":
+			data[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case name == "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := data[key]; ok {
+					delete(data, key)
+					deleted++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", deleted)
+		case name == "INCR":
+			n, err := strconv.Atoi(data[args[1]])
+			if err != nil && data[args[1]] != "" {
+				reply = "-ERR value is not an integer or out of range\r\n"
+				break
+			}
+			data[args[1]] = strconv.Itoa(n + 1)
+			reply = fmt.Sprintf(":%d\r\n", n+1)
+		default:
+			reply = "-ERR unknown command '" + args[0] + "'\r\n"
+		}
+		s.mu.Unlock()
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readFakeCommand 读取客户端发送的命令
+func readFakeCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("无效的命令: %q", line)
+	}
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisClient(t *testing.T) {
+	t.Run("字符串命令", func(t *testing.T) {
+		server := startFakeRedis(t, "")
+		client := redis.NewClient(redis.Options{Addr: server.listener.Addr().String()})
+		defer client.Close()
+
+		require.NoError(t, client.Ping())
+
+		_, err := client.Get("missing")
+		assert.ErrorIs(t, err, redis.Nil)
+
+		require.NoError(t, client.Set("key", "value\r\nwith newline", time.Minute))
+		value, err := client.Get("key")
+		require.NoError(t, err)
+		assert.Equal(t, "value\r\nwith newline", value)
+
+		n, err := client.Incr("counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = client.Incr("counter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		require.NoError(t, client.Del("key", "counter"))
+		_, err = client.Get("key")
+		assert.ErrorIs(t, err, redis.Nil)
+	})
+
+	t.Run("服务器错误不关闭连接", func(t *testing.T) {
+		server := startFakeRedis(t, "")
+		client := redis.NewClient(redis.Options{Addr: server.listener.Addr().String(), PoolSize: 1})
+		defer client.Close()
+
+		require.NoError(t, client.Set("key", "text", 0))
+		_, err := client.Incr("key")
+		var serverErr redis.Error
+		require.ErrorAs(t, err, &serverErr)
+		assert.Contains(t, err.Error(), "not an integer")
+
+		require.NoError(t, client.Ping())
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.Equal(t, 1, server.conns)
+	})
+
+	t.Run("认证和选择数据库", func(t *testing.T) {
+		server := startFakeRedis(t, "secret")
+
+		client := redis.NewClient(redis.Options{Addr: server.listener.Addr().String(), Password: "secret", DB: 2})
+		defer client.Close()
+		require.NoError(t, client.Set("key", "value", 0))
+		server.mu.Lock()
+		assert.Equal(t, "value", server.data[2]["key"])
+		server.mu.Unlock()
+
+		wrong := redis.NewClient(redis.Options{Addr: server.listener.Addr().String(), Password: "wrong"})
+		defer wrong.Close()
+		assert.Error(t, wrong.Ping())
+	})
+
+	t.Run("连接池限制并发连接数", func(t *testing.T) {
+		server := startFakeRedis(t, "")
+		client := redis.NewClient(redis.Options{Addr: server.listener.Addr().String(), PoolSize: 2})
+		defer client.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.Incr("counter")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		value, err := client.Get("counter")
+		require.NoError(t, err)
+		assert.Equal(t, "20", value)
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.LessOrEqual(t, server.conns, 2)
+	})
+
+	t.Run("关闭后返回错误", func(t *testing.T) {
+		server := startFakeRedis(t, "")
+		client := redis.NewClient(redis.Options{Addr: server.listener.Addr().String()})
+		require.NoError(t, client.Ping())
+		require.NoError(t, client.Close())
+		assert.ErrorIs(t, client.Ping(), redis.ErrClosed)
+	})
+
+	t.Run("服务器不可用", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		listener.Close()
+
+		client := redis.NewClient(redis.Options{Addr: addr, DialTimeout: time.Second})
+		defer client.Close()
+		assert.Error(t, client.Ping())
+	})
+}
@@ -0,0 +1,1479 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/internal/cache"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+)
+
+// 仓库实现的一致性测试，所有 TaskRepository / UserRepository 实现都必须通过
+
+// repositoryFactories 返回所有需要校验的仓库实现
+func repositoryFactories() map[string]func(t *testing.T) (repository.UserRepository, repository.TaskRepository) {
+	return map[string]func(t *testing.T) (repository.UserRepository, repository.TaskRepository){
+		"gorm": func(t *testing.T) (repository.UserRepository, repository.TaskRepository) {
+			db := initTestDB(t)
+			return repository.NewUserRepository(db), repository.NewTaskRepository(db)
+		},
+		"memory": func(t *testing.T) (repository.UserRepository, repository.TaskRepository) {
+			return repository.NewMemoryUserRepository(), repository.NewMemoryTaskRepository()
+		},
+		"cached": func(t *testing.T) (repository.UserRepository, repository.TaskRepository) {
+			db := initTestDB(t)
+			c := cache.NewMemoryCache()
+			return repository.NewCachedUserRepository(repository.NewUserRepository(db), c, time.Minute, nil),
+				repository.NewCachedTaskRepository(repository.NewTaskRepository(db), c, time.Minute, nil)
+		},
+	}
+}
+
+func TestTaskRepositoryConformance(t *testing.T) {
+	for name, factory := range repositoryFactories() {
+		t.Run(name, func(t *testing.T) {
+			testTaskRepositoryConformance(t, func(t *testing.T) repository.TaskRepository {
+				_, taskRepo := factory(t)
+				return taskRepo
+			})
+		})
+	}
+}
+
+func TestUserRepositoryConformance(t *testing.T) {
+	for name, factory := range repositoryFactories() {
+		t.Run(name, func(t *testing.T) {
+			testUserRepositoryConformance(t, func(t *testing.T) repository.UserRepository {
+				userRepo, _ := factory(t)
+				return userRepo
+			})
+		})
+	}
+}
+
+func testTaskRepositoryConformance(t *testing.T, newRepo func(t *testing.T) repository.TaskRepository) {
+	t.Run("创建并获取", func(t *testing.T) {
+		repo := newRepo(t)
+		dueDate := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		recurrence := "FREQ=WEEKLY;BYDAY=MO"
+		task := &model.Task{UserID: 1, Title: "task", Description: "desc", DueDate: &dueDate, Recurrence: &recurrence}
+
+		require.NoError(t, repo.Create(task))
+		assert.NotZero(t, task.ID)
+		assert.False(t, task.CreatedAt.IsZero())
+
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "task", found.Title)
+		assert.Equal(t, "desc", found.Description)
+		assert.Equal(t, model.TaskStatusTodo, found.Status)
+		require.NotNil(t, found.DueDate)
+		assert.True(t, dueDate.Equal(*found.DueDate))
+		require.NotNil(t, found.Recurrence)
+		assert.Equal(t, recurrence, *found.Recurrence)
+	})
+
+	t.Run("不存在返回nil", func(t *testing.T) {
+		repo := newRepo(t)
+		found, err := repo.GetByID(999)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("更新", func(t *testing.T) {
+		repo := newRepo(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+
+		task.Title = "updated"
+		task.Status = model.TaskStatusDone
+		require.NoError(t, repo.Update(task))
+
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "updated", found.Title)
+		assert.Equal(t, model.TaskStatusDone, found.Status)
+	})
+
+	t.Run("返回值与仓库隔离", func(t *testing.T) {
+		repo := newRepo(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		found.Title = "changed without update"
+
+		again, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "task", again.Title)
+	})
+
+	t.Run("删除", func(t *testing.T) {
+		repo := newRepo(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+		require.NoError(t, repo.Delete(task.ID))
+
+		found, err := repo.GetByID(task.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		// 删除不存在的任务不报错
+		assert.NoError(t, repo.Delete(task.ID))
+	})
+
+	t.Run("按用户分页和状态过滤", func(t *testing.T) {
+		repo := newRepo(t)
+		for i := 1; i <= 5; i++ {
+			status := model.TaskStatusTodo
+			if i%2 == 0 {
+				status = model.TaskStatusDone
+			}
+			require.NoError(t, repo.Create(&model.Task{UserID: 1, Title: fmt.Sprintf("task %d", i), Status: status}))
+		}
+		require.NoError(t, repo.Create(&model.Task{UserID: 2, Title: "other user"}))
+
+		tasks, total, err := repo.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, "task 1", tasks[0].Title)
+		assert.Equal(t, "task 2", tasks[1].Title)
+
+		tasks, total, err = repo.List(model.TaskFilter{UserID: 1, Page: 3, PageSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "task 5", tasks[0].Title)
+
+		tasks, total, err = repo.List(model.TaskFilter{UserID: 1, Page: 4, PageSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		assert.Empty(t, tasks)
+
+		tasks, total, err = repo.List(model.TaskFilter{UserID: 1, Statuses: []int{model.TaskStatusDone}, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, task := range tasks {
+			assert.Equal(t, model.TaskStatusDone, task.Status)
+		}
+
+		tasks, total, err = repo.List(model.TaskFilter{UserID: 1, Statuses: []int{model.TaskStatusInProgress}, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, tasks)
+
+		tasks, total, err = repo.List(model.TaskFilter{UserID: 3, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("截止日期、逾期、搜索和排序", func(t *testing.T) {
+		repo := newRepo(t)
+		day := func(offset int) *time.Time {
+			d := time.Now().Truncate(time.Second).AddDate(0, 0, offset)
+			return &d
+		}
+		fixtures := []*model.Task{
+			{UserID: 1, Title: "写周报", Description: "汇总本周进度", DueDate: day(-2), Priority: model.TaskPriorityLow},
+			{UserID: 1, Title: "Review PR", Description: "check 100% coverage", DueDate: day(-1), Status: model.TaskStatusDone},
+			{UserID: 1, Title: "plan sprint", DueDate: day(3), Priority: model.TaskPriorityHigh},
+			{UserID: 1, Title: "read book", Priority: model.TaskPriorityHigh},
+		}
+		for _, task := range fixtures {
+			require.NoError(t, repo.Create(task))
+		}
+		titles := func(tasks []*model.Task) []string {
+			var result []string
+			for _, task := range tasks {
+				result = append(result, task.Title)
+			}
+			return result
+		}
+
+		tasks, total, err := repo.List(model.TaskFilter{UserID: 1, Overdue: true, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []string{"写周报"}, titles(tasks))
+
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, DueAfter: day(-1), DueBefore: day(3), Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Review PR"}, titles(tasks))
+
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, Keyword: "REVIEW", Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Review PR"}, titles(tasks))
+
+		// 通配符按普通字符匹配
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, Keyword: "100%", Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Review PR"}, titles(tasks))
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, Keyword: "_", Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, Keyword: "进度", Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"写周报"}, titles(tasks))
+
+		// 升序时没有截止日期的任务排在最前
+		sorts, err := model.ParseTaskSort("due_date")
+		require.NoError(t, err)
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, Sort: sorts, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"read book", "写周报", "Review PR", "plan sprint"}, titles(tasks))
+
+		sorts, err = model.ParseTaskSort("-priority,-due_date")
+		require.NoError(t, err)
+		tasks, _, err = repo.List(model.TaskFilter{UserID: 1, Sort: sorts, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan sprint", "read book", "写周报", "Review PR"}, titles(tasks))
+
+		tasks, _, err = repo.List(model.TaskFilter{
+			UserID:   1,
+			Statuses: []int{model.TaskStatusTodo, model.TaskStatusInProgress},
+			Sort:     []model.TaskSort{{Field: model.TaskSortID, Desc: true}},
+			Page:     1,
+			PageSize: 2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"read book", "plan sprint"}, titles(tasks))
+	})
+
+	t.Run("子任务", func(t *testing.T) {
+		repo := newRepo(t)
+		root := &model.Task{UserID: 1, Title: "root"}
+		require.NoError(t, repo.Create(root))
+		first := &model.Task{UserID: 1, Title: "first", ParentID: &root.ID, Status: model.TaskStatusDone}
+		second := &model.Task{UserID: 1, Title: "second", ParentID: &root.ID}
+		require.NoError(t, repo.Create(first))
+		require.NoError(t, repo.Create(second))
+		nested := &model.Task{UserID: 1, Title: "nested", ParentID: &second.ID}
+		require.NoError(t, repo.Create(nested))
+
+		found, err := repo.GetByID(nested.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.ParentID)
+		assert.Equal(t, second.ID, *found.ParentID)
+
+		children, err := repo.GetChildren(root.ID)
+		require.NoError(t, err)
+		require.Len(t, children, 2)
+		assert.Equal(t, first.ID, children[0].ID)
+		assert.Equal(t, second.ID, children[1].ID)
+
+		progress, err := repo.CountSubtasks([]int{root.ID, second.ID, nested.ID})
+		require.NoError(t, err)
+		assert.Equal(t, map[int]model.SubtaskProgress{
+			root.ID:   {Total: 2, Done: 1},
+			second.ID: {Total: 1, Done: 0},
+		}, progress)
+
+		zero := 0
+		tasks, total, err := repo.List(model.TaskFilter{UserID: 1, ParentID: &zero, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, root.ID, tasks[0].ID)
+
+		require.NoError(t, repo.DeleteByIDs([]int{nested.ID, second.ID}))
+		children, err = repo.GetChildren(root.ID)
+		require.NoError(t, err)
+		require.Len(t, children, 1)
+		assert.Equal(t, first.ID, children[0].ID)
+	})
+
+	t.Run("版本号", func(t *testing.T) {
+		repo := newRepo(t)
+		task := &model.Task{UserID: 1, Title: "task"}
+		require.NoError(t, repo.Create(task))
+		assert.Equal(t, 1, task.Version)
+
+		task.Title = "first"
+		require.NoError(t, repo.Update(task))
+		assert.Equal(t, 2, task.Version)
+
+		// 版本号过期时不做修改
+		stale := &model.Task{ID: task.ID, UserID: 1, Title: "stale"}
+		updated, err := repo.UpdateIfVersion(stale, 1)
+		require.NoError(t, err)
+		assert.False(t, updated)
+		assert.Zero(t, stale.Version)
+		found, err := repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", found.Title)
+		assert.Equal(t, 2, found.Version)
+
+		found.Title = "second"
+		found.Description = ""
+		updated, err = repo.UpdateIfVersion(found, 2)
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, 3, found.Version)
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "second", found.Title)
+		assert.Equal(t, 3, found.Version)
+		assert.Equal(t, task.CreatedAt.Unix(), found.CreatedAt.Unix())
+
+		// 批量移动项目同样增加版本号
+		projectID := 7
+		require.NoError(t, repo.UpdateProject([]int{task.ID}, &projectID))
+		require.NoError(t, repo.ClearProject(projectID))
+		found, err = repo.GetByID(task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 5, found.Version)
+
+		// 已删除的任务不能更新
+		require.NoError(t, repo.SoftDeleteByIDs([]int{task.ID}, time.Now()))
+		updated, err = repo.UpdateIfVersion(found, 5)
+		require.NoError(t, err)
+		assert.False(t, updated)
+	})
+
+	t.Run("回收站", func(t *testing.T) {
+		repo := newRepo(t)
+		root := &model.Task{UserID: 1, Title: "root"}
+		require.NoError(t, repo.Create(root))
+		child := &model.Task{UserID: 1, Title: "child", ParentID: &root.ID, Status: model.TaskStatusDone}
+		require.NoError(t, repo.Create(child))
+		other := &model.Task{UserID: 1, Title: "other"}
+		require.NoError(t, repo.Create(other))
+
+		deletedAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		require.NoError(t, repo.SoftDeleteByIDs([]int{root.ID, child.ID}, deletedAt))
+
+		// 已删除的任务不出现在普通查询中
+		found, err := repo.GetByID(root.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+		children, err := repo.GetChildren(root.ID)
+		require.NoError(t, err)
+		assert.Empty(t, children)
+		progress, err := repo.CountSubtasks([]int{root.ID})
+		require.NoError(t, err)
+		assert.Empty(t, progress)
+		tasks, total, err := repo.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, other.ID, tasks[0].ID)
+
+		tasks, total, err = repo.List(model.TaskFilter{UserID: 1, Deleted: true, Sort: []model.TaskSort{{Field: model.TaskSortDeletedAt, Desc: true}}, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, root.ID, tasks[0].ID)
+		require.NotNil(t, tasks[0].DeletedAt)
+		assert.True(t, deletedAt.Equal(*tasks[0].DeletedAt))
+
+		deleted, err := repo.GetDeletedByID(root.ID)
+		require.NoError(t, err)
+		require.NotNil(t, deleted)
+		missing, err := repo.GetDeletedByID(other.ID)
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+		children, err = repo.GetDeletedChildren(root.ID)
+		require.NoError(t, err)
+		require.Len(t, children, 1)
+		assert.Equal(t, child.ID, children[0].ID)
+
+		ids, err := repo.ListDeletedBefore(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, []int{root.ID, child.ID}, ids)
+		ids, err = repo.ListDeletedBefore(deletedAt)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+
+		require.NoError(t, repo.Restore([]int{root.ID}))
+		found, err = repo.GetByID(root.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Nil(t, found.DeletedAt)
+		children, err = repo.GetChildren(root.ID)
+		require.NoError(t, err)
+		assert.Empty(t, children)
+	})
+
+	t.Run("事务", func(t *testing.T) {
+		repo := newRepo(t)
+		kept := &model.Task{UserID: 1, Title: "kept"}
+		require.NoError(t, repo.Create(kept))
+
+		// fn 返回错误时回滚全部修改
+		errRollback := fmt.Errorf("rollback")
+		err := repo.Transaction(func(tx repository.Tx) error {
+			txRepo := repo.WithTx(tx)
+			require.NoError(t, txRepo.Create(&model.Task{UserID: 1, Title: "rolled back"}))
+			kept.Title = "changed"
+			require.NoError(t, txRepo.Update(kept))
+			return errRollback
+		})
+		assert.Equal(t, errRollback, err)
+		_, total, err := repo.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		found, err := repo.GetByID(kept.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "kept", found.Title)
+
+		// 嵌套事务失败时只回滚内层的修改
+		err = repo.Transaction(func(tx repository.Tx) error {
+			txRepo := repo.WithTx(tx)
+			if err := txRepo.Create(&model.Task{UserID: 1, Title: "outer"}); err != nil {
+				return err
+			}
+			assert.Equal(t, errRollback, txRepo.Transaction(func(inner repository.Tx) error {
+				require.NoError(t, txRepo.WithTx(inner).Create(&model.Task{UserID: 1, Title: "inner"}))
+				return errRollback
+			}))
+			return txRepo.Transaction(func(inner repository.Tx) error {
+				return txRepo.WithTx(inner).Create(&model.Task{UserID: 1, Title: "inner ok"})
+			})
+		})
+		require.NoError(t, err)
+		tasks, total, err := repo.List(model.TaskFilter{UserID: 1, Sort: []model.TaskSort{{Field: model.TaskSortID}}, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, []string{"kept", "outer", "inner ok"}, []string{tasks[0].Title, tasks[1].Title, tasks[2].Title})
+	})
+
+	t.Run("并发创建", func(t *testing.T) {
+		repo := newRepo(t)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, repo.Create(&model.Task{UserID: 1, Title: fmt.Sprintf("task %d", i)}))
+			}(i)
+		}
+		wg.Wait()
+
+		tasks, total, err := repo.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 100})
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), total)
+		ids := make(map[int]bool)
+		for _, task := range tasks {
+			ids[task.ID] = true
+		}
+		assert.Len(t, ids, 20)
+	})
+}
+
+func TestTaskTagRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) (repository.TaskRepository, repository.TagRepository){
+		"gorm": func(t *testing.T) (repository.TaskRepository, repository.TagRepository) {
+			db := initTestDB(t)
+			return repository.NewTaskRepository(db), repository.NewTagRepository(db)
+		},
+		"memory": func(t *testing.T) (repository.TaskRepository, repository.TagRepository) {
+			return repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository()
+		},
+		"cached": func(t *testing.T) (repository.TaskRepository, repository.TagRepository) {
+			db := initTestDB(t)
+			c := cache.NewMemoryCache()
+			return repository.NewCachedTaskRepository(repository.NewTaskRepository(db), c, time.Minute, nil),
+				repository.NewCachedTagRepository(repository.NewTagRepository(db), c)
+		},
+	}
+
+	for name, newRepos := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("标签增删改查", func(t *testing.T) {
+				_, tagRepo := newRepos(t)
+				work := &model.Tag{UserID: 1, Name: "work", Color: "#ff0000"}
+				require.NoError(t, tagRepo.Create(work))
+				assert.NotZero(t, work.ID)
+				require.NoError(t, tagRepo.Create(&model.Tag{UserID: 1, Name: "home"}))
+				require.NoError(t, tagRepo.Create(&model.Tag{UserID: 2, Name: "work"}))
+
+				// 同一用户下标签名唯一
+				assert.Error(t, tagRepo.Create(&model.Tag{UserID: 1, Name: "work"}))
+
+				found, err := tagRepo.GetByName(1, "work")
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Equal(t, work.ID, found.ID)
+
+				tags, err := tagRepo.GetByUserID(1)
+				require.NoError(t, err)
+				require.Len(t, tags, 2)
+				assert.Equal(t, "home", tags[0].Name)
+
+				work.Color = "#00ff00"
+				require.NoError(t, tagRepo.Update(work))
+				found, err = tagRepo.GetByID(work.ID)
+				require.NoError(t, err)
+				assert.Equal(t, "#00ff00", found.Color)
+
+				require.NoError(t, tagRepo.Delete(work.ID))
+				found, err = tagRepo.GetByID(work.ID)
+				assert.NoError(t, err)
+				assert.Nil(t, found)
+			})
+
+			t.Run("任务标签和优先级过滤", func(t *testing.T) {
+				taskRepo, tagRepo := newRepos(t)
+				work := &model.Tag{UserID: 1, Name: "work"}
+				home := &model.Tag{UserID: 1, Name: "home"}
+				require.NoError(t, tagRepo.Create(work))
+				require.NoError(t, tagRepo.Create(home))
+
+				first := &model.Task{UserID: 1, Title: "first", Priority: model.TaskPriorityHigh, Tags: []model.Tag{*work, *home}}
+				second := &model.Task{UserID: 1, Title: "second", Priority: model.TaskPriorityLow, Tags: []model.Tag{*home}}
+				require.NoError(t, taskRepo.Create(first))
+				require.NoError(t, taskRepo.Create(second))
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 1, Title: "third"}))
+
+				found, err := taskRepo.GetByID(first.ID)
+				require.NoError(t, err)
+				assert.Equal(t, model.TaskPriorityHigh, found.Priority)
+				assert.ElementsMatch(t, []int{work.ID, home.ID}, found.TagIDs())
+
+				tasks, total, err := taskRepo.List(model.TaskFilter{UserID: 1, Priorities: []int{model.TaskPriorityHigh}, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				require.Len(t, tasks, 1)
+				assert.Equal(t, "first", tasks[0].Title)
+
+				tasks, total, err = taskRepo.List(model.TaskFilter{UserID: 1, TagID: home.ID, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+				require.Len(t, tasks, 2)
+				assert.Len(t, tasks[0].Tags, 2)
+
+				// 替换标签
+				found.Tags = []model.Tag{*work}
+				require.NoError(t, taskRepo.Update(found))
+				tasks, total, err = taskRepo.List(model.TaskFilter{UserID: 1, TagID: home.ID, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				assert.Equal(t, "second", tasks[0].Title)
+			})
+		})
+	}
+}
+
+func testUserRepositoryConformance(t *testing.T, newRepo func(t *testing.T) repository.UserRepository) {
+	t.Run("创建并获取", func(t *testing.T) {
+		repo := newRepo(t)
+		user := &model.User{Username: "alice", PasswordHash: "hash"}
+		require.NoError(t, repo.Create(user))
+		assert.NotZero(t, user.ID)
+
+		found, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "alice", found.Username)
+
+		found, err = repo.GetByUsername("alice")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, user.ID, found.ID)
+	})
+
+	t.Run("不存在返回nil", func(t *testing.T) {
+		repo := newRepo(t)
+		found, err := repo.GetByID(999)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		found, err = repo.GetByUsername("nobody")
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("用户名唯一", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Create(&model.User{Username: "alice", PasswordHash: "hash"}))
+		assert.Error(t, repo.Create(&model.User{Username: "alice", PasswordHash: "hash"}))
+	})
+
+	t.Run("更新和删除", func(t *testing.T) {
+		repo := newRepo(t)
+		user := &model.User{Username: "alice", PasswordHash: "hash"}
+		require.NoError(t, repo.Create(user))
+
+		user.PasswordHash = "new hash"
+		require.NoError(t, repo.Update(user))
+		found, err := repo.GetCredentials(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "new hash", found.PasswordHash)
+
+		require.NoError(t, repo.Delete(user.ID))
+		found, err = repo.GetByID(user.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+}
+
+func TestTokenRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.TokenRepository{
+		"gorm": func(t *testing.T) repository.TokenRepository {
+			return repository.NewTokenRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.TokenRepository {
+			return repository.NewMemoryTokenRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			now := time.Now()
+
+			first := &model.RefreshToken{UserID: 1, TokenHash: "hash-1", FamilyID: "family", ExpiresAt: now.Add(time.Hour)}
+			second := &model.RefreshToken{UserID: 1, TokenHash: "hash-2", FamilyID: "family", ExpiresAt: now.Add(time.Hour)}
+			require.NoError(t, repo.CreateRefreshToken(first))
+			require.NoError(t, repo.CreateRefreshToken(second))
+			assert.Error(t, repo.CreateRefreshToken(&model.RefreshToken{UserID: 1, TokenHash: "hash-1", FamilyID: "other", ExpiresAt: now}))
+
+			found, err := repo.GetRefreshTokenByHash("hash-1")
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, first.ID, found.ID)
+			assert.Nil(t, found.RevokedAt)
+
+			found, err = repo.GetRefreshTokenByHash("missing")
+			assert.NoError(t, err)
+			assert.Nil(t, found)
+
+			// 只有第一次吊销成功
+			ok, err := repo.RevokeRefreshToken(first.ID, now)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			ok, err = repo.RevokeRefreshToken(first.ID, now)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, repo.RevokeRefreshTokenFamily("family", now))
+			family, err := repo.GetRefreshTokensByFamily("family")
+			require.NoError(t, err)
+			require.Len(t, family, 2)
+			for _, token := range family {
+				assert.NotNil(t, token.RevokedAt)
+			}
+
+			// 按用户吊销不影响其他用户
+			other := &model.RefreshToken{UserID: 1, TokenHash: "hash-3", FamilyID: "other", ExpiresAt: now.Add(time.Hour)}
+			require.NoError(t, repo.CreateRefreshToken(other))
+			require.NoError(t, repo.CreateRefreshToken(&model.RefreshToken{UserID: 2, TokenHash: "hash-4", FamilyID: "another", ExpiresAt: now.Add(time.Hour)}))
+			require.NoError(t, repo.RevokeUserRefreshTokens(1, now))
+			userTokens, err := repo.GetRefreshTokensByUser(1)
+			require.NoError(t, err)
+			require.Len(t, userTokens, 3)
+			for _, token := range userTokens {
+				assert.NotNil(t, token.RevokedAt)
+			}
+			found, err = repo.GetRefreshTokenByHash("hash-4")
+			require.NoError(t, err)
+			assert.Nil(t, found.RevokedAt)
+
+			revoked, err := repo.IsAccessTokenRevoked("access-1")
+			require.NoError(t, err)
+			assert.False(t, revoked)
+			require.NoError(t, repo.RevokeAccessToken(&model.RevokedToken{TokenID: "access-1", UserID: 1, ExpiresAt: now.Add(-time.Minute)}))
+			require.NoError(t, repo.RevokeAccessToken(&model.RevokedToken{TokenID: "access-1", UserID: 1, ExpiresAt: now.Add(-time.Minute)}))
+			revoked, err = repo.IsAccessTokenRevoked("access-1")
+			require.NoError(t, err)
+			assert.True(t, revoked)
+
+			// 清理过期记录
+			require.NoError(t, repo.DeleteExpired(now))
+			revoked, err = repo.IsAccessTokenRevoked("access-1")
+			require.NoError(t, err)
+			assert.False(t, revoked)
+			found, err = repo.GetRefreshTokenByHash("hash-1")
+			require.NoError(t, err)
+			assert.NotNil(t, found)
+		})
+	}
+}
+
+func TestProjectRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository){
+		"gorm": func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository) {
+			db := initTestDB(t)
+			return repository.NewTaskRepository(db), repository.NewProjectRepository(db)
+		},
+		"memory": func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository) {
+			return repository.NewMemoryTaskRepository(), repository.NewMemoryProjectRepository()
+		},
+	}
+
+	for name, newRepos := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("项目增删改查和排序", func(t *testing.T) {
+				_, projectRepo := newRepos(t)
+				work := &model.Project{UserID: 1, Name: "work", Position: 2}
+				home := &model.Project{UserID: 1, Name: "home", Position: 1, Archived: true}
+				require.NoError(t, projectRepo.Create(work))
+				require.NoError(t, projectRepo.Create(home))
+				require.NoError(t, projectRepo.Create(&model.Project{UserID: 2, Name: "other"}))
+				assert.NotZero(t, work.ID)
+
+				projects, err := projectRepo.GetByUserID(1, true)
+				require.NoError(t, err)
+				require.Len(t, projects, 2)
+				assert.Equal(t, "home", projects[0].Name)
+
+				projects, err = projectRepo.GetByUserID(1, false)
+				require.NoError(t, err)
+				require.Len(t, projects, 1)
+				assert.Equal(t, "work", projects[0].Name)
+
+				require.NoError(t, projectRepo.UpdatePositions(map[int]int{work.ID: 1, home.ID: 2}))
+				projects, err = projectRepo.GetByUserID(1, true)
+				require.NoError(t, err)
+				assert.Equal(t, "work", projects[0].Name)
+
+				work.Color = "#00ff00"
+				require.NoError(t, projectRepo.Update(work))
+				found, err := projectRepo.GetByID(work.ID)
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Equal(t, "#00ff00", found.Color)
+
+				require.NoError(t, projectRepo.Delete(work.ID))
+				found, err = projectRepo.GetByID(work.ID)
+				assert.NoError(t, err)
+				assert.Nil(t, found)
+			})
+
+			t.Run("任务项目过滤和移动", func(t *testing.T) {
+				taskRepo, projectRepo := newRepos(t)
+				work := &model.Project{UserID: 1, Name: "work"}
+				home := &model.Project{UserID: 1, Name: "home"}
+				require.NoError(t, projectRepo.Create(work))
+				require.NoError(t, projectRepo.Create(home))
+
+				first := &model.Task{UserID: 1, Title: "first", ProjectID: &work.ID}
+				second := &model.Task{UserID: 1, Title: "second", ProjectID: &work.ID}
+				require.NoError(t, taskRepo.Create(first))
+				require.NoError(t, taskRepo.Create(second))
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 1, Title: "third"}))
+
+				count := func(projectID int) int64 {
+					_, total, err := taskRepo.List(model.TaskFilter{UserID: 1, ProjectID: &projectID, Page: 1, PageSize: 10})
+					require.NoError(t, err)
+					return total
+				}
+				assert.Equal(t, int64(2), count(work.ID))
+				assert.Equal(t, int64(1), count(0))
+
+				require.NoError(t, taskRepo.UpdateProject([]int{first.ID}, &home.ID))
+				assert.Equal(t, int64(1), count(work.ID))
+				assert.Equal(t, int64(1), count(home.ID))
+
+				require.NoError(t, taskRepo.ClearProject(work.ID))
+				assert.Equal(t, int64(0), count(work.ID))
+				assert.Equal(t, int64(2), count(0))
+
+				found, err := taskRepo.GetByID(first.ID)
+				require.NoError(t, err)
+				require.NotNil(t, found.ProjectID)
+				assert.Equal(t, home.ID, *found.ProjectID)
+			})
+		})
+	}
+}
+
+func TestCollaboratorRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) (repository.TaskRepository, repository.CollaboratorRepository){
+		"gorm": func(t *testing.T) (repository.TaskRepository, repository.CollaboratorRepository) {
+			db := initTestDB(t)
+			return repository.NewTaskRepository(db), repository.NewCollaboratorRepository(db)
+		},
+		"memory": func(t *testing.T) (repository.TaskRepository, repository.CollaboratorRepository) {
+			return repository.NewMemoryTaskRepository(), repository.NewMemoryCollaboratorRepository()
+		},
+	}
+
+	for name, newRepos := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("协作者增删改查", func(t *testing.T) {
+				_, collabRepo := newRepos(t)
+				require.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: 1, UserID: 2, Role: model.TaskRoleViewer}))
+				require.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: 1, UserID: 3, Role: model.TaskRoleEditor}))
+				require.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: 2, UserID: 2, Role: model.TaskRoleEditor}))
+
+				// 重复保存时更新角色
+				require.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: 1, UserID: 2, Role: model.TaskRoleEditor}))
+				found, err := collabRepo.Get(1, 2)
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Equal(t, model.TaskRoleEditor, found.Role)
+
+				found, err = collabRepo.Get(1, 4)
+				assert.NoError(t, err)
+				assert.Nil(t, found)
+
+				collaborators, err := collabRepo.GetByTaskID(1)
+				require.NoError(t, err)
+				assert.Len(t, collaborators, 2)
+
+				collaborators, err = collabRepo.GetByUserID(2)
+				require.NoError(t, err)
+				require.Len(t, collaborators, 2)
+				assert.Equal(t, 1, collaborators[0].TaskID)
+
+				require.NoError(t, collabRepo.Delete(1, 3))
+				collaborators, err = collabRepo.GetByTaskID(1)
+				require.NoError(t, err)
+				assert.Len(t, collaborators, 1)
+
+				require.NoError(t, collabRepo.DeleteByTaskIDs([]int{1, 2}))
+				collaborators, err = collabRepo.GetByUserID(2)
+				require.NoError(t, err)
+				assert.Empty(t, collaborators)
+			})
+
+			t.Run("列表包含共享任务", func(t *testing.T) {
+				taskRepo, _ := newRepos(t)
+				shared := &model.Task{UserID: 1, Title: "shared"}
+				require.NoError(t, taskRepo.Create(shared))
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 1, Title: "private"}))
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 2, Title: "mine"}))
+
+				tasks, total, err := taskRepo.List(model.TaskFilter{UserID: 2, SharedTaskIDs: []int{shared.ID}, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+				require.Len(t, tasks, 2)
+				assert.Equal(t, "shared", tasks[0].Title)
+				assert.Equal(t, "mine", tasks[1].Title)
+
+				// 其他条件同样作用于共享任务
+				tasks, total, err = taskRepo.List(model.TaskFilter{UserID: 2, SharedTaskIDs: []int{shared.ID}, Keyword: "mine", Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				assert.Equal(t, "mine", tasks[0].Title)
+				mine := tasks[0]
+
+				// 只查询ID和版本号时范围相同，按ID排序
+				shared.Title = "renamed"
+				require.NoError(t, taskRepo.Update(shared))
+				versions, err := taskRepo.ListVersions(model.TaskFilter{UserID: 2, SharedTaskIDs: []int{shared.ID}})
+				require.NoError(t, err)
+				assert.Equal(t, []model.TaskVersion{{ID: shared.ID, Version: 2}, {ID: mine.ID, Version: 1}}, versions)
+
+				// IDs 限定在范围内的任务
+				tasks, total, err = taskRepo.List(model.TaskFilter{UserID: 2, SharedTaskIDs: []int{shared.ID}, IDs: []int{mine.ID, shared.ID + 100}, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				assert.Equal(t, mine.ID, tasks[0].ID)
+				versions, err = taskRepo.ListVersions(model.TaskFilter{UserID: 1, IDs: []int{mine.ID}})
+				require.NoError(t, err)
+				assert.Empty(t, versions)
+			})
+		})
+	}
+}
+
+func TestWorkspaceRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository, repository.WorkspaceRepository){
+		"gorm": func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository, repository.WorkspaceRepository) {
+			db := initTestDB(t)
+			return repository.NewTaskRepository(db), repository.NewProjectRepository(db), repository.NewWorkspaceRepository(db)
+		},
+		"memory": func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository, repository.WorkspaceRepository) {
+			return repository.NewMemoryTaskRepository(), repository.NewMemoryProjectRepository(), repository.NewMemoryWorkspaceRepository()
+		},
+	}
+
+	for name, newRepos := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("工作区与成员", func(t *testing.T) {
+				_, _, repo := newRepos(t)
+				workspace := &model.Workspace{Name: "团队", OwnerID: 1}
+				require.NoError(t, repo.Create(workspace, &model.WorkspaceMember{UserID: 1, Role: model.WorkspaceRoleOwner}))
+				require.NotZero(t, workspace.ID)
+				require.NoError(t, repo.Create(&model.Workspace{Name: "其他", OwnerID: 2}, &model.WorkspaceMember{UserID: 2, Role: model.WorkspaceRoleOwner}))
+
+				member, err := repo.GetMember(workspace.ID, 1)
+				require.NoError(t, err)
+				require.NotNil(t, member)
+				assert.Equal(t, model.WorkspaceRoleOwner, member.Role)
+
+				// 重复保存时更新角色
+				require.NoError(t, repo.SaveMember(&model.WorkspaceMember{WorkspaceID: workspace.ID, UserID: 2, Role: model.WorkspaceRoleGuest}))
+				require.NoError(t, repo.SaveMember(&model.WorkspaceMember{WorkspaceID: workspace.ID, UserID: 2, Role: model.WorkspaceRoleMember}))
+				members, err := repo.GetMembers(workspace.ID)
+				require.NoError(t, err)
+				require.Len(t, members, 2)
+				assert.Equal(t, model.WorkspaceRoleMember, members[1].Role)
+
+				workspaces, err := repo.GetByUserID(2)
+				require.NoError(t, err)
+				assert.Len(t, workspaces, 2)
+
+				workspace.Name = "新名称"
+				require.NoError(t, repo.Update(workspace))
+				found, err := repo.GetByID(workspace.ID)
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Equal(t, "新名称", found.Name)
+
+				require.NoError(t, repo.RemoveMember(workspace.ID, 2))
+				member, err = repo.GetMember(workspace.ID, 2)
+				assert.NoError(t, err)
+				assert.Nil(t, member)
+
+				found, err = repo.GetByID(999)
+				assert.NoError(t, err)
+				assert.Nil(t, found)
+			})
+
+			t.Run("邀请只能接受一次", func(t *testing.T) {
+				_, _, repo := newRepos(t)
+				workspace := &model.Workspace{Name: "团队", OwnerID: 1}
+				require.NoError(t, repo.Create(workspace, &model.WorkspaceMember{UserID: 1, Role: model.WorkspaceRoleOwner}))
+
+				invitation := &model.WorkspaceInvitation{WorkspaceID: workspace.ID, TokenHash: "hash", Role: model.WorkspaceRoleMember, InvitedBy: 1, ExpiresAt: time.Now().Add(time.Hour)}
+				require.NoError(t, repo.CreateInvitation(invitation))
+				found, err := repo.GetInvitationByTokenHash("hash")
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Nil(t, found.AcceptedAt)
+
+				now := time.Now()
+				for _, userID := range []int{2, 3} {
+					accepted := &model.WorkspaceInvitation{ID: found.ID, AcceptedBy: &userID, AcceptedAt: &now}
+					ok, err := repo.AcceptInvitation(accepted, &model.WorkspaceMember{WorkspaceID: workspace.ID, UserID: userID, Role: found.Role})
+					require.NoError(t, err)
+					assert.Equal(t, userID == 2, ok)
+				}
+
+				members, err := repo.GetMembers(workspace.ID)
+				require.NoError(t, err)
+				assert.Len(t, members, 2)
+				found, err = repo.GetInvitationByTokenHash("hash")
+				require.NoError(t, err)
+				require.NotNil(t, found.AcceptedBy)
+				assert.Equal(t, 2, *found.AcceptedBy)
+
+				found, err = repo.GetInvitationByTokenHash("missing")
+				assert.NoError(t, err)
+				assert.Nil(t, found)
+			})
+
+			t.Run("按工作区划分任务和项目", func(t *testing.T) {
+				taskRepo, projectRepo, _ := newRepos(t)
+				workspaceID := 1
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 1, Title: "personal"}))
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 1, WorkspaceID: &workspaceID, Title: "mine"}))
+				require.NoError(t, taskRepo.Create(&model.Task{UserID: 2, WorkspaceID: &workspaceID, Title: "teammate"}))
+				require.NoError(t, projectRepo.Create(&model.Project{UserID: 1, Name: "personal", Position: 1}))
+				require.NoError(t, projectRepo.Create(&model.Project{UserID: 2, WorkspaceID: &workspaceID, Name: "team", Position: 1}))
+
+				tasks, total, err := taskRepo.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				require.Len(t, tasks, 1)
+				assert.Equal(t, "personal", tasks[0].Title)
+
+				_, total, err = taskRepo.List(model.TaskFilter{UserID: 1, WorkspaceID: workspaceID, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+
+				projects, err := projectRepo.GetByUserID(1, true)
+				require.NoError(t, err)
+				assert.Len(t, projects, 1)
+				projects, err = projectRepo.GetByUserID(2, true)
+				require.NoError(t, err)
+				assert.Empty(t, projects)
+				projects, err = projectRepo.GetByWorkspaceID(workspaceID, true)
+				require.NoError(t, err)
+				require.Len(t, projects, 1)
+				assert.Equal(t, "team", projects[0].Name)
+			})
+		})
+	}
+}
+
+func TestAssigneeRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) (repository.TaskRepository, repository.AssigneeRepository){
+		"gorm": func(t *testing.T) (repository.TaskRepository, repository.AssigneeRepository) {
+			db := initTestDB(t)
+			return repository.NewTaskRepository(db), repository.NewAssigneeRepository(db)
+		},
+		"memory": func(t *testing.T) (repository.TaskRepository, repository.AssigneeRepository) {
+			return repository.NewMemoryTaskRepository(), repository.NewMemoryAssigneeRepository()
+		},
+	}
+
+	for name, newRepos := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("负责人增删改查", func(t *testing.T) {
+				_, repo := newRepos(t)
+				now := time.Now()
+				require.NoError(t, repo.Add(&model.TaskAssignee{TaskID: 1, UserID: 2, AssignedBy: 1, CreatedAt: now}))
+				require.NoError(t, repo.Add(&model.TaskAssignee{TaskID: 1, UserID: 3, AssignedBy: 1, CreatedAt: now.Add(time.Second)}))
+				// 重复添加不做修改
+				require.NoError(t, repo.Add(&model.TaskAssignee{TaskID: 1, UserID: 2, AssignedBy: 4, CreatedAt: now}))
+				require.NoError(t, repo.Add(&model.TaskAssignee{TaskID: 2, UserID: 2, AssignedBy: 1, CreatedAt: now}))
+
+				assignees, err := repo.GetByTaskIDs([]int{1})
+				require.NoError(t, err)
+				require.Len(t, assignees, 2)
+				assert.Equal(t, 2, assignees[0].UserID)
+				assert.Equal(t, 1, assignees[0].AssignedBy)
+
+				// 替换时保留仍在列表中的负责人
+				require.NoError(t, repo.Replace(1, []*model.TaskAssignee{
+					{TaskID: 1, UserID: 3, AssignedBy: 4, CreatedAt: now.Add(time.Minute)},
+					{TaskID: 1, UserID: 5, AssignedBy: 4, CreatedAt: now.Add(time.Minute)},
+				}))
+				assignees, err = repo.GetByTaskIDs([]int{1, 2})
+				require.NoError(t, err)
+				require.Len(t, assignees, 3)
+				assert.Equal(t, 3, assignees[0].UserID)
+				assert.Equal(t, 1, assignees[0].AssignedBy)
+				assert.Equal(t, 5, assignees[1].UserID)
+				assert.Equal(t, 2, assignees[2].TaskID)
+
+				assigned, err := repo.GetByUserID(2)
+				require.NoError(t, err)
+				require.Len(t, assigned, 1)
+				assert.Equal(t, 2, assigned[0].TaskID)
+
+				require.NoError(t, repo.Delete(1, 3))
+				require.NoError(t, repo.Replace(2, nil))
+				assignees, err = repo.GetByTaskIDs([]int{1, 2})
+				require.NoError(t, err)
+				require.Len(t, assignees, 1)
+
+				require.NoError(t, repo.DeleteByTaskIDs([]int{1}))
+				assignees, err = repo.GetByTaskIDs([]int{1})
+				require.NoError(t, err)
+				assert.Empty(t, assignees)
+			})
+
+			t.Run("按负责人过滤任务", func(t *testing.T) {
+				taskRepo, _ := newRepos(t)
+				workspaceID := 1
+				personal := &model.Task{UserID: 1, Title: "personal"}
+				others := &model.Task{UserID: 2, Title: "others"}
+				team := &model.Task{UserID: 2, WorkspaceID: &workspaceID, Title: "team"}
+				for _, task := range []*model.Task{personal, others, team} {
+					require.NoError(t, taskRepo.Create(task))
+				}
+
+				_, total, err := taskRepo.List(model.TaskFilter{UserID: 1, AssigneeID: 1, AssignedTaskIDs: []int{}, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(0), total)
+
+				tasks, total, err := taskRepo.List(model.TaskFilter{UserID: 1, AssigneeID: 1, AssignedTaskIDs: []int{personal.ID, team.ID}, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				assert.Equal(t, personal.ID, tasks[0].ID)
+
+				// 跨空间时只按指派范围过滤
+				tasks, total, err = taskRepo.List(model.TaskFilter{UserID: 1, AssigneeID: 1, AssignedTaskIDs: []int{others.ID, team.ID}, AllScopes: true, Page: 1, PageSize: 10})
+				require.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+				assert.Equal(t, others.ID, tasks[0].ID)
+			})
+		})
+	}
+}
+
+func TestCommentRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.CommentRepository{
+		"gorm": func(t *testing.T) repository.CommentRepository {
+			return repository.NewCommentRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.CommentRepository {
+			return repository.NewMemoryCommentRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("评论分页和删除", func(t *testing.T) {
+				repo := newRepo(t)
+				now := time.Now()
+				for i := 0; i < 5; i++ {
+					comment := &model.Comment{TaskID: 1, UserID: 1, Body: fmt.Sprintf("评论%d", i), CreatedAt: now.Add(time.Duration(i) * time.Second)}
+					require.NoError(t, repo.Create(comment))
+				}
+				require.NoError(t, repo.Create(&model.Comment{TaskID: 2, UserID: 1, Body: "其他任务", CreatedAt: now}))
+
+				comments, total, err := repo.ListByTaskID(1, 2, 2)
+				require.NoError(t, err)
+				assert.Equal(t, int64(5), total)
+				require.Len(t, comments, 2)
+				assert.Equal(t, "评论2", comments[0].Body)
+				assert.Equal(t, "评论3", comments[1].Body)
+
+				// 删除只标记删除时间，已删除的评论不再返回
+				comment, err := repo.GetByID(comments[0].ID)
+				require.NoError(t, err)
+				require.NotNil(t, comment)
+				deletedAt := time.Now()
+				comment.DeletedAt = &deletedAt
+				require.NoError(t, repo.Update(comment))
+
+				deleted, err := repo.GetByID(comment.ID)
+				require.NoError(t, err)
+				assert.Nil(t, deleted)
+				comments, total, err = repo.ListByTaskID(1, 1, 10)
+				require.NoError(t, err)
+				assert.Equal(t, int64(4), total)
+				assert.Len(t, comments, 4)
+
+				require.NoError(t, repo.DeleteByTaskIDs([]int{1}))
+				_, total, err = repo.ListByTaskID(1, 1, 10)
+				require.NoError(t, err)
+				assert.Zero(t, total)
+				_, total, err = repo.ListByTaskID(2, 1, 10)
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+			})
+		})
+	}
+}
+
+func TestHistoryRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.HistoryRepository{
+		"gorm": func(t *testing.T) repository.HistoryRepository {
+			return repository.NewHistoryRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.HistoryRepository {
+			return repository.NewMemoryHistoryRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("追加和分页获取", func(t *testing.T) {
+				repo := newRepo(t)
+				now := time.Now()
+				require.NoError(t, repo.Create(&model.TaskHistory{TaskID: 1, UserID: 1, Action: model.TaskHistoryActionCreate, CreatedAt: now,
+					Changes: []model.FieldChange{{Field: "title", After: "写周报"}, {Field: "priority", After: 2}}}))
+				require.NoError(t, repo.Create(&model.TaskHistory{TaskID: 1, UserID: 2, Action: model.TaskHistoryActionUpdate, CreatedAt: now.Add(time.Second),
+					Changes: []model.FieldChange{{Field: "tags", Before: []string{}, After: []string{"工作"}}}}))
+				require.NoError(t, repo.Create(&model.TaskHistory{TaskID: 1, UserID: 2, Action: model.TaskHistoryActionUpdate, CreatedAt: now.Add(2 * time.Second),
+					Changes: []model.FieldChange{{Field: "status", Before: "done", After: "todo"}}}))
+				require.NoError(t, repo.Create(&model.TaskHistory{TaskID: 2, UserID: 1, Action: model.TaskHistoryActionDelete, CreatedAt: now}))
+
+				// 最新的排在前面
+				entries, total, err := repo.ListByTaskID(1, 1, 2)
+				require.NoError(t, err)
+				assert.Equal(t, int64(3), total)
+				require.Len(t, entries, 2)
+				assert.Equal(t, model.TaskHistoryActionUpdate, entries[0].Action)
+				assert.Equal(t, []model.FieldChange{{Field: "status", Before: "done", After: "todo"}}, entries[0].Changes)
+				assert.Equal(t, []model.FieldChange{{Field: "tags", Before: []any{}, After: []any{"工作"}}}, entries[1].Changes)
+
+				// 变更值与 JSON 解码后的类型一致
+				entries, _, err = repo.ListByTaskID(1, 2, 2)
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				assert.Equal(t, model.TaskHistoryActionCreate, entries[0].Action)
+				assert.Equal(t, []model.FieldChange{{Field: "title", After: "写周报"}, {Field: "priority", After: float64(2)}}, entries[0].Changes)
+
+				entries, total, err = repo.ListByTaskID(2, 1, 10)
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), total)
+				assert.Empty(t, entries[0].Changes)
+			})
+		})
+	}
+}
+
+func TestIdempotencyRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.IdempotencyRepository{
+		"gorm": func(t *testing.T) repository.IdempotencyRepository {
+			return repository.NewIdempotencyRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.IdempotencyRepository {
+			return repository.NewMemoryIdempotencyRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("占用、保存响应和过期", func(t *testing.T) {
+				repo := newRepo(t)
+				now := time.Now().Truncate(time.Millisecond)
+				record := &model.IdempotencyRecord{UserID: 1, Key: "key", Fingerprint: "a", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+				created, err := repo.Create(record)
+				require.NoError(t, err)
+				assert.True(t, created)
+
+				// 同一用户的幂等键只能占用一次，其他用户不受影响
+				created, err = repo.Create(&model.IdempotencyRecord{UserID: 1, Key: "key", Fingerprint: "b", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+				require.NoError(t, err)
+				assert.False(t, created)
+				created, err = repo.Create(&model.IdempotencyRecord{UserID: 2, Key: "key", Fingerprint: "b", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+				require.NoError(t, err)
+				assert.True(t, created)
+
+				found, err := repo.Get(1, "key", now)
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Equal(t, "a", found.Fingerprint)
+				assert.False(t, found.IsCompleted())
+
+				record.StatusCode = 201
+				record.Headers = map[string]string{"Content-Type": "application/json"}
+				record.Body = `{"id":1}`
+				require.NoError(t, repo.Complete(record))
+				found, err = repo.Get(1, "key", now)
+				require.NoError(t, err)
+				assert.Equal(t, 201, found.StatusCode)
+				assert.Equal(t, record.Headers, found.Headers)
+				assert.Equal(t, record.Body, found.Body)
+
+				// 过期后不再返回，可以重新占用
+				found, err = repo.Get(1, "key", now.Add(time.Hour))
+				require.NoError(t, err)
+				assert.Nil(t, found)
+				created, err = repo.Create(&model.IdempotencyRecord{UserID: 1, Key: "key", Fingerprint: "c", CreatedAt: now.Add(time.Hour), ExpiresAt: now.Add(2 * time.Hour)})
+				require.NoError(t, err)
+				assert.True(t, created)
+
+				// 删除后可以重新占用
+				require.NoError(t, repo.Delete(2, "key"))
+				found, err = repo.Get(2, "key", now)
+				require.NoError(t, err)
+				assert.Nil(t, found)
+
+				require.NoError(t, repo.DeleteExpired(now.Add(3*time.Hour)))
+				found, err = repo.Get(1, "key", now.Add(time.Hour))
+				require.NoError(t, err)
+				assert.Nil(t, found)
+			})
+		})
+	}
+}
+
+func TestWebhookRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.WebhookRepository{
+		"gorm": func(t *testing.T) repository.WebhookRepository {
+			return repository.NewWebhookRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.WebhookRepository {
+			return repository.NewMemoryWebhookRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Webhook 的增删改查", func(t *testing.T) {
+				repo := newRepo(t)
+				first := &model.Webhook{UserID: 1, URL: "https://example.com/a", Secret: "s1", Events: []string{"task.created"}, Active: true}
+				require.NoError(t, repo.Create(first))
+				assert.NotZero(t, first.ID)
+				second := &model.Webhook{UserID: 1, URL: "https://example.com/b", Secret: "s2", Events: []string{"task.deleted"}, Active: false}
+				require.NoError(t, repo.Create(second))
+				require.NoError(t, repo.Create(&model.Webhook{UserID: 2, URL: "https://example.com/c", Secret: "s3", Events: []string{"task.updated"}, Active: true}))
+
+				found, err := repo.GetByID(first.ID)
+				require.NoError(t, err)
+				require.NotNil(t, found)
+				assert.Equal(t, "s1", found.Secret)
+				assert.Equal(t, []string{"task.created"}, found.Events)
+				assert.True(t, found.Active)
+
+				webhooks, err := repo.ListByUserID(1)
+				require.NoError(t, err)
+				require.Len(t, webhooks, 2)
+				assert.Equal(t, first.ID, webhooks[0].ID)
+				assert.False(t, webhooks[1].Active)
+
+				// 只返回启用的 Webhook
+				active, err := repo.ListActiveByUserIDs([]int{1, 2})
+				require.NoError(t, err)
+				require.Len(t, active, 2)
+				assert.Equal(t, first.ID, active[0].ID)
+				assert.Equal(t, 2, active[1].UserID)
+				active, err = repo.ListActiveByUserIDs(nil)
+				require.NoError(t, err)
+				assert.Empty(t, active)
+
+				found.Events = []string{"task.created", "task.completed"}
+				found.Active = false
+				require.NoError(t, repo.Update(found))
+				found, err = repo.GetByID(first.ID)
+				require.NoError(t, err)
+				assert.Equal(t, []string{"task.created", "task.completed"}, found.Events)
+				assert.False(t, found.Active)
+
+				// 删除时一并删除投递记录
+				now := time.Now()
+				require.NoError(t, repo.CreateDelivery(&model.WebhookDelivery{WebhookID: first.ID, EventID: 1, EventType: "task.created", Payload: "{}", Status: model.WebhookDeliveryPending, NextAttemptAt: &now}))
+				require.NoError(t, repo.Delete(first.ID))
+				found, err = repo.GetByID(first.ID)
+				require.NoError(t, err)
+				assert.Nil(t, found)
+				_, total, err := repo.ListDeliveries(first.ID, 1, 10)
+				require.NoError(t, err)
+				assert.Zero(t, total)
+			})
+
+			t.Run("投递记录", func(t *testing.T) {
+				repo := newRepo(t)
+				webhook := &model.Webhook{UserID: 1, URL: "https://example.com", Secret: "s", Events: []string{"task.created"}, Active: true}
+				require.NoError(t, repo.Create(webhook))
+
+				now := time.Now().Truncate(time.Millisecond)
+				later := now.Add(time.Minute)
+				due := &model.WebhookDelivery{WebhookID: webhook.ID, EventID: 1, EventType: "task.created", Payload: `{"id":1}`, Status: model.WebhookDeliveryPending, NextAttemptAt: &now}
+				require.NoError(t, repo.CreateDelivery(due))
+				require.NoError(t, repo.CreateDelivery(&model.WebhookDelivery{WebhookID: webhook.ID, EventID: 2, EventType: "task.created", Payload: `{"id":2}`, Status: model.WebhookDeliveryPending, NextAttemptAt: &later}))
+				require.NoError(t, repo.CreateDelivery(&model.WebhookDelivery{WebhookID: webhook.ID, EventID: 3, EventType: "task.created", Payload: `{"id":3}`, Status: model.WebhookDeliverySucceeded}))
+
+				deliveries, total, err := repo.ListDeliveries(webhook.ID, 1, 2)
+				require.NoError(t, err)
+				assert.Equal(t, int64(3), total)
+				require.Len(t, deliveries, 2)
+				assert.Equal(t, int64(3), deliveries[0].EventID)
+				assert.Equal(t, int64(2), deliveries[1].EventID)
+
+				// 只领取到期的记录，领取后在租期内不会被再次领取
+				lease := now.Add(30 * time.Second)
+				claimed, err := repo.ClaimDueDeliveries(now, lease, 10)
+				require.NoError(t, err)
+				require.Len(t, claimed, 1)
+				assert.Equal(t, due.ID, claimed[0].ID)
+				assert.Equal(t, `{"id":1}`, claimed[0].Payload)
+				claimed, err = repo.ClaimDueDeliveries(now, lease, 10)
+				require.NoError(t, err)
+				assert.Empty(t, claimed)
+
+				// 租期结束后重新领取，按到期时间排序
+				claimed, err = repo.ClaimDueDeliveries(later, later.Add(30*time.Second), 10)
+				require.NoError(t, err)
+				require.Len(t, claimed, 2)
+				assert.Equal(t, due.ID, claimed[0].ID)
+
+				delivered := now.Add(time.Minute)
+				claimed[0].Status = model.WebhookDeliverySucceeded
+				claimed[0].Attempts = 1
+				claimed[0].ResponseStatus = 204
+				claimed[0].NextAttemptAt = nil
+				claimed[0].DeliveredAt = &delivered
+				require.NoError(t, repo.UpdateDelivery(claimed[0]))
+				deliveries, _, err = repo.ListDeliveries(webhook.ID, 1, 10)
+				require.NoError(t, err)
+				require.Len(t, deliveries, 3)
+				assert.Equal(t, model.WebhookDeliverySucceeded, deliveries[2].Status)
+				assert.Equal(t, 204, deliveries[2].ResponseStatus)
+				assert.Nil(t, deliveries[2].NextAttemptAt)
+				require.NotNil(t, deliveries[2].DeliveredAt)
+				assert.True(t, delivered.Equal(*deliveries[2].DeliveredAt))
+			})
+		})
+	}
+}
+
+func TestCalendarTokenRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.CalendarTokenRepository{
+		"gorm": func(t *testing.T) repository.CalendarTokenRepository {
+			return repository.NewCalendarTokenRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.CalendarTokenRepository {
+			return repository.NewMemoryCalendarTokenRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			now := time.Now().Truncate(time.Second)
+
+			found, err := repo.GetByUserID(1)
+			require.NoError(t, err)
+			assert.Nil(t, found)
+
+			require.NoError(t, repo.Save(&model.CalendarToken{UserID: 1, TokenHash: "hash-1", CreatedAt: now}))
+			require.NoError(t, repo.Save(&model.CalendarToken{UserID: 2, TokenHash: "hash-2", CreatedAt: now}))
+			// 不同用户的令牌哈希不能相同
+			assert.Error(t, repo.Save(&model.CalendarToken{UserID: 3, TokenHash: "hash-1", CreatedAt: now}))
+
+			found, err = repo.GetByHash("hash-1")
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, 1, found.UserID)
+
+			// 再次保存时替换旧令牌
+			require.NoError(t, repo.Save(&model.CalendarToken{UserID: 1, TokenHash: "hash-3", CreatedAt: now.Add(time.Minute)}))
+			found, err = repo.GetByHash("hash-1")
+			require.NoError(t, err)
+			assert.Nil(t, found)
+			found, err = repo.GetByUserID(1)
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, "hash-3", found.TokenHash)
+			assert.True(t, found.CreatedAt.Equal(now.Add(time.Minute)))
+
+			require.NoError(t, repo.Delete(1))
+			found, err = repo.GetByHash("hash-3")
+			require.NoError(t, err)
+			assert.Nil(t, found)
+			found, err = repo.GetByUserID(2)
+			require.NoError(t, err)
+			assert.NotNil(t, found)
+		})
+	}
+}
+
+func TestCalDAVRepositoryConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) repository.CalDAVRepository{
+		"gorm": func(t *testing.T) repository.CalDAVRepository {
+			return repository.NewCalDAVRepository(initTestDB(t))
+		},
+		"memory": func(t *testing.T) repository.CalDAVRepository {
+			return repository.NewMemoryCalDAVRepository()
+		},
+	}
+
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+
+			first := &model.CalDAVObject{UserID: 1, TaskID: 1, Name: "a.ics", UID: "a", ETag: `"1-1"`, SyncSeq: 1}
+			second := &model.CalDAVObject{UserID: 1, TaskID: 2, Name: "b.ics", UID: "b", ETag: `"2-1"`, SyncSeq: 1}
+			other := &model.CalDAVObject{UserID: 2, TaskID: 1, Name: "a.ics", UID: "a", ETag: `"1-1"`, SyncSeq: 1}
+			require.NoError(t, repo.Save(first, second))
+			require.NoError(t, repo.Save(other))
+			assert.NotZero(t, first.ID)
+			assert.NotEqual(t, first.ID, second.ID)
+
+			// 同一用户的资源名称和任务都不能重复
+			assert.Error(t, repo.Save(&model.CalDAVObject{UserID: 1, TaskID: 3, Name: "a.ics", UID: "c", ETag: `"3-1"`}))
+			assert.Error(t, repo.Save(&model.CalDAVObject{UserID: 1, TaskID: 2, Name: "c.ics", UID: "c", ETag: `"2-1"`}))
+
+			objects, err := repo.ListByUserID(1)
+			require.NoError(t, err)
+			require.Len(t, objects, 2)
+			assert.Equal(t, "a.ics", objects[0].Name)
+			assert.Equal(t, "b.ics", objects[1].Name)
+
+			second.Deleted = true
+			second.SyncSeq = 2
+			require.NoError(t, repo.Save(second))
+			found, err := repo.GetByName(1, "b.ics")
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.True(t, found.Deleted)
+			assert.Equal(t, int64(2), found.SyncSeq)
+			assert.Equal(t, "b", found.UID)
+
+			found, err = repo.GetByName(1, "missing.ics")
+			require.NoError(t, err)
+			assert.Nil(t, found)
+			found, err = repo.GetByName(3, "a.ics")
+			require.NoError(t, err)
+			assert.Nil(t, found)
+		})
+	}
+}
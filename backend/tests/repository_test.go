@@ -80,12 +80,13 @@ func TestTaskRepository(t *testing.T) {
 	taskRepo := repository.NewTaskRepository(db)
 
 	// 创建测试任务
+	dueDate := time.Now().Add(24 * time.Hour)
 	task := &model.Task{
 		UserID:      1,
 		Title:       "Test Task",
 		Description: "Test Description",
 		Status:      model.TaskStatusTodo,
-		DueDate:     time.Now().Add(24 * time.Hour),
+		DueDate:     &dueDate,
 	}
 
 	// 测试创建任务
@@ -123,7 +124,7 @@ func TestTaskRepository(t *testing.T) {
 
 	// 测试获取用户任务列表
 	t.Run("测试获取用户任务列表", func(t *testing.T) {
-		tasks, total, err := taskRepo.GetByUserID(1, "", 1, 10)
+		tasks, total, err := taskRepo.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
 		assert.NoError(t, err)
 		assert.NotZero(t, total)
 		assert.NotEmpty(t, tasks)
@@ -139,3 +140,28 @@ func TestTaskRepository(t *testing.T) {
 		assert.Nil(t, found)
 	})
 }
+
+func TestTagRepositoryDeleteDetachesTasks(t *testing.T) {
+	db := initTestDB(t)
+	defer cleanupTestDB(t, db)
+
+	tagRepo := repository.NewTagRepository(db)
+	taskRepo := repository.NewTaskRepository(db)
+
+	tag := &model.Tag{UserID: 1, Name: "work"}
+	if err := tagRepo.Create(tag); err != nil {
+		t.Fatalf("创建标签失败: %v", err)
+	}
+	task := &model.Task{UserID: 1, Title: "task", Tags: []model.Tag{*tag}}
+	if err := taskRepo.Create(task); err != nil {
+		t.Fatalf("创建任务失败: %v", err)
+	}
+
+	// 删除标签时同时清理任务关联
+	if err := tagRepo.Delete(tag.ID); err != nil {
+		t.Fatalf("删除标签失败: %v", err)
+	}
+	found, err := taskRepo.GetByID(task.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, found.Tags)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/pkg/rrule"
+)
+
+// 用例取自 RFC 5545 第 3.8.5.3 节的示例，时区改为 UTC
+func TestRRuleOccurrences(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		limit   int
+		want    []time.Time
+	}{
+		{
+			name:    "每天，共5次",
+			rule:    "FREQ=DAILY;COUNT=5",
+			dtstart: date(1997, 9, 2),
+			limit:   10,
+			want:    []time.Time{date(1997, 9, 2), date(1997, 9, 3), date(1997, 9, 4), date(1997, 9, 5), date(1997, 9, 6)},
+		},
+		{
+			name:    "每隔一周的周二和周四",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;WKST=SU;BYDAY=TU,TH",
+			dtstart: date(1997, 9, 2),
+			limit:   6,
+			want:    []time.Time{date(1997, 9, 2), date(1997, 9, 4), date(1997, 9, 16), date(1997, 9, 18), date(1997, 9, 30), date(1997, 10, 2)},
+		},
+		{
+			name:    "每月第一个周五",
+			rule:    "FREQ=MONTHLY;COUNT=4;BYDAY=1FR",
+			dtstart: date(1997, 9, 5),
+			limit:   10,
+			want:    []time.Time{date(1997, 9, 5), date(1997, 10, 3), date(1997, 11, 7), date(1997, 12, 5)},
+		},
+		{
+			name:    "每隔一个月的第一个和最后一个周日",
+			rule:    "FREQ=MONTHLY;INTERVAL=2;COUNT=6;BYDAY=1SU,-1SU",
+			dtstart: date(1997, 9, 7),
+			limit:   10,
+			want:    []time.Time{date(1997, 9, 7), date(1997, 9, 28), date(1997, 11, 2), date(1997, 11, 30), date(1998, 1, 4), date(1998, 1, 25)},
+		},
+		{
+			name:    "每月31日跳过没有31日的月份",
+			rule:    "FREQ=MONTHLY;COUNT=4",
+			dtstart: date(2024, 1, 31),
+			limit:   10,
+			want:    []time.Time{date(2024, 1, 31), date(2024, 3, 31), date(2024, 5, 31), date(2024, 7, 31)},
+		},
+		{
+			name:    "每年2月29日",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: date(2024, 2, 29),
+			limit:   10,
+			want:    []time.Time{date(2024, 2, 29), date(2028, 2, 29)},
+		},
+		{
+			name:    "每年最后一个周五",
+			rule:    "FREQ=YEARLY;BYDAY=-1FR",
+			dtstart: date(2023, 12, 29),
+			limit:   3,
+			want:    []time.Time{date(2023, 12, 29), date(2024, 12, 27), date(2025, 12, 26)},
+		},
+		{
+			name:    "UNTIL 包含当天",
+			rule:    "FREQ=WEEKLY;UNTIL=19970916",
+			dtstart: date(1997, 9, 2),
+			limit:   10,
+			want:    []time.Time{date(1997, 9, 2), date(1997, 9, 9), date(1997, 9, 16)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := rrule.Parse(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.Occurrences(tt.dtstart, tt.limit))
+		})
+	}
+}
+
+func TestRRuleUntilDateTime(t *testing.T) {
+	rule, err := rrule.Parse("RRULE:FREQ=DAILY;UNTIL=19971224T000000Z")
+	require.NoError(t, err)
+
+	dtstart := time.Date(1997, 9, 2, 9, 0, 0, 0, time.UTC)
+	occurrences := rule.Occurrences(dtstart, 1000)
+	assert.Len(t, occurrences, 113)
+	assert.Equal(t, time.Date(1997, 12, 23, 9, 0, 0, 0, time.UTC), occurrences[len(occurrences)-1])
+}
+
+func TestRRuleFloatingUntil(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*3600)
+
+	// 浮动日期时间按 DTSTART 的时区解释，而不是 UTC
+	rule, err := rrule.Parse("FREQ=DAILY;UNTIL=20240105T200000")
+	require.NoError(t, err)
+	occurrences := rule.Occurrences(time.Date(2024, 1, 1, 20, 0, 0, 0, zone), 10)
+	require.Len(t, occurrences, 5)
+	assert.Equal(t, time.Date(2024, 1, 5, 20, 0, 0, 0, zone), occurrences[4])
+	assert.Equal(t, "FREQ=DAILY;UNTIL=20240105T200000", rule.String())
+
+	// 纯日期包含 DTSTART 时区中的整天
+	rule, err = rrule.Parse("FREQ=DAILY;UNTIL=20240103")
+	require.NoError(t, err)
+	occurrences = rule.Occurrences(time.Date(2024, 1, 1, 23, 30, 0, 0, zone), 10)
+	require.Len(t, occurrences, 3)
+	assert.Equal(t, time.Date(2024, 1, 3, 23, 30, 0, 0, zone), occurrences[2])
+	assert.Equal(t, "FREQ=DAILY;UNTIL=20240103", rule.String())
+
+	// UTC 日期时间不受 DTSTART 时区影响
+	rule, err = rrule.Parse("FREQ=DAILY;UNTIL=20240105T200000Z")
+	require.NoError(t, err)
+	assert.Len(t, rule.Occurrences(time.Date(2024, 1, 1, 20, 0, 0, 0, zone), 10), 4)
+}
+
+func TestRRuleAfter(t *testing.T) {
+	rule, err := rrule.Parse("FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4")
+	require.NoError(t, err)
+
+	// 2024-01-01 是周一
+	dtstart := time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)
+	next, ok := rule.After(dtstart, dtstart)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 3, 8, 30, 0, 0, time.UTC), next)
+
+	next, ok = rule.After(dtstart, time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 8, 8, 30, 0, 0, time.UTC), next)
+
+	// 第4次之后序列结束
+	_, ok = rule.After(dtstart, next)
+	assert.False(t, ok)
+}
+
+func TestRRuleParse(t *testing.T) {
+	rule, err := rrule.Parse("freq=monthly;interval=2;byday=-1fr,2MO;count=3")
+	require.NoError(t, err)
+	assert.Equal(t, rrule.Monthly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []rrule.WeekdayNum{{N: -1, Weekday: time.Friday}, {N: 2, Weekday: time.Monday}}, rule.ByDay)
+	assert.Equal(t, "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,2MO;COUNT=3", rule.String())
+
+	rule, err = rrule.Parse("FREQ=DAILY;UNTIL=20240131T235959Z")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=DAILY;UNTIL=20240131T235959Z", rule.String())
+
+	invalid := map[string]error{
+		"":                                  rrule.ErrMissingFreq,
+		"INTERVAL=2":                        rrule.ErrMissingFreq,
+		"FREQ=HOURLY":                       rrule.ErrUnsupportedRule,
+		"FREQ=DAILY;BYMONTH=1":              rrule.ErrUnsupportedRule,
+		"FREQ=DAILY;INTERVAL=0":             rrule.ErrInvalidRule,
+		"FREQ=DAILY;COUNT=-1":               rrule.ErrInvalidRule,
+		"FREQ=DAILY;FREQ=WEEKLY":            rrule.ErrInvalidRule,
+		"FREQ=WEEKLY;BYDAY=XX":              rrule.ErrInvalidRule,
+		"FREQ=WEEKLY;BYDAY=1MO":             rrule.ErrOrdinalNotAllowed,
+		"FREQ=DAILY;UNTIL=tomorrow":         rrule.ErrInvalidRule,
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101": rrule.ErrUntilAndCount,
+	}
+	for text, want := range invalid {
+		_, err := rrule.Parse(text)
+		assert.ErrorIs(t, err, want, text)
+	}
+}
@@ -1,28 +1,38 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"todolist/config"
+	"todolist/internal/cache"
+	"todolist/internal/event"
 	"todolist/internal/model"
 	"todolist/internal/repository"
 	"todolist/internal/service"
+	"todolist/pkg/jwt"
 )
 
 func setupTestService(t *testing.T) (service.UserService, service.TaskService) {
-	// 初始化测试数据库
-	db := initTestDB(t)
-	defer cleanupTestDB(t, db)
+	// 加载配置
+	err := config.LoadConfig("../config/config.yaml")
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
 
-	// 创建仓储实例
-	userRepo := repository.NewUserRepository(db)
-	taskRepo := repository.NewTaskRepository(db)
+	// 使用内存仓储，服务测试无需数据库
+	userRepo := repository.NewMemoryUserRepository()
+	taskRepo := repository.NewMemoryTaskRepository()
+	tokenRepo := repository.NewMemoryTokenRepository()
 
 	// 创建服务实例
-	userService := service.NewUserService(userRepo)
-	taskService := service.NewTaskService(taskRepo)
+	userService := service.NewUserService(userRepo, tokenRepo)
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
 
 	return userService, taskService
 }
@@ -48,18 +58,119 @@ func TestUserService(t *testing.T) {
 		_, err := userService.Login("test_user", "wrong_password")
 		assert.Error(t, err)
 	})
+
+	// 测试刷新令牌轮换
+	t.Run("测试刷新令牌轮换", func(t *testing.T) {
+		pair, err := userService.Login("test_user", "password123")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, pair.AccessToken)
+		assert.NotEmpty(t, pair.RefreshToken)
+		assert.Equal(t, "Bearer", pair.TokenType)
+		assert.Positive(t, pair.ExpiresIn)
+
+		refreshed, err := userService.Refresh(pair.RefreshToken)
+		assert.NoError(t, err)
+		assert.NotEqual(t, pair.RefreshToken, refreshed.RefreshToken)
+		assert.NotEqual(t, pair.AccessToken, refreshed.AccessToken)
+
+		// 新的刷新令牌可以继续使用
+		_, err = userService.Refresh(refreshed.RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	// 测试刷新令牌重放
+	t.Run("测试刷新令牌重放", func(t *testing.T) {
+		pair, err := userService.Login("test_user", "password123")
+		assert.NoError(t, err)
+
+		refreshed, err := userService.Refresh(pair.RefreshToken)
+		assert.NoError(t, err)
+
+		// 再次使用已轮换的令牌会吊销整个令牌族
+		_, err = userService.Refresh(pair.RefreshToken)
+		assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+		_, err = userService.Refresh(refreshed.RefreshToken)
+		assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+		// 该令牌族签发的访问令牌同样失效
+		claims, err := jwt.ParseToken(refreshed.AccessToken)
+		assert.NoError(t, err)
+		revoked, err := userService.IsTokenRevoked(claims.Id)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	// 测试无效刷新令牌
+	t.Run("测试无效刷新令牌", func(t *testing.T) {
+		_, err := userService.Refresh("not-a-refresh-token")
+		assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+	})
+
+	// 测试退出登录
+	t.Run("测试退出登录", func(t *testing.T) {
+		pair, err := userService.Login("test_user", "password123")
+		assert.NoError(t, err)
+		claims, err := jwt.ParseToken(pair.AccessToken)
+		assert.NoError(t, err)
+
+		err = userService.Logout(claims.UserID, claims.Id, time.Unix(claims.ExpiresAt, 0), pair.RefreshToken)
+		assert.NoError(t, err)
+
+		revoked, err := userService.IsTokenRevoked(claims.Id)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		_, err = userService.Refresh(pair.RefreshToken)
+		assert.Error(t, err)
+	})
+
+	// 测试修改密码吊销全部会话
+	t.Run("测试修改密码吊销全部会话", func(t *testing.T) {
+		first, err := userService.Login("test_user", "password123")
+		assert.NoError(t, err)
+		second, err := userService.Login("test_user", "password123")
+		assert.NoError(t, err)
+		// 已轮换的刷新令牌签发的访问令牌在修改密码前仍然有效
+		rotated, err := userService.Refresh(second.RefreshToken)
+		assert.NoError(t, err)
+
+		claims, err := jwt.ParseToken(first.AccessToken)
+		assert.NoError(t, err)
+		assert.NoError(t, userService.UpdatePassword(claims.UserID, "password123", "new_password"))
+
+		_, err = userService.Refresh(first.RefreshToken)
+		assert.Error(t, err, "修改密码之前的刷新令牌不能再使用")
+		_, err = userService.Refresh(rotated.RefreshToken)
+		assert.Error(t, err)
+
+		for _, accessToken := range []string{first.AccessToken, second.AccessToken, rotated.AccessToken} {
+			claims, err := jwt.ParseToken(accessToken)
+			assert.NoError(t, err)
+			revoked, err := userService.IsTokenRevoked(claims.Id)
+			assert.NoError(t, err)
+			assert.True(t, revoked, "修改密码之前的访问令牌应被吊销")
+		}
+
+		// 使用新密码登录签发的令牌不受影响
+		pair, err := userService.Login("test_user", "new_password")
+		assert.NoError(t, err)
+		_, err = userService.Refresh(pair.RefreshToken)
+		assert.NoError(t, err)
+	})
 }
 
 func TestTaskService(t *testing.T) {
 	_, taskService := setupTestService(t)
 
 	// 创建测试任务
+	dueDate := time.Now().Add(24 * time.Hour)
 	task := &model.Task{
 		UserID:      1,
 		Title:       "Test Task",
 		Description: "Test Description",
 		Status:      model.TaskStatusTodo,
-		DueDate:     time.Now().Add(24 * time.Hour),
+		DueDate:     &dueDate,
 	}
 
 	// 测试创建任务
@@ -93,7 +204,7 @@ func TestTaskService(t *testing.T) {
 
 	// 测试获取用户任务列表
 	t.Run("测试获取用户任务列表", func(t *testing.T) {
-		tasks, total, err := taskService.List(task.UserID, "", 1, 10)
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: task.UserID, Page: 1, PageSize: 10})
 		assert.NoError(t, err)
 		assert.NotZero(t, total)
 		assert.NotEmpty(t, tasks)
@@ -101,11 +212,1360 @@ func TestTaskService(t *testing.T) {
 
 	// 测试删除任务
 	t.Run("测试删除任务", func(t *testing.T) {
-		err := taskService.Delete(task.ID, task.UserID)
+		err := taskService.Delete(task.ID, task.UserID, service.DeleteOptions{})
 		assert.NoError(t, err)
 
 		found, err := taskService.Get(task.ID, task.UserID)
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, service.ErrTaskNotFound)
 		assert.Nil(t, found)
 	})
 }
+
+func TestTagService(t *testing.T) {
+	tagRepo := repository.NewMemoryTagRepository()
+	tagService := service.NewTagService(tagRepo)
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), tagRepo, repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+
+	work := &model.Tag{UserID: 1, Name: " work ", Color: "#3366ff"}
+
+	t.Run("测试创建标签", func(t *testing.T) {
+		err := tagService.Create(work)
+		assert.NoError(t, err)
+		assert.Equal(t, "work", work.Name)
+
+		assert.Equal(t, service.ErrTagExists, tagService.Create(&model.Tag{UserID: 1, Name: "work"}))
+		assert.Equal(t, service.ErrEmptyTagName, tagService.Create(&model.Tag{UserID: 1, Name: "  "}))
+		assert.Equal(t, service.ErrInvalidTagColor, tagService.Create(&model.Tag{UserID: 1, Name: "home", Color: "blue"}))
+
+		// 不同用户可以使用相同的标签名
+		assert.NoError(t, tagService.Create(&model.Tag{UserID: 2, Name: "work"}))
+	})
+
+	t.Run("测试其他用户无法访问标签", func(t *testing.T) {
+		_, err := tagService.Get(work.ID, 2)
+		assert.Equal(t, service.ErrTagAccessDenied, err)
+	})
+
+	t.Run("测试任务使用标签", func(t *testing.T) {
+		task := &model.Task{UserID: 1, Title: "task", Priority: model.TaskPriorityHigh, Tags: []model.Tag{{ID: work.ID}}}
+		assert.NoError(t, taskService.Create(task))
+		assert.Equal(t, "work", task.Tags[0].Name)
+
+		// 不能使用其他用户的标签
+		other := &model.Task{UserID: 2, Title: "task", Tags: []model.Tag{{ID: work.ID}}}
+		assert.Equal(t, service.ErrInvalidTags, taskService.Create(other))
+
+		invalid := &model.Task{UserID: 1, Title: "task", Priority: 9}
+		assert.Equal(t, service.ErrInvalidPriority, taskService.Create(invalid))
+
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: 1, Priorities: []int{model.TaskPriorityHigh}, TagID: work.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, tasks, 1)
+
+		// 空切片清空标签
+		update := &model.Task{ID: task.ID, UserID: 1, Tags: []model.Tag{}}
+		assert.NoError(t, taskService.Update(update))
+		assert.Empty(t, update.Tags)
+		assert.Equal(t, model.TaskPriorityHigh, update.Priority)
+	})
+
+	t.Run("测试删除标签", func(t *testing.T) {
+		assert.Equal(t, service.ErrTagAccessDenied, tagService.Delete(work.ID, 2))
+		assert.NoError(t, tagService.Delete(work.ID, 1))
+		_, err := tagService.Get(work.ID, 1)
+		assert.Equal(t, service.ErrTagNotFound, err)
+	})
+}
+
+func TestTaskServiceSubtasks(t *testing.T) {
+	_, taskService := setupTestService(t)
+
+	create := func(title string, parentID *int) *model.Task {
+		task := &model.Task{UserID: 1, Title: title, ParentID: parentID}
+		assert.NoError(t, taskService.Create(task))
+		return task
+	}
+	root := create("发布版本", nil)
+	build := create("构建", &root.ID)
+	test := create("测试", &root.ID)
+	unit := create("单元测试", &test.ID)
+
+	t.Run("测试获取子任务和完成情况", func(t *testing.T) {
+		children, err := taskService.Subtasks(root.ID, 1)
+		assert.NoError(t, err)
+		assert.Len(t, children, 2)
+		assert.Equal(t, &model.SubtaskProgress{Total: 1, Done: 0}, children[1].Subtasks)
+
+		found, err := taskService.Get(root.ID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.SubtaskProgress{Total: 2, Done: 0}, found.Subtasks)
+
+		_, err = taskService.Subtasks(root.ID, 2)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+	})
+
+	t.Run("测试层级限制和环检测", func(t *testing.T) {
+		err := taskService.Create(&model.Task{UserID: 1, Title: "太深", ParentID: &unit.ID})
+		assert.Equal(t, service.ErrTaskDepthExceeded, err)
+
+		err = taskService.Create(&model.Task{UserID: 2, Title: "他人任务", ParentID: &root.ID})
+		assert.Equal(t, service.ErrInvalidParentTask, err)
+
+		err = taskService.Update(&model.Task{ID: root.ID, UserID: 1, ParentID: &unit.ID})
+		assert.Equal(t, service.ErrTaskCycle, err)
+
+		err = taskService.Update(&model.Task{ID: root.ID, UserID: 1, ParentID: &root.ID})
+		assert.Equal(t, service.ErrTaskCycle, err)
+
+		// 两层的子树挂到第二层任务下会超过限制
+		err = taskService.Update(&model.Task{ID: test.ID, UserID: 1, ParentID: &build.ID})
+		assert.Equal(t, service.ErrTaskDepthExceeded, err)
+	})
+
+	t.Run("测试子任务完成后汇总到父任务", func(t *testing.T) {
+		assert.NoError(t, taskService.Update(&model.Task{ID: build.ID, UserID: 1, Status: model.TaskStatusDone}))
+		assert.NoError(t, taskService.Update(&model.Task{ID: unit.ID, UserID: 1, Status: model.TaskStatusDone}))
+
+		found, err := taskService.Get(test.ID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskStatusDone, found.Status)
+
+		found, err = taskService.Get(root.ID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskStatusDone, found.Status)
+		assert.Equal(t, &model.SubtaskProgress{Total: 2, Done: 2}, found.Subtasks)
+
+		// 新增未完成的子任务后父任务重新打开
+		create("回归测试", &test.ID)
+		found, err = taskService.Get(root.ID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskStatusInProgress, found.Status)
+	})
+
+	t.Run("测试移动子任务", func(t *testing.T) {
+		zero := 0
+		assert.NoError(t, taskService.Update(&model.Task{ID: build.ID, UserID: 1, ParentID: &zero}))
+
+		found, err := taskService.Get(build.ID, 1)
+		assert.NoError(t, err)
+		assert.Nil(t, found.ParentID)
+
+		roots, _, err := taskService.List(model.TaskFilter{UserID: 1, ParentID: &zero, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Len(t, roots, 2)
+	})
+
+	t.Run("测试删除存在子任务的任务", func(t *testing.T) {
+		err := taskService.Delete(root.ID, 1, service.DeleteOptions{})
+		assert.Equal(t, service.ErrTaskHasSubtasks, err)
+
+		assert.NoError(t, taskService.Delete(root.ID, 1, service.DeleteOptions{Cascade: true}))
+		for _, id := range []int{root.ID, test.ID, unit.ID} {
+			_, err := taskService.Get(id, 1)
+			assert.Equal(t, service.ErrTaskNotFound, err)
+		}
+		_, err = taskService.Get(build.ID, 1)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTaskServiceRecurrence(t *testing.T) {
+	_, taskService := setupTestService(t)
+	weekly := "freq=weekly;byday=mo,th;count=3"
+	dueDate := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC) // 周一
+
+	task := &model.Task{UserID: 1, Title: "周会", Priority: model.TaskPriorityHigh, DueDate: &dueDate, Recurrence: &weekly}
+
+	t.Run("测试创建重复任务", func(t *testing.T) {
+		assert.NoError(t, taskService.Create(task))
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3", *task.Recurrence)
+
+		invalid := "FREQ=HOURLY"
+		err := taskService.Create(&model.Task{UserID: 1, Title: "invalid", DueDate: &dueDate, Recurrence: &invalid})
+		assert.ErrorIs(t, err, service.ErrInvalidRecurrence)
+
+		err = taskService.Create(&model.Task{UserID: 1, Title: "no due date", Recurrence: &weekly})
+		assert.Equal(t, service.ErrRecurrenceNoDue, err)
+	})
+
+	t.Run("测试完成后生成下一次任务", func(t *testing.T) {
+		update := &model.Task{ID: task.ID, UserID: 1, Status: model.TaskStatusDone}
+		assert.NoError(t, taskService.Update(update))
+		assert.Nil(t, update.Recurrence)
+
+		next := update.NextOccurrence
+		if assert.NotNil(t, next) {
+			assert.NotEqual(t, task.ID, next.ID)
+			assert.Equal(t, "周会", next.Title)
+			assert.Equal(t, model.TaskStatusTodo, next.Status)
+			assert.Equal(t, model.TaskPriorityHigh, next.Priority)
+			assert.Equal(t, time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC), *next.DueDate)
+			assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=2", *next.Recurrence)
+		}
+
+		// 第二次完成后生成最后一次
+		update = &model.Task{ID: next.ID, UserID: 1, Status: model.TaskStatusDone}
+		assert.NoError(t, taskService.Update(update))
+		last := update.NextOccurrence
+		if assert.NotNil(t, last) {
+			assert.Equal(t, time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC), *last.DueDate)
+			assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=1", *last.Recurrence)
+		}
+
+		// COUNT 用尽后不再生成
+		update = &model.Task{ID: last.ID, UserID: 1, Status: model.TaskStatusDone}
+		assert.NoError(t, taskService.Update(update))
+		assert.Nil(t, update.NextOccurrence)
+
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Len(t, tasks, 3)
+	})
+
+	t.Run("测试取消重复", func(t *testing.T) {
+		daily := "FREQ=DAILY"
+		task := &model.Task{UserID: 1, Title: "打卡", DueDate: &dueDate, Recurrence: &daily}
+		assert.NoError(t, taskService.Create(task))
+
+		empty := ""
+		update := &model.Task{ID: task.ID, UserID: 1, Recurrence: &empty}
+		assert.NoError(t, taskService.Update(update))
+		assert.Nil(t, update.Recurrence)
+
+		update = &model.Task{ID: task.ID, UserID: 1, Status: model.TaskStatusDone}
+		assert.NoError(t, taskService.Update(update))
+		assert.Nil(t, update.NextOccurrence)
+	})
+}
+
+func TestProjectService(t *testing.T) {
+	taskRepo := repository.NewMemoryTaskRepository()
+	projectRepo := repository.NewMemoryProjectRepository()
+	projectService := service.NewProjectService(projectRepo, taskRepo, repository.NewMemoryWorkspaceRepository())
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), projectRepo, repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+
+	work := &model.Project{UserID: 1, Name: " 工作 ", Color: "#3366ff"}
+	home := &model.Project{UserID: 1, Name: "家庭"}
+
+	t.Run("测试创建项目", func(t *testing.T) {
+		assert.NoError(t, projectService.Create(work))
+		assert.NoError(t, projectService.Create(home))
+		assert.Equal(t, "工作", work.Name)
+		assert.Equal(t, 1, work.Position)
+		assert.Equal(t, 2, home.Position)
+
+		assert.Equal(t, service.ErrEmptyProjectName, projectService.Create(&model.Project{UserID: 1, Name: " "}))
+		assert.Equal(t, service.ErrInvalidProjectColor, projectService.Create(&model.Project{UserID: 1, Name: "x", Color: "red"}))
+	})
+
+	t.Run("测试其他用户无法访问项目", func(t *testing.T) {
+		_, err := projectService.Get(work.ID, 2)
+		assert.Equal(t, service.ErrProjectAccessDenied, err)
+
+		task := &model.Task{UserID: 2, Title: "task", ProjectID: &work.ID}
+		assert.Equal(t, service.ErrInvalidProject, taskService.Create(task))
+	})
+
+	t.Run("测试调整项目顺序", func(t *testing.T) {
+		projects, err := projectService.Reorder(1, 0, []int{home.ID})
+		assert.NoError(t, err)
+		if assert.Len(t, projects, 2) {
+			assert.Equal(t, home.ID, projects[0].ID)
+			assert.Equal(t, work.ID, projects[1].ID)
+		}
+
+		_, err = projectService.Reorder(1, 0, []int{home.ID, home.ID})
+		assert.Equal(t, service.ErrDuplicateProjectSort, err)
+		_, err = projectService.Reorder(2, 0, []int{home.ID})
+		assert.Equal(t, service.ErrProjectNotFound, err)
+	})
+
+	t.Run("测试在项目间移动任务", func(t *testing.T) {
+		parent := &model.Task{UserID: 1, Title: "parent", ProjectID: &work.ID}
+		assert.NoError(t, taskService.Create(parent))
+		child := &model.Task{UserID: 1, Title: "child", ParentID: &parent.ID}
+		assert.NoError(t, taskService.Create(child))
+		// 子任务继承父任务的项目
+		assert.Equal(t, work.ID, *child.ProjectID)
+
+		assert.Equal(t, service.ErrSubtaskProject, taskService.Update(&model.Task{ID: child.ID, UserID: 1, ProjectID: &home.ID}))
+
+		// 移动父任务时子任务一起移动
+		assert.NoError(t, taskService.Update(&model.Task{ID: parent.ID, UserID: 1, ProjectID: &home.ID}))
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: 1, ProjectID: &home.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, tasks, 2)
+
+		// 指向0表示移出项目
+		none := 0
+		assert.NoError(t, taskService.Update(&model.Task{ID: parent.ID, UserID: 1, ProjectID: &none}))
+		_, total, err = taskService.List(model.TaskFilter{UserID: 1, ProjectID: &none, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+	})
+
+	t.Run("测试归档项目", func(t *testing.T) {
+		project, err := projectService.SetArchived(work.ID, 1, true)
+		assert.NoError(t, err)
+		assert.True(t, project.Archived)
+
+		projects, err := projectService.List(1, 0, false)
+		assert.NoError(t, err)
+		assert.Len(t, projects, 1)
+
+		task := &model.Task{UserID: 1, Title: "task", ProjectID: &work.ID}
+		assert.Equal(t, service.ErrProjectArchived, taskService.Create(task))
+	})
+
+	t.Run("测试删除项目", func(t *testing.T) {
+		task := &model.Task{UserID: 1, Title: "task", ProjectID: &home.ID}
+		assert.NoError(t, taskService.Create(task))
+
+		assert.Equal(t, service.ErrProjectAccessDenied, projectService.Delete(home.ID, 2))
+		assert.NoError(t, projectService.Delete(home.ID, 1))
+
+		found, err := taskService.Get(task.ID, 1)
+		assert.NoError(t, err)
+		assert.Nil(t, found.ProjectID)
+	})
+}
+
+func TestTaskSharing(t *testing.T) {
+	userRepo := repository.NewMemoryUserRepository()
+	taskRepo := repository.NewMemoryTaskRepository()
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+	collaboratorService := service.NewCollaboratorService(taskRepo, collabRepo, userRepo, repository.NewMemoryWorkspaceRepository())
+
+	owner := &model.User{Username: "owner", PasswordHash: "hash"}
+	editor := &model.User{Username: "editor", PasswordHash: "hash"}
+	viewer := &model.User{Username: "viewer", PasswordHash: "hash"}
+	for _, user := range []*model.User{owner, editor, viewer} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+
+	task := &model.Task{UserID: owner.ID, Title: "共享任务"}
+	assert.NoError(t, taskService.Create(task))
+	child := &model.Task{UserID: owner.ID, Title: "子任务", ParentID: &task.ID}
+	assert.NoError(t, taskService.Create(child))
+	assert.NoError(t, taskService.Create(&model.Task{UserID: owner.ID, Title: "私有任务"}))
+
+	t.Run("测试邀请协作者", func(t *testing.T) {
+		_, err := taskService.Get(task.ID, editor.ID)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+
+		collaborator, err := collaboratorService.Invite(task.ID, owner.ID, "editor", model.TaskRoleEditor)
+		assert.NoError(t, err)
+		assert.Equal(t, "editor", collaborator.Username)
+		_, err = collaboratorService.Invite(task.ID, owner.ID, "viewer", model.TaskRoleViewer)
+		assert.NoError(t, err)
+
+		_, err = collaboratorService.Invite(task.ID, owner.ID, "owner", model.TaskRoleViewer)
+		assert.Equal(t, service.ErrCollaboratorIsOwner, err)
+		_, err = collaboratorService.Invite(task.ID, owner.ID, "nobody", model.TaskRoleViewer)
+		assert.Equal(t, service.ErrUserNotFound, err)
+		_, err = collaboratorService.Invite(task.ID, owner.ID, "viewer", model.TaskRoleOwner)
+		assert.Equal(t, service.ErrInvalidCollaboratorRole, err)
+		_, err = collaboratorService.Invite(task.ID, editor.ID, "viewer", model.TaskRoleEditor)
+		assert.Equal(t, service.ErrTaskOwnerOnly, err)
+
+		collaborators, err := collaboratorService.List(task.ID, viewer.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, collaborators, 3) {
+			assert.Equal(t, model.TaskRoleOwner, collaborators[0].Role)
+			assert.Equal(t, "owner", collaborators[0].Username)
+		}
+	})
+
+	t.Run("测试共享任务的访问权限", func(t *testing.T) {
+		found, err := taskService.Get(task.ID, viewer.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskRoleViewer, found.Role)
+
+		// 子任务一并共享
+		found, err = taskService.Get(child.ID, editor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskRoleEditor, found.Role)
+
+		update := &model.Task{ID: task.ID, UserID: viewer.ID, Title: "改名"}
+		assert.Equal(t, service.ErrTaskReadOnly, taskService.Update(update))
+
+		update = &model.Task{ID: task.ID, UserID: editor.ID, Title: "改名", Status: model.TaskStatusInProgress}
+		assert.NoError(t, taskService.Update(update))
+		assert.Equal(t, "改名", update.Title)
+		assert.Equal(t, model.TaskRoleEditor, update.Role)
+
+		// 只有所有者可以移动和删除任务
+		top := 0
+		assert.Equal(t, service.ErrTaskOwnerOnly, taskService.Update(&model.Task{ID: child.ID, UserID: editor.ID, ParentID: &top}))
+		assert.Equal(t, service.ErrTaskOwnerOnly, taskService.Delete(task.ID, editor.ID, service.DeleteOptions{Cascade: true}))
+	})
+
+	t.Run("测试列表包含共享任务", func(t *testing.T) {
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: viewer.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		for _, task := range tasks {
+			assert.Equal(t, model.TaskRoleViewer, task.Role)
+		}
+
+		mine := &model.Task{UserID: viewer.ID, Title: "自己的任务"}
+		assert.NoError(t, taskService.Create(mine))
+		tasks, total, err = taskService.List(model.TaskFilter{UserID: viewer.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, model.TaskRoleOwner, tasks[len(tasks)-1].Role)
+	})
+
+	t.Run("测试移除协作者", func(t *testing.T) {
+		// 协作者可以退出共享，但不能移除其他人
+		assert.Equal(t, service.ErrTaskOwnerOnly, collaboratorService.Remove(task.ID, viewer.ID, editor.ID))
+		assert.NoError(t, collaboratorService.Remove(task.ID, viewer.ID, viewer.ID))
+		_, err := taskService.Get(task.ID, viewer.ID)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+
+		assert.Equal(t, service.ErrCollaboratorNotFound, collaboratorService.Remove(task.ID, owner.ID, viewer.ID))
+		assert.NoError(t, collaboratorService.Remove(task.ID, owner.ID, editor.ID))
+		_, total, err := taskService.List(model.TaskFilter{UserID: editor.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+}
+
+func TestWorkspaceService(t *testing.T) {
+	userRepo := repository.NewMemoryUserRepository()
+	taskRepo := repository.NewMemoryTaskRepository()
+	projectRepo := repository.NewMemoryProjectRepository()
+	workspaceRepo := repository.NewMemoryWorkspaceRepository()
+	workspaceService := service.NewWorkspaceService(workspaceRepo, userRepo)
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), projectRepo, repository.NewMemoryCollaboratorRepository(), workspaceRepo, repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+	projectService := service.NewProjectService(projectRepo, taskRepo, workspaceRepo)
+
+	owner := &model.User{Username: "owner", PasswordHash: "hash"}
+	admin := &model.User{Username: "admin", PasswordHash: "hash"}
+	member := &model.User{Username: "member", PasswordHash: "hash"}
+	guest := &model.User{Username: "guest", PasswordHash: "hash"}
+	outsider := &model.User{Username: "outsider", PasswordHash: "hash"}
+	for _, user := range []*model.User{owner, admin, member, guest, outsider} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+
+	workspace := &model.Workspace{Name: " 团队 ", OwnerID: owner.ID}
+	assert.NoError(t, workspaceService.Create(workspace))
+	assert.Equal(t, "团队", workspace.Name)
+	assert.Equal(t, model.WorkspaceRoleOwner, workspace.Role)
+	assert.Equal(t, service.ErrEmptyWorkspaceName, workspaceService.Create(&model.Workspace{Name: " ", OwnerID: owner.ID}))
+
+	join := func(inviter *model.User, user *model.User, role string) {
+		invitation, err := workspaceService.Invite(workspace.ID, inviter.ID, role)
+		if assert.NoError(t, err) {
+			_, err = workspaceService.AcceptInvitation(invitation.Token, user.ID)
+			assert.NoError(t, err)
+		}
+	}
+
+	t.Run("测试邀请加入", func(t *testing.T) {
+		invitation, err := workspaceService.Invite(workspace.ID, owner.ID, model.WorkspaceRoleAdmin)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, invitation.Token)
+
+		joined, err := workspaceService.AcceptInvitation(invitation.Token, admin.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.WorkspaceRoleAdmin, joined.Role)
+
+		// 邀请只能使用一次
+		_, err = workspaceService.AcceptInvitation(invitation.Token, member.ID)
+		assert.Equal(t, service.ErrInvalidInvitation, err)
+		_, err = workspaceService.AcceptInvitation("invalid", member.ID)
+		assert.Equal(t, service.ErrInvalidInvitation, err)
+
+		// 管理员不能邀请同级角色，访客不能邀请
+		_, err = workspaceService.Invite(workspace.ID, admin.ID, model.WorkspaceRoleAdmin)
+		assert.Equal(t, service.ErrWorkspacePermission, err)
+		_, err = workspaceService.Invite(workspace.ID, owner.ID, model.WorkspaceRoleOwner)
+		assert.Equal(t, service.ErrInvalidWorkspaceRole, err)
+		_, err = workspaceService.Invite(workspace.ID, outsider.ID, model.WorkspaceRoleGuest)
+		assert.Equal(t, service.ErrNotWorkspaceMember, err)
+
+		join(admin, member, model.WorkspaceRoleMember)
+		join(admin, guest, model.WorkspaceRoleGuest)
+
+		invitation, err = workspaceService.Invite(workspace.ID, owner.ID, model.WorkspaceRoleGuest)
+		assert.NoError(t, err)
+		_, err = workspaceService.AcceptInvitation(invitation.Token, member.ID)
+		assert.Equal(t, service.ErrAlreadyWorkspaceMember, err)
+
+		members, err := workspaceService.Members(workspace.ID, guest.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, members, 4) {
+			assert.Equal(t, "owner", members[0].Username)
+		}
+		workspaces, err := workspaceService.List(member.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, workspaces, 1) {
+			assert.Equal(t, model.WorkspaceRoleMember, workspaces[0].Role)
+		}
+	})
+
+	t.Run("测试邀请过期", func(t *testing.T) {
+		sum := sha256.Sum256([]byte("expired-token"))
+		expired := &model.WorkspaceInvitation{
+			WorkspaceID: workspace.ID,
+			TokenHash:   hex.EncodeToString(sum[:]),
+			Role:        model.WorkspaceRoleGuest,
+			InvitedBy:   owner.ID,
+			ExpiresAt:   time.Now().Add(-time.Minute),
+		}
+		assert.NoError(t, workspaceRepo.CreateInvitation(expired))
+
+		_, err := workspaceService.AcceptInvitation("expired-token", outsider.ID)
+		assert.Equal(t, service.ErrInvitationExpired, err)
+		role, err := workspaceService.MemberRole(workspace.ID, outsider.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, role)
+	})
+
+	t.Run("测试成员角色管理", func(t *testing.T) {
+		_, err := workspaceService.UpdateMemberRole(workspace.ID, admin.ID, owner.ID, model.WorkspaceRoleMember)
+		assert.Equal(t, service.ErrWorkspaceOwnerRole, err)
+		_, err = workspaceService.UpdateMemberRole(workspace.ID, member.ID, guest.ID, model.WorkspaceRoleMember)
+		assert.Equal(t, service.ErrWorkspacePermission, err)
+		_, err = workspaceService.UpdateMemberRole(workspace.ID, admin.ID, guest.ID, model.WorkspaceRoleAdmin)
+		assert.Equal(t, service.ErrWorkspacePermission, err)
+
+		updated, err := workspaceService.UpdateMemberRole(workspace.ID, admin.ID, guest.ID, model.WorkspaceRoleMember)
+		assert.NoError(t, err)
+		assert.Equal(t, model.WorkspaceRoleMember, updated.Role)
+		_, err = workspaceService.UpdateMemberRole(workspace.ID, admin.ID, guest.ID, model.WorkspaceRoleGuest)
+		assert.NoError(t, err)
+
+		assert.Equal(t, service.ErrWorkspaceOwnerRole, workspaceService.RemoveMember(workspace.ID, owner.ID, owner.ID))
+		assert.Equal(t, service.ErrWorkspaceMemberNotFound, workspaceService.RemoveMember(workspace.ID, admin.ID, outsider.ID))
+
+		_, err = workspaceService.Rename(workspace.ID, member.ID, "新名称")
+		assert.Equal(t, service.ErrWorkspacePermission, err)
+		renamed, err := workspaceService.Rename(workspace.ID, admin.ID, "新名称")
+		assert.NoError(t, err)
+		assert.Equal(t, "新名称", renamed.Name)
+	})
+
+	t.Run("测试工作区任务权限", func(t *testing.T) {
+		wsID := workspace.ID
+		task := &model.Task{UserID: member.ID, WorkspaceID: &wsID, Title: "团队任务"}
+		assert.NoError(t, taskService.Create(task))
+		assert.Equal(t, service.ErrWorkspacePermission, taskService.Create(&model.Task{UserID: guest.ID, WorkspaceID: &wsID, Title: "task"}))
+		assert.Equal(t, service.ErrNotWorkspaceMember, taskService.Create(&model.Task{UserID: outsider.ID, WorkspaceID: &wsID, Title: "task"}))
+		assert.NoError(t, taskService.Create(&model.Task{UserID: member.ID, Title: "个人任务"}))
+
+		// 访客只读，管理员拥有所有者权限
+		found, err := taskService.Get(task.ID, guest.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskRoleViewer, found.Role)
+		assert.Equal(t, service.ErrTaskReadOnly, taskService.Update(&model.Task{ID: task.ID, UserID: guest.ID, Title: "改名"}))
+		_, err = taskService.Get(task.ID, outsider.ID)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: admin.ID, WorkspaceID: wsID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, model.TaskRoleOwner, tasks[0].Role)
+
+		// 个人空间不包含工作区任务
+		_, total, err = taskService.List(model.TaskFilter{UserID: member.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		_, _, err = taskService.List(model.TaskFilter{UserID: outsider.ID, WorkspaceID: wsID, Page: 1, PageSize: 10})
+		assert.Equal(t, service.ErrNotWorkspaceMember, err)
+
+		// 个人任务不能挂到工作区任务下
+		assert.Equal(t, service.ErrInvalidParentTask, taskService.Create(&model.Task{UserID: member.ID, Title: "task", ParentID: &task.ID}))
+		subtask := &model.Task{UserID: admin.ID, WorkspaceID: &wsID, Title: "子任务", ParentID: &task.ID}
+		assert.NoError(t, taskService.Create(subtask))
+
+		assert.NoError(t, taskService.Delete(task.ID, admin.ID, service.DeleteOptions{Cascade: true}))
+	})
+
+	t.Run("测试工作区项目权限", func(t *testing.T) {
+		wsID := workspace.ID
+		assert.Equal(t, service.ErrWorkspacePermission, projectService.Create(&model.Project{UserID: member.ID, WorkspaceID: &wsID, Name: "项目"}))
+		project := &model.Project{UserID: admin.ID, WorkspaceID: &wsID, Name: "项目"}
+		assert.NoError(t, projectService.Create(project))
+
+		projects, err := projectService.List(guest.ID, wsID, false)
+		assert.NoError(t, err)
+		assert.Len(t, projects, 1)
+		projects, err = projectService.List(admin.ID, 0, false)
+		assert.NoError(t, err)
+		assert.Empty(t, projects)
+
+		_, err = projectService.Get(project.ID, outsider.ID)
+		assert.Equal(t, service.ErrProjectAccessDenied, err)
+		_, err = projectService.SetArchived(project.ID, member.ID, true)
+		assert.Equal(t, service.ErrWorkspacePermission, err)
+
+		// 工作区任务只能放入同一工作区的项目
+		task := &model.Task{UserID: member.ID, WorkspaceID: &wsID, Title: "task", ProjectID: &project.ID}
+		assert.NoError(t, taskService.Create(task))
+		assert.Equal(t, service.ErrInvalidProject, taskService.Create(&model.Task{UserID: admin.ID, Title: "task", ProjectID: &project.ID}))
+	})
+
+	t.Run("测试离开工作区后失去自己创建的任务的权限", func(t *testing.T) {
+		leaver := &model.User{Username: "leaver", PasswordHash: "hash"}
+		assert.NoError(t, userRepo.Create(leaver))
+		join(owner, leaver, model.WorkspaceRoleMember)
+
+		wsID := workspace.ID
+		task := &model.Task{UserID: leaver.ID, WorkspaceID: &wsID, Title: "自己的团队任务"}
+		assert.NoError(t, taskService.Create(task))
+		found, err := taskService.Get(task.ID, leaver.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskRoleOwner, found.Role)
+
+		// 降为访客后只读
+		_, err = workspaceService.UpdateMemberRole(workspace.ID, owner.ID, leaver.ID, model.WorkspaceRoleGuest)
+		assert.NoError(t, err)
+		found, err = taskService.Get(task.ID, leaver.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskRoleViewer, found.Role)
+		_, err = taskService.Patch(task.ID, leaver.ID, model.TaskPatch{Title: model.Some("改名")})
+		assert.Equal(t, service.ErrTaskReadOnly, err)
+		tasks, _, err := taskService.List(model.TaskFilter{UserID: leaver.ID, WorkspaceID: wsID, Page: 1, PageSize: -1})
+		assert.NoError(t, err)
+		for _, listed := range tasks {
+			assert.Equal(t, model.TaskRoleViewer, listed.Role)
+		}
+
+		// 移出工作区后无权访问
+		assert.NoError(t, workspaceService.RemoveMember(workspace.ID, owner.ID, leaver.ID))
+		_, err = taskService.Get(task.ID, leaver.ID)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+		_, err = taskService.Patch(task.ID, leaver.ID, model.TaskPatch{Title: model.Some("改名")})
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+		assert.Equal(t, service.ErrTaskAccessDenied, taskService.Delete(task.ID, leaver.ID, service.DeleteOptions{}))
+
+		// 任务仍由工作区管理
+		found, err = taskService.Get(task.ID, admin.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "自己的团队任务", found.Title)
+	})
+}
+
+func TestTaskAssignees(t *testing.T) {
+	userRepo := repository.NewMemoryUserRepository()
+	taskRepo := repository.NewMemoryTaskRepository()
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	workspaceRepo := repository.NewMemoryWorkspaceRepository()
+	assigneeRepo := repository.NewMemoryAssigneeRepository()
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, workspaceRepo, assigneeRepo, repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+	collaboratorService := service.NewCollaboratorService(taskRepo, collabRepo, userRepo, workspaceRepo)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, userRepo)
+
+	owner := &model.User{Username: "owner", PasswordHash: "hash"}
+	editor := &model.User{Username: "editor", PasswordHash: "hash"}
+	viewer := &model.User{Username: "viewer", PasswordHash: "hash"}
+	outsider := &model.User{Username: "outsider", PasswordHash: "hash"}
+	for _, user := range []*model.User{owner, editor, viewer, outsider} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+
+	task := &model.Task{UserID: owner.ID, Title: "共享任务"}
+	assert.NoError(t, taskService.Create(task))
+	_, err := collaboratorService.Invite(task.ID, owner.ID, "editor", model.TaskRoleEditor)
+	assert.NoError(t, err)
+	_, err = collaboratorService.Invite(task.ID, owner.ID, "viewer", model.TaskRoleViewer)
+	assert.NoError(t, err)
+
+	workspace := &model.Workspace{Name: "团队", OwnerID: owner.ID}
+	assert.NoError(t, workspaceService.Create(workspace))
+	assert.NoError(t, workspaceRepo.SaveMember(&model.WorkspaceMember{WorkspaceID: workspace.ID, UserID: viewer.ID, Role: model.WorkspaceRoleMember}))
+	teamTask := &model.Task{UserID: owner.ID, WorkspaceID: &workspace.ID, Title: "团队任务"}
+	assert.NoError(t, taskService.Create(teamTask))
+
+	t.Run("测试指派权限", func(t *testing.T) {
+		updated, err := taskService.SetAssignees(task.ID, owner.ID, []int{viewer.ID, viewer.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{viewer.ID}, updated.Assignees)
+
+		// 负责人必须有权访问任务，只读协作者不能指派
+		_, err = taskService.AddAssignee(task.ID, owner.ID, outsider.ID)
+		assert.Equal(t, service.ErrInvalidAssignee, err)
+		_, err = taskService.AddAssignee(task.ID, owner.ID, 999)
+		assert.Equal(t, service.ErrInvalidAssignee, err)
+		_, err = taskService.AddAssignee(task.ID, viewer.ID, viewer.ID)
+		assert.Equal(t, service.ErrTaskReadOnly, err)
+
+		updated, err = taskService.AddAssignee(task.ID, editor.ID, editor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{viewer.ID, editor.ID}, updated.Assignees)
+
+		found, err := taskService.Get(task.ID, owner.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{viewer.ID, editor.ID}, found.Assignees)
+
+		// 工作区成员可以指派给其他成员
+		_, err = taskService.AddAssignee(teamTask.ID, viewer.ID, viewer.ID)
+		assert.NoError(t, err)
+		_, err = taskService.AddAssignee(teamTask.ID, owner.ID, editor.ID)
+		assert.Equal(t, service.ErrInvalidAssignee, err)
+	})
+
+	t.Run("测试按负责人过滤", func(t *testing.T) {
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: owner.ID, AssigneeID: viewer.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, task.ID, tasks[0].ID)
+
+		_, total, err = taskService.List(model.TaskFilter{UserID: owner.ID, AssigneeID: owner.ID, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("测试指派给我视图", func(t *testing.T) {
+		tasks, total, err := taskService.List(model.TaskFilter{UserID: viewer.ID, AllScopes: true, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		if assert.Len(t, tasks, 2) {
+			assert.Equal(t, model.TaskRoleViewer, tasks[0].Role)
+			assert.Equal(t, model.TaskRoleEditor, tasks[1].Role)
+		}
+
+		// 失去访问权限后不再出现在视图中
+		assert.NoError(t, collaboratorService.Remove(task.ID, owner.ID, viewer.ID))
+		tasks, total, err = taskService.List(model.TaskFilter{UserID: viewer.ID, AllScopes: true, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, teamTask.ID, tasks[0].ID)
+	})
+
+	t.Run("测试移除负责人", func(t *testing.T) {
+		assert.Equal(t, service.ErrAssigneeNotFound, taskService.RemoveAssignee(task.ID, owner.ID, owner.ID))
+		assert.NoError(t, taskService.RemoveAssignee(task.ID, editor.ID, editor.ID))
+
+		updated, err := taskService.SetAssignees(task.ID, owner.ID, []int{})
+		assert.NoError(t, err)
+		assert.Empty(t, updated.Assignees)
+
+		// 回收站中的任务不出现在指派给我视图中，永久删除时一并删除指派
+		assert.NoError(t, taskService.Delete(teamTask.ID, owner.ID, service.DeleteOptions{}))
+		tasks, _, err := taskService.List(model.TaskFilter{UserID: viewer.ID, AllScopes: true, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, tasks)
+		assert.NoError(t, taskService.Purge(teamTask.ID, owner.ID))
+		assigned, err := assigneeRepo.GetByUserID(viewer.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, assigned)
+	})
+}
+
+func TestCommentService(t *testing.T) {
+	userRepo := repository.NewMemoryUserRepository()
+	taskRepo := repository.NewMemoryTaskRepository()
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	commentRepo := repository.NewMemoryCommentRepository()
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), commentRepo, repository.NewMemoryHistoryRepository(), nil)
+	commentService := service.NewCommentService(taskService, commentRepo, userRepo)
+
+	owner := &model.User{Username: "owner", PasswordHash: "hash"}
+	viewer := &model.User{Username: "viewer", PasswordHash: "hash"}
+	outsider := &model.User{Username: "outsider", PasswordHash: "hash"}
+	for _, user := range []*model.User{owner, viewer, outsider} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+
+	task := &model.Task{UserID: owner.ID, Title: "讨论任务"}
+	assert.NoError(t, taskService.Create(task))
+	assert.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: task.ID, UserID: viewer.ID, Role: model.TaskRoleViewer, CreatedAt: time.Now()}))
+
+	var ownerComment, viewerComment *model.Comment
+
+	t.Run("测试发表评论", func(t *testing.T) {
+		ownerComment = &model.Comment{TaskID: task.ID, UserID: owner.ID, Body: "  第一条评论  "}
+		assert.NoError(t, commentService.Create(ownerComment))
+		assert.Equal(t, "第一条评论", ownerComment.Body)
+		assert.Equal(t, "owner", ownerComment.Username)
+		assert.Nil(t, ownerComment.EditedAt)
+
+		// 只读协作者也可以评论
+		viewerComment = &model.Comment{TaskID: task.ID, UserID: viewer.ID, Body: "收到"}
+		assert.NoError(t, commentService.Create(viewerComment))
+
+		err := commentService.Create(&model.Comment{TaskID: task.ID, UserID: outsider.ID, Body: "路过"})
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+		err = commentService.Create(&model.Comment{TaskID: task.ID, UserID: owner.ID, Body: "   "})
+		assert.Equal(t, service.ErrEmptyComment, err)
+		err = commentService.Create(&model.Comment{TaskID: task.ID, UserID: owner.ID, Body: strings.Repeat("字", model.MaxCommentLength+1)})
+		assert.Equal(t, service.ErrCommentTooLong, err)
+		err = commentService.Create(&model.Comment{TaskID: 999, UserID: owner.ID, Body: "不存在"})
+		assert.Equal(t, service.ErrTaskNotFound, err)
+	})
+
+	t.Run("测试获取评论", func(t *testing.T) {
+		comments, total, err := commentService.List(task.ID, viewer.ID, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		if assert.Len(t, comments, 1) {
+			assert.Equal(t, ownerComment.ID, comments[0].ID)
+			assert.Equal(t, "owner", comments[0].Username)
+		}
+
+		// 页码和每页数量不合法时使用默认值
+		comments, _, err = commentService.List(task.ID, owner.ID, 0, 0)
+		assert.NoError(t, err)
+		assert.Len(t, comments, 2)
+
+		_, _, err = commentService.List(task.ID, outsider.ID, 1, 10)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+	})
+
+	t.Run("测试编辑评论", func(t *testing.T) {
+		_, err := commentService.Update(task.ID, viewerComment.ID, owner.ID, "改写")
+		assert.Equal(t, service.ErrCommentAuthorOnly, err)
+		_, err = commentService.Update(task.ID, 999, viewer.ID, "改写")
+		assert.Equal(t, service.ErrCommentNotFound, err)
+
+		updated, err := commentService.Update(task.ID, viewerComment.ID, viewer.ID, "收到，马上处理")
+		assert.NoError(t, err)
+		assert.Equal(t, "收到，马上处理", updated.Body)
+		assert.NotNil(t, updated.EditedAt)
+	})
+
+	t.Run("测试删除评论", func(t *testing.T) {
+		assert.Equal(t, service.ErrCommentDeleteDenied, commentService.Delete(task.ID, ownerComment.ID, viewer.ID))
+
+		// 任务所有者可以删除其他人的评论
+		assert.NoError(t, commentService.Delete(task.ID, viewerComment.ID, owner.ID))
+		assert.Equal(t, service.ErrCommentNotFound, commentService.Delete(task.ID, viewerComment.ID, owner.ID))
+		_, err := commentService.Update(task.ID, viewerComment.ID, viewer.ID, "再改")
+		assert.Equal(t, service.ErrCommentNotFound, err)
+
+		comments, total, err := commentService.List(task.ID, owner.ID, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, comments, 1)
+
+		// 任务移入回收站时保留评论，永久删除时一并删除
+		assert.NoError(t, taskService.Delete(task.ID, owner.ID, service.DeleteOptions{}))
+		_, total, err = commentRepo.ListByTaskID(task.ID, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.NoError(t, taskService.Purge(task.ID, owner.ID))
+		_, total, err = commentRepo.ListByTaskID(task.ID, 1, 10)
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+	})
+}
+
+func TestTaskHistory(t *testing.T) {
+	userRepo := repository.NewMemoryUserRepository()
+	taskRepo := repository.NewMemoryTaskRepository()
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	historyRepo := repository.NewMemoryHistoryRepository()
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), historyRepo, nil)
+	historyService := service.NewHistoryService(taskService, historyRepo, userRepo)
+
+	owner := &model.User{Username: "owner", PasswordHash: "hash"}
+	editor := &model.User{Username: "editor", PasswordHash: "hash"}
+	outsider := &model.User{Username: "outsider", PasswordHash: "hash"}
+	for _, user := range []*model.User{owner, editor, outsider} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+
+	task := &model.Task{UserID: owner.ID, Title: "写周报", Priority: model.TaskPriorityHigh}
+	assert.NoError(t, taskService.Create(task))
+	assert.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: task.ID, UserID: editor.ID, Role: model.TaskRoleEditor, CreatedAt: time.Now()}))
+
+	t.Run("测试创建记录", func(t *testing.T) {
+		entries, total, err := historyService.List(task.ID, owner.ID, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, model.TaskHistoryActionCreate, entries[0].Action)
+			assert.Equal(t, "owner", entries[0].Username)
+			assert.Contains(t, entries[0].Changes, model.FieldChange{Field: "title", After: "写周报"})
+			assert.Contains(t, entries[0].Changes, model.FieldChange{Field: "status", After: "todo"})
+		}
+
+		_, _, err = historyService.List(task.ID, outsider.ID, 1, 10)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+	})
+
+	t.Run("测试字段级变更", func(t *testing.T) {
+		update := &model.Task{ID: task.ID, UserID: owner.ID, Status: model.TaskStatusDone}
+		assert.NoError(t, taskService.Update(update))
+		update = &model.Task{ID: task.ID, UserID: editor.ID, Title: "写月报", Status: model.TaskStatusTodo}
+		assert.NoError(t, taskService.Update(update))
+		// 没有变化的更新不记录
+		update = &model.Task{ID: task.ID, UserID: editor.ID, Status: model.TaskStatusTodo}
+		assert.NoError(t, taskService.Update(update))
+
+		entries, total, err := historyService.List(task.ID, editor.ID, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		if assert.Len(t, entries, 3) {
+			assert.Equal(t, "editor", entries[0].Username)
+			assert.Equal(t, []model.FieldChange{
+				{Field: "title", Before: "写周报", After: "写月报"},
+				{Field: "status", Before: "done", After: "todo"},
+			}, entries[0].Changes)
+			assert.Equal(t, "owner", entries[1].Username)
+			assert.Equal(t, []model.FieldChange{{Field: "status", Before: "todo", After: "done"}}, entries[1].Changes)
+		}
+	})
+
+	t.Run("测试子任务汇总和负责人变更", func(t *testing.T) {
+		child := &model.Task{UserID: owner.ID, Title: "子任务", ParentID: &task.ID}
+		assert.NoError(t, taskService.Create(child))
+		update := &model.Task{ID: child.ID, UserID: editor.ID, Status: model.TaskStatusDone}
+		assert.NoError(t, taskService.Update(update))
+		_, err := taskService.SetAssignees(task.ID, owner.ID, []int{editor.ID})
+		assert.NoError(t, err)
+
+		entries, _, err := historyService.List(task.ID, owner.ID, 1, 2)
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 2) {
+			assert.Equal(t, []model.FieldChange{{Field: "assignees", Before: []any{}, After: []any{float64(editor.ID)}}}, entries[0].Changes)
+			// 子任务全部完成时父任务的状态变化记在完成子任务的用户名下
+			assert.Equal(t, "editor", entries[1].Username)
+			assert.Equal(t, []model.FieldChange{{Field: "status", Before: "todo", After: "done"}}, entries[1].Changes)
+		}
+	})
+
+	t.Run("测试删除记录", func(t *testing.T) {
+		assert.NoError(t, taskService.Delete(task.ID, owner.ID, service.DeleteOptions{Cascade: true}))
+
+		// 任务删除后历史记录仍然保留
+		entries, _, err := historyRepo.ListByTaskID(task.ID, 1, 1)
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, model.TaskHistoryActionDelete, entries[0].Action)
+			assert.Equal(t, owner.ID, entries[0].UserID)
+			assert.Contains(t, entries[0].Changes, model.FieldChange{Field: "title", Before: "写月报"})
+		}
+	})
+}
+
+func TestTaskTrash(t *testing.T) {
+	taskRepo := repository.NewMemoryTaskRepository()
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	historyRepo := repository.NewMemoryHistoryRepository()
+	taskService := service.NewTaskService(taskRepo, repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), historyRepo, nil)
+
+	owner, editor, outsider := 1, 2, 3
+	parent := &model.Task{UserID: owner, Title: "父任务"}
+	assert.NoError(t, taskService.Create(parent))
+	first := &model.Task{UserID: owner, Title: "子任务一", ParentID: &parent.ID}
+	second := &model.Task{UserID: owner, Title: "子任务二", ParentID: &parent.ID}
+	assert.NoError(t, taskService.Create(first))
+	assert.NoError(t, taskService.Create(second))
+	assert.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: parent.ID, UserID: editor, Role: model.TaskRoleEditor, CreatedAt: time.Now()}))
+
+	t.Run("测试移入回收站", func(t *testing.T) {
+		assert.NoError(t, taskService.Delete(second.ID, owner, service.DeleteOptions{}))
+		time.Sleep(time.Millisecond)
+		assert.NoError(t, taskService.Delete(parent.ID, owner, service.DeleteOptions{Cascade: true}))
+
+		_, err := taskService.Get(parent.ID, owner)
+		assert.Equal(t, service.ErrTaskNotFound, err)
+		tasks, _, err := taskService.List(model.TaskFilter{UserID: owner, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, tasks)
+
+		// 默认按删除时间倒序
+		tasks, total, err := taskService.Trash(model.TaskFilter{UserID: owner, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		if assert.Len(t, tasks, 3) {
+			assert.Equal(t, second.ID, tasks[2].ID)
+			assert.Equal(t, model.TaskRoleOwner, tasks[0].Role)
+		}
+		tasks, _, err = taskService.Trash(model.TaskFilter{UserID: outsider, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("测试恢复任务", func(t *testing.T) {
+		_, err := taskService.Restore(first.ID, owner)
+		assert.Equal(t, service.ErrParentInTrash, err)
+		_, err = taskService.Restore(parent.ID, editor)
+		assert.Equal(t, service.ErrTaskOwnerOnly, err)
+		_, err = taskService.Restore(parent.ID, outsider)
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+
+		// 只恢复与父任务同时删除的子任务
+		restored, err := taskService.Restore(parent.ID, owner)
+		assert.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+		if assert.NotNil(t, restored.Subtasks) {
+			assert.Equal(t, 1, restored.Subtasks.Total)
+		}
+		_, err = taskService.Get(first.ID, owner)
+		assert.NoError(t, err)
+		_, err = taskService.Get(second.ID, owner)
+		assert.Equal(t, service.ErrTaskNotFound, err)
+
+		_, err = taskService.Restore(parent.ID, owner)
+		assert.Equal(t, service.ErrTaskNotFound, err)
+
+		entries, _, err := historyRepo.ListByTaskID(parent.ID, 1, 1)
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, model.TaskHistoryActionRestore, entries[0].Action)
+		}
+	})
+
+	t.Run("测试永久删除", func(t *testing.T) {
+		assert.Equal(t, service.ErrTaskNotFound, taskService.Purge(parent.ID, owner))
+		assert.NoError(t, taskService.Purge(second.ID, owner))
+		_, err := taskService.Restore(second.ID, owner)
+		assert.Equal(t, service.ErrTaskNotFound, err)
+
+		assert.NoError(t, taskService.Delete(parent.ID, owner, service.DeleteOptions{Cascade: true}))
+		purged, err := taskService.PurgeExpired(time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+		purged, err = taskService.PurgeExpired(time.Now().Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, purged)
+
+		_, total, err := taskService.Trash(model.TaskFilter{UserID: owner, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+		collaborator, err := collabRepo.Get(parent.ID, editor)
+		assert.NoError(t, err)
+		assert.Nil(t, collaborator)
+	})
+}
+
+func TestTaskServiceVersion(t *testing.T) {
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), nil)
+
+	task := &model.Task{UserID: 1, Title: "两个标签页"}
+	assert.NoError(t, taskService.Create(task))
+	assert.Equal(t, 1, task.Version)
+
+	// 第一个标签页基于版本1修改成功
+	first := &model.Task{ID: task.ID, UserID: 1, Title: "标签页一", Version: 1}
+	assert.NoError(t, taskService.Update(first))
+	assert.Equal(t, 2, first.Version)
+
+	// 第二个标签页仍基于版本1，不能覆盖
+	second := &model.Task{ID: task.ID, UserID: 1, Title: "标签页二", Version: 1}
+	assert.Equal(t, service.ErrVersionConflict, taskService.Update(second))
+	found, err := taskService.Get(task.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "标签页一", found.Title)
+
+	// 不提供版本号时不检查
+	assert.NoError(t, taskService.Update(&model.Task{ID: task.ID, UserID: 1, Title: "强制修改"}))
+	found, err = taskService.Get(task.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, found.Version)
+
+	// 子任务完成引起的父任务状态变化同样增加版本号
+	child := &model.Task{UserID: 1, Title: "子任务", ParentID: &task.ID}
+	assert.NoError(t, taskService.Create(child))
+	assert.NoError(t, taskService.Update(&model.Task{ID: child.ID, UserID: 1, Status: model.TaskStatusDone}))
+	found, err = taskService.Get(task.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TaskStatusDone, found.Status)
+	assert.Equal(t, 4, found.Version)
+}
+
+func TestTaskServicePatch(t *testing.T) {
+	tagRepo := repository.NewMemoryTagRepository()
+	historyRepo := repository.NewMemoryHistoryRepository()
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), tagRepo, repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), historyRepo, nil)
+
+	tag := &model.Tag{UserID: 1, Name: "work"}
+	assert.NoError(t, tagRepo.Create(tag))
+	dueDate := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := &model.Task{UserID: 1, Title: "写周报", Description: "周五之前", DueDate: &dueDate, Priority: model.TaskPriorityHigh, Tags: []model.Tag{{ID: tag.ID}}}
+	assert.NoError(t, taskService.Create(task))
+	assert.NoError(t, taskService.Update(&model.Task{ID: task.ID, UserID: 1, Status: model.TaskStatusInProgress}))
+
+	t.Run("null 清空字段，其他字段保持不变", func(t *testing.T) {
+		updated, err := taskService.Patch(task.ID, 1, model.TaskPatch{
+			Description: model.Some(""),
+			DueDate:     model.Some[*time.Time](nil),
+			TagIDs:      model.Some([]int{}),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "写周报", updated.Title)
+		assert.Empty(t, updated.Description)
+		assert.Nil(t, updated.DueDate)
+		assert.Empty(t, updated.Tags)
+		// 未提供状态时不会被重置为待办
+		assert.Equal(t, model.TaskStatusInProgress, updated.Status)
+		assert.Equal(t, model.TaskPriorityHigh, updated.Priority)
+
+		histories, _, err := historyRepo.ListByTaskID(task.ID, 1, 10)
+		assert.NoError(t, err)
+		fields := make([]string, 0)
+		for _, change := range histories[0].Changes {
+			fields = append(fields, change.Field)
+		}
+		assert.ElementsMatch(t, []string{"description", "due_date", "tags"}, fields)
+	})
+
+	t.Run("可以显式设置为待办", func(t *testing.T) {
+		updated, err := taskService.Patch(task.ID, 1, model.TaskPatch{Status: model.Some(model.TaskStatusTodo)})
+		assert.NoError(t, err)
+		assert.Equal(t, model.TaskStatusTodo, updated.Status)
+	})
+
+	t.Run("校验字段", func(t *testing.T) {
+		_, err := taskService.Patch(task.ID, 1, model.TaskPatch{Title: model.Some("")})
+		assert.Equal(t, service.ErrEmptyTitle, err)
+		_, err = taskService.Patch(task.ID, 1, model.TaskPatch{Status: model.Some(5)})
+		assert.Equal(t, service.ErrInvalidStatus, err)
+		rule := "FREQ=DAILY"
+		_, err = taskService.Patch(task.ID, 1, model.TaskPatch{Recurrence: model.Some(&rule)})
+		assert.Equal(t, service.ErrRecurrenceNoDue, err)
+	})
+
+	t.Run("版本号", func(t *testing.T) {
+		found, err := taskService.Get(task.ID, 1)
+		assert.NoError(t, err)
+		_, err = taskService.Patch(task.ID, 1, model.TaskPatch{Title: model.Some("改名"), Version: found.Version - 1})
+		assert.Equal(t, service.ErrVersionConflict, err)
+		updated, err := taskService.Patch(task.ID, 1, model.TaskPatch{Title: model.Some("改名"), Version: found.Version})
+		assert.NoError(t, err)
+		assert.Equal(t, found.Version+1, updated.Version)
+	})
+
+	t.Run("其他用户无权修改", func(t *testing.T) {
+		_, err := taskService.Patch(task.ID, 2, model.TaskPatch{Title: model.Some("改名")})
+		assert.Equal(t, service.ErrTaskAccessDenied, err)
+	})
+}
+
+func TestTaskBatch(t *testing.T) {
+	factories := map[string]func(t *testing.T) (service.TaskService, repository.HistoryRepository){
+		"gorm": func(t *testing.T) (service.TaskService, repository.HistoryRepository) {
+			// 事务中的全部读写都要使用同一个连接，否则单连接的 SQLite 会相互等待
+			db := initTestDB(t)
+			historyRepo := repository.NewHistoryRepository(db)
+			return service.NewTaskService(repository.NewTaskRepository(db), repository.NewTagRepository(db), repository.NewProjectRepository(db), repository.NewCollaboratorRepository(db), repository.NewWorkspaceRepository(db), repository.NewAssigneeRepository(db), repository.NewCommentRepository(db), historyRepo, nil), historyRepo
+		},
+		"memory": func(t *testing.T) (service.TaskService, repository.HistoryRepository) {
+			historyRepo := repository.NewMemoryHistoryRepository()
+			return service.NewTaskService(repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), historyRepo, nil), historyRepo
+		},
+		"cached": func(t *testing.T) (service.TaskService, repository.HistoryRepository) {
+			db := initTestDB(t)
+			c := cache.NewMemoryCache()
+			historyRepo := repository.NewHistoryRepository(db)
+			taskRepo := repository.NewCachedTaskRepository(repository.NewTaskRepository(db), c, time.Minute, nil)
+			tagRepo := repository.NewCachedTagRepository(repository.NewTagRepository(db), c)
+			return service.NewTaskService(taskRepo, tagRepo, repository.NewProjectRepository(db), repository.NewCollaboratorRepository(db), repository.NewWorkspaceRepository(db), repository.NewAssigneeRepository(db), repository.NewCommentRepository(db), historyRepo, nil), historyRepo
+		},
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			taskService, historyRepo := factory(t)
+			existing := &model.Task{UserID: 1, Title: "已有任务"}
+			assert.NoError(t, taskService.Create(existing))
+
+			t.Run("逐项执行，失败项单独回滚", func(t *testing.T) {
+				results, err := taskService.Batch(1, []service.BatchOperation{
+					{Op: service.BatchOpCreate, Task: &model.Task{Title: "新任务"}},
+					{Op: service.BatchOpUpdate, TaskID: existing.ID, Patch: model.TaskPatch{Status: model.Some(model.TaskStatusDone)}},
+					{Op: service.BatchOpCreate, Task: &model.Task{Title: ""}},
+					{Op: service.BatchOpDelete, TaskID: 999},
+				}, service.BatchOptions{})
+				assert.NoError(t, err)
+				assert.Len(t, results, 4)
+				assert.NoError(t, results[0].Err)
+				assert.NotZero(t, results[0].TaskID)
+				assert.NoError(t, results[1].Err)
+				assert.Equal(t, model.TaskStatusDone, results[1].Task.Status)
+				assert.Equal(t, service.ErrEmptyTitle, results[2].Err)
+				assert.Equal(t, service.ErrTaskNotFound, results[3].Err)
+
+				created, err := taskService.Get(results[0].TaskID, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, "新任务", created.Title)
+				_, total, err := taskService.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+			})
+
+			t.Run("原子执行，任意一项失败全部回滚", func(t *testing.T) {
+				_, before, err := historyRepo.ListByTaskID(existing.ID, 1, 10)
+				assert.NoError(t, err)
+
+				results, err := taskService.Batch(1, []service.BatchOperation{
+					{Op: service.BatchOpCreate, Task: &model.Task{Title: "不会保存"}},
+					{Op: service.BatchOpUpdate, TaskID: existing.ID, Patch: model.TaskPatch{Title: model.Some("不会修改")}},
+					{Op: service.BatchOpDelete, TaskID: 999},
+					{Op: service.BatchOpDelete, TaskID: existing.ID},
+				}, service.BatchOptions{Atomic: true})
+				assert.NoError(t, err)
+				assert.Equal(t, service.ErrBatchAborted, results[0].Err)
+				assert.Zero(t, results[0].TaskID)
+				assert.Equal(t, service.ErrBatchAborted, results[1].Err)
+				assert.Nil(t, results[1].Task)
+				assert.Equal(t, service.ErrTaskNotFound, results[2].Err)
+				assert.Equal(t, service.ErrBatchAborted, results[3].Err)
+
+				found, err := taskService.Get(existing.ID, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, "已有任务", found.Title)
+				_, total, err := taskService.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+				if name == "gorm" {
+					// 历史记录与任务在同一事务中回滚
+					_, after, err := historyRepo.ListByTaskID(existing.ID, 1, 10)
+					assert.NoError(t, err)
+					assert.Equal(t, before, after)
+				}
+			})
+
+			t.Run("预演返回结果但全部回滚", func(t *testing.T) {
+				results, err := taskService.Batch(1, []service.BatchOperation{
+					{Op: service.BatchOpCreate, Task: &model.Task{Title: "预演任务"}},
+					{Op: service.BatchOpUpdate, TaskID: existing.ID, Patch: model.TaskPatch{Title: model.Some("预演修改")}},
+					{Op: service.BatchOpCreate, Task: &model.Task{Title: ""}},
+				}, service.BatchOptions{DryRun: true})
+				assert.NoError(t, err)
+				assert.NoError(t, results[0].Err)
+				assert.Equal(t, "预演任务", results[0].Task.Title)
+				assert.Equal(t, "预演修改", results[1].Task.Title)
+				assert.Equal(t, service.ErrEmptyTitle, results[2].Err)
+
+				found, err := taskService.Get(existing.ID, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, "已有任务", found.Title)
+				_, total, err := taskService.List(model.TaskFilter{UserID: 1, Page: 1, PageSize: 10})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), total)
+			})
+
+			t.Run("原子执行全部成功", func(t *testing.T) {
+				results, err := taskService.Batch(1, []service.BatchOperation{
+					{Op: service.BatchOpUpdate, TaskID: existing.ID, Patch: model.TaskPatch{Description: model.Some("批量修改")}},
+					{Op: service.BatchOpDelete, TaskID: existing.ID},
+				}, service.BatchOptions{Atomic: true})
+				assert.NoError(t, err)
+				for _, result := range results {
+					assert.NoError(t, result.Err)
+				}
+				_, err = taskService.Get(existing.ID, 1)
+				assert.Equal(t, service.ErrTaskNotFound, err)
+			})
+
+			t.Run("请求校验", func(t *testing.T) {
+				_, err := taskService.Batch(1, nil, service.BatchOptions{})
+				assert.Equal(t, service.ErrEmptyBatch, err)
+				_, err = taskService.Batch(1, make([]service.BatchOperation, service.MaxBatchOperations+1), service.BatchOptions{})
+				assert.Equal(t, service.ErrTooManyOperations, err)
+				results, err := taskService.Batch(1, []service.BatchOperation{{Op: "archive", TaskID: 1}}, service.BatchOptions{})
+				assert.NoError(t, err)
+				assert.Equal(t, service.ErrInvalidBatchOp, results[0].Err)
+			})
+		})
+	}
+}
+
+func TestTaskEvents(t *testing.T) {
+	collabRepo := repository.NewMemoryCollaboratorRepository()
+	workspaceRepo := repository.NewMemoryWorkspaceRepository()
+	bus := event.NewBus(event.DefaultHistorySize)
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), collabRepo, workspaceRepo, repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), bus)
+
+	collaborator, _, _ := bus.Subscribe(2, 0)
+	defer collaborator.Close()
+	stranger, _, _ := bus.Subscribe(3, 0)
+	defer stranger.Close()
+	next := func(sub *event.Subscription) event.Event {
+		select {
+		case e := <-sub.Events():
+			return e
+		default:
+			t.Fatal("没有收到事件")
+			return event.Event{}
+		}
+	}
+
+	parent := &model.Task{UserID: 1, Title: "父任务"}
+	assert.NoError(t, taskService.Create(parent))
+	assert.NoError(t, collabRepo.Save(&model.TaskCollaborator{TaskID: parent.ID, UserID: 2, Role: model.TaskRoleEditor}))
+	assert.Empty(t, collaborator.Events(), "共享之前的事件不推送给协作者")
+
+	t.Run("协作者收到子任务的事件", func(t *testing.T) {
+		child := &model.Task{UserID: 1, Title: "子任务", ParentID: &parent.ID}
+		assert.NoError(t, taskService.Create(child))
+		e := next(collaborator)
+		assert.Equal(t, event.TaskCreated, e.Type)
+		assert.Equal(t, child.ID, e.Task.ID)
+		assert.Equal(t, []int{1, 2}, e.UserIDs)
+
+		_, err := taskService.Patch(child.ID, 2, model.TaskPatch{Title: model.Some("改名")})
+		assert.NoError(t, err)
+		e = next(collaborator)
+		assert.Equal(t, event.TaskUpdated, e.Type)
+		assert.Equal(t, "改名", e.Task.Title)
+
+		// 没有字段变化时不推送
+		_, err = taskService.Patch(child.ID, 2, model.TaskPatch{Title: model.Some("改名")})
+		assert.NoError(t, err)
+		assert.Empty(t, collaborator.Events())
+
+		assert.NoError(t, taskService.Delete(parent.ID, 1, service.DeleteOptions{Cascade: true}))
+		deleted := []int{next(collaborator).Task.ID, next(collaborator).Task.ID}
+		assert.ElementsMatch(t, []int{parent.ID, child.ID}, deleted)
+
+		// 恢复的任务与新建的任务相同
+		_, err = taskService.Restore(parent.ID, 1)
+		assert.NoError(t, err)
+		restored := []int{}
+		for len(collaborator.Events()) > 0 {
+			e := next(collaborator)
+			assert.Equal(t, event.TaskCreated, e.Type)
+			restored = append(restored, e.Task.ID)
+		}
+		assert.ElementsMatch(t, []int{parent.ID, child.ID}, restored)
+	})
+
+	t.Run("批量操作回滚时不推送", func(t *testing.T) {
+		results, err := taskService.Batch(1, []service.BatchOperation{
+			{Op: service.BatchOpUpdate, TaskID: parent.ID, Patch: model.TaskPatch{Title: model.Some("批量修改")}},
+			{Op: service.BatchOpCreate, Task: &model.Task{Title: ""}},
+		}, service.BatchOptions{Atomic: true})
+		assert.NoError(t, err)
+		assert.Error(t, results[1].Err)
+		assert.Empty(t, collaborator.Events())
+
+		results, err = taskService.Batch(1, []service.BatchOperation{
+			{Op: service.BatchOpUpdate, TaskID: parent.ID, Patch: model.TaskPatch{Title: model.Some("批量修改")}},
+			{Op: service.BatchOpCreate, Task: &model.Task{Title: ""}},
+		}, service.BatchOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, results[0].Err)
+		e := next(collaborator)
+		assert.Equal(t, "批量修改", e.Task.Title)
+		assert.Empty(t, collaborator.Events())
+	})
+
+	t.Run("离开工作区的创建者收不到事件", func(t *testing.T) {
+		workspace := &model.Workspace{Name: "团队", OwnerID: 1}
+		assert.NoError(t, workspaceRepo.Create(workspace, &model.WorkspaceMember{UserID: 1, Role: model.WorkspaceRoleOwner}))
+		assert.NoError(t, workspaceRepo.SaveMember(&model.WorkspaceMember{WorkspaceID: workspace.ID, UserID: 4, Role: model.WorkspaceRoleMember}))
+		task := &model.Task{UserID: 4, WorkspaceID: &workspace.ID, Title: "团队任务"}
+		assert.NoError(t, taskService.Create(task))
+
+		assert.NoError(t, workspaceRepo.RemoveMember(workspace.ID, 4))
+		leaver, _, _ := bus.Subscribe(4, 0)
+		defer leaver.Close()
+		_, err := taskService.Patch(task.ID, 1, model.TaskPatch{Title: model.Some("改名")})
+		assert.NoError(t, err)
+		assert.Empty(t, leaver.Events())
+	})
+
+	assert.Empty(t, stranger.Events(), "无权访问的用户收不到事件")
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"todolist/config"
+	"todolist/internal/event"
+	"todolist/internal/model"
+	"todolist/internal/repository"
+	"todolist/internal/service"
+)
+
+// webhookRequest 接收方收到的请求
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver 记录收到的 Webhook 请求，按 statuses 的顺序返回状态码，用完后返回 204
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+	received chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	receiver := &webhookReceiver{statuses: statuses, received: make(chan struct{}, 100)}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, webhookRequest{header: r.Header.Clone(), body: body})
+		status := http.StatusNoContent
+		if len(receiver.statuses) > 0 {
+			status, receiver.statuses = receiver.statuses[0], receiver.statuses[1:]
+		}
+		receiver.mu.Unlock()
+		w.WriteHeader(status)
+		receiver.received <- struct{}{}
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// Requests 返回已收到的请求
+func (r *webhookReceiver) Requests() []webhookRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhookRequest{}, r.requests...)
+}
+
+// setupWebhookTest 创建发布事件的任务服务和 Webhook 服务，返回接收全部事件的订阅。
+// 接收方监听在本机，因此允许投递到内网地址
+func setupWebhookTest(t *testing.T) (service.TaskService, service.WebhookService, repository.WebhookRepository, *event.Subscription) {
+	require.NoError(t, config.LoadConfig("../config/config.yaml"))
+	config.GlobalConfig.Webhook.AllowPrivateNetworks = true
+	bus := event.NewBus(event.DefaultHistorySize)
+	taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), bus)
+	webhookRepo := repository.NewMemoryWebhookRepository()
+	sub, _, _ := bus.Subscribe(0, 0)
+	t.Cleanup(sub.Close)
+	return taskService, service.NewWebhookService(webhookRepo), webhookRepo, sub
+}
+
+// enqueueAll 为订阅中已收到的事件创建投递记录
+func enqueueAll(t *testing.T, webhookService service.WebhookService, sub *event.Subscription) int {
+	created := 0
+	for len(sub.Events()) > 0 {
+		n, err := webhookService.Enqueue(<-sub.Events())
+		require.NoError(t, err)
+		created += n
+	}
+	return created
+}
+
+func TestWebhookService(t *testing.T) {
+	t.Run("注册和修改", func(t *testing.T) {
+		_, webhookService, _, _ := setupWebhookTest(t)
+
+		err := webhookService.Create(&model.Webhook{UserID: 1, URL: "ftp://example.com", Events: []string{event.TaskCreated}})
+		assert.Equal(t, service.ErrInvalidWebhookURL, err)
+		err = webhookService.Create(&model.Webhook{UserID: 1, URL: "https://example.com", Events: []string{"task.commented"}})
+		assert.Equal(t, service.ErrInvalidWebhookEvents, err)
+
+		webhook := &model.Webhook{UserID: 1, URL: "https://example.com", Events: []string{event.TaskUpdated, event.TaskCreated, event.TaskCreated}, Active: true}
+		require.NoError(t, webhookService.Create(webhook))
+		assert.Equal(t, []string{event.TaskCreated, event.TaskUpdated}, webhook.Events)
+		assert.NotEmpty(t, webhook.Secret, "未提供密钥时生成随机密钥")
+		secret := webhook.Secret
+
+		// 其他用户的 Webhook 视为不存在
+		_, err = webhookService.Get(webhook.ID, 2)
+		assert.Equal(t, service.ErrWebhookNotFound, err)
+		assert.Equal(t, service.ErrWebhookNotFound, webhookService.Delete(webhook.ID, 2))
+
+		updated, err := webhookService.Update(&model.Webhook{ID: webhook.ID, UserID: 1, URL: "https://example.com/v2", Events: []string{event.TaskDeleted}})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/v2", updated.URL)
+		assert.False(t, updated.Active)
+		assert.Equal(t, secret, updated.Secret, "未提供密钥时保留原来的密钥")
+
+		require.NoError(t, webhookService.Delete(webhook.ID, 1))
+		webhooks, err := webhookService.List(1)
+		require.NoError(t, err)
+		assert.Empty(t, webhooks)
+	})
+
+	t.Run("投递签名的事件", func(t *testing.T) {
+		taskService, webhookService, _, sub := setupWebhookTest(t)
+		receiver := newWebhookReceiver(t)
+		completed := &model.Webhook{UserID: 1, URL: receiver.URL, Secret: "secret", Events: []string{event.TaskCompleted}, Active: true}
+		require.NoError(t, webhookService.Create(completed))
+		all := &model.Webhook{UserID: 1, URL: receiver.URL, Secret: "other", Events: service.WebhookEvents, Active: true}
+		require.NoError(t, webhookService.Create(all))
+		// 看不到任务的用户和停用的 Webhook 不会收到事件
+		require.NoError(t, webhookService.Create(&model.Webhook{UserID: 2, URL: receiver.URL, Events: service.WebhookEvents, Active: true}))
+		require.NoError(t, webhookService.Create(&model.Webhook{UserID: 1, URL: receiver.URL, Events: service.WebhookEvents, Active: false}))
+
+		task := &model.Task{UserID: 1, Title: "写周报"}
+		require.NoError(t, taskService.Create(task))
+		_, err := taskService.Patch(task.ID, 1, model.TaskPatch{Status: model.Some(model.TaskStatusDone)})
+		require.NoError(t, err)
+		// 创建事件投递给 all，完成事件同时投递给 completed 和 all
+		assert.Equal(t, 4, enqueueAll(t, webhookService, sub))
+
+		delivered, err := webhookService.DeliverDue(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 4, delivered)
+
+		var completedRequests []webhookRequest
+		for _, req := range receiver.Requests() {
+			assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+			assert.NotEmpty(t, req.header.Get(service.WebhookDeliveryHeader))
+			if req.header.Get(service.WebhookEventHeader) == event.TaskCompleted {
+				completedRequests = append(completedRequests, req)
+			}
+		}
+		require.Len(t, completedRequests, 2)
+
+		// 各个 Webhook 使用自己的密钥签名
+		signatures := []string{
+			completedRequests[0].header.Get(service.WebhookSignatureHeader),
+			completedRequests[1].header.Get(service.WebhookSignatureHeader),
+		}
+		assert.ElementsMatch(t, []string{
+			service.SignWebhookPayload("secret", completedRequests[0].body),
+			service.SignWebhookPayload("other", completedRequests[0].body),
+		}, signatures)
+		assert.Equal(t, completedRequests[0].body, completedRequests[1].body)
+
+		var payload struct {
+			Event   string              `json:"event"`
+			EventID int64               `json:"event_id"`
+			Task    map[string]any      `json:"task"`
+			Changes []model.FieldChange `json:"changes"`
+		}
+		require.NoError(t, json.Unmarshal(completedRequests[0].body, &payload))
+		assert.Equal(t, event.TaskCompleted, payload.Event)
+		assert.Equal(t, int64(2), payload.EventID)
+		assert.Equal(t, "done", payload.Task["status"])
+		assert.Equal(t, "写周报", payload.Task["title"])
+		assert.Equal(t, []model.FieldChange{{Field: "status", Before: "todo", After: "done"}}, payload.Changes)
+
+		deliveries, total, err := webhookService.Deliveries(completed.ID, 1, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, model.WebhookDeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, http.StatusNoContent, deliveries[0].ResponseStatus)
+		assert.NotNil(t, deliveries[0].DeliveredAt)
+		assert.Nil(t, deliveries[0].NextAttemptAt)
+	})
+
+	t.Run("失败后按指数退避重试", func(t *testing.T) {
+		taskService, webhookService, _, sub := setupWebhookTest(t)
+		receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+		webhook := &model.Webhook{UserID: 1, URL: receiver.URL, Events: []string{event.TaskCreated}, Active: true}
+		require.NoError(t, webhookService.Create(webhook))
+
+		require.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "任务"}))
+		assert.Equal(t, 1, enqueueAll(t, webhookService, sub))
+
+		base := config.GlobalConfig.Webhook.RetryBaseSeconds
+		now := time.Now()
+		deliver := func(at time.Time) (int, *model.WebhookDelivery) {
+			n, err := webhookService.DeliverDue(at)
+			require.NoError(t, err)
+			deliveries, _, err := webhookService.Deliveries(webhook.ID, 1, 1, 10)
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			return n, deliveries[0]
+		}
+
+		n, delivery := deliver(now)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, model.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+		assert.Contains(t, delivery.LastError, "500")
+		require.NotNil(t, delivery.NextAttemptAt)
+		assert.WithinDuration(t, now.Add(base), *delivery.NextAttemptAt, time.Millisecond)
+
+		// 未到重试时间不投递
+		n, _ = deliver(now.Add(base - time.Second))
+		assert.Zero(t, n)
+
+		// 第二次失败后等待时间翻倍
+		now = now.Add(base)
+		n, delivery = deliver(now)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.WithinDuration(t, now.Add(2*base), *delivery.NextAttemptAt, time.Millisecond)
+
+		now = now.Add(2 * base)
+		n, delivery = deliver(now)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, model.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Empty(t, delivery.LastError)
+		assert.Len(t, receiver.Requests(), 3)
+		// 重试时发送相同的请求
+		requests := receiver.Requests()
+		assert.Equal(t, requests[0].body, requests[2].body)
+		assert.Equal(t, requests[0].header.Get(service.WebhookDeliveryHeader), requests[2].header.Get(service.WebhookDeliveryHeader))
+	})
+
+	t.Run("重试次数用尽后标记为失败", func(t *testing.T) {
+		taskService, webhookService, _, sub := setupWebhookTest(t)
+		config.GlobalConfig.Webhook.MaxAttempts = 2
+		receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError)
+		webhook := &model.Webhook{UserID: 1, URL: receiver.URL, Events: []string{event.TaskCreated}, Active: true}
+		require.NoError(t, webhookService.Create(webhook))
+		require.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "任务"}))
+		enqueueAll(t, webhookService, sub)
+
+		now := time.Now()
+		_, err := webhookService.DeliverDue(now)
+		require.NoError(t, err)
+		_, err = webhookService.DeliverDue(now.Add(time.Hour))
+		require.NoError(t, err)
+		n, err := webhookService.DeliverDue(now.Add(48 * time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		deliveries, _, err := webhookService.Deliveries(webhook.ID, 1, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliveryFailed, deliveries[0].Status)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Nil(t, deliveries[0].NextAttemptAt)
+	})
+
+	t.Run("拒绝本机和内网地址", func(t *testing.T) {
+		taskService, webhookService, _, sub := setupWebhookTest(t)
+		receiver := newWebhookReceiver(t)
+		// 注册后改为解析到内网地址的域名在投递时被拒绝，这里用关闭开关前注册的 Webhook 模拟
+		webhook := &model.Webhook{UserID: 1, URL: receiver.URL, Events: []string{event.TaskCreated}, Active: true}
+		require.NoError(t, webhookService.Create(webhook))
+		config.GlobalConfig.Webhook.AllowPrivateNetworks = false
+
+		for _, url := range []string{
+			receiver.URL,
+			"http://localhost:8080/hook",
+			"http://[::1]/hook",
+			"http://10.0.0.1/hook",
+			"http://192.168.1.1/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"http://0.0.0.0/hook",
+		} {
+			err := webhookService.Create(&model.Webhook{UserID: 1, URL: url, Events: []string{event.TaskCreated}})
+			assert.Equal(t, service.ErrInvalidWebhookURL, err, url)
+		}
+
+		require.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "任务"}))
+		assert.Equal(t, 1, enqueueAll(t, webhookService, sub))
+		_, err := webhookService.DeliverDue(time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, receiver.Requests())
+		deliveries, _, err := webhookService.Deliveries(webhook.ID, 1, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Zero(t, deliveries[0].ResponseStatus)
+		assert.Contains(t, deliveries[0].LastError, "不允许连接本机或内网地址")
+	})
+
+	t.Run("不跟随重定向", func(t *testing.T) {
+		taskService, webhookService, _, sub := setupWebhookTest(t)
+		target := newWebhookReceiver(t)
+		redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+		t.Cleanup(redirect.Close)
+		webhook := &model.Webhook{UserID: 1, URL: redirect.URL, Events: []string{event.TaskCreated}, Active: true}
+		require.NoError(t, webhookService.Create(webhook))
+
+		require.NoError(t, taskService.Create(&model.Task{UserID: 1, Title: "任务"}))
+		enqueueAll(t, webhookService, sub)
+		_, err := webhookService.DeliverDue(time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, target.Requests())
+		deliveries, _, err := webhookService.Deliveries(webhook.ID, 1, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Equal(t, http.StatusTemporaryRedirect, deliveries[0].ResponseStatus)
+	})
+
+	t.Run("订阅事件总线并投递", func(t *testing.T) {
+		require.NoError(t, config.LoadConfig("../config/config.yaml"))
+		config.GlobalConfig.Webhook.AllowPrivateNetworks = true
+		bus := event.NewBus(event.DefaultHistorySize)
+		taskService := service.NewTaskService(repository.NewMemoryTaskRepository(), repository.NewMemoryTagRepository(), repository.NewMemoryProjectRepository(), repository.NewMemoryCollaboratorRepository(), repository.NewMemoryWorkspaceRepository(), repository.NewMemoryAssigneeRepository(), repository.NewMemoryCommentRepository(), repository.NewMemoryHistoryRepository(), bus)
+		webhookService := service.NewWebhookService(repository.NewMemoryWebhookRepository())
+		receiver := newWebhookReceiver(t)
+		require.NoError(t, webhookService.Create(&model.Webhook{UserID: 1, URL: receiver.URL, Events: []string{event.TaskDeleted}, Active: true}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		service.StartWebhookDispatcher(ctx, bus, webhookService, time.Hour)
+
+		task := &model.Task{UserID: 1, Title: "任务"}
+		require.NoError(t, taskService.Create(task))
+		require.NoError(t, taskService.Delete(task.ID, 1, service.DeleteOptions{}))
+
+		// 创建投递记录后立即投递，不等待定时投递
+		select {
+		case <-receiver.received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("没有收到 Webhook 请求")
+		}
+		requests := receiver.Requests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, event.TaskDeleted, requests[0].header.Get(service.WebhookEventHeader))
+	})
+}
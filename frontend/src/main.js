@@ -5,6 +5,7 @@ import 'element-plus/dist/index.css'
 import * as ElementPlusIconsVue from '@element-plus/icons-vue'
 import { createPinia } from 'pinia'
 import router from './router'
+import { installAuthInterceptor } from './stores/user'
 import App from './App.vue'
 
 const app = createApp(App)
@@ -17,4 +18,8 @@ for (const [key, component] of Object.entries(ElementPlusIconsVue)) {
 app.use(ElementPlus)
 app.use(createPinia())
 app.use(router)
+
+// 访问令牌过期时自动刷新
+installAuthInterceptor(router)
+
 app.mount('#app')
@@ -4,6 +4,7 @@ import axios from 'axios'
 export const useUserStore = defineStore('user', {
   state: () => ({
     token: localStorage.getItem('token') || '',
+    refreshToken: localStorage.getItem('refreshToken') || '',
     userInfo: null
   }),
   
@@ -14,9 +15,11 @@ export const useUserStore = defineStore('user', {
           username,
           password
         })
-        const token = response.data.data
-        this.token = token
-        localStorage.setItem('token', token)
+        const { access_token, refresh_token } = response.data.data
+        this.token = access_token
+        this.refreshToken = refresh_token
+        localStorage.setItem('token', access_token)
+        localStorage.setItem('refreshToken', refresh_token)
         return true
       } catch (error) {
         console.error('Login failed:', error)
@@ -50,10 +53,88 @@ export const useUserStore = defineStore('user', {
       }
     },
 
-    logout() {
+    async refresh() {
+      try {
+        const response = await axios.post('http://localhost:8080/api/v1/users/refresh', {
+          refresh_token: this.refreshToken
+        })
+        const { access_token, refresh_token } = response.data.data
+        this.token = access_token
+        this.refreshToken = refresh_token
+        localStorage.setItem('token', access_token)
+        localStorage.setItem('refreshToken', refresh_token)
+        return true
+      } catch (error) {
+        console.error('Refresh failed:', error)
+        return false
+      }
+    },
+
+    async logout() {
+      const { token, refreshToken } = this
+      // 先清除本地状态，再通知服务端吊销令牌
       this.token = ''
+      this.refreshToken = ''
       this.userInfo = null
       localStorage.removeItem('token')
+      localStorage.removeItem('refreshToken')
+
+      if (!token) {
+        return
+      }
+      try {
+        await axios.post('http://localhost:8080/api/v1/users/logout', {
+          refresh_token: refreshToken
+        }, {
+          headers: { Authorization: `Bearer ${token}` }
+        })
+      } catch (error) {
+        console.error('Logout failed:', error)
+      }
     }
   }
-}) 
\ No newline at end of file
+})
+
+// 不需要刷新令牌重试的地址：登录、刷新令牌本身失败时直接返回错误
+const noRetryPaths = ['/users/login', '/users/register', '/users/refresh', '/users/logout']
+
+// installAuthInterceptor 访问令牌过期（401）时使用刷新令牌换取新令牌并重试一次请求，
+// 同时发生的多个 401 共用一次刷新；刷新失败时退出登录并跳转到登录页
+export function installAuthInterceptor(router) {
+  let refreshing = null
+
+  axios.interceptors.response.use(undefined, async (error) => {
+    const config = error.config
+    const userStore = useUserStore()
+    if (error.response?.status !== 401 || !config || config._retried ||
+        noRetryPaths.some(path => config.url?.endsWith(path))) {
+      return Promise.reject(error)
+    }
+    if (!userStore.refreshToken) {
+      return Promise.reject(error)
+    }
+
+    config._retried = true
+    // 其他请求已经刷新过令牌时直接使用新令牌重试
+    const current = `Bearer ${userStore.token}`
+    if (userStore.token && config.headers.Authorization !== current) {
+      config.headers.Authorization = current
+      return axios(config)
+    }
+    if (!refreshing) {
+      refreshing = userStore.refresh().finally(() => {
+        refreshing = null
+      })
+    }
+    if (!(await refreshing)) {
+      await userStore.logout()
+      if (router.currentRoute.value.meta.requiresAuth) {
+        router.push('/login')
+      }
+      return Promise.reject(error)
+    }
+
+    config.headers.Authorization = `Bearer ${userStore.token}`
+    return axios(config)
+  })
+}